@@ -0,0 +1,251 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/commands"
+	"github.com/spf13/pflag"
+)
+
+// Kinds of subcommand arguments, for completion
+const (
+	argsFiles       = ""            // File names, completed by the shell
+	argsKeys        = "keys"        // Variable names set by the -f files
+	argsAssignments = "assignments" // KEY=value assignments to variables set by the -f files
+)
+
+// subcommand is a subcommand of envvars-cli
+type subcommand struct {
+	names []string // The name, then any aliases
+	// define defines the subcommand's flags and returns the action running it with the arguments
+	// left after parsing them
+	define      func(name string, flags *pflag.FlagSet) func(args []string) error
+	args        string // What the arguments are, for completion
+	errorStatus int    // Exit status on errors other than an *commands.ExitError (default: 1)
+}
+
+// subcommands are the subcommands of envvars-cli. The merge itself is run without one, with the
+// flags defined in main; completion is dispatched there too, since it needs them.
+var subcommands = []subcommand{
+	{names: []string{"scan-secrets"}, define: scanSecretsCommand},
+	{names: []string{"lint"}, define: lintCommand},
+	{names: []string{"get"}, define: getCommand, args: argsKeys},
+	{names: []string{"set"}, define: setCommand, args: argsAssignments},
+	{names: []string{"unset"}, define: unsetCommand, args: argsKeys},
+	{names: []string{"convert"}, define: convertCommand},
+	{names: []string{"encrypt"}, define: encryptCommand},
+	{names: []string{"decrypt"}, define: decryptCommand},
+	{names: []string{"template"}, define: templateCommand},
+	{names: []string{"init"}, define: initCommand},
+	{names: []string{"doctor"}, define: doctorCommand},
+	{names: []string{"sign"}, define: signatureCommand},
+	{names: []string{"verify"}, define: signatureCommand},
+	{names: []string{"run", "exec"}, define: runCommand},
+	{names: []string{"diff"}, define: diffCommand, errorStatus: 2},
+	{names: []string{"kubectl"}, define: kubectlCommand},
+	{names: []string{"docker-args"}, define: dockerArgsCommand},
+	{names: []string{"direnv"}, define: direnvCommand},
+	{names: []string{"--github-action"}, define: gitHubActionCommand},
+}
+
+// findSubcommand returns the subcommand named, or aliased, name
+func findSubcommand(name string) (subcommand, bool) {
+	for _, cmd := range subcommands {
+		if slices.Contains(cmd.names, name) {
+			return cmd, true
+		}
+	}
+	return subcommand{}, false
+}
+
+// runSubcommand parses args with the subcommand's flags and runs it, exiting when it fails: with
+// the code of an *commands.ExitError, otherwise with the subcommand's error status after
+// printing the error
+func runSubcommand(cmd subcommand, name string, args []string) {
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	action := cmd.define(name, flags)
+	err := flags.Parse(args)
+	if err == nil {
+		err = action(flags.Args())
+	}
+	if err == nil {
+		return
+	}
+
+	var exitErr *commands.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(cmp.Or(cmd.errorStatus, 1))
+}
+
+// runCompletion runs the completion subcommand, which writes a shell's completion script, or
+// the hidden command the scripts call for candidates; root holds the merge's flags
+func runCompletion(name string, args []string, root *pflag.FlagSet) {
+	if name == commands.CompleteCommand {
+		for _, candidate := range complete(args, root) {
+			fmt.Println(candidate)
+		}
+		return
+	}
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: usage: envvars-cli completion %s\n", strings.Join(commands.CompletionShells, "|"))
+		os.Exit(1)
+	}
+	if err := commands.WriteCompletionScript(os.Stdout, args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// complete returns the completion candidates for the last of words, the arguments on the
+// command line: subcommand names, then the flags of the subcommand (or of the merge), or the
+// variables set by the -f files where the subcommand takes variable names. It returns none
+// where the shell should complete file names.
+func complete(words []string, root *pflag.FlagSet) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current, previous := words[len(words)-1], words[:len(words)-1]
+
+	var candidates []string
+	switch {
+	case len(previous) == 0 && !strings.HasPrefix(current, "-"):
+		candidates = append(candidates, "completion")
+		for _, cmd := range subcommands {
+			if !strings.HasPrefix(cmd.names[0], "-") {
+				candidates = append(candidates, cmd.names...)
+			}
+		}
+	case len(previous) > 0 && previous[0] == "completion":
+		if len(previous) == 1 {
+			candidates = commands.CompletionShells
+		}
+	default:
+		flags, cmd := root, subcommand{}
+		if len(previous) > 0 {
+			if found, ok := findSubcommand(previous[0]); ok {
+				cmd = found
+				flags = pflag.NewFlagSet(previous[0], pflag.ContinueOnError)
+				cmd.define(previous[0], flags)
+				previous = previous[1:]
+			}
+		}
+		candidates = completeArgs(cmd, flags, previous, current)
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
+
+// completeArgs returns the candidates for current following previous, the arguments after the
+// subcommand's name: its flags, or the variables set by the -f files where it takes variable
+// names
+func completeArgs(cmd subcommand, flags *pflag.FlagSet, previous []string, current string) []string {
+	// The value of a flag, such as a file name, is left to the shell
+	if len(previous) > 0 {
+		if flag := lookupFlag(flags, previous[len(previous)-1]); flag != nil && flag.NoOptDefVal == "" && !strings.Contains(previous[len(previous)-1], "=") {
+			return nil
+		}
+	}
+
+	if strings.HasPrefix(current, "-") {
+		var candidates []string
+		flags.VisitAll(func(flag *pflag.Flag) {
+			if flag.Hidden {
+				return
+			}
+			candidates = append(candidates, "--"+flag.Name)
+			if flag.Shorthand != "" {
+				candidates = append(candidates, "-"+flag.Shorthand)
+			}
+		})
+		return candidates
+	}
+	if cmd.args == argsFiles {
+		return nil
+	}
+
+	flags.SetOutput(io.Discard)
+	flags.Parse(previous)
+	files, _ := flags.GetStringArray("file")
+	keys := commands.CompletionKeys(files)
+	if cmd.args == argsAssignments {
+		for i, key := range keys {
+			keys[i] = key + "="
+		}
+	}
+	return keys
+}
+
+// lookupFlag returns the flag that arg, a "--name" or "-x" argument, sets
+func lookupFlag(flags *pflag.FlagSet, arg string) *pflag.Flag {
+	name, _, _ := strings.Cut(arg, "=")
+	if long, ok := strings.CutPrefix(name, "--"); ok {
+		return flags.Lookup(long)
+	}
+	if short, ok := strings.CutPrefix(name, "-"); ok && len(short) == 1 {
+		return flags.ShorthandLookup(short)
+	}
+	return nil
+}
+
+// sourceArg is a source given on the command line
+type sourceArg struct {
+	sourceType string // Type of the source, or "" for a --source spec
+	value      string
+}
+
+// sourceFlag is a source flag whose values are added to a list shared by every source flag, so
+// sources keep the order they were given in whatever form each flag takes (--env a.env,
+// --env=a.env, or -ea.env)
+type sourceFlag struct {
+	sourceType string
+	args       *[]sourceArg
+	values     []string // This flag's own values
+}
+
+// String returns the flag's values
+func (f *sourceFlag) String() string {
+	return "[" + strings.Join(f.values, ",") + "]"
+}
+
+// Set adds a value to the flag and to the shared list
+func (f *sourceFlag) Set(value string) error {
+	f.values = append(f.values, value)
+	*f.args = append(*f.args, sourceArg{sourceType: f.sourceType, value: value})
+	return nil
+}
+
+// Type names the flag's value type in usage messages
+func (f *sourceFlag) Type() string {
+	return "stringArray"
+}
+
+// defineSourceFlags defines the flags adding sources of each type, collecting them in args
+func defineSourceFlags(flags *pflag.FlagSet, args *[]sourceArg) {
+	define := func(name string, shorthand string, sourceType string, usage string) {
+		flags.VarP(&sourceFlag{sourceType: sourceType, args: args}, name, shorthand, usage)
+	}
+	define("env", "e", "env", "Read and parse environment variable files (can be specified multiple times)")
+	define("json", "j", "json", "Process a JSON file (can be specified multiple times)")
+	define("yaml", "y", "yaml", "Process a YAML file (can be specified multiple times)")
+	define("ini", "", "ini", "Process an INI file, prefixing keys with their section (can be specified multiple times)")
+	define("toml", "", "toml", "Process a TOML file, flattening tables into prefixed keys (can be specified multiple times)")
+	define("hcl", "", "hcl", "Process an HCL file such as Terraform .tfvars, reading its top-level attributes (can be specified multiple times)")
+	define("properties", "", "properties", "Process a Java .properties file (can be specified multiple times)")
+	define("k8s", "", "k8s", "Read a ConfigMap or Secret from a manifest file or the cluster (k8s://[namespace/]kind/name)")
+	define("source", "", "", "Add a source as comma-separated fields: type="+strings.Join(commands.SourceTypes, "|")+",path=<file>[,priority=<n>][,key=<decryption key>] (can be specified multiple times)")
+}
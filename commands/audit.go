@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/notwillk/envvars-cli/formatters"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// AuditSyslog sends audit entries to the system log instead of a file
+const AuditSyslog = "syslog"
+
+// AuditEntry records the keys one command read from a secret backend
+type AuditEntry struct {
+	Time    string   `json:"time"`
+	User    string   `json:"user"`
+	PID     int      `json:"pid"`
+	Command string   `json:"command"`
+	Type    string   `json:"type"`
+	Source  string   `json:"source"`
+	Keys    []string `json:"keys"`
+}
+
+// isSecretBackend reports whether source reads from a secret backend, whose reads are audited:
+// SOPS files and Secrets read from a cluster
+func isSecretBackend(source Source) bool {
+	if source.Type == "k8s" {
+		reference, ok, _ := ParseKubernetesReference(source.FilePath)
+		return ok && reference.Kind == formatters.KubernetesSecret
+	}
+	return source.Type == "sops"
+}
+
+// isLocalPlaintext reports whether source is an unencrypted file on disk, which secrets in it
+// are exposed by when it is committed or readable by others
+func isLocalPlaintext(source Source) bool {
+	if source.Type == "sops" || source.FilePath == sources.StdinPath || IsRemoteURL(source.FilePath) {
+		return false
+	}
+	return !strings.HasPrefix(source.FilePath, KubernetesReferencePrefix)
+}
+
+// audit appends an entry for a source read from a secret backend to the --audit-log destination.
+// Failing to record the access is an error, so secrets are never read unaudited.
+func (cmd *MergeCommand) audit(source Source, envFile sources.EnvFile) error {
+	if cmd.options.AuditLog == "" || !isSecretBackend(source) {
+		return nil
+	}
+
+	keys := make([]string, 0, len(envFile.Variables))
+	for _, variable := range envFile.Variables {
+		keys = append(keys, variable.Key)
+	}
+	slices.Sort(keys)
+	entry := AuditEntry{
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		User:    currentUser(),
+		PID:     os.Getpid(),
+		Command: cmd.name,
+		Type:    source.Type,
+		Source:  source.FilePath,
+		Keys:    slices.Compact(keys),
+	}
+	if err := writeAuditEntry(cmd.options.AuditLog, entry); err != nil {
+		return fmt.Errorf("failed to write audit log '%s': %w", cmd.options.AuditLog, err)
+	}
+	return nil
+}
+
+// writeAuditEntry writes entry as one JSON line to the audit log file (appending, and creating
+// it readable only by its owner) or to syslog
+func writeAuditEntry(destination string, entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	var writer io.WriteCloser
+	if destination == AuditSyslog {
+		writer, err = openSyslog()
+	} else {
+		writer, err = os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(append(data, '\n')); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// currentUser returns the name of the OS user running the command, falling back to the user ID
+func currentUser() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return fmt.Sprintf("uid:%d", os.Getuid())
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package commands
+
+import (
+	"fmt"
+	"io"
+)
+
+// openSyslog reports that syslog is not available on this platform
+func openSyslog() (io.WriteCloser, error) {
+	return nil, fmt.Errorf("syslog is not supported on this platform")
+}
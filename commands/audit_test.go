@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+func TestMergeCommand_Audit(t *testing.T) {
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	cmd := CreateMergeCommand(nil, Options{AuditLog: auditLog})
+	envFile := sources.EnvFile{Variables: []sources.EnvVar{
+		{Key: "DB_PASSWORD", Value: "hunter2"},
+		{Key: "API_TOKEN", Value: "abc123"},
+	}}
+
+	if err := cmd.audit(Source{FilePath: "plain.env", Type: "env"}, envFile); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := os.Stat(auditLog); !os.IsNotExist(err) {
+		t.Errorf("Expected plaintext sources not to be audited")
+	}
+
+	for range 2 {
+		if err := cmd.audit(Source{FilePath: "secrets.enc.yaml", Type: "sops"}, envFile); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(auditLog)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("Expected values to be left out of the audit log")
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(lines))
+	}
+
+	var entry AuditEntry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Failed to decode entry: %v", err)
+	}
+	if entry.Command != "merge" || entry.Type != "sops" || entry.Source != "secrets.enc.yaml" || entry.User == "" || entry.Time == "" {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+	expected := []string{"API_TOKEN", "DB_PASSWORD"}
+	if !reflect.DeepEqual(entry.Keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, entry.Keys)
+	}
+
+	if info, err := os.Stat(auditLog); err == nil && info.Mode().Perm()&0077 != 0 {
+		t.Errorf("Expected an owner-only audit log, got mode %04o", info.Mode().Perm())
+	}
+}
+
+func TestMergeCommand_Audit_Unwritable(t *testing.T) {
+	cmd := CreateMergeCommand(nil, Options{AuditLog: filepath.Join(t.TempDir(), "missing", "audit.log")})
+	err := cmd.audit(Source{FilePath: "secrets.enc.yaml", Type: "sops"}, sources.EnvFile{})
+	if err == nil || !strings.Contains(err.Error(), "failed to write audit log") {
+		t.Errorf("Expected an audit log error, got: %v", err)
+	}
+}
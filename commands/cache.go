@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// cachedSource is a parsed source along with the file state it was parsed from
+type cachedSource struct {
+	path    string
+	modTime time.Time
+	size    int64
+	envFile sources.EnvFile
+	// Modification times of the files the source #include'd, which it must be reparsed for too
+	includes map[string]time.Time
+}
+
+// sourceCache keeps parsed sources between merges so that repeated merges
+// (watch/serve modes) only reparse files that changed on disk
+type sourceCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedSource
+}
+
+// newSourceCache creates an empty source cache
+func newSourceCache() *sourceCache {
+	return &sourceCache{entries: make(map[string]cachedSource)}
+}
+
+// cacheKey identifies a source by everything that affects how it is parsed
+func cacheKey(source Source) string {
+	return source.Type + "\x00" + source.FilePath + "\x00" + source.DecryptionKey
+}
+
+// get returns the cached parse of source if the file is unchanged since it was cached
+func (c *sourceCache) get(source Source) (sources.EnvFile, bool) {
+	info, err := os.Stat(source.FilePath)
+	if err != nil {
+		return sources.EnvFile{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[cacheKey(source)]
+	if !ok || !entry.modTime.Equal(info.ModTime()) || entry.size != info.Size() {
+		return sources.EnvFile{}, false
+	}
+	for path, modTime := range entry.includes {
+		if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(modTime) {
+			return sources.EnvFile{}, false
+		}
+	}
+	return entry.envFile, true
+}
+
+// put records the parse of source; info must be the file state observed before parsing
+func (c *sourceCache) put(source Source, info os.FileInfo, envFile sources.EnvFile) {
+	if info == nil {
+		return
+	}
+
+	includes := make(map[string]time.Time, len(envFile.Includes))
+	for _, path := range envFile.Includes {
+		includeInfo, err := os.Stat(path)
+		if err != nil {
+			return
+		}
+		includes[path] = includeInfo.ModTime()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[cacheKey(source)] = cachedSource{
+		path:     source.FilePath,
+		modTime:  info.ModTime(),
+		size:     info.Size(),
+		envFile:  envFile,
+		includes: includes,
+	}
+}
+
+// invalidate drops every cached entry for the given file paths
+func (c *sourceCache) invalidate(paths ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		for _, path := range paths {
+			if entry.path == path {
+				delete(c.entries, key)
+			}
+		}
+	}
+}
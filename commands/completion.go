@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CompleteCommand is the hidden subcommand the completion scripts call with the words of the
+// command line, the last being the one to complete; it prints a candidate per line
+const CompleteCommand = "__complete"
+
+// completionScripts are the completion scripts by shell. Each asks envvars-cli for candidates
+// and falls back to file names when there are none.
+var completionScripts = map[string]string{
+	"bash": `# envvars-cli completion for bash. Load it in ~/.bashrc with:
+#
+#     source <(envvars-cli completion bash)
+#
+_envvars_cli() {
+  local IFS=$'\n'
+  COMPREPLY=($(envvars-cli __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _envvars_cli envvars-cli
+`,
+	"zsh": `#compdef envvars-cli
+# envvars-cli completion for zsh. Load it in ~/.zshrc with:
+#
+#     source <(envvars-cli completion zsh)
+#
+_envvars_cli() {
+  local -a candidates
+  candidates=("${(@f)$(envvars-cli __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+  if [[ -n "${candidates[1]}" ]]; then
+    compadd -- "${candidates[@]}"
+  else
+    _files
+  fi
+}
+compdef _envvars_cli envvars-cli
+`,
+	"fish": `# envvars-cli completion for fish. Load it with:
+#
+#     envvars-cli completion fish > ~/.config/fish/completions/envvars-cli.fish
+#
+function __envvars_cli_complete
+    envvars-cli __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null
+end
+complete -c envvars-cli -a '(__envvars_cli_complete)'
+`,
+	"powershell": `# envvars-cli completion for PowerShell. Load it in $PROFILE with:
+#
+#     envvars-cli completion powershell | Out-String | Invoke-Expression
+#
+Register-ArgumentCompleter -Native -CommandName envvars-cli -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') { $words += '""' }
+    envvars-cli __complete @words 2>$null | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`,
+}
+
+// CompletionShells are the shells completion scripts are available for
+var CompletionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// WriteCompletionScript writes the completion script for shell
+func WriteCompletionScript(w io.Writer, shell string) error {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell '%s', expected one of: %s", shell, strings.Join(CompletionShells, ", "))
+	}
+	_, err := fmt.Fprint(w, script)
+	return err
+}
+
+// CompletionKeys returns the keys the files (typed by extension) set, for completing variable
+// names; files that cannot be merged contribute none
+func CompletionKeys(files []string) []string {
+	sourceList, err := SourcesForFiles(files)
+	if err != nil || len(sourceList) == 0 {
+		return nil
+	}
+	variables, err := CreateMergeCommand(sourceList, Options{Format: "env"}).Merge()
+	if err != nil {
+		return nil
+	}
+	return variables.Keys()
+}
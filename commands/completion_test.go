@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteCompletionScript(t *testing.T) {
+	for _, shell := range CompletionShells {
+		var buffer bytes.Buffer
+		if err := WriteCompletionScript(&buffer, shell); err != nil {
+			t.Fatalf("Expected no error for %s, got: %v", shell, err)
+		}
+		if !strings.Contains(buffer.String(), "envvars-cli "+CompleteCommand) {
+			t.Errorf("Expected the %s script to call %s, got:\n%s", shell, CompleteCommand, buffer.String())
+		}
+	}
+	if err := WriteCompletionScript(&bytes.Buffer{}, "tcsh"); err == nil {
+		t.Errorf("Expected an error for an unsupported shell")
+	}
+}
+
+func TestCompletionKeys(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envPath, []byte("ZED=1\nALPHA=2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	expected := []string{"ZED", "ALPHA"}
+	if keys := CompletionKeys([]string{envPath}); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+	if keys := CompletionKeys([]string{filepath.Join(dir, "missing.env")}); keys != nil {
+		t.Errorf("Expected no keys for a missing file, got %v", keys)
+	}
+}
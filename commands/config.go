@@ -0,0 +1,312 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/sources"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigNames are the project config file names looked for in each directory, in order
+var ProjectConfigNames = []string{"envvars.yaml", "envvars.yml", ".envvarsrc"}
+
+// ConfigSource is a source declared in a project config. Exactly one field is set;
+// SOPS sources use the same [key_name]@[path-to-file] format as --sops.
+type ConfigSource struct {
+	Env        string `yaml:"env"`
+	JSON       string `yaml:"json"`
+	YAML       string `yaml:"yaml"`
+	INI        string `yaml:"ini"`
+	TOML       string `yaml:"toml"`
+	HCL        string `yaml:"hcl"`
+	Properties string `yaml:"properties"`
+	SOPS       string `yaml:"sops"`
+}
+
+// DirectivePolicy restricts the directives env files may use
+type DirectivePolicy struct {
+	Allow []string `yaml:"allow"` // Directive names env files may use (omitted allows all, [] allows none)
+}
+
+// ConfigSettings are the defaults a project config (or one of its profiles) declares.
+// Unset fields leave the command-line defaults in place.
+type ConfigSettings struct {
+	Sources         []ConfigSource   `yaml:"sources"`
+	Excludes        []string         `yaml:"excludes"`        // Patterns of files glob sources and discovery skip
+	SecretPatterns  []string         `yaml:"secret_patterns"` // Patterns of keys whose values are masked
+	AuditLog        *string          `yaml:"audit_log"`       // File (or "syslog") recording secret backend reads
+	Format          *string          `yaml:"format"`
+	Sort            *string          `yaml:"sort"`
+	Export          *bool            `yaml:"export"`
+	Duplicates      *string          `yaml:"duplicates"`
+	OnDuplicate     *string          `yaml:"on_duplicate"`
+	InvalidKeys     *string          `yaml:"invalid_keys"`
+	Strict          *bool            `yaml:"strict"`
+	StrictParse     *bool            `yaml:"strict_parse"`
+	NoEscape        *bool            `yaml:"no_escape"`
+	StrictEmpty     *bool            `yaml:"strict_empty"`
+	RequireNonEmpty *bool            `yaml:"require_nonempty"`
+	Delimiter       *string          `yaml:"delimiter"`
+	NestedAsJSON    *bool            `yaml:"nested_as_json"`
+	Directives      *DirectivePolicy `yaml:"directives"`
+}
+
+// ProjectConfig is a project config file (envvars.yaml or .envvarsrc)
+type ProjectConfig struct {
+	Path           string `yaml:"-"` // Path the config was loaded from
+	ConfigSettings `yaml:",inline"`
+	Profiles       map[string]ConfigSettings `yaml:"profiles"` // Named overrides selected with --profile
+}
+
+// FindProjectConfig walks up from dir looking for a project config file and returns its path,
+// or "" if there is none
+func FindProjectConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory '%s': %w", dir, err)
+	}
+
+	for {
+		for _, name := range ProjectConfigNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadProjectConfig reads the project config at path. An empty path discovers the config by
+// walking up from the working directory, returning nil when no config is found.
+func LoadProjectConfig(path string) (*ProjectConfig, error) {
+	if path == "" {
+		workingDir, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		path, err = FindProjectConfig(workingDir)
+		if err != nil || path == "" {
+			return nil, err
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project config '%s': %w", path, err)
+	}
+
+	config := &ProjectConfig{Path: path}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse project config '%s': %w", path, err)
+	}
+
+	settings := []ConfigSettings{config.ConfigSettings}
+	for _, profile := range config.Profiles {
+		settings = append(settings, profile)
+	}
+	for _, s := range settings {
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("invalid project config '%s': %w", path, err)
+		}
+	}
+
+	return config, nil
+}
+
+// validate checks the sources, excludes, and directive policy of the settings
+func (s ConfigSettings) validate() error {
+	for i, source := range s.Sources {
+		set := 0
+		for _, path := range []string{source.Env, source.JSON, source.YAML, source.INI, source.TOML, source.HCL, source.Properties, source.SOPS} {
+			if path != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("source %d must set exactly one of env, json, yaml, ini, toml, hcl, properties, or sops", i+1)
+		}
+		if source.SOPS != "" && !strings.Contains(source.SOPS, "@") {
+			return fmt.Errorf("source %d: invalid SOPS source '%s', expected [key_name]@[path-to-file]", i+1, source.SOPS)
+		}
+	}
+
+	if err := ValidateExcludes(s.Excludes); err != nil {
+		return err
+	}
+
+	if s.Directives != nil {
+		for _, name := range s.Directives.Allow {
+			if !sources.IsDirectiveName(name) {
+				return fmt.Errorf("unknown directive '%s' in directive policy", name)
+			}
+		}
+	}
+	return nil
+}
+
+// Settings returns the config's settings with the named profile applied on top. Sources and
+// every other field the profile sets replace the top-level ones; an empty name selects no profile.
+func (c *ProjectConfig) Settings(profile string) (ConfigSettings, error) {
+	settings := c.ConfigSettings
+	if profile == "" {
+		return settings, nil
+	}
+
+	override, ok := c.Profiles[profile]
+	if !ok {
+		return ConfigSettings{}, fmt.Errorf("profile '%s' not found in project config '%s'", profile, c.Path)
+	}
+	if override.Sources != nil {
+		settings.Sources = override.Sources
+	}
+	if override.Excludes != nil {
+		settings.Excludes = override.Excludes
+	}
+	if override.SecretPatterns != nil {
+		settings.SecretPatterns = override.SecretPatterns
+	}
+	setIfPresent(&settings.AuditLog, override.AuditLog)
+	setIfPresent(&settings.Format, override.Format)
+	setIfPresent(&settings.Sort, override.Sort)
+	setIfPresent(&settings.Export, override.Export)
+	setIfPresent(&settings.Duplicates, override.Duplicates)
+	setIfPresent(&settings.OnDuplicate, override.OnDuplicate)
+	setIfPresent(&settings.InvalidKeys, override.InvalidKeys)
+	setIfPresent(&settings.Strict, override.Strict)
+	setIfPresent(&settings.StrictParse, override.StrictParse)
+	setIfPresent(&settings.NoEscape, override.NoEscape)
+	setIfPresent(&settings.StrictEmpty, override.StrictEmpty)
+	setIfPresent(&settings.RequireNonEmpty, override.RequireNonEmpty)
+	setIfPresent(&settings.Delimiter, override.Delimiter)
+	setIfPresent(&settings.NestedAsJSON, override.NestedAsJSON)
+	setIfPresent(&settings.Directives, override.Directives)
+	return settings, nil
+}
+
+// setIfPresent replaces *target with value when value is set
+func setIfPresent[T any](target **T, value *T) {
+	if value != nil {
+		*target = value
+	}
+}
+
+// ResolveSources returns the sources declared by settings, with paths resolved relative to the
+// directory containing the config file and glob patterns expanded
+func (c *ProjectConfig) ResolveSources(settings ConfigSettings) ([]Source, error) {
+	dir := filepath.Dir(c.Path)
+	resolve := func(path string) string {
+		path = filepath.FromSlash(path)
+		if filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+
+	var result []Source
+	for _, source := range settings.Sources {
+		entry := Source{}
+		var path string
+		switch {
+		case source.Env != "":
+			entry.Type, path = "env", source.Env
+		case source.JSON != "":
+			entry.Type, path = "json", source.JSON
+		case source.YAML != "":
+			entry.Type, path = "yaml", source.YAML
+		case source.INI != "":
+			entry.Type, path = "ini", source.INI
+		case source.TOML != "":
+			entry.Type, path = "toml", source.TOML
+		case source.HCL != "":
+			entry.Type, path = "hcl", source.HCL
+		case source.Properties != "":
+			entry.Type, path = "properties", source.Properties
+		case source.SOPS != "":
+			parts := strings.SplitN(source.SOPS, "@", 2)
+			entry.Type, entry.DecryptionKey, path = "sops", parts[0], parts[1]
+		}
+
+		paths, err := ExpandSource(resolve(path), entry.Type, DirectoryOptions{}, settings.Excludes)
+		if err != nil {
+			return nil, err
+		}
+		for _, filePath := range paths {
+			entry.FilePath = filePath
+			entry.Priority = len(result)
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+// ApplyTo sets the options the settings declare, skipping any whose command-line flag
+// was given explicitly (isSet reports whether a flag was)
+func (s ConfigSettings) ApplyTo(options *Options, isSet func(flag string) bool) {
+	apply := func(flag string, set func()) {
+		if !isSet(flag) {
+			set()
+		}
+	}
+	if s.Format != nil {
+		apply("format", func() { options.Format = *s.Format })
+	}
+	if s.Sort != nil {
+		apply("sort", func() { options.Sort = *s.Sort })
+	}
+	if s.Export != nil {
+		apply("export", func() { options.Export = *s.Export })
+	}
+	if s.Duplicates != nil {
+		apply("duplicates", func() { options.Duplicates = *s.Duplicates })
+	}
+	if s.OnDuplicate != nil {
+		apply("on-duplicate", func() { options.OnDuplicate = *s.OnDuplicate })
+	}
+	if s.InvalidKeys != nil {
+		apply("invalid-keys", func() { options.InvalidKeys = *s.InvalidKeys })
+	}
+	if s.Strict != nil {
+		apply("strict", func() { options.Strict = *s.Strict })
+	}
+	if s.StrictParse != nil {
+		apply("strict-parse", func() { options.StrictParse = *s.StrictParse })
+	}
+	if s.NoEscape != nil {
+		apply("no-escape", func() { options.NoEscape = *s.NoEscape })
+	}
+	if s.StrictEmpty != nil {
+		apply("strict-empty", func() { options.StrictEmpty = *s.StrictEmpty })
+	}
+	if s.RequireNonEmpty != nil {
+		apply("require-nonempty", func() { options.RequireNonEmpty = *s.RequireNonEmpty })
+	}
+	if s.Delimiter != nil {
+		apply("delimiter", func() { options.Delimiter = *s.Delimiter })
+	}
+	if s.NestedAsJSON != nil {
+		apply("nested-as-json", func() { options.NestedAsJSON = *s.NestedAsJSON })
+	}
+	if s.SecretPatterns != nil {
+		apply("secret-pattern", func() { options.SecretPatterns = s.SecretPatterns })
+	}
+	if s.AuditLog != nil {
+		apply("audit-log", func() { options.AuditLog = *s.AuditLog })
+	}
+	if s.Directives != nil {
+		options.AllowedDirectives = s.Directives.Allow
+	}
+}
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindProjectConfig_WalksUp(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".envvarsrc")
+	if err := os.WriteFile(configPath, []byte("format: json\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	nested := filepath.Join(dir, "services", "api")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	result, err := FindProjectConfig(nested)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result != configPath {
+		t.Errorf("Expected %v, got %v", configPath, result)
+	}
+
+	// envvars.yaml takes precedence over .envvarsrc in the same directory
+	yamlPath := filepath.Join(dir, "envvars.yaml")
+	if err := os.WriteFile(yamlPath, []byte("format: yaml\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	result, err = FindProjectConfig(nested)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result != yamlPath {
+		t.Errorf("Expected %v, got %v", yamlPath, result)
+	}
+}
+
+func TestLoadProjectConfig_SourcesProfilesAndOptions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{".env", ".env.production", "config.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(""), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(dir, "envvars.yaml")
+	config := `sources:
+  - env: .env
+  - json: config.json
+format: yaml
+export: true
+profiles:
+  production:
+    sources:
+      - env: .env
+      - env: .env.production
+    format: json
+    directives:
+      allow: [require]
+`
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	loaded, err := LoadProjectConfig(configPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	settings, err := loaded.Settings("")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	sources, err := loaded.ResolveSources(settings)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expectedSources := []Source{
+		{FilePath: filepath.Join(dir, ".env"), Type: "env", Priority: 0},
+		{FilePath: filepath.Join(dir, "config.json"), Type: "json", Priority: 1},
+	}
+	if !reflect.DeepEqual(sources, expectedSources) {
+		t.Errorf("Expected %v, got %v", expectedSources, sources)
+	}
+
+	// Options from the config apply unless the flag was given explicitly
+	options := Options{Format: "env"}
+	settings.ApplyTo(&options, func(flag string) bool { return flag == "format" })
+	if options.Format != "env" || !options.Export {
+		t.Errorf("Expected format env and export from config, got %+v", options)
+	}
+
+	settings, err = loaded.Settings("production")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	sources, err = loaded.ResolveSources(settings)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(sources) != 2 || sources[1].FilePath != filepath.Join(dir, ".env.production") {
+		t.Errorf("Expected the production profile's sources, got %v", sources)
+	}
+	options = Options{}
+	settings.ApplyTo(&options, func(string) bool { return false })
+	if options.Format != "json" || !options.Export || !reflect.DeepEqual(options.AllowedDirectives, []string{"require"}) {
+		t.Errorf("Expected profile options on top of the config, got %+v", options)
+	}
+
+	if _, err := loaded.Settings("staging"); err == nil {
+		t.Error("Expected error for unknown profile")
+	}
+}
+
+func TestLoadProjectConfig_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"fromat: json\n", "field fromat not found"},
+		{"sources:\n  - env: a.env\n    json: b.json\n", "exactly one of"},
+		{"sources:\n  - sops: secrets.yaml\n", "invalid SOPS source"},
+		{"directives:\n  allow: [include-all]\n", "unknown directive 'include-all'"},
+		{"excludes: ['[.env']\n", "invalid exclude pattern"},
+	}
+
+	for _, test := range tests {
+		configPath := filepath.Join(t.TempDir(), "envvars.yaml")
+		if err := os.WriteFile(configPath, []byte(test.content), 0o644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		_, err := LoadProjectConfig(configPath)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Expected error containing %q for %q, got: %v", test.expected, test.content, err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/formatters"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// ConvertCommand converts a single source to another format
+type ConvertCommand struct {
+	input            string
+	options          Options
+	preserveComments bool
+}
+
+// CreateConvertCommand creates a convert command reading input (typed by extension, or a
+// type=... source spec) and writing it as options.Format to options.Output, or stdout. With
+// preserveComments, an env file converted to env keeps its comments, blank lines, and layout.
+func CreateConvertCommand(input string, options Options, preserveComments bool) *ConvertCommand {
+	return &ConvertCommand{
+		input:            input,
+		options:          options,
+		preserveComments: preserveComments,
+	}
+}
+
+// Execute converts the input. The output format defaults to the one the output file's name
+// implies, then env; variables are written in the order the input defines them.
+func (cmd *ConvertCommand) Execute() error {
+	if cmd.input == "" {
+		return fmt.Errorf("convert requires an input file")
+	}
+	sourceList, err := SourcesForFiles([]string{cmd.input})
+	if err != nil {
+		return err
+	}
+	if len(sourceList) != 1 {
+		return fmt.Errorf("convert reads a single file, but '%s' is %d files", cmd.input, len(sourceList))
+	}
+
+	options := cmd.options
+	if options.Format == "" {
+		options.Format = "env"
+		if format, ok := FormatForPath(options.Output); ok && options.Output != "" {
+			options.Format = format
+		}
+	}
+	options.Sort = "source"
+	merge := CreateMergeCommand(sourceList, options)
+
+	if !cmd.preserveComments {
+		return merge.Execute()
+	}
+	if source := sourceList[0]; source.Type != "env" || IsRemoteURL(source.FilePath) || source.FilePath == "-" || options.Format != "env" {
+		return fmt.Errorf("--preserve-comments only applies to converting a local env file to env")
+	}
+	return cmd.convertDocument(merge, sourceList[0].FilePath)
+}
+
+// convertDocument rewrites the env file's assignments with their merged values, written the way
+// the env format writes them, and keeps every other line. Keys the merge drops are removed along
+// with repeated assignments, and variables only an #include defines are appended.
+func (cmd *ConvertCommand) convertDocument(merge *MergeCommand, filePath string) error {
+	variables, err := merge.Merge()
+	if err != nil {
+		return err
+	}
+	document, err := sources.ReadEnvDocument(filePath)
+	if err != nil {
+		return err
+	}
+
+	assignments := document.Assignments()
+	firsts := make(map[string]int)
+	for i, assignment := range assignments {
+		if _, seen := firsts[assignment.Key]; !seen {
+			firsts[assignment.Key] = i
+		}
+	}
+	// From the last, so changing lines does not move the assignments before them
+	for i, assignment := range slices.Backward(assignments) {
+		value, ok := variables.Get(assignment.Key)
+		if !ok || firsts[assignment.Key] != i {
+			document.Remove(assignment)
+			continue
+		}
+		line := formatters.FormatENVLine(assignment.Key, value, formatters.Options{})
+		document.SetValue(assignment, strings.TrimPrefix(line, assignment.Key+"="))
+	}
+	secret := false
+	for _, key := range variables.Keys() {
+		value, _ := variables.Get(key)
+		secret = secret || merge.isSecret(key, value)
+		if _, ok := firsts[key]; !ok {
+			document.Append(formatters.FormatENVLine(key, value, formatters.Options{}))
+		}
+	}
+
+	writer, finishOutput, err := merge.openOutput(secret)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(document.Bytes())
+	return finishOutput(err)
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertCommand_Execute(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(yamlPath, []byte("ZED: last\nALPHA: first value\n"), 0644); err != nil {
+		t.Fatalf("Failed to write YAML file: %v", err)
+	}
+
+	// The output file's name implies the format, and the input's order is kept
+	outputPath := filepath.Join(dir, "config.env")
+	if err := CreateConvertCommand(yamlPath, Options{Output: outputPath}, false).Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := "ZED=last\nALPHA=\"first value\"\n"
+	if data, _ := os.ReadFile(outputPath); string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+
+	if err := CreateConvertCommand(yamlPath, Options{Format: "env"}, true).Execute(); err == nil {
+		t.Errorf("Expected an error preserving comments of a YAML file")
+	}
+}
+
+func TestConvertCommand_PreserveComments(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "shared.env"), []byte("SHARED=yes\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	envPath := filepath.Join(dir, "app.env")
+	content := "# App settings\n" +
+		"#include shared.env\n" +
+		"\n" +
+		"export NAME='my app' # display name\n" +
+		"URL=http://${HOST}/api\n" +
+		"HOST=localhost\n" +
+		"NAME=other\n"
+	if err := os.WriteFile(envPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	outputPath := filepath.Join(dir, "out.env")
+	if err := CreateConvertCommand(envPath, Options{Output: outputPath}, true).Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	expected := "# App settings\n" +
+		"#include shared.env\n" +
+		"\n" +
+		"export NAME=other # display name\n" +
+		"URL=http://localhost/api\n" +
+		"HOST=localhost\n" +
+		"SHARED=yes\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"fmt"
+)
+
+// DecryptCommand decrypts a single SOPS file and writes it in any output format
+type DecryptCommand struct {
+	input   string
+	options Options
+}
+
+// CreateDecryptCommand creates a decrypt command writing the SOPS file input, a YAML, JSON, or
+// dotenv document, as options.Format to options.Output, or stdout. options.AWSProfile,
+// options.AWSRole, and options.KeyServices apply to its keys.
+func CreateDecryptCommand(input string, options Options) *DecryptCommand {
+	return &DecryptCommand{
+		input:   input,
+		options: options,
+	}
+}
+
+// Execute decrypts the input and writes its variables in the order the file defines them. The
+// output format defaults to the one the output file's name implies, then env.
+func (cmd *DecryptCommand) Execute() error {
+	if cmd.input == "" {
+		return fmt.Errorf("decrypt requires an input file")
+	}
+	options := cmd.options
+	if options.Format == "" {
+		options.Format = "env"
+		if format, ok := FormatForPath(options.Output); ok && options.Output != "" {
+			options.Format = format
+		}
+	}
+	options.Sort = "source"
+	return CreateMergeCommand([]Source{{FilePath: cmd.input, Type: "sops"}}, options).Execute()
+}
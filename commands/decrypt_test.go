@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecryptCommand_Execute_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if err := CreateDecryptCommand(filepath.Join(dir, "missing.enc.json"), Options{}).Execute(); err == nil || !strings.Contains(err.Error(), "missing.enc.json") {
+		t.Errorf("Expected an error naming the missing file, got %v", err)
+	}
+
+	// A file without SOPS metadata cannot be decrypted, and no output is written
+	plainPath := filepath.Join(dir, "plain.env")
+	if err := os.WriteFile(plainPath, []byte("NAME=app\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	outputPath := filepath.Join(dir, "out.json")
+	if err := CreateDecryptCommand(plainPath, Options{Output: outputPath}).Execute(); err == nil {
+		t.Errorf("Expected an error decrypting a plain file")
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no output file after a failed decryption")
+	}
+}
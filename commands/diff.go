@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// DiffCommand compares the variables merged from two groups of sources
+type DiffCommand struct {
+	from        []string
+	to          []string
+	format      string
+	showSecrets bool
+	out         io.Writer
+}
+
+// Diff is the difference between two sets of variables
+type Diff struct {
+	Added   []string     `json:"added"`   // Keys only in the second set
+	Removed []string     `json:"removed"` // Keys only in the first set
+	Changed []DiffChange `json:"changed"` // Keys whose values differ
+}
+
+// DiffChange is a key set to different values in the two sets
+type DiffChange struct {
+	Key  string `json:"key"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// CreateDiffCommand creates a diff command comparing the variables merged from the from files
+// with those merged from the to files (see SourcesForFiles), reported as "text" or "json".
+// Secret values are masked unless showSecrets is set.
+func CreateDiffCommand(from []string, to []string, format string, showSecrets bool) *DiffCommand {
+	return &DiffCommand{
+		from:        from,
+		to:          to,
+		format:      format,
+		showSecrets: showSecrets,
+		out:         os.Stdout,
+	}
+}
+
+// Execute merges both groups and writes their differences. Like diff(1), finding any is
+// reported as an *ExitError with code 1.
+func (cmd *DiffCommand) Execute() error {
+	if cmd.format != "text" && cmd.format != "json" {
+		return fmt.Errorf("unsupported diff format: %s", cmd.format)
+	}
+	if len(cmd.from) == 0 || len(cmd.to) == 0 {
+		return fmt.Errorf("diff requires sources on both sides")
+	}
+
+	from, fromCmd, err := cmd.merge(cmd.from)
+	if err != nil {
+		return err
+	}
+	to, toCmd, err := cmd.merge(cmd.to)
+	if err != nil {
+		return err
+	}
+
+	diff := DiffVariables(from, to)
+	if !cmd.showSecrets {
+		for i, change := range diff.Changed {
+			diff.Changed[i].From = fromCmd.mask(change.Key, change.From)
+			diff.Changed[i].To = toCmd.mask(change.Key, change.To)
+		}
+	}
+	if err := cmd.writeDiff(diff); err != nil {
+		return err
+	}
+	if !diff.Empty() {
+		return &ExitError{Code: 1}
+	}
+	return nil
+}
+
+// merge merges one side's files, returning the merge command for masking its values
+func (cmd *DiffCommand) merge(files []string) (*sources.Variables, *MergeCommand, error) {
+	sourceList, err := SourcesForFiles(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	mergeCmd := CreateMergeCommand(sourceList, Options{Format: "env", ShowSecrets: cmd.showSecrets})
+	variables, err := mergeCmd.Merge()
+	if err != nil {
+		return nil, nil, err
+	}
+	return variables, mergeCmd, nil
+}
+
+// DiffVariables returns the keys added, removed, and changed from one set of variables to
+// another, each sorted by key
+func DiffVariables(from *sources.Variables, to *sources.Variables) Diff {
+	diff := Diff{Added: []string{}, Removed: []string{}, Changed: []DiffChange{}}
+	for _, key := range sortedKeys(from) {
+		fromValue, _ := from.Get(key)
+		toValue, ok := to.Get(key)
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, key)
+		case fromValue != toValue:
+			diff.Changed = append(diff.Changed, DiffChange{Key: key, From: fromValue, To: toValue})
+		}
+	}
+	for _, key := range sortedKeys(to) {
+		if _, ok := from.Get(key); !ok {
+			diff.Added = append(diff.Added, key)
+		}
+	}
+	return diff
+}
+
+// Empty reports whether the two sets of variables are the same
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// sortedKeys returns the keys of variables in lexical order
+func sortedKeys(variables *sources.Variables) []string {
+	keys := variables.Keys()
+	slices.Sort(keys)
+	return keys
+}
+
+// writeDiff writes the diff as JSON, or as one line per key: "+ KEY" for added keys,
+// "- KEY" for removed ones, and "~ KEY: from -> to" for changed ones
+func (cmd *DiffCommand) writeDiff(diff Diff) error {
+	if cmd.format == "json" {
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode diff: %w", err)
+		}
+		_, err = fmt.Fprintln(cmd.out, string(data))
+		return err
+	}
+
+	for _, key := range diff.Added {
+		if _, err := fmt.Fprintf(cmd.out, "+ %s\n", key); err != nil {
+			return err
+		}
+	}
+	for _, key := range diff.Removed {
+		if _, err := fmt.Fprintf(cmd.out, "- %s\n", key); err != nil {
+			return err
+		}
+	}
+	for _, change := range diff.Changed {
+		if _, err := fmt.Fprintf(cmd.out, "~ %s: %q -> %q\n", change.Key, change.From, change.To); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+func TestDiffVariables(t *testing.T) {
+	from := sources.VariablesFromMap(map[string]string{"KEEP": "1", "CHANGE": "old", "REMOVE": "x"})
+	to := sources.VariablesFromMap(map[string]string{"KEEP": "1", "CHANGE": "new", "ADD": "y"})
+
+	diff := DiffVariables(from, to)
+	expected := Diff{
+		Added:   []string{"ADD"},
+		Removed: []string{"REMOVE"},
+		Changed: []DiffChange{{Key: "CHANGE", From: "old", To: "new"}},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, diff)
+	}
+	if !DiffVariables(from, from).Empty() {
+		t.Errorf("Expected no differences between identical sets")
+	}
+}
+
+func TestDiffCommand_Execute(t *testing.T) {
+	dir := t.TempDir()
+	devPath := filepath.Join(dir, "dev.env")
+	prodPath := filepath.Join(dir, "prod.json")
+	if err := os.WriteFile(devPath, []byte("HOST=localhost\nDB_PASSWORD=dev\nDEBUG=1\n"), 0600); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	if err := os.WriteFile(prodPath, []byte(`{"HOST": "example.com", "DB_PASSWORD": "prod", "REPLICAS": "3"}`), 0600); err != nil {
+		t.Fatalf("Failed to write JSON file: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := CreateDiffCommand([]string{devPath}, []string{prodPath}, "text", false)
+	cmd.out = &out
+	err := cmd.Execute()
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Fatalf("Expected exit code 1, got: %v", err)
+	}
+
+	expected := "+ REPLICAS\n- DEBUG\n~ DB_PASSWORD: \"********\" -> \"********\"\n~ HOST: \"localhost\" -> \"example.com\"\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+
+	out.Reset()
+	cmd = CreateDiffCommand([]string{devPath}, []string{devPath}, "text", false)
+	cmd.out = &out
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("Expected no differences, got: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no output, got %q", out.String())
+	}
+}
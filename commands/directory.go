@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DirectoryOptions controls which files a directory source loads
+type DirectoryOptions struct {
+	Recursive  bool     // Also load the files in subdirectories
+	Extensions []string // Extensions of the files to load, e.g. ".env" (empty uses the source type's)
+}
+
+// directoryExtensions are the extensions of the files each source type loads from a directory
+// by default
+var directoryExtensions = map[string][]string{
+	"env":        {".env"},
+	"json":       {".json"},
+	"yaml":       {".yaml", ".yml"},
+	"ini":        {".ini"},
+	"toml":       {".toml"},
+	"hcl":        {".hcl", ".tfvars"},
+	"properties": {".properties"},
+	"sops":       {".env", ".json", ".yaml", ".yml"},
+	"k8s":        {".yaml", ".yml", ".json"},
+}
+
+// isDirectory reports whether a source path names a directory
+func isDirectory(path string) bool {
+	if IsRemoteURL(path) || strings.HasPrefix(path, KubernetesReferencePrefix) {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// ExpandSourceDirectory lists the files a directory source loads, following the conf.d
+// convention: the files with one of the extensions directly inside dir (or anywhere below it
+// when recursive), in lexical order of their paths so later files override earlier ones.
+// Excluded files (see IsExcluded) are skipped, and a directory without matching files is an error.
+func ExpandSourceDirectory(dir string, sourceType string, options DirectoryOptions, excludes []string) ([]string, error) {
+	extensions := directoryExtensions[sourceType]
+	if len(options.Extensions) > 0 {
+		extensions = make([]string, 0, len(options.Extensions))
+		for _, extension := range options.Extensions {
+			extensions = append(extensions, "."+strings.TrimPrefix(strings.ToLower(extension), "."))
+		}
+	}
+
+	var matches []string
+	err := filepath.WalkDir(dir, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if current != dir && !options.Recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !slices.Contains(extensions, strings.ToLower(filepath.Ext(current))) || IsExcluded(current, excludes) {
+			return nil
+		}
+		matches = append(matches, current)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory '%s': %w", dir, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no %s files in directory '%s'", strings.Join(extensions, ", "), dir)
+	}
+
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// ExpandSource expands a source path into the files it names: the matching files of a
+// directory (see ExpandSourceDirectory), or otherwise the file or glob pattern's matches (see
+// ExpandSourcePath)
+func ExpandSource(path string, sourceType string, directory DirectoryOptions, excludes []string) ([]string, error) {
+	if isDirectory(path) {
+		return ExpandSourceDirectory(path, sourceType, directory, excludes)
+	}
+	return ExpandSourcePath(path, excludes)
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandSource_Directory(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"conf.d/20-app.env", "conf.d/10-base.env", "conf.d/README.md", "conf.d/30-db.yaml", "conf.d/local/40-dev.env"} {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("KEY=value\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	confDir := filepath.Join(dir, "conf.d") + string(filepath.Separator)
+	join := func(names ...string) []string {
+		paths := make([]string, len(names))
+		for i, name := range names {
+			paths[i] = filepath.Join(dir, "conf.d", filepath.FromSlash(name))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name       string
+		sourceType string
+		options    DirectoryOptions
+		excludes   []string
+		expected   []string
+	}{
+		{"env files", "env", DirectoryOptions{}, nil, join("10-base.env", "20-app.env")},
+		{"recursive", "env", DirectoryOptions{Recursive: true}, nil, join("10-base.env", "20-app.env", "local/40-dev.env")},
+		{"source type", "yaml", DirectoryOptions{}, nil, join("30-db.yaml")},
+		{"extensions", "env", DirectoryOptions{Extensions: []string{"yaml", ".ENV"}}, nil, join("10-base.env", "20-app.env", "30-db.yaml")},
+		{"excludes", "env", DirectoryOptions{}, []string{"20-*"}, join("10-base.env")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			paths, err := ExpandSource(confDir, test.sourceType, test.options, test.excludes)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if !reflect.DeepEqual(paths, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, paths)
+			}
+		})
+	}
+
+	if _, err := ExpandSource(confDir, "toml", DirectoryOptions{}, nil); err == nil {
+		t.Errorf("Expected an error for a directory without matching files")
+	}
+}
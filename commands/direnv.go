@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// DirenvStdlib defines the use_envvars function for direnv. Installed in direnv's direnvrc, it
+// lets an .envrc load merged variables with "use envvars [OPTIONS]", taking the same options as
+// envvars-cli itself.
+const DirenvStdlib = `# envvars-cli integration for direnv. Add this to ~/.config/direnv/direnvrc
+# (or eval "$(envvars-cli direnv)" there), then call "use envvars [OPTIONS]" in an .envrc:
+#
+#     use envvars --env .env --env .env.local
+#
+use_envvars() {
+  if ! has envvars-cli; then
+    log_error "use envvars: envvars-cli is not installed"
+    return 1
+  fi
+  local output
+  output="$(envvars-cli --direnv "$@")" || return
+  eval "$output"
+}
+`
+
+// WriteDirenvStdlib writes the direnv snippet defining use_envvars
+func WriteDirenvStdlib(w io.Writer) error {
+	_, err := fmt.Fprint(w, DirenvStdlib)
+	return err
+}
+
+// watchFiles returns the local files the merge read, in merge order and followed by the files
+// they #include, for direnv and --watch to watch
+func (cmd *MergeCommand) watchFiles() []string {
+	var paths []string
+	for _, source := range cmd.sources {
+		if source.FilePath == sources.StdinPath || IsRemoteURL(source.FilePath) || strings.HasPrefix(source.FilePath, KubernetesReferencePrefix) {
+			continue
+		}
+		if !slices.Contains(paths, source.FilePath) {
+			paths = append(paths, source.FilePath)
+		}
+	}
+	for _, path := range cmd.includes {
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
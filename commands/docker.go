@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/formatters"
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// Modes for passing merged variables to docker build
+const (
+	DockerBuildArgs = "build-args" // --build-arg KEY=value for each variable
+	DockerSecrets   = "secrets"    // A file per variable, passed with --secret id=KEY,src=FILE
+)
+
+// DockerArgsCommand turns merged variables into arguments for docker build
+type DockerArgsCommand struct {
+	files      []string
+	as         string
+	secretsDir string
+	print0     bool
+	out        io.Writer
+}
+
+// CreateDockerArgsCommand creates a docker-args command merging the given files (typed by
+// extension) and passing them as DockerBuildArgs or DockerSecrets. Secret files are written to
+// secretsDir. With print0 the arguments are NUL-terminated instead of shell-quoted.
+func CreateDockerArgsCommand(files []string, as string, secretsDir string, print0 bool) *DockerArgsCommand {
+	return &DockerArgsCommand{
+		files:      files,
+		as:         as,
+		secretsDir: secretsDir,
+		print0:     print0,
+		out:        os.Stdout,
+	}
+}
+
+// Execute merges the files and writes the docker build arguments
+func (cmd *DockerArgsCommand) Execute() error {
+	if cmd.as != DockerBuildArgs && cmd.as != DockerSecrets {
+		return fmt.Errorf("unsupported --as mode '%s', expected %s or %s", cmd.as, DockerBuildArgs, DockerSecrets)
+	}
+	if cmd.as == DockerSecrets && cmd.secretsDir == "" {
+		return fmt.Errorf("--as %s requires --secrets-dir for the secret files", DockerSecrets)
+	}
+	if len(cmd.files) == 0 {
+		return fmt.Errorf("docker-args requires at least one file (-f)")
+	}
+
+	sourceList, err := SourcesForFiles(cmd.files)
+	if err != nil {
+		return err
+	}
+
+	mergeCmd := CreateMergeCommand(sourceList, Options{Format: "env"})
+	variables, err := mergeCmd.Merge()
+	if err != nil {
+		return err
+	}
+
+	var args []string
+	switch cmd.as {
+	case DockerBuildArgs:
+		for _, key := range variables.Keys() {
+			value, _ := variables.Get(key)
+			if mergeCmd.isSecret(key, value) {
+				logging.Warnf("'%s' holds a secret, and build args are recorded in the image history; use --as %s", key, DockerSecrets)
+			}
+			args = append(args, "--build-arg", key+"="+value)
+		}
+	case DockerSecrets:
+		if args, err = cmd.writeSecrets(variables); err != nil {
+			return err
+		}
+	}
+	return cmd.writeArgs(args)
+}
+
+// writeSecrets writes each variable's value to its own owner-only file in the secrets directory
+// and returns the --secret arguments naming them. In the Dockerfile each is read with
+// RUN --mount=type=secret,id=KEY.
+func (cmd *DockerArgsCommand) writeSecrets(variables *sources.Variables) ([]string, error) {
+	if err := os.MkdirAll(cmd.secretsDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create secrets directory '%s': %w", cmd.secretsDir, err)
+	}
+
+	var args []string
+	for _, key := range variables.Keys() {
+		value, _ := variables.Get(key)
+		if key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+			return nil, fmt.Errorf("key '%s' cannot be used as a secret file name", key)
+		}
+		path := filepath.Join(cmd.secretsDir, key)
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, secretFileMode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create secret file '%s': %w", path, err)
+		}
+		// The mode only applies to new files, so an existing file is tightened explicitly
+		if err := file.Chmod(secretFileMode); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to restrict permissions of secret file '%s': %w", path, err)
+		}
+		if _, err := file.WriteString(value); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write secret file '%s': %w", path, err)
+		}
+		if err := file.Close(); err != nil {
+			return nil, fmt.Errorf("failed to write secret file '%s': %w", path, err)
+		}
+		args = append(args, "--secret", "id="+key+",src="+path)
+	}
+	return args, nil
+}
+
+// writeArgs writes the arguments NUL-terminated, or shell-quoted on one line for
+// eval "docker build $(envvars-cli docker-args ...) ."
+func (cmd *DockerArgsCommand) writeArgs(args []string) error {
+	if cmd.print0 {
+		for _, arg := range args {
+			if strings.ContainsRune(arg, 0) {
+				return fmt.Errorf("argument '%s' contains a NUL byte and cannot be written NUL-separated", strings.SplitN(arg, "=", 2)[0])
+			}
+			if _, err := io.WriteString(cmd.out, arg+"\x00"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = formatters.ShellQuote(arg)
+	}
+	_, err := fmt.Fprintln(cmd.out, strings.Join(quoted, " "))
+	return err
+}
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDockerArgsCommand_BuildArgs(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	jsonPath := filepath.Join(dir, "override.json")
+	if err := os.WriteFile(envPath, []byte("NAME=demo\nGREETING=\"hello world\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	if err := os.WriteFile(jsonPath, []byte(`{"NAME": "override"}`), 0644); err != nil {
+		t.Fatalf("Failed to write JSON file: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := CreateDockerArgsCommand([]string{envPath, jsonPath}, DockerBuildArgs, "", false)
+	cmd.out = &out
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := "--build-arg NAME=override --build-arg 'GREETING=hello world'\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+
+	out.Reset()
+	cmd.print0 = true
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected = "--build-arg\x00NAME=override\x00--build-arg\x00GREETING=hello world\x00"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestDockerArgsCommand_Secrets(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envPath, []byte("DB_PASSWORD=hunter2\n"), 0600); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	secretsDir := filepath.Join(dir, "secrets")
+
+	var out bytes.Buffer
+	cmd := CreateDockerArgsCommand([]string{envPath}, DockerSecrets, secretsDir, false)
+	cmd.out = &out
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	secretPath := filepath.Join(secretsDir, "DB_PASSWORD")
+	expected := "--secret " + "id=DB_PASSWORD,src=" + secretPath + "\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+	data, err := os.ReadFile(secretPath)
+	if err != nil {
+		t.Fatalf("Failed to read secret file: %v", err)
+	}
+	if string(data) != "hunter2" {
+		t.Errorf("Expected %q, got %q", "hunter2", string(data))
+	}
+}
+
+func TestDockerArgsCommand_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  *DockerArgsCommand
+	}{
+		{"unknown mode", CreateDockerArgsCommand([]string{".env"}, "labels", "", false)},
+		{"secrets without directory", CreateDockerArgsCommand([]string{".env"}, DockerSecrets, "", false)},
+		{"no files", CreateDockerArgsCommand(nil, DockerBuildArgs, "", false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cmd.Execute(); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
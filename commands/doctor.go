@@ -0,0 +1,337 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/notwillk/envvars-cli/sources"
+	"gopkg.in/yaml.v3"
+)
+
+// Doctor check statuses
+const (
+	DoctorOK   = "ok"
+	DoctorWarn = "warn"
+	DoctorFail = "fail"
+)
+
+// doctorSchemaTimeout bounds each request checking that a remote schema is reachable
+const doctorSchemaTimeout = 10 * time.Second
+
+// DoctorCheck is the outcome of one of the doctor's checks
+type DoctorCheck struct {
+	Status string // DoctorOK, DoctorWarn, or DoctorFail
+	Name   string // What was checked: a file, key type, schema, or binary
+	Detail string
+}
+
+// DoctorCommand checks that the sources of a merge can be read and what they need is available
+type DoctorCommand struct {
+	files      []string
+	configPath string
+	checks     []DoctorCheck
+	out        io.Writer
+	getenv     func(key string) string
+	lookPath   func(file string) (string, error)
+	client     *http.Client
+}
+
+// CreateDoctorCommand creates a doctor command checking the files (typed by extension), or
+// without any, the sources of the project config at configPath (found from the working
+// directory when empty)
+func CreateDoctorCommand(files []string, configPath string) *DoctorCommand {
+	return &DoctorCommand{
+		files:      files,
+		configPath: configPath,
+		out:        os.Stdout,
+		getenv:     os.Getenv,
+		lookPath:   exec.LookPath,
+		client:     &http.Client{Timeout: doctorSchemaTimeout, Transport: httpTransport},
+	}
+}
+
+// Execute runs the checks and prints a report, failing when any check failed. Each source must
+// exist and parse; SOPS files need credentials for one of their key types, JSON sources a
+// reachable $schema, and k8s:// sources kubectl. A missing git is a warning, since the checks
+// that sources holding secrets are ignored by git are then skipped.
+func (cmd *DoctorCommand) Execute() error {
+	sourceList := cmd.sources()
+	for _, source := range sourceList {
+		cmd.checkSource(source)
+	}
+	if slices.ContainsFunc(sourceList, func(source Source) bool {
+		return strings.HasPrefix(source.FilePath, KubernetesReferencePrefix)
+	}) {
+		cmd.checkBinary("kubectl", DoctorFail, "needed to read k8s:// sources")
+	}
+	cmd.checkBinary("git", DoctorWarn, "without it, sources holding secrets are not checked against .gitignore")
+
+	failures := 0
+	for _, check := range cmd.checks {
+		if check.Status == DoctorFail {
+			failures++
+		}
+		fmt.Fprintf(cmd.out, "%-6s %s: %s\n", "["+check.Status+"]", check.Name, check.Detail)
+	}
+	if failures > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", failures)
+	}
+	fmt.Fprintf(cmd.out, "No problems found\n")
+	return nil
+}
+
+// report records the outcome of a check
+func (cmd *DoctorCommand) report(status string, name string, format string, args ...any) {
+	cmd.checks = append(cmd.checks, DoctorCheck{Status: status, Name: name, Detail: fmt.Sprintf(format, args...)})
+}
+
+// sources returns the sources to check: the files, or the project config's
+func (cmd *DoctorCommand) sources() []Source {
+	if len(cmd.files) > 0 {
+		sourceList, err := SourcesForFiles(cmd.files)
+		if err != nil {
+			cmd.report(DoctorFail, "sources", "%v", err)
+		}
+		return sourceList
+	}
+
+	config, err := LoadProjectConfig(cmd.configPath)
+	if err != nil {
+		cmd.report(DoctorFail, "project config", "%v", err)
+		return nil
+	}
+	if config == nil {
+		cmd.report(DoctorFail, "sources", "none to check: give files with -f or add a project config (%s)", strings.Join(ProjectConfigNames, ", "))
+		return nil
+	}
+	cmd.report(DoctorOK, "project config", "%s", config.Path)
+	settings, err := config.Settings("")
+	if err == nil {
+		var sourceList []Source
+		if sourceList, err = config.ResolveSources(settings); err == nil {
+			return sourceList
+		}
+	}
+	cmd.report(DoctorFail, "project config", "%v", err)
+	return nil
+}
+
+// checkSource checks that a source exists and parses, after checking what its type needs
+func (cmd *DoctorCommand) checkSource(source Source) {
+	path := source.FilePath
+	local := path != sources.StdinPath && !IsRemoteURL(path) && !strings.HasPrefix(path, KubernetesReferencePrefix)
+	if local {
+		if _, err := os.Stat(path); err != nil {
+			cmd.report(DoctorFail, path, "%v", unwrapPathError(err))
+			return
+		}
+	}
+	if local && source.Type == "sops" && !cmd.checkSOPSKeys(source) {
+		return
+	}
+	if local && source.Type == "json" {
+		cmd.checkSchema(path)
+	}
+
+	variables, err := CreateMergeCommand([]Source{source}, Options{Format: "env"}).Merge()
+	if err != nil {
+		cmd.report(DoctorFail, path, "%v", err)
+		return
+	}
+	cmd.report(DoctorOK, path, "%s source, %d variable(s)", source.Type, variables.Len())
+}
+
+// sopsCredentials lists, by SOPS key type, the environment variables and files (relative to
+// the home directory) that provide credentials for it
+var sopsCredentials = map[string]struct {
+	env   []string
+	files []string
+}{
+	"age":      {env: []string{"SOPS_AGE_KEY", "SOPS_AGE_KEY_FILE", "SOPS_AGE_KEY_CMD"}, files: []string{".config/sops/age/keys.txt"}},
+	"kms":      {env: []string{"AWS_ACCESS_KEY_ID", "AWS_PROFILE", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_CONTAINER_CREDENTIALS_FULL_URI", "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"}, files: []string{".aws/credentials", ".aws/config"}},
+	"gcp_kms":  {env: []string{"GOOGLE_APPLICATION_CREDENTIALS", "GOOGLE_CREDENTIALS"}, files: []string{".config/gcloud/application_default_credentials.json"}},
+	"azure_kv": {env: []string{"AZURE_CLIENT_ID", "AZURE_FEDERATED_TOKEN_FILE"}},
+	"hc_vault": {env: []string{"VAULT_TOKEN"}, files: []string{".vault-token"}},
+}
+
+// checkSOPSKeys checks that credentials are configured for at least one of the key types a
+// SOPS file is encrypted for. It reports false when none are, since decrypting would fail.
+func (cmd *DoctorCommand) checkSOPSKeys(source Source) bool {
+	keyTypes, err := sopsKeyTypes(source.FilePath)
+	if err != nil {
+		cmd.report(DoctorFail, source.FilePath, "%v", err)
+		return false
+	}
+	if len(keyTypes) == 0 {
+		cmd.report(DoctorFail, source.FilePath, "no SOPS metadata; the file is not encrypted with SOPS")
+		return false
+	}
+
+	home, _ := os.UserHomeDir()
+	for _, keyType := range keyTypes {
+		if keyType == "pgp" {
+			if _, err := cmd.lookPath("gpg"); err == nil {
+				return true
+			}
+			continue
+		}
+		credentials := sopsCredentials[keyType]
+		if slices.ContainsFunc(credentials.env, func(name string) bool { return cmd.getenv(name) != "" }) {
+			return true
+		}
+		if (keyType == "kms" && (source.AWSProfile != "" || source.AWSRole != "")) || len(source.KeyServices) > 0 {
+			return true
+		}
+		for _, file := range credentials.files {
+			if _, err := os.Stat(filepath.Join(home, file)); home != "" && err == nil {
+				return true
+			}
+		}
+	}
+
+	cmd.report(DoctorFail, source.FilePath, "no credentials found for its SOPS keys (%s); set %s", strings.Join(keyTypes, ", "), sopsCredentialHint(keyTypes))
+	return false
+}
+
+// sopsCredentialHint suggests how to provide credentials for the key types
+func sopsCredentialHint(keyTypes []string) string {
+	var hints []string
+	for _, keyType := range keyTypes {
+		if keyType == "pgp" {
+			hints = append(hints, "install gpg with the private key")
+			continue
+		}
+		if credentials, ok := sopsCredentials[keyType]; ok {
+			hints = append(hints, "$"+credentials.env[0])
+		}
+	}
+	return strings.Join(hints, " or ")
+}
+
+// sopsKeyTypes returns the key types (age, pgp, kms, ...) listed in the metadata of the SOPS
+// file at filePath, which is YAML, JSON, or dotenv (see sources.SOPSFormatForPath)
+func sopsKeyTypes(filePath string) ([]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file '%s': %w", filePath, err)
+	}
+
+	var keyTypes []string
+	if sources.SOPSFormatForPath(filePath) == "env" {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			rest, found := strings.CutPrefix(scanner.Text(), "sops_")
+			keyType, _, hasList := strings.Cut(rest, "__list_")
+			if found && hasList && !slices.Contains(keyTypes, keyType) {
+				keyTypes = append(keyTypes, keyType)
+			}
+		}
+		return keyTypes, nil
+	}
+
+	// YAML parses JSON documents too
+	var document struct {
+		SOPS map[string]any `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse file '%s': %w", filePath, err)
+	}
+	for keyType, keys := range document.SOPS {
+		if list, ok := keys.([]any); ok && len(list) > 0 && keyType != "key_groups" {
+			keyTypes = append(keyTypes, keyType)
+		}
+	}
+	if groups, ok := document.SOPS["key_groups"].([]any); ok {
+		for _, group := range groups {
+			if group, ok := group.(map[string]any); ok {
+				for keyType := range group {
+					if !slices.Contains(keyTypes, keyType) {
+						keyTypes = append(keyTypes, keyType)
+					}
+				}
+			}
+		}
+	}
+	slices.Sort(keyTypes)
+	return keyTypes, nil
+}
+
+// checkSchema checks that the $schema a JSON source references, if any, is reachable
+func (cmd *DoctorCommand) checkSchema(filePath string) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return
+	}
+	var document map[string]any
+	if json.Unmarshal(data, &document) != nil {
+		return
+	}
+	schemaURL, ok := document["$schema"].(string)
+	if !ok || schemaURL == "" {
+		return
+	}
+
+	name := filePath + " $schema"
+	if err := sources.CheckSchemaScheme(schemaURL); err != nil {
+		cmd.report(DoctorFail, name, "%v", err)
+		return
+	}
+	if !IsRemoteURL(schemaURL) {
+		path := sources.LocalSchemaPath(schemaURL, filePath)
+		if _, err := os.Stat(path); err != nil {
+			cmd.report(DoctorFail, name, "%v", unwrapPathError(err))
+			return
+		}
+		cmd.report(DoctorOK, name, "%s", path)
+		return
+	}
+
+	parsed, err := url.Parse(schemaURL)
+	if err != nil {
+		cmd.report(DoctorFail, name, "invalid URL: %v", unwrapURLError(err))
+		return
+	}
+	response, err := cmd.client.Get(schemaURL)
+	if err != nil {
+		cmd.report(DoctorFail, name, "%s is unreachable: %v", redactURL(parsed), unwrapURLError(err))
+		return
+	}
+	response.Body.Close()
+	if response.StatusCode >= 400 {
+		cmd.report(DoctorFail, name, "%s returned %s", redactURL(parsed), response.Status)
+		return
+	}
+	cmd.report(DoctorOK, name, "%s is reachable", redactURL(parsed))
+}
+
+// checkBinary checks that a program is on the PATH, reporting status with why it is needed
+// when it is not
+func (cmd *DoctorCommand) checkBinary(name string, status string, reason string) {
+	path, err := cmd.lookPath(name)
+	if err != nil {
+		cmd.report(status, name, "not found on the PATH; %s", reason)
+		return
+	}
+	cmd.report(DoctorOK, name, "%s", path)
+}
+
+// unwrapPathError drops the operation and path of an *fs.PathError, which the report already names
+func unwrapPathError(err error) error {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDoctorCommand_Execute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/schema.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"type": "object"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.env":         "A=1\nB=2\n",
+		"broken.json":      "{",
+		"remote.json":      `{"$schema": "` + server.URL + `/schema.json", "C": "3"}`,
+		"gone.json":        `{"$schema": "` + server.URL + `/gone.json", "D": "4"}`,
+		"secrets.enc.yaml": "PASSWORD: ENC[AES256_GCM,data:abc]\nsops:\n    age:\n        - recipient: age1abc\n",
+	}
+	var paths []string
+	for _, name := range []string{"base.env", "broken.json", "remote.json", "gone.json", "missing.env"} {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	cmd := CreateDoctorCommand(append(paths, "type=sops,key=dev,path="+filepath.Join(dir, "secrets.enc.yaml")), "")
+	var out bytes.Buffer
+	cmd.out = &out
+	cmd.getenv = func(string) string { return "" }
+	cmd.lookPath = func(file string) (string, error) { return "", errors.New("not found") }
+	t.Setenv("HOME", dir)
+
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "5 problem(s)") {
+		t.Errorf("Expected 5 problems, got %v\n%s", err, out.String())
+	}
+	var statuses []string
+	for _, check := range cmd.checks {
+		statuses = append(statuses, check.Status+" "+filepath.Base(check.Name))
+	}
+	expected := []string{
+		"ok base.env",
+		"fail broken.json",
+		"ok remote.json $schema",
+		"ok remote.json",
+		"fail gone.json $schema",
+		"fail gone.json",
+		"fail missing.env",
+		"fail secrets.enc.yaml",
+		"warn git",
+	}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("Expected %v, got %v", expected, statuses)
+	}
+	if !strings.Contains(out.String(), "no credentials found for its SOPS keys (age); set $SOPS_AGE_KEY") {
+		t.Errorf("Expected the missing age key to be reported, got:\n%s", out.String())
+	}
+}
+
+func TestSOPSKeyTypes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"secrets.enc.yaml": "A: ENC[x]\nsops:\n    kms:\n        - arn: arn:aws:kms:us-east-1:1:key/1\n    pgp: []\n    age:\n        - recipient: age1abc\n",
+		"secrets.enc.json": `{"A": "ENC[x]", "sops": {"key_groups": [{"pgp": [{"fp": "ABC"}]}]}}`,
+		"secrets.enc.env":  "A=ENC[x]\nsops_age__list_0__map_recipient=age1abc\nsops_version=3.10.2\n",
+	}
+	expected := map[string][]string{
+		"secrets.enc.yaml": {"age", "kms"},
+		"secrets.enc.json": {"pgp"},
+		"secrets.enc.env":  {"age"},
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		keyTypes, err := sopsKeyTypes(path)
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got: %v", name, err)
+		}
+		if !reflect.DeepEqual(keyTypes, expected[name]) {
+			t.Errorf("Expected %v for %s, got %v", expected[name], name, keyTypes)
+		}
+	}
+}
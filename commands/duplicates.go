@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// Policies for keys set more than once, within a file or across sources
+const (
+	OnDuplicateWarn      = "warn"       // Warn on stderr; the later value wins
+	OnDuplicateError     = "error"      // Fail the merge
+	OnDuplicateFirstWins = "first-wins" // The first value set wins
+	OnDuplicateLastWins  = "last-wins"  // The later value wins (the default)
+)
+
+// OnDuplicatePolicies lists the duplicate key policies
+var OnDuplicatePolicies = []string{OnDuplicateWarn, OnDuplicateError, OnDuplicateFirstWins, OnDuplicateLastWins}
+
+// duplicateModes maps each policy to the parser's handling of a key assigned twice in one file
+var duplicateModes = map[string]string{
+	OnDuplicateWarn:      sources.DuplicatesWarn,
+	OnDuplicateError:     sources.DuplicatesError,
+	OnDuplicateFirstWins: sources.DuplicatesFirst,
+	OnDuplicateLastWins:  sources.DuplicatesLast,
+}
+
+// duplicates returns the handling of a key assigned twice in one env file: the one implied by
+// the duplicate key policy, if any, otherwise the Duplicates option
+func (cmd *MergeCommand) duplicates() string {
+	if mode, ok := duplicateModes[cmd.options.OnDuplicate]; ok {
+		return mode
+	}
+	return cmd.options.Duplicates
+}
+
+// duplicateChecker applies a duplicate key policy to keys a source sets that an earlier
+// source already set
+type duplicateChecker struct {
+	policy  string
+	origins map[string]string // Source file that set each key first
+}
+
+// newDuplicateChecker creates a checker for policy, or returns nil when sources may override
+// each other freely
+func newDuplicateChecker(policy string, interactive bool) (*duplicateChecker, error) {
+	if policy == "" {
+		return nil, nil
+	}
+	if _, ok := duplicateModes[policy]; !ok {
+		return nil, fmt.Errorf("unsupported duplicate key policy '%s', expected %s", policy, strings.Join(OnDuplicatePolicies, ", "))
+	}
+	if interactive {
+		return nil, fmt.Errorf("--interactive chooses the value of keys set by several sources, so it cannot be combined with --on-duplicate")
+	}
+	if policy == OnDuplicateLastWins {
+		return nil, nil
+	}
+	return &duplicateChecker{policy: policy, origins: make(map[string]string)}, nil
+}
+
+// check reports each key envFile sets that variables already hold, failing under the error
+// policy, and returns the earlier values to keep under first-wins
+func (c *duplicateChecker) check(variables *sources.Variables, source Source, envFile sources.EnvFile) (map[string]string, error) {
+	keep := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, variable := range envFile.Variables {
+		if seen[variable.Key] {
+			continue
+		}
+		seen[variable.Key] = true
+
+		current, exists := variables.Get(variable.Key)
+		if !exists {
+			c.origins[variable.Key] = source.FilePath
+			continue
+		}
+
+		location := fmt.Sprintf("'%s'", source.FilePath)
+		if variable.Line > 0 {
+			location = fmt.Sprintf("line %d of '%s'", variable.Line, source.FilePath)
+		}
+		switch c.policy {
+		case OnDuplicateError:
+			return nil, fmt.Errorf("duplicate key '%s': set by '%s' and again at %s", variable.Key, c.origins[variable.Key], location)
+		case OnDuplicateWarn:
+			logging.Warnf("duplicate key '%s': set by '%s' and again at %s, whose value wins", variable.Key, c.origins[variable.Key], location)
+		case OnDuplicateFirstWins:
+			keep[variable.Key] = current
+		}
+	}
+	return keep, nil
+}
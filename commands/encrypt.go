@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// EncryptCommand merges sources and writes the result as a SOPS-encrypted file
+type EncryptCommand struct {
+	files  []string
+	output string
+	format string
+	keys   sources.SOPSEncryptionKeys
+	out    io.Writer
+}
+
+// CreateEncryptCommand creates an encrypt command merging the files (typed by extension) and
+// encrypting the result for keys as format ("yaml", "json", or "env") into output, or stdout
+func CreateEncryptCommand(files []string, output string, format string, keys sources.SOPSEncryptionKeys) *EncryptCommand {
+	return &EncryptCommand{
+		files:  files,
+		output: output,
+		format: format,
+		keys:   keys,
+		out:    os.Stdout,
+	}
+}
+
+// Execute merges the files and writes them encrypted, in the order they define the variables.
+// The format defaults to the one the output file's name implies, then yaml.
+func (cmd *EncryptCommand) Execute() error {
+	if len(cmd.files) == 0 {
+		return fmt.Errorf("encrypt requires at least one file (-f)")
+	}
+	format := cmd.format
+	if format == "" {
+		format = "yaml"
+		if implied, ok := FormatForPath(cmd.output); ok && cmd.output != "" && slices.Contains(sources.SOPSFormats(), implied) {
+			format = implied
+		}
+	}
+
+	sourceList, err := SourcesForFiles(cmd.files)
+	if err != nil {
+		return err
+	}
+	variables, err := CreateMergeCommand(sourceList, Options{Format: "env"}).Merge()
+	if err != nil {
+		return err
+	}
+	var list []sources.EnvVar
+	for _, key := range variables.Keys() {
+		value, _ := variables.Get(key)
+		list = append(list, sources.EnvVar{Key: key, Value: value})
+	}
+
+	// The SOPS config is looked up from the output's directory, or the working directory
+	path := cmd.output
+	if path == "" {
+		path = "stdout." + format
+	}
+	encrypted, err := sources.EncryptSOPSData(list, format, path, cmd.keys)
+	if err != nil {
+		return err
+	}
+
+	if cmd.output == "" {
+		_, err = cmd.out.Write(encrypted)
+		return err
+	}
+	if err := os.WriteFile(cmd.output, encrypted, 0644); err != nil {
+		return fmt.Errorf("failed to write file '%s': %w", cmd.output, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+func TestEncryptCommand_Execute_Errors(t *testing.T) {
+	t.Setenv(sources.SOPSAgeRecipientsEnv, "")
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.env")
+	if err := os.WriteFile(basePath, []byte("PASSWORD=hunter2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	keys := sources.SOPSEncryptionKeys{Age: []string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"}}
+
+	tests := []struct {
+		name     string
+		files    []string
+		output   string
+		format   string
+		keys     sources.SOPSEncryptionKeys
+		expected string
+	}{
+		{"no files", nil, "", "", keys, "at least one file"},
+		{"unsupported format", []string{basePath}, "", "toml", keys, "unsupported SOPS format 'toml'"},
+		{"no keys", []string{basePath}, filepath.Join(dir, "secrets.enc.yaml"), "", sources.SOPSEncryptionKeys{}, "no SOPS keys"},
+		{"missing file", []string{filepath.Join(dir, "missing.env")}, "", "", keys, "missing.env"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CreateEncryptCommand(test.files, test.output, test.format, test.keys).Execute()
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Expected an error containing %q, got %v", test.expected, err)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(dir, "secrets.enc.yaml")); !os.IsNotExist(err) {
+		t.Errorf("Expected no output file after a failed encryption")
+	}
+}
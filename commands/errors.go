@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// errorReport is the machine-readable form of an error printed with --error-format json
+type errorReport struct {
+	Error  string `json:"error"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// PrintError writes err to stderr, either as text or, when format is "json", as a single
+// JSON object including the file, line, and column the error was found at (when known)
+func PrintError(err error, format string) {
+	if format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	report := errorReport{Error: err.Error()}
+	var parseErr *sources.ParseError
+	if errors.As(err, &parseErr) {
+		report.File = parseErr.File
+		report.Line = parseErr.Line
+		report.Column = parseErr.Column
+	}
+
+	data, marshalErr := json.Marshal(report)
+	if marshalErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, string(data))
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// DefaultFetchConcurrency is the number of simultaneous fetches allowed per backend by default
+const DefaultFetchConcurrency = 4
+
+// remoteSourceTypes lists the source types whose loading may involve network round trips
+// (SOPS decryption can call out to KMS, Vault, or a key service)
+var remoteSourceTypes = map[string]bool{
+	"sops": true,
+}
+
+// fetchBackend returns the backend whose concurrency and rate limits apply to loading a
+// source, or "" for local sources
+func fetchBackend(source Source) string {
+	if IsRemoteURL(source.FilePath) {
+		return "http"
+	}
+	if remoteSourceTypes[source.Type] {
+		return source.Type
+	}
+	return ""
+}
+
+// rateLimiter spaces out fetch starts to at most a fixed number per second
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter creates a limiter for perSecond fetches per second (nil when unlimited)
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next fetch is allowed to start
+func (r *rateLimiter) wait() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	start := r.next
+	if start.Before(now) {
+		start = now
+	}
+	r.next = start.Add(r.interval)
+	r.mu.Unlock()
+
+	time.Sleep(time.Until(start))
+}
+
+// backendLimits holds the concurrency and rate limits for a single backend
+type backendLimits struct {
+	slots   chan struct{}
+	limiter *rateLimiter
+}
+
+// prefetchRemoteSources loads all remote-backed sources in parallel, honoring per-backend
+// concurrency and rate limits. Results are keyed by the source's index in cmd.sources.
+// Errors from all failed sources are aggregated into a single error, in source order.
+func (cmd *MergeCommand) prefetchRemoteSources() (map[int]sources.EnvFile, error) {
+	concurrency := cmd.options.FetchConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultFetchConcurrency
+	}
+
+	backends := make(map[string]*backendLimits)
+	results := make(map[int]sources.EnvFile)
+	failures := make([]error, len(cmd.sources))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	pending := make(map[int]bool)
+	for i, source := range cmd.sources {
+		if fetchBackend(source) == "" {
+			continue
+		}
+		if _, ok := cmd.cache.get(source); ok {
+			continue
+		}
+		pending[i] = true
+	}
+
+	progress := &fetchProgress{total: len(pending)}
+	if logging.Enabled(logging.LevelWarn) {
+		progress.out = cmd.progressOut
+	}
+	start := time.Now()
+
+	for i, source := range cmd.sources {
+		if !pending[i] {
+			continue
+		}
+
+		backend := fetchBackend(source)
+		limits, ok := backends[backend]
+		if !ok {
+			limits = &backendLimits{
+				slots:   make(chan struct{}, concurrency),
+				limiter: newRateLimiter(cmd.options.FetchRate),
+			}
+			backends[backend] = limits
+		}
+
+		wg.Add(1)
+		go func(i int, source Source, limits *backendLimits) {
+			defer wg.Done()
+
+			limits.slots <- struct{}{}
+			defer func() { <-limits.slots }()
+			limits.limiter.wait()
+
+			progress.started(source)
+			fetchStart := time.Now()
+			envFile, err := cmd.cachedLoadSource(source)
+			progress.finished(source, time.Since(fetchStart), err)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures[i] = err
+				return
+			}
+			results[i] = envFile
+		}(i, source, limits)
+	}
+
+	wg.Wait()
+	progress.summarize(time.Since(start))
+
+	if err := errors.Join(failures...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
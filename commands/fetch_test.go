@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notwillk/envvars-cli/logging"
+)
+
+func TestRateLimiter_SpacesFetches(t *testing.T) {
+	limiter := newRateLimiter(20) // one fetch every 50ms
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.wait()
+	}
+	elapsed := time.Since(start)
+
+	// The first fetch starts immediately, the next two wait one interval each
+	if elapsed < 90*time.Millisecond {
+		t.Errorf("Expected fetches to be spaced by the rate limit, took only %v", elapsed)
+	}
+}
+
+func TestRateLimiter_Unlimited(t *testing.T) {
+	limiter := newRateLimiter(0)
+	if limiter != nil {
+		t.Fatal("Expected no limiter when the rate is unlimited")
+	}
+	// A nil limiter never blocks
+	limiter.wait()
+}
+
+func TestPrefetchRemoteSources_AggregatesErrors(t *testing.T) {
+	sources := []Source{
+		{FilePath: "missing-one.yaml", Type: "sops", Priority: 0, DecryptionKey: "key"},
+		{FilePath: "config.env", Type: "env", Priority: 1},
+		{FilePath: "missing-two.yaml", Type: "sops", Priority: 2, DecryptionKey: "key"},
+	}
+	cmd := CreateMergeCommand(sources, Options{FetchConcurrency: 1, FetchRate: 100})
+
+	_, err := cmd.prefetchRemoteSources()
+	if err == nil {
+		t.Fatal("Expected an error for missing SOPS files")
+	}
+
+	message := err.Error()
+	if !strings.Contains(message, "missing-one.yaml") || !strings.Contains(message, "missing-two.yaml") {
+		t.Errorf("Expected errors for both failed sources, got: %v", err)
+	}
+	if strings.Index(message, "missing-one.yaml") > strings.Index(message, "missing-two.yaml") {
+		t.Errorf("Expected errors in source order, got: %v", err)
+	}
+	if strings.Contains(message, "config.env") {
+		t.Errorf("Expected local env sources not to be prefetched, got: %v", err)
+	}
+}
+
+func TestPrefetchRemoteSources_Progress(t *testing.T) {
+	sources := []Source{
+		{FilePath: "missing-one.yaml", Type: "sops", Priority: 0, DecryptionKey: "key"},
+		{FilePath: "config.env", Type: "env", Priority: 1},
+		{FilePath: "missing-two.yaml", Type: "sops", Priority: 2, DecryptionKey: "key"},
+	}
+	cmd := CreateMergeCommand(sources, Options{FetchConcurrency: 1})
+	var progress bytes.Buffer
+	cmd.progressOut = &progress
+
+	var logs bytes.Buffer
+	previous := logging.SetOutput(&logs)
+	defer logging.SetOutput(previous)
+	logging.SetLevel(logging.LevelInfo)
+	defer logging.SetLevel(logging.LevelWarn)
+
+	if _, err := cmd.prefetchRemoteSources(); err == nil {
+		t.Fatal("Expected an error for missing SOPS files")
+	}
+
+	output := progress.String()
+	for _, text := range []string{"Fetching sops source 'missing-one.yaml'...", "[1/2] failed sops source", "[2/2] failed sops source"} {
+		if !strings.Contains(output, text) {
+			t.Errorf("Expected progress to contain %q, got:\n%s", text, output)
+		}
+	}
+	if strings.Contains(output, "config.env") {
+		t.Errorf("Expected no progress for local sources, got:\n%s", output)
+	}
+	if !strings.Contains(logs.String(), "Fetched 2 remote source(s) in") || !strings.Contains(logs.String(), "'missing-two.yaml'") {
+		t.Errorf("Expected a timing summary in verbose output, got:\n%s", logs.String())
+	}
+}
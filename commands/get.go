@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// GetCommand prints the merged value of a single variable
+type GetCommand struct {
+	key   string
+	files []string
+	out   io.Writer
+}
+
+// CreateGetCommand creates a get command printing key's value after merging the files (typed
+// by extension)
+func CreateGetCommand(key string, files []string) *GetCommand {
+	return &GetCommand{
+		key:   key,
+		files: files,
+		out:   os.Stdout,
+	}
+}
+
+// Execute merges the files and writes the value as is, without quoting, followed by a newline.
+// A key the merge does not set is an error.
+func (cmd *GetCommand) Execute() error {
+	if cmd.key == "" {
+		return fmt.Errorf("get requires a key")
+	}
+	if len(cmd.files) == 0 {
+		return fmt.Errorf("get requires at least one file (-f)")
+	}
+
+	sourceList, err := SourcesForFiles(cmd.files)
+	if err != nil {
+		return err
+	}
+	variables, err := CreateMergeCommand(sourceList, Options{Format: "env"}).Merge()
+	if err != nil {
+		return err
+	}
+
+	value, ok := variables.Get(cmd.key)
+	if !ok {
+		return fmt.Errorf("key '%s' is not set", cmd.key)
+	}
+	_, err = fmt.Fprintln(cmd.out, value)
+	return err
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetCommand_Execute(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.env")
+	prodPath := filepath.Join(dir, "prod.env")
+	if err := os.WriteFile(basePath, []byte("HOST=localhost\nDATABASE_URL=postgres://${HOST}/app\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	if err := os.WriteFile(prodPath, []byte("DATABASE_URL=\"postgres://db.internal/app with space\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := CreateGetCommand("DATABASE_URL", []string{basePath, prodPath})
+	cmd.out = &out
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "postgres://db.internal/app with space\n" {
+		t.Errorf("Expected the raw value, got %q", out.String())
+	}
+
+	out.Reset()
+	cmd = CreateGetCommand("DATABASE_URL", []string{basePath})
+	cmd.out = &out
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "postgres://localhost/app\n" {
+		t.Errorf("Expected the resolved value, got %q", out.String())
+	}
+
+	err := CreateGetCommand("MISSING", []string{basePath}).Execute()
+	if err == nil || !strings.Contains(err.Error(), "key 'MISSING' is not set") {
+		t.Errorf("Expected a missing key error, got %v", err)
+	}
+}
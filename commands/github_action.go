@@ -0,0 +1,226 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/formatters"
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// GitHubActionCommand runs a merge as a GitHub Actions step: inputs come from INPUT_* variables,
+// the merged variables are written to $GITHUB_ENV and/or $GITHUB_OUTPUT, secrets are masked in
+// the job log, and problems are reported as annotations
+type GitHubActionCommand struct {
+	getenv func(string) string
+	out    io.Writer // Where workflow commands are written
+}
+
+// CreateGitHubActionCommand creates a GitHub Action command reading its inputs and the
+// runner's file paths through getenv
+func CreateGitHubActionCommand(getenv func(string) string) *GitHubActionCommand {
+	return &GitHubActionCommand{getenv: getenv, out: os.Stdout}
+}
+
+// input returns the named action input. The runner exposes inputs as INPUT_<NAME> with the
+// name upper-cased and spaces replaced by underscores.
+func (cmd *GitHubActionCommand) input(name string) string {
+	return strings.TrimSpace(cmd.getenv("INPUT_" + strings.ToUpper(strings.ReplaceAll(name, " ", "_"))))
+}
+
+// listInput splits a multi-line or comma-separated input into its non-empty entries
+func (cmd *GitHubActionCommand) listInput(name string) []string {
+	var values []string
+	for _, value := range strings.FieldsFunc(cmd.input(name), func(r rune) bool { return r == '\n' || r == ',' }) {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// boolInput reads a "true"/"false" input, using fallback when it is not set
+func (cmd *GitHubActionCommand) boolInput(name string, fallback bool) (bool, error) {
+	switch strings.ToLower(cmd.input(name)) {
+	case "":
+		return fallback, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return false, fmt.Errorf("input '%s' must be true or false, got '%s'", name, cmd.input(name))
+	}
+}
+
+// Execute merges the sources given by the inputs and hands the result to the runner. Errors are
+// also emitted as error annotations, and warnings as warning annotations.
+func (cmd *GitHubActionCommand) Execute() error {
+	previous := logging.SetWarningHook(func(message string) {
+		cmd.command("warning", nil, message)
+	})
+	defer logging.SetWarningHook(previous)
+
+	err := cmd.execute()
+	if err != nil {
+		var parseErr *sources.ParseError
+		if errors.As(err, &parseErr) {
+			properties := []string{"file=" + parseErr.File}
+			if parseErr.Line > 0 {
+				properties = append(properties, fmt.Sprintf("line=%d", parseErr.Line))
+			}
+			if parseErr.Column > 0 {
+				properties = append(properties, fmt.Sprintf("col=%d", parseErr.Column))
+			}
+			cmd.command("error", properties, err.Error())
+		} else {
+			cmd.command("error", nil, err.Error())
+		}
+	}
+	return err
+}
+
+// execute runs the merge and writes its result
+func (cmd *GitHubActionCommand) execute() error {
+	exportEnv, err := cmd.boolInput("export-env", true)
+	if err != nil {
+		return err
+	}
+	setOutputs, err := cmd.boolInput("outputs", false)
+	if err != nil {
+		return err
+	}
+	strict, err := cmd.boolInput("strict", false)
+	if err != nil {
+		return err
+	}
+
+	var sourceList []Source
+	add := func(pattern string, sourceType string, decryptionKey string) error {
+		paths, err := ExpandSourcePath(pattern, nil)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			sourceList = append(sourceList, Source{FilePath: path, Type: sourceType, Priority: len(sourceList), DecryptionKey: decryptionKey})
+		}
+		return nil
+	}
+	for _, sourceType := range []string{"env", "json", "yaml"} {
+		for _, pattern := range cmd.listInput(sourceType) {
+			if err := add(pattern, sourceType, ""); err != nil {
+				return err
+			}
+		}
+	}
+	for _, value := range cmd.listInput("sops") {
+		key, path, ok := strings.Cut(value, "@")
+		if !ok {
+			return fmt.Errorf("invalid sops input '%s', expected [key_name]@[path-to-file]", value)
+		}
+		if err := add(path, "sops", key); err != nil {
+			return err
+		}
+	}
+	if len(sourceList) == 0 {
+		return fmt.Errorf("no sources given; set at least one of the env, json, yaml, or sops inputs")
+	}
+
+	mergeCmd := CreateMergeCommand(sourceList, Options{
+		Format:         "env",
+		Strict:         strict,
+		Require:        cmd.listInput("require"),
+		SecretPatterns: cmd.listInput("secret-patterns"),
+	})
+	variables, err := mergeCmd.Merge()
+	if err != nil {
+		return err
+	}
+
+	// Mask secrets before they can reach the log through later steps
+	keys := variables.Keys()
+	slices.Sort(keys)
+	var secrets []string
+	for _, key := range keys {
+		value, _ := variables.Get(key)
+		if mergeCmd.isSecret(key, value) {
+			secrets = append(secrets, value)
+		}
+	}
+	maskSecrets(cmd.out, secrets)
+
+	if exportEnv {
+		if err := cmd.appendFileCommand("GITHUB_ENV", keys, variables); err != nil {
+			return err
+		}
+	}
+	if setOutputs {
+		if err := cmd.appendFileCommand("GITHUB_OUTPUT", keys, variables); err != nil {
+			return err
+		}
+	}
+	logging.Infof("Exported %d variables", len(keys))
+	return nil
+}
+
+// appendFileCommand appends the variables to the runner file named by the fileVariable
+// environment variable, using the multi-line KEY<<DELIMITER syntax so any value is safe
+func (cmd *GitHubActionCommand) appendFileCommand(fileVariable string, keys []string, variables *sources.Variables) error {
+	path := cmd.getenv(fileVariable)
+	if path == "" {
+		return fmt.Errorf("$%s is not set; --github-action must run inside a GitHub Actions step", fileVariable)
+	}
+
+	var builder strings.Builder
+	if err := formatters.OutputAsGitHub(variables.Map(), formatters.Options{Order: keys, Writer: &builder}); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open $%s '%s': %w", fileVariable, path, err)
+	}
+	if _, err := file.WriteString(builder.String()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write $%s '%s': %w", fileVariable, path, err)
+	}
+	return file.Close()
+}
+
+// command writes a workflow command to the step's output
+func (cmd *GitHubActionCommand) command(name string, properties []string, message string) {
+	writeWorkflowCommand(cmd.out, name, properties, message)
+}
+
+// maskSecrets writes an add-mask workflow command for each line of a secret value, so the runner
+// hides them in the log of the job
+func maskSecrets(w io.Writer, secrets []string) {
+	for _, value := range secrets {
+		for _, line := range strings.Split(value, "\n") {
+			if strings.TrimSpace(line) != "" {
+				writeWorkflowCommand(w, "add-mask", nil, line)
+			}
+		}
+	}
+}
+
+// writeWorkflowCommand writes a workflow command, escaping its properties and message as the runner expects
+func writeWorkflowCommand(w io.Writer, name string, properties []string, message string) {
+	escape := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	escapeProperty := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+	for i, property := range properties {
+		key, value, _ := strings.Cut(property, "=")
+		properties[i] = key + "=" + escapeProperty.Replace(value)
+	}
+
+	command := "::" + name
+	if len(properties) > 0 {
+		command += " " + strings.Join(properties, ",")
+	}
+	fmt.Fprintf(w, "%s::%s\n", command, escape.Replace(message))
+}
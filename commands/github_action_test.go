@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitHubActionCommand_Execute(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envPath, []byte("APP_NAME=demo\nDB_PASSWORD=\"line1\\nline2\"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	githubEnv := filepath.Join(dir, "github_env")
+	githubOutput := filepath.Join(dir, "github_output")
+
+	inputs := map[string]string{
+		"INPUT_ENV":     envPath,
+		"INPUT_OUTPUTS": "true",
+		"GITHUB_ENV":    githubEnv,
+		"GITHUB_OUTPUT": githubOutput,
+	}
+	var out bytes.Buffer
+	cmd := CreateGitHubActionCommand(func(name string) string { return inputs[name] })
+	cmd.out = &out
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := "::add-mask::line1\n::add-mask::line2\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+	for _, path := range []string{githubEnv, githubOutput} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		if !strings.Contains(string(data), "\ndemo\n") || !strings.Contains(string(data), "\nline1\nline2\n") {
+			t.Errorf("Expected both values in heredoc form in %s, got %q", path, string(data))
+		}
+		if !strings.HasPrefix(string(data), "APP_NAME<<ghadelimiter_") {
+			t.Errorf("Expected APP_NAME first in %s, got %q", path, string(data))
+		}
+	}
+}
+
+func TestGitHubActionCommand_ErrorAnnotations(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputs   map[string]string
+		expected string
+	}{
+		{"no sources", map[string]string{}, "::error::no sources given"},
+		{"bad boolean", map[string]string{"INPUT_STRICT": "yes"}, "::error::input 'strict' must be true or false"},
+		{"missing file", map[string]string{"INPUT_ENV": "missing.env", "GITHUB_ENV": "unused"}, "::error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := CreateGitHubActionCommand(func(name string) string { return tt.inputs[name] })
+			cmd.out = &out
+			if err := cmd.Execute(); err == nil {
+				t.Fatalf("Expected an error")
+			}
+			if !strings.HasPrefix(out.String(), tt.expected) {
+				t.Errorf("Expected output starting with %q, got %q", tt.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestGitHubActionCommand_EscapesAnnotations(t *testing.T) {
+	var out bytes.Buffer
+	cmd := CreateGitHubActionCommand(func(string) string { return "" })
+	cmd.out = &out
+	cmd.command("error", []string{"file=a,b:c.env", "line=3"}, "100% bad\nvalue")
+
+	expected := "::error file=a%2Cb%3Ac.env,line=3::100%25 bad%0Avalue\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// holdsSecrets reports whether envFile has a sensitive value (see isSecret)
+func (cmd *MergeCommand) holdsSecrets(envFile sources.EnvFile) bool {
+	return slices.ContainsFunc(envFile.Variables, func(variable sources.EnvVar) bool {
+		return cmd.isSecret(variable.Key, variable.Value)
+	})
+}
+
+// checkIgnored warns when a plaintext source holding secrets lies in a git repository without
+// being covered by .gitignore, where it is one "git add" away from being committed. Under --strict
+// this is an error.
+func (cmd *MergeCommand) checkIgnored(source Source) error {
+	ignored, err := isGitIgnored(source.FilePath)
+	if err != nil {
+		logging.Debugf("Could not check whether '%s' is ignored by git: %v", source.FilePath, err)
+		return nil
+	}
+	if ignored {
+		return nil
+	}
+
+	if cmd.options.Strict {
+		return fmt.Errorf("'%s' contains secrets but is not ignored by git; --strict requires it to be in .gitignore", source.FilePath)
+	}
+	logging.Warnf("'%s' contains secrets but is not ignored by git; add it to .gitignore or encrypt it with SOPS", source.FilePath)
+	return nil
+}
+
+// isGitIgnored reports whether filePath is ignored by git. Files outside a git repository count
+// as ignored, since they cannot be committed by accident.
+func isGitIgnored(filePath string) (bool, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return false, err
+	}
+	root := parentDirsToGitRoot(filepath.Dir(absPath))[0]
+	if _, err := os.Stat(filepath.Join(root, ".git")); err != nil {
+		return true, nil
+	}
+
+	// check-ignore exits 0 for ignored paths and 1 for paths that are not ignored
+	err = exec.Command("git", "-C", root, "check-ignore", "--quiet", "--", absPath).Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return false, nil
+	default:
+		return false, err
+	}
+}
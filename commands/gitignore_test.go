@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/notwillk/envvars-cli/logging"
+)
+
+func TestMergeCommand_Merge_GitIgnoreCheck(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	if err := exec.Command("git", "init", "--quiet", dir).Run(); err != nil {
+		t.Fatalf("Failed to init repository: %v", err)
+	}
+	files := map[string]string{
+		".gitignore":    "*.local.env\n",
+		"secrets.env":   "API_TOKEN=abc123\n",
+		"plain.env":     "HOST=localhost\n",
+		"app.local.env": "API_TOKEN=abc123\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	var warnings []string
+	previous := logging.SetWarningHook(func(message string) { warnings = append(warnings, message) })
+	defer logging.SetWarningHook(previous)
+
+	tests := []struct {
+		file   string
+		warned bool
+	}{
+		{"secrets.env", true},
+		{"plain.env", false},
+		{"app.local.env", false},
+	}
+	for _, test := range tests {
+		warnings = nil
+		path := filepath.Join(dir, test.file)
+		if _, err := CreateMergeCommand([]Source{{FilePath: path, Type: "env"}}, Options{}).Merge(); err != nil {
+			t.Errorf("%s: expected no error, got: %v", test.file, err)
+		}
+		warned := len(warnings) == 1 && strings.Contains(warnings[0], "not ignored by git")
+		if warned != test.warned {
+			t.Errorf("%s: expected warned %v, got warnings %v", test.file, test.warned, warnings)
+		}
+	}
+
+	_, err := CreateMergeCommand([]Source{{FilePath: filepath.Join(dir, "secrets.env"), Type: "env"}}, Options{Strict: true}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "--strict requires it to be in .gitignore") {
+		t.Errorf("Expected a --strict error, got: %v", err)
+	}
+}
+
+func TestMergeCommand_Execute_StreamingGitIgnoreCheck(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	if err := exec.Command("git", "init", "--quiet", dir).Run(); err != nil {
+		t.Fatalf("Failed to init repository: %v", err)
+	}
+	secrets := filepath.Join(dir, "secrets.env")
+	if err := os.WriteFile(secrets, []byte("API_TOKEN=abc123\n"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	outputPath := filepath.Join(t.TempDir(), "out.env")
+
+	var warnings []string
+	previous := logging.SetWarningHook(func(message string) { warnings = append(warnings, message) })
+	defer logging.SetWarningHook(previous)
+
+	// Streamed and normal merges check the source alike
+	for _, threshold := range []int64{0, 1} {
+		warnings = nil
+		os.Remove(outputPath)
+		sources := []Source{{FilePath: secrets, Type: "env"}}
+		cmd := CreateMergeCommand(sources, Options{Format: "env", StreamThreshold: threshold, Output: outputPath})
+		if cmd.shouldStream() != (threshold > 0) {
+			t.Fatalf("threshold %d: expected streaming %v", threshold, threshold > 0)
+		}
+		if err := cmd.Execute(); err != nil {
+			t.Errorf("threshold %d: expected no error, got: %v", threshold, err)
+		}
+		if len(warnings) != 1 || !strings.Contains(warnings[0], "not ignored by git") {
+			t.Errorf("threshold %d: expected a gitignore warning, got %v", threshold, warnings)
+		}
+
+		os.Remove(outputPath)
+		err := CreateMergeCommand(sources, Options{Format: "env", StreamThreshold: threshold, Output: outputPath, Strict: true}).Execute()
+		if err == nil || !strings.Contains(err.Error(), "--strict requires it to be in .gitignore") {
+			t.Errorf("threshold %d: expected a --strict error, got: %v", threshold, err)
+		}
+		if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+			t.Errorf("threshold %d: expected no output to be written, got: %v", threshold, err)
+		}
+	}
+}
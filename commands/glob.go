@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// isGlobPattern reports whether a source path contains glob metacharacters
+func isGlobPattern(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// ExpandSourcePath expands a source path that is a glob pattern into the files it matches,
+// in lexical order so merges are deterministic. "*", "?", and "[...]" match within a single
+// path element and "**" matches any number of directories. Matches that are excluded (see
+// IsExcluded) are skipped. Paths without metacharacters and URLs are returned as given, and a
+// pattern that matches no files is an error.
+func ExpandSourcePath(pattern string, excludes []string) ([]string, error) {
+	if !isGlobPattern(pattern) || IsRemoteURL(pattern) {
+		return []string{pattern}, nil
+	}
+
+	slashed := filepath.ToSlash(pattern)
+	if _, err := path.Match(strings.ReplaceAll(slashed, "**", "*"), ""); err != nil {
+		return nil, fmt.Errorf("invalid glob pattern '%s': %w", pattern, err)
+	}
+
+	// Walk from the deepest directory that contains no metacharacters
+	segments := strings.Split(slashed, "/")
+	fixed := 0
+	for fixed < len(segments)-1 && !isGlobPattern(segments[fixed]) {
+		fixed++
+	}
+	root := strings.Join(segments[:fixed], "/")
+	if root == "" && strings.HasPrefix(slashed, "/") {
+		root = "/"
+	}
+	walkRoot := root
+	if walkRoot == "" {
+		walkRoot = "."
+	}
+
+	recursive := strings.Contains(slashed, "**")
+	var matches []string
+	excluded := 0
+	err := filepath.WalkDir(filepath.FromSlash(walkRoot), func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(filepath.FromSlash(walkRoot), current)
+		if err != nil {
+			return err
+		}
+		names := strings.Split(filepath.ToSlash(relative), "/")
+		if entry.IsDir() {
+			// Without "**" there is no need to descend below the pattern's depth
+			if !recursive && relative != "." && len(names) >= len(segments)-fixed {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matchSegments(segments[fixed:], names) {
+			if IsExcluded(current, excludes) {
+				excluded++
+			} else {
+				matches = append(matches, current)
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to expand glob pattern '%s': %w", pattern, err)
+	}
+	if len(matches) == 0 {
+		if excluded > 0 {
+			return nil, fmt.Errorf("no files match pattern '%s' (%d excluded)", pattern, excluded)
+		}
+		return nil, fmt.Errorf("no files match pattern '%s'", pattern)
+	}
+
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// ValidateExcludes checks that exclude patterns are well-formed
+func ValidateExcludes(excludes []string) error {
+	for _, pattern := range excludes {
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern '%s': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// IsExcluded reports whether a discovered file matches any exclude pattern. Patterns without
+// a "/" match the file name (e.g. ".env.test" or "*~"); patterns with one match the trailing
+// elements of the path (e.g. "legacy/*.env", or from the root with a leading "/"), with "**"
+// matching any number of directories.
+func IsExcluded(filePath string, excludes []string) bool {
+	names := strings.Split(filepath.ToSlash(filepath.Clean(filePath)), "/")
+	for _, pattern := range excludes {
+		if !strings.Contains(pattern, "/") {
+			if matched, _ := path.Match(pattern, names[len(names)-1]); matched {
+				return true
+			}
+			continue
+		}
+
+		patterns := strings.Split(pattern, "/")
+		for i := range names {
+			if matchSegments(patterns, names[i:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchSegments matches path elements against pattern elements, where "**" matches zero or more elements
+func matchSegments(patterns []string, names []string) bool {
+	if len(patterns) == 0 {
+		return len(names) == 0
+	}
+	if patterns[0] == "**" {
+		for i := 0; i <= len(names); i++ {
+			if matchSegments(patterns[1:], names[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(names) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(patterns[0], names[0]); !matched {
+		return false
+	}
+	return matchSegments(patterns[1:], names[1:])
+}
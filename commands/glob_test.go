@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandSourcePath(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"base.env",
+		"config/b.env",
+		"config/a.env",
+		"config/notes.txt",
+		"config/nested/c.env",
+		"config/nested/deeper/d.env",
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("KEY=value\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	join := func(names ...string) []string {
+		paths := make([]string, len(names))
+		for i, name := range names {
+			paths[i] = filepath.Join(dir, filepath.FromSlash(name))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"config/*.env", join("config/a.env", "config/b.env")},
+		{"config/?.env", join("config/a.env", "config/b.env")},
+		{"config/[b].env", join("config/b.env")},
+		{"config/**/*.env", join("config/a.env", "config/b.env", "config/nested/c.env", "config/nested/deeper/d.env")},
+		{"**/*.env", join("base.env", "config/a.env", "config/b.env", "config/nested/c.env", "config/nested/deeper/d.env")},
+		{"*/nested/*.env", join("config/nested/c.env")},
+		{"base.env", join("base.env")},
+	}
+
+	for _, test := range tests {
+		result, err := ExpandSourcePath(filepath.Join(dir, test.pattern), nil)
+		if err != nil {
+			t.Errorf("Pattern %q: unexpected error: %v", test.pattern, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("Pattern %q: expected %v, got %v", test.pattern, test.expected, result)
+		}
+	}
+
+	if _, err := ExpandSourcePath(filepath.Join(dir, "missing/*.env"), nil); err == nil {
+		t.Error("Expected error for pattern matching no files")
+	}
+	if _, err := ExpandSourcePath(filepath.Join(dir, "config/[.env"), nil); err == nil {
+		t.Error("Expected error for malformed pattern")
+	}
+}
+
+func TestExpandSourcePath_Excludes(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"a.env", "a.env~", "b.env.bak", "legacy/c.env", "d.env"} {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("KEY=value\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	result, err := ExpandSourcePath(filepath.Join(dir, "**/*"), []string{"*~", "*.bak", "legacy/*.env", "d.env"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := []string{filepath.Join(dir, "a.env")}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	// Paths without metacharacters are never excluded
+	result, err = ExpandSourcePath(filepath.Join(dir, "d.env"), []string{"d.env"})
+	if err != nil || len(result) != 1 {
+		t.Errorf("Expected explicit path to be kept, got %v (%v)", result, err)
+	}
+
+	_, err = ExpandSourcePath(filepath.Join(dir, "*.env"), []string{"*.env"})
+	if err == nil || !strings.Contains(err.Error(), "(2 excluded)") {
+		t.Errorf("Expected error reporting excluded files, got %v", err)
+	}
+
+	if err := ValidateExcludes([]string{"[.env"}); err == nil {
+		t.Error("Expected error for malformed exclude pattern")
+	}
+}
+
+func TestIsExcluded(t *testing.T) {
+	tests := []struct {
+		path     string
+		excludes []string
+		expected bool
+	}{
+		{"config/.env.test", []string{".env.test"}, true},
+		{"config/.env~", []string{"*~"}, true},
+		{"config/.env", []string{"*~", ".env.test"}, false},
+		{"app/legacy/old.env", []string{"legacy/*.env"}, true},
+		{"app/legacy/nested/old.env", []string{"legacy/*.env"}, false},
+		{"app/legacy/nested/old.env", []string{"legacy/**"}, true},
+		{"app/legacy/old.env", []string{"/legacy/*.env"}, false},
+		{"/legacy/old.env", []string{"/legacy/*.env"}, true},
+	}
+
+	for _, test := range tests {
+		if result := IsExcluded(filepath.FromSlash(test.path), test.excludes); result != test.expected {
+			t.Errorf("IsExcluded(%q, %v): expected %v, got %v", test.path, test.excludes, test.expected, result)
+		}
+	}
+}
@@ -5,9 +5,10 @@ import (
 	"os"
 )
 
-// ShowHelp displays the help message for the CLI
+// ShowHelp displays the help message for the CLI on stderr, so that stdout only ever
+// carries variable output (e.g. for eval "$(envvars-cli ...)")
 func ShowHelp() {
-	fmt.Fprintf(os.Stdout, `envvars-cli - Environment Variable File Processor
+	fmt.Fprintf(os.Stderr, `envvars-cli - Environment Variable File Processor
 
 USAGE:
     envvars-cli [COMMAND] [OPTIONS]
@@ -16,14 +17,241 @@ COMMANDS:
     help, -h, --help     Show this help message
     version, -v, --version  Show version information
     merge               Process and merge environment variable files
+    scan-secrets <file>...  Report values that look like credentials (private keys, AWS access
+                         keys, GitHub tokens, JWTs, passwords in URLs, high-entropy strings) in
+                         unencrypted .env, .json, and .yaml files; exits 1 when any are found.
+                         Values are never printed. Use -f json for a machine-readable report.
+    lint [--fix] <file>...  Check env files for duplicate keys, invalid key names, unresolvable
+                         ${VAR} references, trailing whitespace, inconsistent quoting, and
+                         misspelled directives, reported as file:line:column with a rule ID; exits
+                         1 when any are found. --fix fixes whitespace and quoting in place
+    sign --key <private.pem> <bundle>  Write a detached Ed25519 signature (<bundle>.sig, or
+                         --signature <file>) over the canonical form of an env, JSON, or YAML bundle:
+                         its variables sorted by key and its directives in order, so reformatting
+                         keeps the signature valid
+    verify --key <public.pem> <bundle>  Check a bundle against its signature; exits 1 if the bundle
+                         was modified or signed with another key
+    get <KEY> -f <file>...  Print the merged value of KEY as is, without quoting; exits 1 when
+                         the files do not set it
+    set <KEY=value>... -f <file>  Set variables in an env file in place: existing assignments keep
+                         their position, quoting style, and inline comments; new keys are appended
+    unset <KEY>... -f <file> [--all-files]  Remove the assignments of the keys from an env file,
+                         leaving the rest of it unchanged; --all-files edits every -f file given
+    convert <file> [--to <format>] [-o <file>]  Convert one source, of any input type, to any output
+                         format (default: implied by the -o file's name, otherwise env), keeping its
+                         order; --preserve-comments keeps an env file's comments and layout in env output
+    encrypt -f <file>... [-o <file>]  Merge the files and write the result SOPS-encrypted as yaml,
+                         json, or env (--format, default: implied by the -o file's name, otherwise
+                         yaml) for the --age, --pgp, and --kms keys given; without any, for the
+                         recipients in $SOPS_AGE_RECIPIENTS or the matching .sops.yaml creation rule
+    decrypt <file> [--format <format>] [-o <file>]  Decrypt a SOPS file (YAML, JSON, or dotenv) on its
+                         own and write it in any output format (default: implied by the -o file's
+                         name, otherwise env); --aws-profile, --aws-role, and --keyservice apply
+    template -f <file>... <template> [-o <file>]  Render a file ("-" for stdin) with the merged variables:
+                         as a Go text/template ({{.KEY}}), or with --mode subst as $KEY and ${KEY}
+                         references like envsubst. Unset variables fail unless --allow-missing
+    init [--var KEY=value]...  Create a starter .env, a .env.example without secret values, and an
+                         envvars.schema.json requiring the keys, and add the env file patterns to
+                         .gitignore (unless --no-gitignore). On a terminal it asks for the variables
+                         and about .gitignore, unless --yes; existing files are kept unless --force
+    doctor [-f <file>...]  Check that the files (or the project config's sources) exist and parse,
+                         that SOPS files have credentials for their keys, that JSON $schema references
+                         are reachable, and that kubectl and git are installed where needed, printing
+                         a report; fails when a check fails
+    completion bash|zsh|fish|powershell  Write the shell's completion script, which completes
+                         subcommands, flags, and the variable names of get, set, and unset from
+                         their -f files
+    run -f <file> [--clean] [--watch] [--] <command> [args...]  Run a command with the merged files
+                         (exec is an alias): the variables are added to the current environment,
+                         overriding variables of the same name, or with --clean replace it. The
+                         command's exit status is envvars-cli's. With --watch the command is
+                         restarted (SIGTERM, then SIGKILL after 10s) whenever a file changes
+    diff -f <first> -f <second>  Compare the variables of two sources, or of two merged groups with
+                         --from <file>... --to <file>...: "+ KEY" added, "- KEY" removed, "~ KEY" changed
+                         (secret values masked unless --show-secrets). Exits 1 when they differ and 2
+                         on errors. Use --format json for a machine-readable report
+    docker-args -f <file> [--as build-args|secrets]  Print docker build arguments for the merged
+                         files: a --build-arg per variable (secrets are warned about, since build args
+                         are kept in the image history), or with --as secrets --secrets-dir <dir>, a
+                         --secret id=KEY,src=<dir>/KEY per variable, each written to an owner-only file
+                         for RUN --mount=type=secret,id=KEY. Arguments are shell-quoted for eval, or
+                         NUL-terminated with -0
+    kubectl apply -f <file> --as secret/NAME|configmap/NAME [-n <ns>] [--prune]  Write the merged
+                         files to a Secret (base64-encoded) or ConfigMap through kubectl: the object
+                         is created, or its data updated, keeping keys the files do not set unless
+                         --prune is given. Installed (or linked) as kubectl-envvars on the PATH, the
+                         binary is a kubectl plugin: kubectl envvars apply ...
+    direnv               Print the direnv function use_envvars; add it to direnvrc, then call
+                         "use envvars [OPTIONS]" in an .envrc to load the merged variables
+    --github-action      Run as a GitHub Actions step (see action.yml): read settings from INPUT_*
+                         variables (env, json, yaml, sops, require, secret-patterns, strict,
+                         export-env, outputs), write the merged variables to $GITHUB_ENV and/or
+                         $GITHUB_OUTPUT, mask secret values, and report problems as annotations
 
 OPTIONS:
-    -e, --env <file>     Read and parse environment variable files (can be specified multiple times)
-    -f, --format <fmt>   Output format: json, yaml, or env (default: env)
+    -e, --env <file>     Read and parse environment variable files (can be specified multiple times).
+                         A file named "-" is read from stdin, for --json, --yaml, --ini, --toml, --hcl, --properties, and --sops too.
+                         Sources except sops may also be http:// or https:// URLs, fetched with the --http-* options,
+                         or directories, whose files of the source's type load in lexical order (conf.d style)
+    -f, --format <fmt>   Output format: json, yaml, env, docker-env, compose, tfvars, shell, github,
+                         properties, k8s-configmap, or k8s-secret (default: env). docker-env writes unquoted KEY=value lines for
+                         docker run --env-file, which takes values literally, and fails on multi-line
+                         values. compose writes an environment: mapping for a docker-compose.yml
+                         service, with $ escaped as $$ so Compose does not interpolate values.
+                         tfvars writes key = "value" assignments for terraform -var-file. shell
+                         writes commands exporting the variables in the --shell dialect, for eval.
+                         github writes KEY<<DELIMITER records for $GITHUB_ENV and $GITHUB_OUTPUT.
+                         properties writes a Java .properties file, escaped as Properties.store does.
+                         The k8s formats write a complete ConfigMap or Secret manifest in YAML, with
+                         Secret values base64-encoded
+    --k8s-name <name>    Name of the ConfigMap or Secret (required by the k8s formats)
+    --k8s-namespace <ns> Namespace of the ConfigMap or Secret (default: none, so kubectl's applies)
+    --tfvars-lowercase   Lowercase the keys of tfvars output (AWS_REGION becomes aws_region)
+    --shell <shell>      Dialect of the shell format, implying it: bash (default), zsh, sh, fish
+                         (set -gx KEY 'value'), or powershell ($env:KEY = 'value')
+    --github-env         Append the output to the file named by $GITHUB_ENV, setting the variables
+                         for the following steps of a GitHub Actions job (implies --format github).
+                         Secret values are masked in the job log with ::add-mask::
+    -o, --output <file>  Write the output to a file instead of stdout. Without --format, the format
+                         is inferred from the extension (.json, .yaml/.yml, .env/.env.*, .tfvars,
+                         .properties). The file is only replaced once the output is complete
+    -0, --print0         Write env output as raw KEY=value records terminated by NUL instead of escaped
+                         lines, so values containing newlines can be read with xargs -0
+    --summary-json <file> Also write a JSON report of the run to this file: status, duration, each
+                         source's added, overridden, and removed keys, the merged keys, and warnings
+    --dry-run            Print the merge plan instead of the output: the sources in the order they
+                         are applied, the keys each one adds, overrides, or removes, and the
+                         directives it contains
     -j, --json <file>    Process a JSON file
     -y, --yaml <file>    Process a YAML file
-    -s, --sops <key@file> Process SOPS-encrypted files in format [key_name]@[path-to-file] (can be specified multiple times)
-    -V, --verbose        Enable verbose output
+    --ini <file>         Process an INI file; keys in a [section] are prefixed with its name, so
+                         [db] host=x becomes db_host (nested [db.replica] sections db_replica_)
+    --toml <file>        Process a TOML file; tables are flattened like nested JSON, so [db] port =
+                         5432 becomes db_port, and arrays of tables are kept as JSON
+    --hcl <file>         Process an HCL file such as Terraform .tfvars: its top-level attributes, and
+                         the defaults of variable blocks in .tf files. Values must be literals;
+                         objects are flattened like nested JSON and null values skipped
+    --properties <file>  Process a Java .properties file (key=value, key:value, or key value, with
+                         backslash continuations and \uXXXX escapes). Dotted keys such as
+                         server.port are kept by --invalid-keys relaxed, or become server_port
+                         with --invalid-keys sanitize
+    --k8s <source>       Read the data of a ConfigMap or Secret (Secret data base64-decoded) from a
+                         manifest file in YAML or JSON, "-" for stdin, or from the cluster through
+                         kubectl with k8s://[NAMESPACE/]secret/NAME or k8s://[NAMESPACE/]configmap/NAME
+    -s, --sops <key@file> Process SOPS-encrypted files (YAML, JSON, or dotenv, by extension) in format
+                         [key_name]@[path-to-file] (can be specified multiple times)
+    --exclude-file <patterns> Skip files matching these comma-separated patterns in glob sources and
+                         --auto/--env-name discovery, e.g. --exclude-file '.env.test,*~,*.bak'.
+                         Patterns without "/" match the file name; others match the end of the path
+    --dir-recursive      Also load the files in subdirectories of directory sources
+    --dir-ext <exts>     Load the files with these comma-separated extensions from directory sources,
+                         e.g. --dir-ext .env,.conf (default: the source type's, such as .env for --env)
+    --source <spec>      Add a source as comma-separated fields: type (env, json, yaml, ini, toml,
+                         hcl, properties, sops, or k8s), path (file or glob pattern), and optionally
+                         priority (integer) and key (sops decryption key). Sops sources may also
+                         set aws-profile, aws-role, and keyservice, overriding the options below
+                         for that file. --env, --json, --yaml, --ini, --toml, --hcl, --properties,
+                         --k8s, and --sops are shorthands for sources without an explicit priority,
+                         which get increasing priorities in command-line order
+    --aws-profile <name> AWS profile used to decrypt the KMS keys of SOPS sources
+    --aws-role <arn>     Role assumed (through STS) to decrypt the KMS keys of SOPS sources, instead of
+                         relying on ambient credentials
+    --keyservice <addr>  SOPS key service to decrypt with, as unix:///path or tcp://host:port (can be
+                         specified multiple times); local decryption is tried first
+    -V, --verbose        Show progress on stderr, including how long each remote source took to fetch;
+                         repeat (-VV) to add per-variable and directive detail
+    -q, --quiet          Suppress warnings; only errors are written to stderr
+    --export             Prefix env output lines with 'export ' so they can be sourced by a shell
+    --direnv             Write the output as shell code for direnv: a watch_file declaration for each
+                         source, then an export per variable (see the direnv command)
+    --duplicates <mode>  Handling of keys assigned twice in one env file: warn, error, first, or last
+                         (default: last)
+    --on-duplicate <policy>  Handling of keys set more than once, whether twice in one file or by several
+                         sources: warn (the later value wins, with a warning), error, first-wins, or
+                         last-wins. Without it later sources silently override earlier ones
+    --sort <order>       Output order: key (default), or source/none to keep the order variables are
+                         defined in across sources
+    --preserve-order     Write keys in the order they first appear in the source files, for env, JSON,
+                         and YAML output alike (same as --sort source)
+    --secret-pattern <patterns> Treat keys matching these comma-separated patterns as secrets, in
+                         addition to *_SECRET, *_TOKEN, *PASSWORD*, *_KEY, and similar names and keys
+                         marked with #secret; their values are masked in logs, prompts, and errors
+    --show-secrets       Show secret values instead of masking them
+    --audit-log <dest>   Record each read of a secret backend (SOPS) as a JSON line with the time, OS user,
+                         pid, command, source, and keys read (never values). <dest> is a file, appended to
+                         and created with mode 0600, or "syslog". Failing to record a read is an error.
+    --redact[=<mode>]    Replace the output values of secret keys and values that look like credentials,
+                         for sharing the shape of an environment: mask (default) writes ***, hmac writes
+                         a stable keyed hash so equal values can be compared across environments
+    --redact-key <key>   Key for --redact=hmac (default: $ENVVARS_REDACT_KEY)
+    --interactive        When sources set the same key to different values, prompt on the terminal
+                         to choose which value wins (secret-looking values are masked)
+    --strict             Treat ambiguities that are otherwise allowed as errors, such as sources
+                         with equal priorities or plaintext sources with secrets that git does not ignore
+                         or that others can read; implies --strict-parse
+    --strict-parse       Fail on malformed env file lines: lines that are neither comments nor KEY=value
+                         assignments, and keys that are not valid variable names (by default both are
+                         skipped with a warning). Errors name the file, line, and column
+    --no-escape          Keep \n, \t, \r, \\, \", \$, and \uXXXX in double-quoted env values as written
+                         instead of expanding them (single-quoted values are always literal)
+    --delimiter <str>    Delimiter joining the keys of nested JSON, YAML, and SOPS structures (default: _).
+                         Joined keys are checked like any other, so a delimiter such as . needs
+                         --invalid-keys relaxed or keep
+    --nested-as-json     Emit nested JSON, YAML, and SOPS values as JSON strings instead of flattening them
+    --strict-empty       Fail on source files with no content (by default they contribute no variables)
+    --prefix <str>       Add a prefix to every output key (e.g. MYAPP_)
+    --strip-prefix <str> Remove a prefix from incoming keys, so MYAPP_PORT is merged as PORT
+    --drop-unprefixed    With --strip-prefix, drop incoming keys that do not have the prefix
+    --only <patterns>    Output only keys matching these comma-separated patterns ("*" matches anything,
+                         case-insensitive), e.g. --only 'DB_*,REDIS_*'
+    --except <patterns>  Omit keys matching these comma-separated patterns, e.g. --except '*_SECRET'
+    --require <keys>     Fail unless these comma-separated keys are in the merged output, listing every
+                         missing key
+    --require-file <file> Like --require, reading keys from a file (one or more per line, # comments)
+    --fail-if-empty      Fail when the merged output has no variables, e.g. because of a wrong path or
+                         an over-aggressive filter
+    --min-keys <n>       Fail when the merged output has fewer than n variables
+    --require-nonempty   Treat required keys (#require or --require) that are set to an empty string
+                         as missing (by default they pass with a warning)
+    --invalid-keys <mode> Handling of keys that are not valid variable names: warn (drop with a
+                         warning, default), error, keep, relaxed (accept unicode letters, dots, and
+                         dashes), or sanitize (transliterate to a POSIX name, reporting each rename)
+    --error-format <fmt> Error output format: text (default) or json, which includes the file and
+                         line of parse and directive errors
+    --max-line-size <n>  Maximum line length in bytes for env files (default: 1MB)
+    --config <file>      Project config file (default: envvars.yaml, envvars.yml, or .envvarsrc found
+                         by walking up from the working directory)
+    --no-config          Ignore any project config file
+    --policy <file>      Policy file (default: envvars-policy.yaml found by walking up from the working
+                         directory); see POLICY
+    --profile <name>     Apply the named profile from the project config
+    --env-name <name>    Load the conventional chain .env, .env.<name>, .env.local, .env.<name>.local
+                         from the working directory (later files win; missing files are skipped)
+    --auto               Merge the .env files found in the working directory: the --env-name chain,
+                         or .env and .env.local without one (-V lists what was found and skipped)
+    --auto-parents       Like --auto, also loading parent directories up to the git root (nearest wins)
+    --schema-cache-dir <dir> Cache remote JSON schemas on disk in this directory
+    --cache-dir <dir>    Cache decrypted SOPS sources between runs, so unchanged files are merged without
+                         contacting their key service. Entries are encrypted with --cache-identity.
+    --cache-identity <file> age identity file (as created by age-keygen) that encrypts the --cache-dir
+                         cache; required with --cache-dir
+    --fetch-concurrency <n>  Maximum simultaneous fetches per remote backend (default: 4)
+    --fetch-rate <n>     Maximum remote fetches started per second per backend (default: unlimited)
+    --source-timeout <duration> Fail when reading any one source (file or secret backend) takes
+                         longer than this, e.g. 30s (default: no limit)
+    --max-source-size <bytes> Fail when a source file is larger than this (default: no limit)
+    --http-header <header> Header sent when fetching http(s):// sources, as 'Name: value'
+                         (can be specified multiple times)
+    --http-token <token> Bearer token sent when fetching https:// sources; never sent over plain
+                         http (default: $ENVVARS_HTTP_TOKEN)
+    --http-timeout <duration> Timeout for each request fetching an http(s):// source (default: 30s)
+    --http-retries <n>   Retries after a connection failure or a 429 or 5xx response, with
+                         exponential backoff (default: 2, -1 disables them)
+    --stream-threshold <bytes> Stream env files of at least this size with bounded memory; ${VAR}
+                         references and directives are not allowed (env output only)
+    --watch              Keep running, merging and writing the output again whenever an input file
+                         or a file it #includes changes; errors are reported until the next change
+    --watch-interval <duration> How often --watch checks the files for changes (default: 500ms)
 
 EXAMPLES:
     # Parse a single environment file (default ENV format)
@@ -32,6 +260,12 @@ EXAMPLES:
     # Parse multiple environment files
     envvars-cli --env dev.env --env prod.env
 
+    # Load .env, .env.production, .env.local, and .env.production.local (like Next.js)
+    envvars-cli --env-name production
+
+    # Merge every env file under config/, in lexical path order (quote the pattern)
+    envvars-cli --env 'config/**/*.env'
+
     # Output as JSON
     envvars-cli --env config.env --format json
 
@@ -41,6 +275,24 @@ EXAMPLES:
     # Output as ENV (default)
     envvars-cli --env config.env --format env
 
+    # Write YAML to a file, inferring the format from its extension
+    envvars-cli --env config.env -o merged.yaml
+
+    # Inspect how a merge would proceed without producing output
+    envvars-cli --env base.env --env production.env --dry-run
+
+    # Output shell-sourceable lines
+    envvars-cli --env config.env --export
+
+    # Fail when a key is assigned twice in the same file
+    envvars-cli --env config.env --duplicates error
+
+    # Fail when any key is set twice, in one file or across files
+    envvars-cli --env base.env --env local.env --on-duplicate error
+
+    # Keep the order variables are defined in
+    envvars-cli --env base.env --env local.env --sort source
+
     # Process JSON files
     envvars-cli --json config.json
     envvars-cli --json config.json --format yaml
@@ -56,16 +308,151 @@ EXAMPLES:
     envvars-cli --sops "age1key123@secrets.enc.yaml"
     envvars-cli --sops "age1key123@secrets.enc.yaml" --format json
 
+    # Declare type, path, priority, and decryption key in one place
+    envvars-cli --source type=env,path=base.env --source 'type=sops,path=secrets.yaml,priority=10,key=age1key123'
+
+    # Assume a different role for each environment's SOPS file
+    envvars-cli --source 'type=sops,path=staging.yaml,key=kms,aws-role=arn:aws:iam::111:role/staging-ci' \
+                --source 'type=sops,path=prod.yaml,key=kms,aws-role=arn:aws:iam::222:role/prod-ci'
+
+    # Check plain-text files for committed credentials
+    envvars-cli scan-secrets .env 'config/**/*.yaml'
+
+    # Check the env files for problems, fixing what can be fixed automatically
+    envvars-cli lint --fix .env conf.d/
+
+    # Sign a bundle for deployment and verify it on the target
+    openssl genpkey -algorithm ed25519 -out signing.pem && openssl pkey -in signing.pem -pubout -out signing.pub.pem
+    envvars-cli --env .env --env .env.production -o bundle.env && envvars-cli sign --key signing.pem bundle.env
+    envvars-cli verify --key signing.pub.pem bundle.env
+
+    # Compare development and production, including a SOPS file the extension cannot identify
+    envvars-cli diff --from base.env --from dev.env --to base.env --to 'type=sops,path=prod.enc.yaml,key=age1key123'
+
+    # Use a single merged value in a script
+    psql "$(envvars-cli get DATABASE_URL -f base.env -f prod.env)"
+
+    # Change a value without disturbing the rest of the file
+    envvars-cli set LOG_LEVEL=debug API_URL=https://staging.example.com -f .env
+
+    # Remove a retired key from every environment's file
+    envvars-cli unset LEGACY_TOKEN -f dev.env -f prod.env --all-files
+
+    # Convert a YAML config to an env file
+    envvars-cli convert config.yaml --to env -o config.env
+
+    # Encrypt merged secrets for an age recipient
+    envvars-cli encrypt -f plain.env --age age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p -o secrets.enc.yaml
+
+    # Decrypt a SOPS file to an env file
+    envvars-cli decrypt secrets.enc.yaml --format env -o .env
+
+    # Render a config file, envsubst-style
+    envvars-cli template -f prod.env nginx.conf.tmpl --mode subst -o nginx.conf
+
+    # Scaffold the env files of a new project
+    envvars-cli init --var DATABASE_URL=postgres://localhost/app --var API_KEY= --yes
+
+    # Find out why a CI job cannot load its environment
+    envvars-cli doctor -f base.env -f secrets.enc.yaml
+
+    # Enable completion in bash
+    source <(envvars-cli completion bash)
+
+    # Run a server with the production environment
+    envvars-cli run -f base.env -f prod.env -- ./server --port 8080
+
+    # Restart a development server whenever the env files change
+    envvars-cli run --watch -f .env -f .env.local -- ./server
+
+    # Build an image with the merged variables as BuildKit secrets
+    eval "docker build $(envvars-cli docker-args -f .env --as secrets --secrets-dir .secrets) ."
+
+    # Load the merged variables into the current fish or PowerShell session
+    envvars-cli --env .env --shell fish | source
+    envvars-cli --env .env --shell powershell | Out-String | Invoke-Expression
+
+    # Pass the merged variables to a container
+    docker run --env-file <(envvars-cli --env .env --format docker-env) myimage
+
+    # Write a Secret manifest for kubectl apply
+    envvars-cli --env prod.env --format k8s-secret --k8s-name myapp --k8s-namespace prod | kubectl apply -f -
+
+    # Sync a Secret with the production env files, dropping keys they no longer set
+    kubectl envvars apply -f base.env -f prod.env --as secret/myapp -n prod --prune
+
+    # Set the merged variables for the following steps of a GitHub Actions job
+    - run: envvars-cli --env .env --github-env
+
+    # Use from a GitHub Actions workflow
+    - uses: notwillk/envvars-cli@main
+      with:
+        env: .env
+        sops: age1key123@secrets.enc.yaml
+
+    # Convert a Kubernetes Secret's data piped in on stdin
+    kubectl get configmap myapp -o jsonpath='{.data}' | envvars-cli --json - --format env
+
+    # Compare a Secret in the cluster with the local env file
+    envvars-cli diff -f k8s://prod/secret/myapp -f prod.env
+
+    # Merge shared settings served over HTTPS (the token is read from $ENVVARS_HTTP_TOKEN)
+    envvars-cli --yaml https://config.internal/shared.yaml --env .env
+
     # Show help
     envvars-cli --help
 
     # Show version
     envvars-cli --version
 
+PROJECT CONFIG:
+    A project config declares default sources and options, so running envvars-cli with no
+    arguments merges the project's files. Source paths are relative to the config file, sources
+    given on the command line replace the config's, and flags override its options. Profiles
+    override any of the top-level settings; directives.allow limits the directives env files may use.
+
+        sources:
+          - env: .env
+          - sops: age1key123@secrets.enc.yaml
+        excludes: ['*~', '*.bak']
+        secret_patterns: ['*_DSN']
+        format: env
+        sort: source
+        directives:
+          allow: [require, remove]
+        profiles:
+          ci:
+            format: json
+            strict_parse: true
+
+POLICY:
+    A policy file sets guardrails that every command enforces, so platform teams can constrain how
+    application teams handle secrets. Unlike the project config it is not skipped by --no-config.
+    deny_plaintext lists key patterns that may only be stored in SOPS sources, deny_secret_formats
+    lists output formats that may not be written while the output holds unredacted secrets, and
+    allowed_source_types and allowed_push_backends limit the backends read from and written to.
+
+        deny_plaintext: ['*_PROD_*']
+        deny_secret_formats: [env]
+        allowed_source_types: [env, yaml, sops]
+        allowed_push_backends: [sops]
+
 DESCRIPTION:
     envvars-cli is a command-line tool for parsing and processing environment variable files.
     It supports parsing .env, .json, .yaml, and SOPS-encrypted files with comments, quoted values, and variable references.
+    In env files, ${VAR} refers to another variable of the file, ${VAR:-fallback} uses fallback when
+    VAR is unset or empty, and ${VAR:?message} fails with message (and the file and line) instead.
     Multiple files can be processed, with later files taking precedence over earlier ones.
+    Sources are merged in ascending priority, so a higher --source priority overrides a lower one;
+    sources with equal priorities keep their command-line order (rejected under --strict).
+    File paths may be glob patterns ("*", "?", "[...]", and "**" for any number of directories);
+    the matching files are merged in lexical path order, and a pattern matching no files is an error.
     SOPS files are automatically decrypted using the provided decryption key before processing.
+    A plaintext source holding secrets inside a git repository is reported when .gitignore does not
+    cover it, since it could be committed by accident, and when its group or other users can read it.
+    An --output file that receives secrets is created readable only by its owner (mode 0600).
+    When stderr is a terminal, the progress and timing of each remote (e.g. SOPS) fetch is shown there.
+    Only the output goes to stdout; help, warnings, progress, and -V/-VV diagnostics are written to
+    stderr, so eval "$(envvars-cli --env config.env --export)" never executes diagnostics.
 `)
 }
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/notwillk/envvars-cli/logging"
+)
+
+// Defaults for fetching sources over HTTP(S)
+const (
+	DefaultHTTPTimeout = 30 * time.Second
+	DefaultHTTPRetries = 2
+)
+
+// HTTPTokenEnv names the environment variable holding the bearer token sent to HTTP(S) sources
+// when --http-token is not given, which keeps the token out of the process list
+const HTTPTokenEnv = "ENVVARS_HTTP_TOKEN"
+
+// httpRetryDelay is the wait before the first retry of a failed fetch; it doubles for each retry
+var httpRetryDelay = 500 * time.Millisecond
+
+// httpTransport makes the requests for URL sources (replaced by tests to trust their servers)
+var httpTransport = http.DefaultTransport
+
+// IsRemoteURL reports whether a source path is an http:// or https:// URL to fetch the source from
+func IsRemoteURL(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// fetchURL downloads a source over HTTP(S), keeping its content for the parser. Connection
+// failures and 429 and 5xx responses are retried up to --http-retries times with exponential
+// backoff; the body is limited by --max-source-size.
+func (cmd *MergeCommand) fetchURL(rawURL string) error {
+	if _, ok := cmd.fetchedURLs.Load(rawURL); ok {
+		return nil
+	}
+
+	request, err := cmd.httpRequest(rawURL)
+	if err != nil {
+		return err
+	}
+	timeout := cmd.options.HTTPTimeout
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+	client := &http.Client{Transport: httpTransport, Timeout: timeout}
+	retries := cmd.options.HTTPRetries
+	if retries == 0 {
+		retries = DefaultHTTPRetries
+	}
+
+	delay := httpRetryDelay
+	for attempt := 0; ; attempt++ {
+		data, retry, err := cmd.fetchOnce(client, request)
+		if err == nil {
+			cmd.fetchedURLs.Store(rawURL, data)
+			return nil
+		}
+		if !retry || retries < 0 || attempt >= retries {
+			return err
+		}
+		logging.Warnf("%v; retrying in %s", err, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// httpRequest builds the request for a source URL with the --http-header headers and the
+// bearer token, which is only sent over https
+func (cmd *MergeCommand) httpRequest(rawURL string) (*http.Request, error) {
+	request, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source URL '%s': %w", rawURL, err)
+	}
+	for _, header := range cmd.options.HTTPHeaders {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid HTTP header '%s', expected 'Name: value'", header)
+		}
+		request.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	if token := cmp.Or(cmd.options.HTTPToken, os.Getenv(HTTPTokenEnv)); token != "" {
+		if request.URL.Scheme != "https" {
+			return nil, fmt.Errorf("refusing to send the bearer token to '%s' over plain http", redactURL(request.URL))
+		}
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	return request, nil
+}
+
+// fetchOnce makes a single attempt at a request, reporting whether a failure may be retried
+func (cmd *MergeCommand) fetchOnce(client *http.Client, request *http.Request) ([]byte, bool, error) {
+	location := redactURL(request.URL)
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to fetch '%s': %w", location, unwrapURLError(err))
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		retry := response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+		return nil, retry, fmt.Errorf("failed to fetch '%s': %s", location, response.Status)
+	}
+
+	body := io.Reader(response.Body)
+	limit := cmd.options.MaxSourceSize
+	if limit > 0 {
+		body = io.LimitReader(body, limit+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read '%s': %w", location, err)
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		return nil, false, fmt.Errorf("'%s' is larger than the maximum source size of %d bytes", location, limit)
+	}
+	return data, false, nil
+}
+
+// redactURL returns u for messages, without any password in its user info
+func redactURL(u *url.URL) string {
+	return u.Redacted()
+}
+
+// unwrapURLError drops the method and URL that net/http adds to errors, which the caller
+// already reports without the URL's credentials
+func unwrapURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useTestServer points URL sources at server for the rest of the test, without retry delays
+func useTestServer(t *testing.T, server *httptest.Server) {
+	transport, delay := httpTransport, httpRetryDelay
+	httpTransport, httpRetryDelay = server.Client().Transport, time.Millisecond
+	t.Cleanup(func() {
+		httpTransport, httpRetryDelay = transport, delay
+		server.Close()
+	})
+}
+
+func TestMergeCommand_Merge_HTTPSource(t *testing.T) {
+	var authorization, custom string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization, custom = r.Header.Get("Authorization"), r.Header.Get("X-Team")
+		switch r.URL.Path {
+		case "/app.env":
+			w.Write([]byte("KEY=from-env\nSHARED=env\n"))
+		case "/app.yaml":
+			w.Write([]byte("database:\n  host: db.internal\nSHARED: yaml\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	useTestServer(t, server)
+
+	sources, err := SourcesForFiles([]string{server.URL + "/app.env", server.URL + "/app.yaml?ref=main"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if sources[0].Type != "env" || sources[1].Type != "yaml" {
+		t.Fatalf("Expected the types env and yaml, got %s and %s", sources[0].Type, sources[1].Type)
+	}
+
+	options := Options{HTTPToken: "token123", HTTPHeaders: []string{"X-Team: payments"}}
+	variables, err := CreateMergeCommand(sources, options).Merge()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := map[string]string{"KEY": "from-env", "SHARED": "yaml", "database_host": "db.internal"}
+	for key, value := range expected {
+		if actual, _ := variables.Get(key); actual != value {
+			t.Errorf("Expected %s to be '%s', got '%s'", key, value, actual)
+		}
+	}
+	if authorization != "Bearer token123" || custom != "payments" {
+		t.Errorf("Expected the token and header to be sent, got '%s' and '%s'", authorization, custom)
+	}
+
+	_, err = CreateMergeCommand([]Source{{FilePath: server.URL + "/missing.env", Type: "env"}}, Options{}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestMergeCommand_Merge_HTTPSourceRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("KEY=value\n"))
+	}))
+	useTestServer(t, server)
+
+	sources := []Source{{FilePath: server.URL + "/app.env", Type: "env"}}
+	variables, err := CreateMergeCommand(sources, Options{}).Merge()
+	if err != nil {
+		t.Fatalf("Expected the fetch to succeed on the third attempt, got: %v", err)
+	}
+	if value, _ := variables.Get("KEY"); value != "value" || attempts != 3 {
+		t.Errorf("Expected KEY to be 'value' after 3 attempts, got '%s' after %d", value, attempts)
+	}
+
+	attempts = 0
+	_, err = CreateMergeCommand(sources, Options{HTTPRetries: -1}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "503 Service Unavailable") || attempts != 1 {
+		t.Errorf("Expected a single failed attempt, got %v after %d", err, attempts)
+	}
+}
+
+func TestMergeCommand_Merge_HTTPSourceLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("KEY=0123456789\n"))
+	}))
+	useTestServer(t, server)
+
+	sources := []Source{{FilePath: server.URL + "/app.env", Type: "env"}}
+	_, err := CreateMergeCommand(sources, Options{MaxSourceSize: 10}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "larger than the maximum source size of 10 bytes") {
+		t.Errorf("Expected a size limit error, got %v", err)
+	}
+
+	// The token is never sent in the clear
+	_, err = CreateMergeCommand(sources, Options{HTTPToken: "token123"}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "over plain http") {
+		t.Errorf("Expected the token to be refused over http, got %v", err)
+	}
+}
@@ -0,0 +1,261 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/formatters"
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// Files created by init
+const (
+	InitEnvFile     = ".env"
+	InitExampleFile = ".env.example"
+	InitSchemaFile  = "envvars.schema.json"
+)
+
+// initGitignorePatterns are the env files init keeps out of git; .env.example is committed
+var initGitignorePatterns = []string{".env", ".env.local", ".env.*.local"}
+
+// InitCommand scaffolds the env files of a new project
+type InitCommand struct {
+	dir         string
+	variables   []string
+	interactive bool
+	gitignore   bool
+	force       bool
+	in          *bufio.Reader
+	out         io.Writer
+}
+
+// CreateInitCommand creates an init command writing a starter .env, .env.example, and
+// envvars.schema.json in dir with the KEY=value variables, and adding the env file patterns to
+// its .gitignore when gitignore is set. When interactive, it asks for the variables (if none are
+// given) and whether to update .gitignore. Existing files are kept unless force is set.
+func CreateInitCommand(dir string, variables []string, interactive bool, gitignore bool, force bool) *InitCommand {
+	return &InitCommand{
+		dir:         dir,
+		variables:   variables,
+		interactive: interactive,
+		gitignore:   gitignore,
+		force:       force,
+		in:          bufio.NewReader(os.Stdin),
+		out:         os.Stdout,
+	}
+}
+
+// Execute writes the files, reporting each one created
+func (cmd *InitCommand) Execute() error {
+	if cmd.interactive && len(cmd.variables) == 0 {
+		if err := cmd.askVariables(); err != nil {
+			return err
+		}
+	}
+	variables, err := parseInitVariables(cmd.variables)
+	if err != nil {
+		return err
+	}
+	if cmd.interactive && cmd.gitignore {
+		if cmd.gitignore, err = cmd.confirm("Add the env file patterns to .gitignore? [Y/n] "); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(cmd.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory '%s': %w", cmd.dir, err)
+	}
+	schema, err := initSchema(variables)
+	if err != nil {
+		return err
+	}
+	files := []struct {
+		name    string
+		content string
+		mode    os.FileMode
+	}{
+		{InitEnvFile, initEnvFile(variables, false), secretFileMode},
+		{InitExampleFile, initEnvFile(variables, true), 0644},
+		{InitSchemaFile, schema, 0644},
+	}
+	for _, file := range files {
+		if err := cmd.writeFile(file.name, file.content, file.mode); err != nil {
+			return err
+		}
+	}
+
+	if cmd.gitignore {
+		return cmd.updateGitignore()
+	}
+	return nil
+}
+
+// askVariables reads KEY=value lines until an empty line or the end of the input
+func (cmd *InitCommand) askVariables() error {
+	fmt.Fprintf(cmd.out, "Variables to start with, one KEY=value per line (empty line to finish):\n")
+	for {
+		fmt.Fprint(cmd.out, "> ")
+		line, err := cmd.in.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			return nil
+		}
+		if _, parseErr := parseInitVariables([]string{line}); parseErr != nil {
+			fmt.Fprintf(cmd.out, "%v\n", parseErr)
+		} else {
+			cmd.variables = append(cmd.variables, line)
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// confirm prompts until the answer is yes or no, with an empty answer meaning yes
+func (cmd *InitCommand) confirm(prompt string) (bool, error) {
+	for {
+		fmt.Fprint(cmd.out, prompt)
+		line, err := cmd.in.ReadString('\n')
+		switch answer := strings.ToLower(strings.TrimSpace(line)); {
+		case answer == "y", answer == "yes", answer == "" && err == nil:
+			return true, nil
+		case answer == "n", answer == "no":
+			return false, nil
+		case errors.Is(err, io.EOF):
+			return false, errors.New("input ended")
+		case err != nil:
+			return false, err
+		}
+		fmt.Fprintf(cmd.out, "Please answer y or n.\n")
+	}
+}
+
+// writeFile writes a file in the command's directory unless it exists and force is not set
+func (cmd *InitCommand) writeFile(name string, content string, mode os.FileMode) error {
+	path := filepath.Join(cmd.dir, name)
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if cmd.force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, mode)
+	if errors.Is(err, os.ErrExist) {
+		logging.Warnf("'%s' already exists; keeping it (use --force to overwrite)", path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create file '%s': %w", path, err)
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write file '%s': %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write file '%s': %w", path, err)
+	}
+	fmt.Fprintf(cmd.out, "Created %s\n", path)
+	return nil
+}
+
+// updateGitignore appends the env file patterns the directory's .gitignore does not list yet
+func (cmd *InitCommand) updateGitignore() error {
+	path := filepath.Join(cmd.dir, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read file '%s': %w", path, err)
+	}
+	existing := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	var missing []string
+	for _, pattern := range initGitignorePatterns {
+		if !slices.Contains(existing, pattern) {
+			missing = append(missing, pattern)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var builder strings.Builder
+	builder.Write(data)
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		builder.WriteString("\n")
+	}
+	builder.WriteString("# Local env files (envvars-cli init)\n")
+	for _, pattern := range missing {
+		builder.WriteString(pattern + "\n")
+	}
+	if err := os.WriteFile(path, []byte(builder.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write file '%s': %w", path, err)
+	}
+	fmt.Fprintf(cmd.out, "Added %s to %s\n", strings.Join(missing, ", "), path)
+	return nil
+}
+
+// parseInitVariables splits KEY=value assignments, checking the keys
+func parseInitVariables(assignments []string) ([]sources.EnvVar, error) {
+	var variables []sources.EnvVar
+	for _, assignment := range assignments {
+		key, value, found := strings.Cut(assignment, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid variable '%s', expected KEY=value", assignment)
+		}
+		if !sources.IsValidKey(key) {
+			return nil, fmt.Errorf("invalid key '%s': keys must be letters, digits, and underscores, not starting with a digit", key)
+		}
+		variables = append(variables, sources.EnvVar{Key: key, Value: value})
+	}
+	return variables, nil
+}
+
+// initEnvFile renders the starter .env, or with example, the .env.example committed in its
+// place, which leaves out the values of secrets
+func initEnvFile(variables []sources.EnvVar, example bool) string {
+	var builder strings.Builder
+	if example {
+		builder.WriteString("# Copy to .env and fill in the values. This file is committed; .env is not.\n")
+	} else {
+		builder.WriteString("# Local environment. Keep it out of git; " + InitExampleFile + " documents the keys.\n")
+	}
+	for _, variable := range variables {
+		value := variable.Value
+		if _, _, found := sources.DetectSecret(value); example && (found || sources.IsSecretKey(variable.Key, nil)) {
+			value = ""
+		}
+		builder.WriteString(formatters.FormatENVLine(variable.Key, value, formatters.Options{}) + "\n")
+	}
+	return builder.String()
+}
+
+// initSchema renders a JSON Schema requiring the variables as strings, for JSON sources to
+// reference with "$schema"
+func initSchema(variables []sources.EnvVar) (string, error) {
+	properties := make(map[string]any)
+	required := []string{}
+	for _, variable := range variables {
+		properties[variable.Key] = map[string]any{"type": "string"}
+		required = append(required, variable.Key)
+	}
+	schema := map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"title":      "Environment variables",
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInitCommand_Execute(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("node_modules\n.env"), 0644); err != nil {
+		t.Fatalf("Failed to write .gitignore: %v", err)
+	}
+
+	cmd := CreateInitCommand(dir, []string{"APP_NAME=my app", "API_TOKEN=abc123"}, false, true, false)
+	cmd.out = &bytes.Buffer{}
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := map[string]string{
+		InitEnvFile:     "APP_NAME=\"my app\"\nAPI_TOKEN=abc123\n",
+		InitExampleFile: "APP_NAME=\"my app\"\nAPI_TOKEN=\n",
+		InitSchemaFile:  "\"required\": [\n    \"APP_NAME\",\n    \"API_TOKEN\"\n  ]",
+		".gitignore":    "node_modules\n.env\n# Local env files (envvars-cli init)\n.env.local\n.env.*.local\n",
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if !strings.Contains(string(data), content) {
+			t.Errorf("Expected %s to contain %q, got %q", name, content, data)
+		}
+	}
+	if info, _ := os.Stat(filepath.Join(dir, InitEnvFile)); info.Mode().Perm() != secretFileMode {
+		t.Errorf("Expected %s to have mode %v, got %v", InitEnvFile, secretFileMode, info.Mode().Perm())
+	}
+
+	// Existing files are kept
+	cmd = CreateInitCommand(dir, []string{"OTHER=1"}, false, false, false)
+	cmd.out = &bytes.Buffer{}
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, InitEnvFile)); strings.Contains(string(data), "OTHER") {
+		t.Errorf("Expected the existing %s to be kept, got %q", InitEnvFile, data)
+	}
+}
+
+func TestInitCommand_Interactive(t *testing.T) {
+	dir := t.TempDir()
+	cmd := CreateInitCommand(dir, nil, true, true, false)
+	cmd.in = bufio.NewReader(strings.NewReader("1BAD=x\nPORT=8080\n\nn\n"))
+	var out bytes.Buffer
+	cmd.out = &out
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(dir, InitEnvFile)); !strings.HasSuffix(string(data), "\nPORT=8080\n") {
+		t.Errorf("Expected PORT in %s, got %q", InitEnvFile, data)
+	}
+	if !strings.Contains(out.String(), "invalid key '1BAD'") {
+		t.Errorf("Expected the invalid key to be reported, got %q", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".gitignore")); !os.IsNotExist(err) {
+		t.Errorf("Expected no .gitignore after answering no")
+	}
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// conflictResolver asks which value wins when a source redefines a key that an earlier
+// source set to a different value
+type conflictResolver struct {
+	in      *bufio.Reader
+	out     io.Writer
+	mask    func(key string, value string) string // Masks secret values in prompts
+	origins map[string]string                     // Source file that set each key's current value
+}
+
+// newConflictResolver creates a resolver that prompts on out and reads answers from in
+func newConflictResolver(in io.Reader, out io.Writer, mask func(key string, value string) string) *conflictResolver {
+	return &conflictResolver{
+		in:      bufio.NewReader(in),
+		out:     out,
+		mask:    mask,
+		origins: make(map[string]string),
+	}
+}
+
+// resolve prompts for each key the source would change and returns the earlier values the
+// user chose to keep
+func (r *conflictResolver) resolve(variables *sources.Variables, source Source, envFile sources.EnvFile) (map[string]string, error) {
+	// The last definition of a key within a file is the one that is merged
+	incoming := make(map[string]string)
+	var keys []string
+	for _, variable := range envFile.Variables {
+		if _, seen := incoming[variable.Key]; !seen {
+			keys = append(keys, variable.Key)
+		}
+		incoming[variable.Key] = variable.Value
+	}
+
+	keep := make(map[string]string)
+	for _, key := range keys {
+		current, exists := variables.Get(key)
+		if !exists || current == incoming[key] {
+			continue
+		}
+
+		fmt.Fprintf(r.out, "Conflict for %s:\n", key)
+		fmt.Fprintf(r.out, "  1) %s: %s\n", r.origins[key], r.mask(key, current))
+		fmt.Fprintf(r.out, "  2) %s: %s\n", source.FilePath, r.mask(key, incoming[key]))
+		choice, err := r.ask("Keep which value? [1/2] (default 2): ")
+		if err != nil {
+			return nil, fmt.Errorf("no choice made for conflicting key '%s': %w", key, err)
+		}
+		if choice == 1 {
+			keep[key] = current
+		}
+	}
+	return keep, nil
+}
+
+// ask prompts until the answer is 1 or 2, with an empty answer choosing 2
+func (r *conflictResolver) ask(prompt string) (int, error) {
+	for {
+		fmt.Fprint(r.out, prompt)
+		line, err := r.in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		switch {
+		case answer == "1":
+			return 1, nil
+		case answer == "2", answer == "" && err == nil:
+			return 2, nil
+		case errors.Is(err, io.EOF):
+			return 0, errors.New("input ended")
+		case err != nil:
+			return 0, err
+		}
+		fmt.Fprintf(r.out, "Please answer 1 or 2.\n")
+	}
+}
+
+// record notes that the source set the keys it defines, except those whose earlier values were kept
+func (r *conflictResolver) record(source Source, envFile sources.EnvFile, kept map[string]string) {
+	for _, variable := range envFile.Variables {
+		if _, ok := kept[variable.Key]; !ok {
+			r.origins[variable.Key] = source.FilePath
+		}
+	}
+}
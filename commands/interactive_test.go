@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeCommand_Merge_Interactive(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.env")
+	if err := os.WriteFile(basePath, []byte("HOST=localhost\nPORT=8080\nDB_PASSWORD=old\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	overridePath := filepath.Join(dir, "override.env")
+	if err := os.WriteFile(overridePath, []byte("HOST=example.com\nPORT=8080\nDB_PASSWORD=new\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	sources := []Source{
+		{FilePath: basePath, Type: "env", Priority: 0},
+		{FilePath: overridePath, Type: "env", Priority: 1},
+	}
+	cmd := CreateMergeCommand(sources, Options{Interactive: true})
+	var prompts bytes.Buffer
+	// Keep the earlier HOST after one invalid answer, and take the default for DB_PASSWORD
+	cmd.promptIn = strings.NewReader("x\n1\n\n")
+	cmd.promptOut = &prompts
+
+	variables, err := cmd.Merge()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := map[string]string{"HOST": "localhost", "PORT": "8080", "DB_PASSWORD": "new"}
+	if !reflect.DeepEqual(variables.Map(), expected) {
+		t.Errorf("Expected %v, got %v", expected, variables.Map())
+	}
+
+	output := prompts.String()
+	for _, text := range []string{"Conflict for HOST:", "1) " + basePath + ": localhost", "Please answer 1 or 2.", "2) " + overridePath + ": ********"} {
+		if !strings.Contains(output, text) {
+			t.Errorf("Expected prompts to contain %q, got:\n%s", text, output)
+		}
+	}
+	if strings.Contains(output, "Conflict for PORT") {
+		t.Errorf("Expected no prompt for a key set to the same value, got:\n%s", output)
+	}
+
+	// Running out of input is an error rather than a silent choice
+	cmd = CreateMergeCommand(sources, Options{Interactive: true})
+	cmd.promptIn = strings.NewReader("")
+	cmd.promptOut = &bytes.Buffer{}
+	if _, err := cmd.Merge(); err == nil || !strings.Contains(err.Error(), "conflicting key 'HOST'") {
+		t.Errorf("Expected an error for missing input, got %v", err)
+	}
+}
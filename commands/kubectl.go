@@ -0,0 +1,229 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/formatters"
+	"github.com/notwillk/envvars-cli/logging"
+)
+
+// KubectlApplyCommand writes merged variables to a ConfigMap or Secret in a cluster through
+// kubectl, as "kubectl envvars apply"
+type KubectlApplyCommand struct {
+	files     []string
+	target    string // kind/name, e.g. secret/myapp
+	namespace string
+	context   string
+	prune     bool
+	kubectl   string // The kubectl binary
+	out       io.Writer
+}
+
+// CreateKubectlApplyCommand creates a command applying the merged files (typed by extension) to
+// the object target ("secret/NAME" or "configmap/NAME") in namespace, with the given kubeconfig
+// context (empty for the current one). Without prune, keys already in the object that the
+// files do not set are kept.
+func CreateKubectlApplyCommand(files []string, target string, namespace string, context string, prune bool) *KubectlApplyCommand {
+	return &KubectlApplyCommand{
+		files:     files,
+		target:    target,
+		namespace: namespace,
+		context:   context,
+		prune:     prune,
+		kubectl:   "kubectl",
+		out:       os.Stdout,
+	}
+}
+
+// ParseKubernetesTarget splits a "kind/name" target into its object kind and name. The kind may
+// be given as kubectl does: secret, configmap, or cm, in any case.
+func ParseKubernetesTarget(target string) (string, string, error) {
+	kind, name, ok := strings.Cut(target, "/")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid target '%s', expected secret/NAME or configmap/NAME", target)
+	}
+	switch strings.ToLower(kind) {
+	case "secret", "secrets":
+		return formatters.KubernetesSecret, name, nil
+	case "configmap", "configmaps", "cm":
+		return formatters.KubernetesConfigMap, name, nil
+	default:
+		return "", "", fmt.Errorf("unsupported kind '%s' in target '%s', expected secret or configmap", kind, target)
+	}
+}
+
+// KubernetesReferencePrefix starts the path of a k8s source that is read from the cluster
+// rather than from a manifest file
+const KubernetesReferencePrefix = "k8s://"
+
+// KubernetesReference is a ConfigMap or Secret in the cluster of the current kubeconfig context
+type KubernetesReference struct {
+	Kind      string
+	Name      string
+	Namespace string // Empty for the context's namespace
+}
+
+// ParseKubernetesReference parses a k8s source path of the form k8s://[NAMESPACE/]KIND/NAME,
+// reporting false when path is not a cluster reference (and so names a manifest file)
+func ParseKubernetesReference(path string) (KubernetesReference, bool, error) {
+	rest, ok := strings.CutPrefix(path, KubernetesReferencePrefix)
+	if !ok {
+		return KubernetesReference{}, false, nil
+	}
+
+	reference := KubernetesReference{}
+	target := rest
+	if strings.Count(rest, "/") == 2 {
+		reference.Namespace, target, _ = strings.Cut(rest, "/")
+	}
+	kind, name, err := ParseKubernetesTarget(target)
+	if err != nil || reference.Namespace == "" && strings.Count(rest, "/") == 2 {
+		return KubernetesReference{}, true, fmt.Errorf("invalid Kubernetes source '%s', expected %s[NAMESPACE/]secret/NAME or %s[NAMESPACE/]configmap/NAME", path, KubernetesReferencePrefix, KubernetesReferencePrefix)
+	}
+	reference.Kind = kind
+	reference.Name = name
+	return reference, true, nil
+}
+
+// Execute merges the files and creates the object, or replaces the data of the existing one
+func (cmd *KubectlApplyCommand) Execute() error {
+	kind, name, err := ParseKubernetesTarget(cmd.target)
+	if err != nil {
+		return err
+	}
+	if len(cmd.files) == 0 {
+		return fmt.Errorf("kubectl envvars apply requires at least one file (-f)")
+	}
+
+	sourceList, err := SourcesForFiles(cmd.files)
+	if err != nil {
+		return err
+	}
+	variables, err := CreateMergeCommand(sourceList, Options{Format: "env"}).Merge()
+	if err != nil {
+		return err
+	}
+	manifest, err := formatters.KubernetesManifest(variables.Map(), kind, name, cmd.namespace)
+	if err != nil {
+		return err
+	}
+
+	resource := strings.ToLower(kind) + "/" + name
+	live, err := cmd.kubectlOutput(nil, "get", resource, "-o", "json", "--ignore-not-found")
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(live)) == 0 {
+		document, err := json.Marshal(manifest)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", resource, err)
+		}
+		if _, err := cmd.kubectlOutput(document, "create", "-f", "-"); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(cmd.out, "%s created (%d keys)\n", resource, len(manifest.Data))
+		return err
+	}
+
+	// Replace the live object's data, keeping its other fields and resourceVersion so a
+	// concurrent change makes the replace fail instead of being overwritten
+	var object map[string]any
+	if err := json.Unmarshal(live, &object); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", resource, err)
+	}
+	current := map[string]string{}
+	if data, ok := object["data"].(map[string]any); ok {
+		for key, value := range data {
+			current[key], _ = value.(string)
+		}
+	}
+	data, changes := mergeKubernetesData(current, manifest.Data, cmd.prune)
+	if changes.empty() {
+		_, err = fmt.Fprintf(cmd.out, "%s unchanged\n", resource)
+		return err
+	}
+	object["data"] = data
+
+	document, err := json.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", resource, err)
+	}
+	if _, err := cmd.kubectlOutput(document, "replace", "-f", "-"); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(cmd.out, "%s configured (%d added, %d changed, %d removed)\n", resource, len(changes.added), len(changes.changed), len(changes.removed))
+	return err
+}
+
+// kubernetesDataChanges lists the keys an update adds, changes, and removes
+type kubernetesDataChanges struct {
+	added, changed, removed []string
+}
+
+// empty reports whether the update leaves the data as it was
+func (c kubernetesDataChanges) empty() bool {
+	return len(c.added) == 0 && len(c.changed) == 0 && len(c.removed) == 0
+}
+
+// mergeKubernetesData returns the data an object should hold after applying desired to its
+// current data: the desired keys overlaid on the current ones, or with prune, exactly the
+// desired keys
+func mergeKubernetesData(current map[string]string, desired map[string]string, prune bool) (map[string]string, kubernetesDataChanges) {
+	var changes kubernetesDataChanges
+	data := maps.Clone(desired)
+	for _, key := range slices.Sorted(maps.Keys(current)) {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		if prune {
+			changes.removed = append(changes.removed, key)
+		} else {
+			data[key] = current[key]
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(desired)) {
+		value, ok := current[key]
+		switch {
+		case !ok:
+			changes.added = append(changes.added, key)
+		case value != desired[key]:
+			changes.changed = append(changes.changed, key)
+		}
+	}
+	return data, changes
+}
+
+// kubectlOutput runs kubectl with the namespace and context options, feeding it stdin, and
+// returns its standard output
+func (cmd *KubectlApplyCommand) kubectlOutput(stdin []byte, args ...string) ([]byte, error) {
+	if cmd.namespace != "" {
+		args = append(args, "--namespace", cmd.namespace)
+	}
+	if cmd.context != "" {
+		args = append(args, "--context", cmd.context)
+	}
+	return runKubectl(cmd.kubectl, stdin, args...)
+}
+
+// runKubectl runs the kubectl binary with args, feeding it stdin, and returns its standard output
+func runKubectl(kubectl string, stdin []byte, args ...string) ([]byte, error) {
+	logging.Debugf("Running %s %s", kubectl, strings.Join(args, " "))
+
+	var stdout, stderr bytes.Buffer
+	process := exec.Command(kubectl, args...)
+	process.Stdin = bytes.NewReader(stdin)
+	process.Stdout = &stdout
+	process.Stderr = &stderr
+	if err := process.Run(); err != nil {
+		return nil, fmt.Errorf("kubectl %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKubernetesTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		kind   string
+		name   string
+		ok     bool
+	}{
+		{"secret/myapp", "Secret", "myapp", true},
+		{"Secret/myapp", "Secret", "myapp", true},
+		{"cm/myapp", "ConfigMap", "myapp", true},
+		{"configmap/myapp", "ConfigMap", "myapp", true},
+		{"deployment/myapp", "", "", false},
+		{"secret/", "", "", false},
+		{"myapp", "", "", false},
+	}
+	for _, tt := range tests {
+		kind, name, err := ParseKubernetesTarget(tt.target)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseKubernetesTarget(%q) error = %v, expected ok %v", tt.target, err, tt.ok)
+			continue
+		}
+		if kind != tt.kind || name != tt.name {
+			t.Errorf("ParseKubernetesTarget(%q) = %q, %q, expected %q, %q", tt.target, kind, name, tt.kind, tt.name)
+		}
+	}
+}
+
+func TestMergeKubernetesData(t *testing.T) {
+	current := map[string]string{"KEEP": "1", "CHANGE": "old", "SAME": "x"}
+	desired := map[string]string{"CHANGE": "new", "SAME": "x", "ADD": "2"}
+
+	data, changes := mergeKubernetesData(current, desired, false)
+	expected := map[string]string{"KEEP": "1", "CHANGE": "new", "SAME": "x", "ADD": "2"}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("Expected %v, got %v", expected, data)
+	}
+	expectedChanges := kubernetesDataChanges{added: []string{"ADD"}, changed: []string{"CHANGE"}}
+	if !reflect.DeepEqual(changes, expectedChanges) {
+		t.Errorf("Expected %+v, got %+v", expectedChanges, changes)
+	}
+
+	data, changes = mergeKubernetesData(current, desired, true)
+	if !reflect.DeepEqual(data, desired) {
+		t.Errorf("Expected %v, got %v", desired, data)
+	}
+	if !reflect.DeepEqual(changes.removed, []string{"KEEP"}) {
+		t.Errorf("Expected %v, got %v", []string{"KEEP"}, changes.removed)
+	}
+
+	if _, changes := mergeKubernetesData(desired, desired, true); !changes.empty() {
+		t.Errorf("Expected no changes, got %+v", changes)
+	}
+}
+
+func TestParseKubernetesReference(t *testing.T) {
+	tests := []struct {
+		path      string
+		reference KubernetesReference
+		ok        bool
+		valid     bool
+	}{
+		{"k8s://secret/myapp", KubernetesReference{Kind: "Secret", Name: "myapp"}, true, true},
+		{"k8s://prod/cm/myapp", KubernetesReference{Kind: "ConfigMap", Name: "myapp", Namespace: "prod"}, true, true},
+		{"manifest.yaml", KubernetesReference{}, false, true},
+		{"k8s://myapp", KubernetesReference{}, true, false},
+		{"k8s:///secret/myapp", KubernetesReference{}, true, false},
+	}
+	for _, tt := range tests {
+		reference, ok, err := ParseKubernetesReference(tt.path)
+		if ok != tt.ok || (err == nil) != tt.valid {
+			t.Errorf("ParseKubernetesReference(%q) = %v, %v, expected %v, valid %v", tt.path, ok, err, tt.ok, tt.valid)
+			continue
+		}
+		if reference != tt.reference {
+			t.Errorf("Expected %+v, got %+v", tt.reference, reference)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// loadSourceWithLimits loads a source, enforcing --max-source-size and --source-timeout the
+// same way for every source type. Only regular files have a size to check; a load that times
+// out is abandoned so a hung mount or backend cannot stall the merge.
+func (cmd *MergeCommand) loadSourceWithLimits(source Source) (sources.EnvFile, error) {
+	timeout := cmd.options.SourceTimeout
+	if timeout <= 0 {
+		return cmd.loadSourceWithSizeLimit(source)
+	}
+
+	type result struct {
+		envFile sources.EnvFile
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		envFile, err := cmd.loadSourceWithSizeLimit(source)
+		done <- result{envFile, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.envFile, r.err
+	case <-time.After(timeout):
+		return sources.EnvFile{}, fmt.Errorf("timed out after %s reading %s source '%s'", timeout, source.Type, source.FilePath)
+	}
+}
+
+// loadSourceWithSizeLimit loads a source after checking it against --max-source-size
+func (cmd *MergeCommand) loadSourceWithSizeLimit(source Source) (sources.EnvFile, error) {
+	if err := checkSourceSize(source, cmd.options.MaxSourceSize); err != nil {
+		return sources.EnvFile{}, err
+	}
+	return cmd.loadSource(source)
+}
+
+// checkSourceSize fails when a regular file source is larger than limit bytes (0 is unlimited)
+func checkSourceSize(source Source, limit int64) error {
+	if limit <= 0 {
+		return nil
+	}
+	info, err := os.Stat(source.FilePath)
+	if err != nil || !info.Mode().IsRegular() {
+		// Missing files are reported by the parser
+		return nil
+	}
+	if info.Size() > limit {
+		return fmt.Errorf("%s source '%s' is %d bytes, larger than the maximum source size of %d bytes", source.Type, source.FilePath, info.Size(), limit)
+	}
+	return nil
+}
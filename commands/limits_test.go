@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeCommand_Merge_MaxSourceSize(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "large.env")
+	if err := os.WriteFile(envPath, []byte("KEY=0123456789\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	sources := []Source{{FilePath: envPath, Type: "env", Priority: 0}}
+	if _, err := CreateMergeCommand(sources, Options{MaxSourceSize: 15}).Merge(); err != nil {
+		t.Errorf("Expected a file at the limit to be read, got: %v", err)
+	}
+
+	_, err := CreateMergeCommand(sources, Options{MaxSourceSize: 10}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "is 15 bytes, larger than the maximum source size of 10 bytes") {
+		t.Errorf("Expected a size limit error, got %v", err)
+	}
+
+	// Streamed merges enforce the limit too
+	err = CreateMergeCommand(sources, Options{Format: "env", StreamThreshold: 1, MaxSourceSize: 10}).Execute()
+	if err == nil || !strings.Contains(err.Error(), "larger than the maximum source size") {
+		t.Errorf("Expected a size limit error when streaming, got %v", err)
+	}
+}
+
+func TestMergeCommand_Merge_SourceTimeout(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "config.env")
+	if err := os.WriteFile(envPath, []byte("KEY=value\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	sources := []Source{{FilePath: envPath, Type: "env", Priority: 0}}
+	variables, err := CreateMergeCommand(sources, Options{SourceTimeout: time.Minute}).Merge()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if value, _ := variables.Get("KEY"); value != "value" {
+		t.Errorf("Expected KEY to be 'value', got '%s'", value)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package commands
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestMergeCommand_Merge_SourceTimeout_HungRead(t *testing.T) {
+	// Opening a FIFO for reading blocks until a writer appears, like a hung network mount
+	fifoPath := filepath.Join(t.TempDir(), "hung.env")
+	if err := syscall.Mkfifo(fifoPath, 0600); err != nil {
+		t.Skipf("Cannot create FIFO: %v", err)
+	}
+
+	sources := []Source{{FilePath: fifoPath, Type: "env", Priority: 0}}
+	start := time.Now()
+	_, err := CreateMergeCommand(sources, Options{SourceTimeout: 100 * time.Millisecond}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms reading env source") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the timeout to stop the merge promptly, took %s", elapsed)
+	}
+}
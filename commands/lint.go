@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// LintCommand checks env files for hygiene problems (see sources.LintEnvFile)
+type LintCommand struct {
+	paths  []string
+	format string
+	fix    bool
+	out    io.Writer
+}
+
+// CreateLintCommand creates a lint command for the given files, directories, or glob patterns,
+// reporting issues as "text" or "json". With fix, the fixable issues are fixed in place and
+// only the remaining ones are reported.
+func CreateLintCommand(paths []string, format string, fix bool) *LintCommand {
+	return &LintCommand{
+		paths:  paths,
+		format: format,
+		fix:    fix,
+		out:    os.Stdout,
+	}
+}
+
+// Execute lints every file and writes the issues. Any issue left is an error, so that the
+// command can guard commits and CI runs.
+func (cmd *LintCommand) Execute() error {
+	if cmd.format != "text" && cmd.format != "json" {
+		return fmt.Errorf("unsupported lint format: %s", cmd.format)
+	}
+	if len(cmd.paths) == 0 {
+		return fmt.Errorf("lint requires at least one file")
+	}
+
+	issues, err := cmd.Lint()
+	if err != nil {
+		return err
+	}
+	if err := cmd.writeIssues(issues); err != nil {
+		return err
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("found %d lint issue(s)", len(issues))
+	}
+	return nil
+}
+
+// Lint returns the issues in every env file, fixing the fixable ones first with fix. Files of
+// other types are skipped.
+func (cmd *LintCommand) Lint() ([]sources.LintIssue, error) {
+	issues := []sources.LintIssue{}
+	for _, pattern := range cmd.paths {
+		filePaths, err := ExpandSource(pattern, "env", DirectoryOptions{}, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, filePath := range filePaths {
+			if sourceType := SourceTypeForPath(filePath); sourceType != "env" {
+				logging.Warnf("skipping %s file '%s': only env files are linted", sourceType, filePath)
+				continue
+			}
+			fileIssues, fixed, err := sources.LintEnvFile(filePath)
+			if err != nil {
+				return nil, err
+			}
+			if cmd.fix {
+				if fileIssues, err = cmd.applyFixes(filePath, fileIssues, fixed); err != nil {
+					return nil, err
+				}
+			}
+			issues = append(issues, fileIssues...)
+		}
+	}
+	return issues, nil
+}
+
+// applyFixes writes the fixed content of a file when it differs, returning the issues left
+func (cmd *LintCommand) applyFixes(filePath string, issues []sources.LintIssue, fixed []byte) ([]sources.LintIssue, error) {
+	remaining := slices.DeleteFunc(issues, func(issue sources.LintIssue) bool {
+		return issue.Fixable
+	})
+	current, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file '%s': %w", filePath, err)
+	}
+	if bytes.Equal(current, fixed) {
+		return remaining, nil
+	}
+
+	// Writing in place keeps the file's permissions
+	if err := os.WriteFile(filePath, fixed, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write file '%s': %w", filePath, err)
+	}
+	logging.Infof("Fixed %d issue(s) in '%s'", len(issues)-len(remaining), filePath)
+	return remaining, nil
+}
+
+// writeIssues writes one "file:line:column: message [rule]" line per issue, or a JSON array
+func (cmd *LintCommand) writeIssues(issues []sources.LintIssue) error {
+	if cmd.format == "json" {
+		data, err := json.MarshalIndent(issues, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode issues: %w", err)
+		}
+		_, err = fmt.Fprintln(cmd.out, string(data))
+		return err
+	}
+
+	for _, issue := range issues {
+		fixable := ""
+		if issue.Fixable {
+			fixable = " (fixable with --fix)"
+		}
+		if _, err := fmt.Fprintf(cmd.out, "%s:%d:%d: %s [%s]%s\n", issue.File, issue.Line, issue.Column, issue.Message, issue.Rule, fixable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
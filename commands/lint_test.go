@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintCommand_Execute(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envPath, []byte("A='one' \nB=\"two\"\nC=\"three\"\nA=again\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := CreateLintCommand([]string{envPath}, "text", false)
+	cmd.out = &out
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "found 3 lint issue(s)") {
+		t.Errorf("Expected 3 issues, got %v", err)
+	}
+	expected := envPath + ":1:3: value of 'A' uses single quotes; most values in the file use double quotes [inconsistent-quoting] (fixable with --fix)\n" +
+		envPath + ":1:8: trailing whitespace [trailing-whitespace] (fixable with --fix)\n" +
+		envPath + ":4:1: duplicate key 'A', first assigned at line 1 [duplicate-key]\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+
+	out.Reset()
+	cmd = CreateLintCommand([]string{dir}, "text", true)
+	cmd.out = &out
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "found 1 lint issue(s)") {
+		t.Errorf("Expected 1 issue left after fixing, got %v", err)
+	}
+	data, err := os.ReadFile(envPath)
+	if err != nil {
+		t.Fatalf("Failed to read env file: %v", err)
+	}
+	if string(data) != "A=\"one\"\nB=\"two\"\nC=\"three\"\nA=again\n" {
+		t.Errorf("Expected the file to be fixed, got %q", data)
+	}
+	if strings.Contains(out.String(), "fixable") {
+		t.Errorf("Expected only the remaining issue, got %q", out.String())
+	}
+}
@@ -1,213 +1,620 @@
 package commands
 
 import (
+	"bytes"
+	"cmp"
 	"fmt"
+	"io"
+	"maps"
 	"os"
-	"regexp"
+	"slices"
 	"strings"
+	"sync"
 
 	formatters "github.com/notwillk/envvars-cli/formatters"
+	"github.com/notwillk/envvars-cli/logging"
 	"github.com/notwillk/envvars-cli/sources"
+	"golang.org/x/term"
 )
 
 // MergeCommand handles the environment variable merging functionality
 type MergeCommand struct {
 	sources []Source
 	options Options
+	cache   *sourceCache
+	// Where --interactive reads answers from and writes prompts to
+	promptIn  io.Reader
+	promptOut io.Writer
+	// Where remote fetch progress is shown (nil when stderr is not a terminal)
+	progressOut io.Writer
+	// Key patterns marked #secret by the sources merged so far
+	markedSecrets []string
+	// Files #include'd by the sources merged so far
+	includes []string
+	// Command name recorded in audit log entries
+	name string
+	// Encrypted on-disk cache of remote sources (nil unless --cache-dir is set)
+	remoteCache *remoteCache
+	// Where a source named "-" is read from, and its content once read
+	stdin     io.Reader
+	stdinData []byte
+	kubectl   string // The kubectl binary, for k8s:// sources
+	// Content fetched for http:// and https:// sources, keyed by URL
+	fetchedURLs sync.Map
 }
 
-// CreateMergeCommand creates a new merge command instance
+// CreateMergeCommand creates a new merge command instance. Sources are merged in ascending
+// priority order, so higher priorities override lower ones; sources with equal priorities
+// keep the order they were given in.
 func CreateMergeCommand(sources []Source, options Options) *MergeCommand {
+	ordered := slices.Clone(sources)
+	slices.SortStableFunc(ordered, func(a, b Source) int {
+		return cmp.Compare(a.Priority, b.Priority)
+	})
+
 	return &MergeCommand{
-		sources: sources,
+		sources: ordered,
 		options: options,
+		cache:   newSourceCache(),
+
+		promptIn:    os.Stdin,
+		promptOut:   os.Stderr,
+		progressOut: terminalStderr(),
+		name:        "merge",
+		stdin:       os.Stdin,
+		kubectl:     "kubectl",
 	}
 }
 
 // Execute runs the merge command
 func (cmd *MergeCommand) Execute() error {
+	if cmd.options.Watch {
+		return cmd.watch(nil)
+	}
+	if cmd.options.SummaryJSON != "" {
+		return cmd.executeWithSummary()
+	}
+	return cmd.execute(nil)
+}
+
+// execute merges and writes the output, or the merge plan for --dry-run, recording what each
+// source contributes in plan when it is non-nil
+func (cmd *MergeCommand) execute(plan *Plan) error {
+	if cmd.options.DryRun && plan == nil {
+		plan = &Plan{}
+	}
+
+	// Very large env inputs are merged with bounded memory
+	if plan == nil && cmd.shouldStream() {
+		return cmd.executeStreaming()
+	}
+
+	variables, err := cmd.merge(plan)
+	if err != nil {
+		return err
+	}
+
+	if cmd.options.DryRun {
+		return plan.Write(os.Stdout)
+	}
+	return cmd.output(variables)
+}
+
+// Invalidate forces the given files to be reparsed on the next call to Merge
+func (cmd *MergeCommand) Invalidate(paths ...string) {
+	cmd.cache.invalidate(paths...)
+}
+
+// Merge processes all sources and returns the merged variables without producing output.
+// Parsed sources are cached on the command, so calling Merge again only reparses the
+// files that changed on disk (or were invalidated) and re-applies the merge pipeline.
+func (cmd *MergeCommand) Merge() (*sources.Variables, error) {
+	return cmd.merge(nil)
+}
+
+// merge runs the merge pipeline, recording what each source contributes in plan when it is non-nil
+func (cmd *MergeCommand) merge(plan *Plan) (*sources.Variables, error) {
 	// Check if any sources are specified
 	if len(cmd.sources) == 0 {
-		return fmt.Errorf("no sources specified")
+		return nil, fmt.Errorf("no sources specified")
+	}
+	if err := cmd.checkPriorities(); err != nil {
+		return nil, err
+	}
+	checker, err := newDuplicateChecker(cmd.options.OnDuplicate, cmd.options.Interactive)
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.options.Policy.CheckSources(cmd.sources); err != nil {
+		return nil, err
+	}
+	for _, address := range cmd.options.KeyServices {
+		if err := sources.ValidateKeyService(address); err != nil {
+			return nil, err
+		}
 	}
 
-	if cmd.options.Verbose {
-		fmt.Fprintf(os.Stderr, "Processing %d sources...\n", len(cmd.sources))
+	if err := cmd.readStdin(); err != nil {
+		return nil, err
 	}
 
-	// Process each source and merge the results
-	variablesMap := make(map[string]string)
+	logging.Infof("Processing %d sources...", len(cmd.sources))
 
-	// Process sources in priority order (higher priority first)
-	for _, source := range cmd.sources {
-		if cmd.options.Verbose {
-			fmt.Fprintf(os.Stderr, "Processing %s file: %s (priority: %d)\n", source.Type, source.FilePath, source.Priority)
+	if cmd.options.SchemaCacheDir != "" {
+		sources.SetSchemaCacheDir(cmd.options.SchemaCacheDir)
+	}
 
-			// Show current state of merged variables before processing this source
-			if len(variablesMap) > 0 {
-				fmt.Fprintf(os.Stderr, "Current merged variables (%d):\n", len(variablesMap))
-				for key, value := range variablesMap {
-					fmt.Fprintf(os.Stderr, "  %s=%s\n", key, value)
+	if cmd.options.CacheDir != "" && cmd.remoteCache == nil {
+		remoteCache, err := openRemoteCache(cmd.options.CacheDir, cmd.options.CacheIdentity)
+		if err != nil {
+			return nil, err
+		}
+		cmd.remoteCache = remoteCache
+	}
+
+	// Fetch remote-backed sources concurrently before merging
+	fetched, err := cmd.prefetchRemoteSources()
+	if err != nil {
+		return nil, err
+	}
+
+	// Process each source and merge the results, keeping the order keys are defined in
+	variables := sources.NewVariables()
+
+	cmd.markedSecrets = nil
+	cmd.includes = nil
+	var resolver *conflictResolver
+	if cmd.options.Interactive {
+		resolver = newConflictResolver(cmd.promptIn, cmd.promptOut, cmd.mask)
+	}
+
+	// Process sources in priority order (lowest first, so higher priorities override)
+	for i, source := range cmd.sources {
+		logging.Infof("Processing %s file: %s (priority: %d)", source.Type, source.FilePath, source.Priority)
+
+		// Show current state of merged variables before processing this source
+		if logging.Enabled(logging.LevelDebug) {
+			if variables.Len() > 0 {
+				logging.Debugf("Current merged variables (%d):", variables.Len())
+				for _, key := range variables.Keys() {
+					value, _ := variables.Get(key)
+					logging.Debugf("  %s=%s", key, cmd.mask(key, value))
 				}
 			} else {
-				fmt.Fprintf(os.Stderr, "No variables merged yet\n")
+				logging.Debugf("No variables merged yet")
 			}
-			fmt.Fprintf(os.Stderr, "\n")
 		}
 
-		var err error
-
-		switch source.Type {
-		case "json":
-			envFile, err := cmd.parseJSONFile(source.FilePath)
+		envFile, ok := fetched[i]
+		if !ok {
+			envFile, err = cmd.cachedLoadSource(source)
 			if err != nil {
-				return fmt.Errorf("failed to parse %s file '%s': %w", source.Type, source.FilePath, err)
+				return nil, err
 			}
-			// Merge JSON variables
-			for _, envVar := range envFile.Variables {
-				variablesMap[envVar.Key] = envVar.Value
+		}
+		if err := cmd.audit(source, envFile); err != nil {
+			return nil, err
+		}
+		if err := cmd.options.Policy.CheckPlaintext(source, envFile); err != nil {
+			return nil, err
+		}
+
+		envFile = cmd.stripPrefix(envFile)
+		cmd.markedSecrets = append(cmd.markedSecrets, sources.SecretPatterns(envFile.Directives)...)
+		cmd.includes = append(cmd.includes, envFile.Includes...)
+		if isLocalPlaintext(source) && cmd.holdsSecrets(envFile) {
+			if err := cmd.checkIgnored(source); err != nil {
+				return nil, err
 			}
-		case "yaml":
-			envFile, err := cmd.parseYAMLFile(source.FilePath)
-			if err != nil {
-				return fmt.Errorf("failed to parse %s file '%s': %w", source.Type, source.FilePath, err)
+			if err := cmd.checkPermissions(source); err != nil {
+				return nil, err
 			}
-			// Merge YAML variables
-			for _, envVar := range envFile.Variables {
-				variablesMap[envVar.Key] = envVar.Value
+		}
+
+		var before map[string]string
+		if plan != nil {
+			before = maps.Clone(variables.Map())
+		}
+
+		var kept map[string]string
+		if resolver != nil {
+			if kept, err = resolver.resolve(variables, source, envFile); err != nil {
+				return nil, err
 			}
-		case "env":
-			// Use the directive-aware ProcessFileWithMerge function
-			options := sources.Options{FilePath: source.FilePath}
-			variablesMap, err = sources.ProcessFileWithMerge(variablesMap, options)
-			if err != nil {
-				return fmt.Errorf("failed to parse %s file '%s': %w", source.Type, source.FilePath, err)
+		}
+		if checker != nil {
+			if kept, err = checker.check(variables, source, envFile); err != nil {
+				return nil, err
 			}
-		case "sops":
-			envFile, err := cmd.parseSOPSFile(source.FilePath, source.DecryptionKey)
-			if err != nil {
-				return fmt.Errorf("failed to parse %s file '%s': %w", source.Type, source.FilePath, err)
+		}
+
+		if source.Type == "env" {
+			// Apply the env file with its directives, merging in place
+			if err := sources.ApplyEnvFileWithOptions(variables, envFile, cmd.sourceOptions(source.FilePath)); err != nil {
+				return nil, err
 			}
-			// Merge SOPS variables
+		} else {
 			for _, envVar := range envFile.Variables {
-				variablesMap[envVar.Key] = envVar.Value
+				variables.Set(envVar.Key, envVar.Value)
+			}
+		}
+
+		// Restore the earlier values that win, unless a directive removed the key
+		for key, value := range kept {
+			if _, exists := variables.Get(key); exists {
+				variables.Set(key, value)
 			}
-		default:
-			return fmt.Errorf("unsupported source type: %s", source.Type)
+		}
+		if resolver != nil {
+			resolver.record(source, envFile, kept)
+		}
+
+		if plan != nil {
+			plan.Sources = append(plan.Sources, planSource(source, envFile, before, variables))
 		}
 	}
 
-	if cmd.options.Verbose {
-		fmt.Fprintf(os.Stderr, "Merged %d variables\n", len(variablesMap))
+	logging.Infof("Merged %d variables", variables.Len())
+
+	variables = cmd.selectKeys(cmd.addPrefix(variables))
+	if err := cmd.checkRequired(variables); err != nil {
+		return nil, err
+	}
+	if err := cmd.checkKeyCount(variables); err != nil {
+		return nil, err
 	}
 
-	// Output in the specified format
+	if plan != nil {
+		plan.Keys = variables.Keys()
+	}
+
+	return variables, nil
+}
+
+// terminalStderr returns stderr when it is a terminal, so progress is not written into logs
+func terminalStderr() io.Writer {
+	if term.IsTerminal(int(os.Stderr.Fd())) {
+		return os.Stderr
+	}
+	return nil
+}
+
+// checkPriorities rejects sources that share a priority under --strict, since their
+// relative precedence then depends only on the order they were given in
+func (cmd *MergeCommand) checkPriorities() error {
+	if !cmd.options.Strict {
+		return nil
+	}
+	for i := 1; i < len(cmd.sources); i++ {
+		previous, current := cmd.sources[i-1], cmd.sources[i]
+		if previous.Priority == current.Priority {
+			return fmt.Errorf("sources '%s' and '%s' have the same priority %d; --strict requires distinct priorities", previous.FilePath, current.FilePath, current.Priority)
+		}
+	}
+	return nil
+}
+
+// output writes the merged variables in the configured format and order
+func (cmd *MergeCommand) output(variables *sources.Variables) error {
+	options := cmd.formatterOptions()
+	switch cmd.options.Sort {
+	case "", "key":
+		// Formatters sort by key when no order is given
+	case "source", "none":
+		options.Order = variables.Keys()
+	default:
+		return fmt.Errorf("unsupported sort order: %s", cmd.options.Sort)
+	}
+
+	var format func(map[string]string, formatters.Options) error
 	switch cmd.options.Format {
 	case "json":
-		return formatters.OutputAsJSON(variablesMap)
+		format = formatters.OutputAsJSONWithOptions
 	case "yaml":
-		return formatters.OutputAsYAML(variablesMap)
+		format = formatters.OutputAsYAMLWithOptions
 	case "env":
-		return formatters.OutputAsENV(variablesMap)
+		format = formatters.OutputAsENVWithOptions
+		if cmd.options.Direnv {
+			format = func(values map[string]string, options formatters.Options) error {
+				return formatters.OutputAsDirenv(values, cmd.watchFiles(), options)
+			}
+		}
+	case "docker-env":
+		format = formatters.OutputAsDockerEnv
+	case "compose":
+		format = formatters.OutputAsCompose
+	case "shell":
+		dialect := cmp.Or(cmd.options.Shell, "bash")
+		format = func(values map[string]string, options formatters.Options) error {
+			return formatters.OutputAsShell(values, dialect, options)
+		}
+	case "github":
+		format = formatters.OutputAsGitHub
+	case "properties":
+		format = formatters.OutputAsProperties
+	case "tfvars":
+		format = func(values map[string]string, options formatters.Options) error {
+			return formatters.OutputAsTFVars(values, cmd.options.TFVarsLowercase, options)
+		}
+	case "k8s-configmap", "k8s-secret":
+		kind := kubernetesKinds[cmd.options.Format]
+		if cmd.options.K8sName == "" {
+			return fmt.Errorf("--format %s requires --k8s-name for the %s", cmd.options.Format, kind)
+		}
+		format = func(values map[string]string, options formatters.Options) error {
+			return formatters.OutputAsKubernetes(values, kind, cmd.options.K8sName, cmd.options.K8sNamespace, options)
+		}
 	default:
 		return fmt.Errorf("unsupported output format: %s", cmd.options.Format)
 	}
-}
 
-// parseJSONFile reads and parses a JSON file
-func (cmd *MergeCommand) parseJSONFile(filePath string) (sources.EnvFile, error) {
-	processor := sources.CreateJSONProcessor()
-	variables, err := processor.ProcessFile(filePath)
-	if err != nil {
-		return sources.EnvFile{}, fmt.Errorf("failed to parse JSON file '%s': %w", filePath, err)
+	values := variables.Map()
+	if cmd.options.Redact != "" {
+		var err error
+		if values, err = cmd.redact(values); err != nil {
+			return err
+		}
 	}
 
-	envFile := sources.EnvFile{
-		Filename:  filePath,
-		Variables: []sources.EnvVar{},
+	// Redacted output no longer holds the secrets
+	secret := false
+	if cmd.options.Redact == "" {
+		for key, value := range values {
+			secret = secret || cmd.isSecret(key, value)
+		}
 	}
 
-	// Convert map[string]string to []EnvVar
-	for key, value := range variables {
-		envFile.Variables = append(envFile.Variables, sources.EnvVar{
-			Key:   key,
-			Value: value,
-			File:  filePath,
-		})
+	if err := cmd.options.Policy.CheckOutput(cmd.options.Format, secret); err != nil {
+		return err
 	}
 
-	return envFile, nil
-}
+	if cmd.options.GitHubEnv {
+		// Mask secrets before they can reach the log through later steps
+		var secrets []string
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			if cmd.isSecret(key, values[key]) {
+				secrets = append(secrets, values[key])
+			}
+		}
+		maskSecrets(os.Stdout, secrets)
+	}
 
-// parseYAMLFile reads and parses a YAML file
-func (cmd *MergeCommand) parseYAMLFile(filePath string) (sources.EnvFile, error) {
-	processor := sources.CreateYAMLProcessor()
-	variables, err := processor.ProcessFile(filePath)
+	writer, finishOutput, err := cmd.openOutput(secret)
 	if err != nil {
-		return sources.EnvFile{}, fmt.Errorf("failed to parse YAML file '%s': %w", filePath, err)
+		return err
+	}
+	options.Writer = writer
+
+	// Output in the specified format
+	return finishOutput(format(values, options))
+}
+
+// formatterOptions builds the formatter options from the command options
+func (cmd *MergeCommand) formatterOptions() formatters.Options {
+	return formatters.Options{
+		Export:         cmd.options.Export,
+		NullTerminated: cmd.options.Print0,
 	}
+}
 
-	envFile := sources.EnvFile{
-		Filename:  filePath,
-		Variables: []sources.EnvVar{},
+// cachedLoadSource returns the cached parse of source when its file is unchanged,
+// otherwise it parses the source and caches the result
+func (cmd *MergeCommand) cachedLoadSource(source Source) (sources.EnvFile, error) {
+	if envFile, ok := cmd.cache.get(source); ok {
+		return envFile, nil
 	}
 
-	// Convert map[string]string to []EnvVar
-	for key, value := range variables {
-		envFile.Variables = append(envFile.Variables, sources.EnvVar{
-			Key:   key,
-			Value: value,
-			File:  filePath,
-		})
+	// Stat before parsing so a change made while parsing invalidates the entry
+	info, _ := os.Stat(source.FilePath)
+	remote := cmd.remoteCache != nil && remoteSourceTypes[source.Type]
+	if remote {
+		if envFile, ok := cmd.remoteCache.get(source); ok {
+			cmd.cache.put(source, info, envFile)
+			return envFile, nil
+		}
+	}
+
+	envFile, err := cmd.loadSourceWithLimits(source)
+	if err != nil {
+		return sources.EnvFile{}, err
+	}
+	cmd.cache.put(source, info, envFile)
+	if remote {
+		if err := cmd.remoteCache.put(source, info, envFile); err != nil {
+			logging.Warnf("failed to cache '%s': %v", source.FilePath, err)
+		}
 	}
 
 	return envFile, nil
 }
 
-// parseSOPSFile reads and parses a SOPS-encrypted file
-func (cmd *MergeCommand) parseSOPSFile(filePath string, decryptionKey string) (sources.EnvFile, error) {
-	processor := sources.CreateSOPSProcessor()
-	variables, err := processor.ProcessFile(filePath, decryptionKey)
-	if err != nil {
-		return sources.EnvFile{}, fmt.Errorf("failed to parse SOPS file '%s': %w", filePath, err)
+// loadSource parses a source into its variables
+func (cmd *MergeCommand) loadSource(source Source) (sources.EnvFile, error) {
+	if IsRemoteURL(source.FilePath) {
+		if source.Type == "sops" {
+			return sources.EnvFile{}, fmt.Errorf("sops source '%s' must be a local file", source.FilePath)
+		}
+		if err := cmd.fetchURL(source.FilePath); err != nil {
+			return sources.EnvFile{}, err
+		}
 	}
 
-	envFile := sources.EnvFile{
-		Filename:  filePath,
-		Variables: variables,
+	switch source.Type {
+	case "env":
+		return sources.ParseFile(cmd.sourceOptions(source.FilePath))
+	case "json":
+		return cmd.parseJSONFile(source.FilePath)
+	case "yaml":
+		return cmd.parseYAMLFile(source.FilePath)
+	case "ini":
+		return cmd.parseINIFile(source.FilePath)
+	case "toml":
+		return cmd.parseTOMLFile(source.FilePath)
+	case "hcl":
+		return cmd.parseHCLFile(source.FilePath)
+	case "properties":
+		return cmd.parsePropertiesFile(source.FilePath)
+	case "sops":
+		return cmd.parseSOPSFile(source)
+	case "k8s":
+		return cmd.parseKubernetesSource(source.FilePath)
+	default:
+		return sources.EnvFile{}, fmt.Errorf("unsupported source type: %s", source.Type)
 	}
+}
 
-	return envFile, nil
+// sourceOptions builds the parser options for the file at filePath
+func (cmd *MergeCommand) sourceOptions(filePath string) sources.Options {
+	return sources.Options{
+		FilePath:        filePath,
+		MaxLineSize:     cmd.options.MaxLineSize,
+		Duplicates:      cmd.duplicates(),
+		StrictParse:     cmd.options.StrictParse || cmd.options.Strict,
+		NoEscape:        cmd.options.NoEscape,
+		InvalidKeys:     cmd.options.InvalidKeys,
+		StrictEmpty:     cmd.options.StrictEmpty,
+		Delimiter:       cmd.options.Delimiter,
+		NestedAsJSON:    cmd.options.NestedAsJSON,
+		RequireNonEmpty: cmd.options.RequireNonEmpty,
+		SecretPatterns:  cmd.options.SecretPatterns,
+		ShowSecrets:     cmd.options.ShowSecrets,
+
+		AllowedDirectives: cmd.options.AllowedDirectives,
+		Reader:            cmd.inputReader(filePath),
+	}
 }
 
-// unquoteValue removes quotes from a value if present
-func (cmd *MergeCommand) unquoteValue(value string) string {
-	value = strings.TrimSpace(value)
+// readStdin reads standard input, once, when a source is named "-". Since prompts also read
+// standard input, such a source cannot be combined with --interactive.
+func (cmd *MergeCommand) readStdin() error {
+	if cmd.stdinData != nil || !slices.ContainsFunc(cmd.sources, func(source Source) bool {
+		return source.FilePath == sources.StdinPath
+	}) {
+		return nil
+	}
+	if cmd.options.Interactive {
+		return fmt.Errorf("--interactive reads answers from standard input, so no source can be read from it")
+	}
+
+	data, err := io.ReadAll(cmd.stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read standard input: %w", err)
+	}
+	cmd.stdinData = data
+	return nil
+}
 
-	// Remove single quotes
-	if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
-		return strings.Trim(value, "'")
+// inputReader returns the content of standard input for a source named "-" or the fetched
+// content of a URL source, or nil to read the file at filePath
+func (cmd *MergeCommand) inputReader(filePath string) io.Reader {
+	if filePath == sources.StdinPath {
+		return bytes.NewReader(cmd.stdinData)
 	}
+	if data, ok := cmd.fetchedURLs.Load(filePath); ok {
+		return bytes.NewReader(data.([]byte))
+	}
+	return nil
+}
+
+// mask returns the value to show for key in logs and prompts: SecretMask for secrets
+// (matching the default or configured patterns, or marked #secret) unless --show-secrets is set
+func (cmd *MergeCommand) mask(key string, value string) string {
+	if cmd.options.ShowSecrets {
+		return value
+	}
+	return sources.MaskSecret(key, value, slices.Concat(cmd.options.SecretPatterns, cmd.markedSecrets))
+}
 
-	// Remove double quotes
-	if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-		return strings.Trim(value, "\"")
+// isSecret reports whether a non-empty, unencrypted value is sensitive: its key holds a secret
+// (see mask) or the value looks like a credential
+func (cmd *MergeCommand) isSecret(key string, value string) bool {
+	if value == "" || sources.IsEncryptedValue(value) {
+		return false
 	}
+	if sources.IsSecretKey(key, slices.Concat(cmd.options.SecretPatterns, cmd.markedSecrets)) {
+		return true
+	}
+	_, _, found := sources.DetectSecret(value)
+	return found
+}
+
+// parseJSONFile reads and parses a JSON file, keeping the document's key order
+func (cmd *MergeCommand) parseJSONFile(filePath string) (sources.EnvFile, error) {
+	processor := sources.CreateJSONProcessor()
+	return processor.ParseFile(cmd.sourceOptions(filePath))
+}
+
+// parseYAMLFile reads and parses a YAML file, keeping the document's key order
+func (cmd *MergeCommand) parseYAMLFile(filePath string) (sources.EnvFile, error) {
+	processor := sources.CreateYAMLProcessor()
+	return processor.ParseFile(cmd.sourceOptions(filePath))
+}
 
-	return value
+// parseINIFile reads and parses an INI file, keeping the document's key order
+func (cmd *MergeCommand) parseINIFile(filePath string) (sources.EnvFile, error) {
+	processor := sources.CreateINIProcessor()
+	return processor.ParseFile(cmd.sourceOptions(filePath))
 }
 
-// resolveVariableReferences resolves ${VAR_NAME} references in values
-func (cmd *MergeCommand) resolveVariableReferences(value string, variables map[string]string) string {
-	// Use regex to find and replace variable references
-	re := regexp.MustCompile(`\$\{([^}]+)\}`)
-	return re.ReplaceAllStringFunc(value, func(match string) string {
-		// Extract variable name from ${VAR_NAME}
-		varName := match[2 : len(match)-1]
-		if replacement, exists := variables[varName]; exists {
-			return replacement
+// parseTOMLFile reads and parses a TOML file, keeping the document's key order
+func (cmd *MergeCommand) parseTOMLFile(filePath string) (sources.EnvFile, error) {
+	processor := sources.CreateTOMLProcessor()
+	return processor.ParseFile(cmd.sourceOptions(filePath))
+}
+
+// parseHCLFile reads and parses an HCL file, keeping the document's key order
+func (cmd *MergeCommand) parseHCLFile(filePath string) (sources.EnvFile, error) {
+	processor := sources.CreateHCLProcessor()
+	return processor.ParseFile(cmd.sourceOptions(filePath))
+}
+
+// parsePropertiesFile reads and parses a Java properties file
+func (cmd *MergeCommand) parsePropertiesFile(filePath string) (sources.EnvFile, error) {
+	processor := sources.CreatePropertiesProcessor()
+	return processor.ParseFile(cmd.sourceOptions(filePath))
+}
+
+// parseKubernetesSource reads the data of a ConfigMap or Secret, from the cluster for a k8s://
+// reference and otherwise from a manifest file
+func (cmd *MergeCommand) parseKubernetesSource(filePath string) (sources.EnvFile, error) {
+	options := cmd.sourceOptions(filePath)
+	reference, ok, err := ParseKubernetesReference(filePath)
+	if err != nil {
+		return sources.EnvFile{}, err
+	}
+	if ok {
+		args := []string{"get", strings.ToLower(reference.Kind), reference.Name, "-o", "json"}
+		if reference.Namespace != "" {
+			args = append(args, "--namespace", reference.Namespace)
 		}
-		// If variable not found, keep the original reference
-		return match
-	})
+		manifest, err := runKubectl(cmd.kubectl, nil, args...)
+		if err != nil {
+			return sources.EnvFile{}, fmt.Errorf("failed to read Kubernetes source '%s': %w", filePath, err)
+		}
+		options.Reader = bytes.NewReader(manifest)
+	}
+
+	processor := sources.CreateKubernetesProcessor()
+	return processor.ParseFile(options)
+}
+
+// parseSOPSFile reads and parses a SOPS-encrypted file
+func (cmd *MergeCommand) parseSOPSFile(source Source) (sources.EnvFile, error) {
+	processor := sources.CreateSOPSProcessor()
+	options := cmd.sourceOptions(source.FilePath)
+	options.SOPSKeys = cmd.sopsKeys(source)
+	return processor.ParseFile(options, source.DecryptionKey)
+}
+
+// sopsKeys returns the key settings for a SOPS source: its own, falling back to the command's
+func (cmd *MergeCommand) sopsKeys(source Source) sources.SOPSKeyOptions {
+	keys := sources.SOPSKeyOptions{
+		AWSProfile:  cmp.Or(source.AWSProfile, cmd.options.AWSProfile),
+		AWSRole:     cmp.Or(source.AWSRole, cmd.options.AWSRole),
+		KeyServices: source.KeyServices,
+	}
+	if keys.KeyServices == nil {
+		keys.KeyServices = cmd.options.KeyServices
+	}
+	return keys
 }
@@ -1,8 +1,14 @@
 package commands
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/notwillk/envvars-cli/logging"
 )
 
 func TestCreateMergeCommand(t *testing.T) {
@@ -10,7 +16,7 @@ func TestCreateMergeCommand(t *testing.T) {
 		{FilePath: "test.env", Type: "env", Priority: 0},
 		{FilePath: "test.json", Type: "json", Priority: 1},
 	}
-	options := Options{Verbose: false, Format: "env"}
+	options := Options{Format: "env"}
 
 	cmd := CreateMergeCommand(sources, options)
 	if cmd == nil {
@@ -21,17 +27,13 @@ func TestCreateMergeCommand(t *testing.T) {
 		t.Errorf("Expected 2 sources, got %d", len(cmd.sources))
 	}
 
-	if !cmd.options.Verbose == false {
-		t.Error("Expected verbose to be false")
-	}
-
 	if cmd.options.Format != "env" {
 		t.Errorf("Expected format to be 'env', got '%s'", cmd.options.Format)
 	}
 }
 
 func TestMergeCommand_Execute_NoSources(t *testing.T) {
-	cmd := CreateMergeCommand([]Source{}, Options{Verbose: false, Format: "env"})
+	cmd := CreateMergeCommand([]Source{}, Options{Format: "env"})
 	err := cmd.Execute()
 	if err == nil {
 		t.Error("Expected error when no sources provided")
@@ -42,13 +44,47 @@ func TestMergeCommand_Execute_UnsupportedSourceType(t *testing.T) {
 	sources := []Source{
 		{FilePath: "test.unsupported", Type: "unsupported", Priority: 0},
 	}
-	cmd := CreateMergeCommand(sources, Options{Verbose: false, Format: "env"})
+	cmd := CreateMergeCommand(sources, Options{Format: "env"})
 	err := cmd.Execute()
 	if err == nil {
 		t.Error("Expected error for unsupported source type")
 	}
 }
 
+func TestMergeCommand_Execute_ErrorsNameFileOnce(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name       string
+		sourceType string
+		content    string
+	}{
+		{"bad.json", "json", `{"a":`},
+		{"bad.yaml", "yaml", "a: [\n"},
+		{"bad.toml", "toml", "a = \n"},
+		{"bad.env", "env", "A=\"x\n"},
+		{"bad.properties", "properties", "a\\u12\n"},
+		{"missing.ini", "ini", ""},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if tt.content != "" {
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", tt.name, err)
+			}
+		}
+
+		err := CreateMergeCommand([]Source{{FilePath: path, Type: tt.sourceType}}, Options{Format: "env"}).Execute()
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+			continue
+		}
+		if count := strings.Count(err.Error(), path); count != 1 {
+			t.Errorf("%s: expected the file to be named once, got %d times: %v", tt.name, count, err)
+		}
+	}
+}
+
 func TestMergeCommand_Execute_UnsupportedOutputFormat(t *testing.T) {
 	// Create a temporary env file for testing
 	tempFile, err := os.CreateTemp("", "test-*.env")
@@ -66,7 +102,7 @@ func TestMergeCommand_Execute_UnsupportedOutputFormat(t *testing.T) {
 	sources := []Source{
 		{FilePath: tempFile.Name(), Type: "env", Priority: 0},
 	}
-	cmd := CreateMergeCommand(sources, Options{Verbose: false, Format: "unsupported"})
+	cmd := CreateMergeCommand(sources, Options{Format: "unsupported"})
 	err = cmd.Execute()
 	if err == nil {
 		t.Error("Expected error for unsupported output format")
@@ -90,11 +126,19 @@ func TestMergeCommand_Execute_VerboseOutput(t *testing.T) {
 	sources := []Source{
 		{FilePath: tempFile.Name(), Type: "env", Priority: 0},
 	}
-	cmd := CreateMergeCommand(sources, Options{Verbose: true, Format: "env"})
+	logging.SetLevel(logging.LevelDebug)
+	defer logging.SetLevel(logging.LevelWarn)
+	var logs strings.Builder
+	defer logging.SetOutput(logging.SetOutput(&logs))
+
+	cmd := CreateMergeCommand(sources, Options{Format: "env"})
 	err = cmd.Execute()
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
+	if !strings.Contains(logs.String(), "Processing env file: "+tempFile.Name()) {
+		t.Errorf("Expected progress output, got: %s", logs.String())
+	}
 }
 
 func TestMergeCommand_Execute_SourcePriority(t *testing.T) {
@@ -124,21 +168,40 @@ func TestMergeCommand_Execute_SourcePriority(t *testing.T) {
 		t.Fatalf("Failed to write to temp file 2: %v", err)
 	}
 
-	// First source has lower priority (0), second has higher priority (1)
+	// The first source has the higher priority, so it overrides the second despite coming first
 	sources := []Source{
-		{FilePath: tempFile1.Name(), Type: "env", Priority: 0},
-		{FilePath: tempFile2.Name(), Type: "env", Priority: 1},
+		{FilePath: tempFile1.Name(), Type: "env", Priority: 1},
+		{FilePath: tempFile2.Name(), Type: "env", Priority: 0},
 	}
-	cmd := CreateMergeCommand(sources, Options{Verbose: false, Format: "env"})
-	err = cmd.Execute()
+	cmd := CreateMergeCommand(sources, Options{Format: "env"})
+	variables, err := cmd.Merge()
 	if err != nil {
-		t.Errorf("Unexpected error: %v", err)
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value, _ := variables.Get("DUPLICATE_KEY"); value != "first_value" {
+		t.Errorf("Expected DUPLICATE_KEY to be 'first_value', got '%s'", value)
+	}
+
+	// Equal priorities keep the given order, so the later source wins
+	sources[0].Priority = 0
+	variables, err = CreateMergeCommand(sources, Options{Format: "env"}).Merge()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value, _ := variables.Get("DUPLICATE_KEY"); value != "second_value" {
+		t.Errorf("Expected DUPLICATE_KEY to be 'second_value', got '%s'", value)
+	}
+
+	// Strict mode rejects equal priorities
+	_, err = CreateMergeCommand(sources, Options{Format: "env", Strict: true}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "same priority 0") {
+		t.Errorf("Expected same priority error under strict mode, got %v", err)
 	}
 }
 
 func TestMergeCommand_parseSOPSFile_NonExistentFile(t *testing.T) {
 	cmd := CreateMergeCommand([]Source{}, Options{})
-	_, err := cmd.parseSOPSFile("nonexistent.yaml", "test-key")
+	_, err := cmd.parseSOPSFile(Source{FilePath: "nonexistent.yaml", Type: "sops", DecryptionKey: "test-key"})
 	if err == nil {
 		t.Error("Expected error for non-existent SOPS file")
 	}
@@ -159,7 +222,7 @@ func TestMergeCommand_parseSOPSFile_InvalidDecryptionKey(t *testing.T) {
 	}
 
 	cmd := CreateMergeCommand([]Source{}, Options{})
-	_, err = cmd.parseSOPSFile(tempFile.Name(), "invalid-key")
+	_, err = cmd.parseSOPSFile(Source{FilePath: tempFile.Name(), Type: "sops", DecryptionKey: "invalid-key"})
 	// This should fail because the file is not actually encrypted with SOPS
 	if err == nil {
 		t.Error("Expected error for invalid SOPS decryption")
@@ -183,7 +246,7 @@ func TestMergeCommand_Execute_WithSOPSSource(t *testing.T) {
 	sources := []Source{
 		{FilePath: tempFile.Name(), Type: "sops", Priority: 0, DecryptionKey: "test-key"},
 	}
-	cmd := CreateMergeCommand(sources, Options{Verbose: false, Format: "env"})
+	cmd := CreateMergeCommand(sources, Options{Format: "env"})
 
 	// This will fail because the file is not actually encrypted, but it tests the SOPS path
 	err = cmd.Execute()
@@ -224,9 +287,292 @@ func TestMergeCommand_Execute_MixedSourceTypes(t *testing.T) {
 		{FilePath: envFile.Name(), Type: "env", Priority: 0},
 		{FilePath: jsonFile.Name(), Type: "json", Priority: 1},
 	}
-	cmd := CreateMergeCommand(sources, Options{Verbose: false, Format: "env"})
+	cmd := CreateMergeCommand(sources, Options{Format: "env"})
 	err = cmd.Execute()
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
 }
+
+func BenchmarkMergeCommand_Execute(b *testing.B) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatalf("Failed to open %s: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+	originalStdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = originalStdout }()
+
+	var sources []Source
+	for f := 0; f < 3; f++ {
+		tempFile, err := os.CreateTemp("", "bench-*.env")
+		if err != nil {
+			b.Fatalf("Failed to create temp file: %v", err)
+		}
+		defer os.Remove(tempFile.Name())
+
+		var builder strings.Builder
+		for i := 0; i < 5000; i++ {
+			fmt.Fprintf(&builder, "KEY_%d_%d=value_%d\n", f, i, i)
+			fmt.Fprintf(&builder, "SHARED_%d=value_from_%d\n", i, f)
+		}
+		if _, err := tempFile.WriteString(builder.String()); err != nil {
+			b.Fatalf("Failed to write to temp file: %v", err)
+		}
+		tempFile.Close()
+
+		sources = append(sources, Source{FilePath: tempFile.Name(), Type: "env", Priority: f})
+	}
+
+	cmd := CreateMergeCommand(sources, Options{Format: "env"})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := cmd.Execute(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMergeCommand_Merge_ReparsesOnlyChangedFiles(t *testing.T) {
+	baseFile, err := os.CreateTemp("", "base-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(baseFile.Name())
+	baseFile.WriteString("BASE=one\nSHARED=base\n")
+	baseFile.Close()
+
+	overrideFile, err := os.CreateTemp("", "override-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(overrideFile.Name())
+	overrideFile.WriteString("SHARED=override\n")
+	overrideFile.Close()
+
+	sources := []Source{
+		{FilePath: baseFile.Name(), Type: "env", Priority: 0},
+		{FilePath: overrideFile.Name(), Type: "env", Priority: 1},
+	}
+	cmd := CreateMergeCommand(sources, Options{Format: "env"})
+
+	firstVariables, err := cmd.Merge()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	first := firstVariables.Map()
+	if first["BASE"] != "one" || first["SHARED"] != "override" {
+		t.Fatalf("Unexpected merge result: %v", first)
+	}
+
+	if _, ok := cmd.cache.get(sources[0]); !ok {
+		t.Fatal("Expected the base file to be cached after the first merge")
+	}
+
+	// Change the override file; the base file stays cached
+	if err := os.WriteFile(overrideFile.Name(), []byte("SHARED=changed\nEXTRA=added\n"), 0o644); err != nil {
+		t.Fatalf("Failed to rewrite override file: %v", err)
+	}
+	cmd.Invalidate(overrideFile.Name())
+	if _, ok := cmd.cache.get(sources[1]); ok {
+		t.Fatal("Expected the override file to be invalidated")
+	}
+
+	secondVariables, err := cmd.Merge()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second := secondVariables.Map()
+	if second["BASE"] != "one" || second["SHARED"] != "changed" || second["EXTRA"] != "added" {
+		t.Errorf("Unexpected re-merge result: %v", second)
+	}
+}
+
+func TestMergeCommand_Merge_KeepsSourceOrder(t *testing.T) {
+	envFile, err := os.CreateTemp("", "base-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(envFile.Name())
+	envFile.WriteString("ZETA=1\nALPHA=2\n")
+	envFile.Close()
+
+	jsonFile, err := os.CreateTemp("", "override-*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(jsonFile.Name())
+	jsonFile.WriteString(`{"MIDDLE": "3", "ZETA": "override", "BETA": "4"}`)
+	jsonFile.Close()
+
+	sources := []Source{
+		{FilePath: envFile.Name(), Type: "env", Priority: 0},
+		{FilePath: jsonFile.Name(), Type: "json", Priority: 1},
+	}
+	cmd := CreateMergeCommand(sources, Options{Format: "env", Sort: "source"})
+
+	variables, err := cmd.Merge()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"ZETA", "ALPHA", "MIDDLE", "BETA"}
+	keys := variables.Keys()
+	if strings.Join(keys, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+	if value, _ := variables.Get("ZETA"); value != "override" {
+		t.Errorf("Expected ZETA=override, got %s", value)
+	}
+}
+
+func TestMergeCommand_Merge_Stdin(t *testing.T) {
+	envFile, err := os.CreateTemp("", "base-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(envFile.Name())
+	envFile.WriteString("NAME=base\nPORT=80\n")
+	envFile.Close()
+
+	sources := []Source{
+		{FilePath: envFile.Name(), Type: "env", Priority: 0},
+		{FilePath: "-", Type: "yaml", Priority: 1},
+	}
+	cmd := CreateMergeCommand(sources, Options{Format: "env"})
+	cmd.stdin = strings.NewReader("NAME: piped\n")
+
+	variables, err := cmd.Merge()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]string{"NAME": "piped", "PORT": "80"}
+	if !reflect.DeepEqual(variables.Map(), expected) {
+		t.Errorf("Expected %v, got %v", expected, variables.Map())
+	}
+
+	// Prompts would compete with the source for stdin
+	cmd = CreateMergeCommand(sources, Options{Format: "env", Interactive: true})
+	cmd.stdin = strings.NewReader("NAME: piped\n")
+	if _, err := cmd.Merge(); err == nil {
+		t.Errorf("Expected an error combining a stdin source with --interactive")
+	}
+}
+
+func TestMergeCommand_Execute_UnsupportedSortOrder(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	tempFile.WriteString("KEY=value\n")
+	tempFile.Close()
+
+	sources := []Source{{FilePath: tempFile.Name(), Type: "env", Priority: 0}}
+	cmd := CreateMergeCommand(sources, Options{Format: "env", Sort: "random"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for unsupported sort order")
+	}
+}
+
+func TestMergeCommand_Merge_MasksSecretsInLogs(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("#secret DATABASE_URL\nAPI_TOKEN=tok-123\nDATABASE_URL=postgres://hunter2\nSENTRY_DSN=https://dsn\nHOST=localhost\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	var logs strings.Builder
+	defer logging.SetOutput(logging.SetOutput(&logs))
+	logging.SetLevel(logging.LevelDebug)
+	defer logging.SetLevel(logging.LevelWarn)
+
+	// The debug dump of merged variables is written before each source after the first
+	sources := []Source{
+		{FilePath: tempFile.Name(), Type: "env", Priority: 0},
+		{FilePath: tempFile.Name(), Type: "env", Priority: 1},
+	}
+	if _, err := CreateMergeCommand(sources, Options{SecretPatterns: []string{"*_DSN"}}).Merge(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	for _, secret := range []string{"tok-123", "hunter2", "https://dsn"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("Expected secret %q to be masked, got:\n%s", secret, logs.String())
+		}
+	}
+	if !strings.Contains(logs.String(), "HOST=localhost") || !strings.Contains(logs.String(), "API_TOKEN=********") {
+		t.Errorf("Expected masked and unmasked values in debug output, got:\n%s", logs.String())
+	}
+
+	logs.Reset()
+	if _, err := CreateMergeCommand(sources, Options{ShowSecrets: true}).Merge(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(logs.String(), "API_TOKEN=tok-123") {
+		t.Errorf("Expected secrets to be shown with ShowSecrets, got:\n%s", logs.String())
+	}
+}
+
+func TestMergeCommand_Merge_OnDuplicate(t *testing.T) {
+	dir := t.TempDir()
+	basePath := dir + "/base.env"
+	localPath := dir + "/local.env"
+	os.WriteFile(basePath, []byte("NAME=base\nPORT=80\n"), 0644)
+	os.WriteFile(localPath, []byte("PORT=8080\nDEBUG=1\nDEBUG=2\n"), 0644)
+	sourceList := []Source{
+		{FilePath: basePath, Type: "env", Priority: 0},
+		{FilePath: localPath, Type: "env", Priority: 1},
+	}
+
+	tests := []struct {
+		policy   string
+		expected map[string]string
+		warnings int
+	}{
+		{"", map[string]string{"NAME": "base", "PORT": "8080", "DEBUG": "2"}, 0},
+		{OnDuplicateLastWins, map[string]string{"NAME": "base", "PORT": "8080", "DEBUG": "2"}, 0},
+		{OnDuplicateFirstWins, map[string]string{"NAME": "base", "PORT": "80", "DEBUG": "1"}, 0},
+		{OnDuplicateWarn, map[string]string{"NAME": "base", "PORT": "8080", "DEBUG": "2"}, 2},
+	}
+	for _, tt := range tests {
+		var warnings []string
+		previous := logging.SetWarningHook(func(message string) { warnings = append(warnings, message) })
+		variables, err := CreateMergeCommand(sourceList, Options{Format: "env", OnDuplicate: tt.policy}).Merge()
+		logging.SetWarningHook(previous)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.policy, err)
+		}
+		if !reflect.DeepEqual(variables.Map(), tt.expected) {
+			t.Errorf("%q: expected %v, got %v", tt.policy, tt.expected, variables.Map())
+		}
+		if len(warnings) != tt.warnings {
+			t.Errorf("%q: expected %d warnings, got %v", tt.policy, tt.warnings, warnings)
+		}
+	}
+
+	// Keys assigned twice in one file fail as the file is parsed, before it is merged
+	_, err := CreateMergeCommand(sourceList, Options{Format: "env", OnDuplicate: OnDuplicateError}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "duplicate key 'DEBUG' at lines 2 and 3") {
+		t.Errorf("Expected a duplicate DEBUG error, got %v", err)
+	}
+	os.WriteFile(localPath, []byte("PORT=8080\n"), 0644)
+	_, err = CreateMergeCommand(sourceList, Options{Format: "env", OnDuplicate: OnDuplicateError}).Merge()
+	expected := fmt.Sprintf("duplicate key 'PORT': set by '%s' and again at line 1 of '%s'", basePath, localPath)
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+
+	_, err = CreateMergeCommand(sourceList, Options{Format: "env", OnDuplicate: "newest"}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "unsupported duplicate key policy 'newest'") {
+		t.Errorf("Expected an unsupported policy error, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/formatters"
+)
+
+// outputFormatsByExtension maps output file extensions to the format they imply
+var outputFormatsByExtension = map[string]string{
+	".json":       "json",
+	".yaml":       "yaml",
+	".yml":        "yaml",
+	".env":        "env",
+	".tfvars":     "tfvars",
+	".properties": "properties",
+}
+
+// FormatForPath infers the output format from an output file path: by extension, or "env" for
+// dotenv-style names such as ".env" and ".env.production". It reports false when the path
+// implies no format.
+func FormatForPath(path string) (string, bool) {
+	name := strings.ToLower(filepath.Base(path))
+	if format, ok := outputFormatsByExtension[filepath.Ext(name)]; ok {
+		return format, true
+	}
+	if name == ".env" || strings.HasPrefix(name, ".env.") {
+		return "env", true
+	}
+	return "", false
+}
+
+// OutputFormats are the formats the merged output can be written in
+var OutputFormats = []string{"json", "yaml", "env", "docker-env", "compose", "tfvars", "shell", "github", "properties", "k8s-configmap", "k8s-secret"}
+
+// kubernetesKinds maps the Kubernetes manifest formats to the kind of object they produce
+var kubernetesKinds = map[string]string{
+	"k8s-configmap": formatters.KubernetesConfigMap,
+	"k8s-secret":    formatters.KubernetesSecret,
+}
+
+// openOutput returns the destination for the merged output and a function that finishes it with
+// the outcome of writing it, returning that error or its own. The --output file is written to a
+// temporary file that replaces it only when writing succeeded, so a failed run leaves an existing
+// file untouched; output for $GITHUB_ENV is appended likewise. Otherwise it is written to stdout.
+func (cmd *MergeCommand) openOutput(secret bool) (io.Writer, func(error) error, error) {
+	if cmd.options.GitHubEnv {
+		return openGitHubEnv()
+	}
+	if cmd.options.Output == "" {
+		return os.Stdout, func(err error) error { return err }, nil
+	}
+	return openOutputFile(cmd.options.Output, secret)
+}
+
+// openOutputFile opens a temporary file beside path that replaces it when finished without error.
+// The file keeps the mode of the one it replaces (0644 for a new file), while one holding secrets
+// is readable only by its owner. Devices and pipes such as /dev/stdout are written in place.
+func openOutputFile(path string, secret bool) (io.Writer, func(error) error, error) {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		if !info.Mode().IsRegular() {
+			file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to open output file '%s': %w", path, err)
+			}
+			return file, func(err error) error { return errors.Join(err, file.Close()) }, nil
+		}
+		mode = info.Mode().Perm()
+	}
+	if secret {
+		mode = secretFileMode
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create output file '%s': %w", path, err)
+	}
+	finish := func(err error) error {
+		if err == nil {
+			err = temp.Chmod(mode)
+		}
+		if closeErr := temp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			if renameErr := os.Rename(temp.Name(), path); renameErr != nil {
+				err = fmt.Errorf("failed to write output file '%s': %w", path, renameErr)
+			}
+		}
+		if err != nil {
+			os.Remove(temp.Name())
+		}
+		return err
+	}
+	return temp, finish, nil
+}
+
+// openGitHubEnv opens the file named by $GITHUB_ENV for appending, which the runner owns and
+// reads the job's environment from after the step. The output is buffered and only appended
+// when finished without error, so a failed run adds no partial records.
+func openGitHubEnv() (io.Writer, func(error) error, error) {
+	path := os.Getenv("GITHUB_ENV")
+	if path == "" {
+		return nil, nil, fmt.Errorf("$GITHUB_ENV is not set; --github-env must run inside a GitHub Actions step")
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open $GITHUB_ENV '%s': %w", path, err)
+	}
+	var buffer bytes.Buffer
+	return &buffer, func(err error) error {
+		if err == nil {
+			_, err = buffer.WriteTo(file)
+		}
+		return errors.Join(err, file.Close())
+	}, nil
+}
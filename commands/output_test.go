@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/notwillk/envvars-cli/formatters"
+)
+
+func TestFormatForPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+		ok       bool
+	}{
+		{"merged.json", "json", true},
+		{"out/configmap.yml", "yaml", true},
+		{"MERGED.YAML", "yaml", true},
+		{"merged.env", "env", true},
+		{".env", "env", true},
+		{"deploy/.env.production", "env", true},
+		{"config.toml", "", false},
+		{"prod.tfvars", "tfvars", true},
+		{"application.properties", "properties", true},
+		{"merged.txt", "", false},
+		{"merged", "", false},
+	}
+
+	for _, test := range tests {
+		format, ok := FormatForPath(test.path)
+		if format != test.expected || ok != test.ok {
+			t.Errorf("FormatForPath(%q): expected (%q, %v), got (%q, %v)", test.path, test.expected, test.ok, format, ok)
+		}
+	}
+}
+
+func TestFormatForPath_FormatsExist(t *testing.T) {
+	for extension, format := range outputFormatsByExtension {
+		if !slices.Contains(OutputFormats, format) {
+			t.Errorf("Expected the format %q implied by %s to be an output format", format, extension)
+		}
+	}
+}
+
+func TestOpenOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "merged.env")
+	if err := os.WriteFile(outputPath, []byte("OLD=1\n"), 0640); err != nil {
+		t.Fatalf("Failed to write output file: %v", err)
+	}
+
+	// A failed write leaves the file untouched and no temporary file behind
+	writer, finish, err := openOutputFile(outputPath, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	io.WriteString(writer, "PARTIAL=")
+	if err := finish(errors.New("format failed")); err == nil || err.Error() != "format failed" {
+		t.Errorf("Expected the write error, got: %v", err)
+	}
+	if data, _ := os.ReadFile(outputPath); string(data) != "OLD=1\n" {
+		t.Errorf("Expected output file to be unchanged, got %q", string(data))
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected only the output file, got %v", entries)
+	}
+
+	// A successful write replaces it, keeping its mode
+	writer, finish, err = openOutputFile(outputPath, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	io.WriteString(writer, "NEW=1\n")
+	if err := finish(nil); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if data, _ := os.ReadFile(outputPath); string(data) != "NEW=1\n" {
+		t.Errorf("Expected the new content, got %q", string(data))
+	}
+	if info, _ := os.Stat(outputPath); info.Mode().Perm() != 0640 {
+		t.Errorf("Expected mode 0640, got %v", info.Mode().Perm())
+	}
+}
+
+func TestMergeCommand_Execute_Output(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "input.env")
+	if err := os.WriteFile(envPath, []byte("B=2\nA=1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	outputPath := filepath.Join(dir, "merged.json")
+	sources := []Source{{FilePath: envPath, Type: "env", Priority: 0}}
+	if err := CreateMergeCommand(sources, Options{Format: "json", Output: outputPath}).Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	expected := "{\n  \"A\": \"1\",\n  \"B\": \"2\"\n}\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(data))
+	}
+
+	// A failed merge leaves an existing output file untouched
+	missing := []Source{{FilePath: filepath.Join(dir, "missing.env"), Type: "env", Priority: 0}}
+	if err := CreateMergeCommand(missing, Options{Format: "json", Output: outputPath}).Execute(); err == nil {
+		t.Fatal("Expected an error for a missing source")
+	}
+	if data, _ := os.ReadFile(outputPath); string(data) != expected {
+		t.Errorf("Expected output file to be unchanged, got %q", string(data))
+	}
+}
+
+func TestMergeCommand_Execute_Direnv(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.env")
+	localPath := filepath.Join(dir, "local.env")
+	if err := os.WriteFile(basePath, []byte("NAME=base\nGREETING=\"it's here\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	if err := os.WriteFile(localPath, []byte("NAME=local\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	outputPath := filepath.Join(dir, "envrc.out")
+	sources := []Source{{FilePath: basePath, Type: "env", Priority: 0}, {FilePath: localPath, Type: "env", Priority: 1}}
+	if err := CreateMergeCommand(sources, Options{Format: "env", Output: outputPath, Direnv: true}).Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	expected := "watch_file " + formatters.ShellQuote(basePath) + "\nwatch_file " + formatters.ShellQuote(localPath) + "\n" +
+		"export GREETING='it'\\''s here'\nexport NAME=local\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(data))
+	}
+}
+
+func TestMergeCommand_Execute_GitHubEnv(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "input.env")
+	if err := os.WriteFile(envPath, []byte("NAME=value\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	githubEnv := filepath.Join(dir, "github_env")
+	if err := os.WriteFile(githubEnv, []byte("EARLIER=1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write GITHUB_ENV file: %v", err)
+	}
+	t.Setenv("GITHUB_ENV", githubEnv)
+
+	sources := []Source{{FilePath: envPath, Type: "env"}}
+	if err := CreateMergeCommand(sources, Options{Format: "github", GitHubEnv: true}).Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	data, err := os.ReadFile(githubEnv)
+	if err != nil {
+		t.Fatalf("Failed to read GITHUB_ENV file: %v", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	if len(lines) != 5 || lines[0] != "EARLIER=1" || !strings.HasPrefix(lines[1], "NAME<<ghadelimiter_") || lines[2] != "value" {
+		t.Errorf("Expected the record appended to GITHUB_ENV, got %q", string(data))
+	}
+
+	t.Setenv("GITHUB_ENV", "")
+	if err := CreateMergeCommand(sources, Options{Format: "github", GitHubEnv: true}).Execute(); err == nil {
+		t.Error("Expected an error without GITHUB_ENV")
+	}
+}
@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/notwillk/envvars-cli/logging"
+)
+
+// secretFileMode is the mode of --output files that contain secrets
+const secretFileMode os.FileMode = 0600
+
+// checkPermissions warns when a source holding secrets can be read by its group or by other
+// users. Under --strict this is an error. Windows file modes do not reflect access, so the
+// check is skipped there.
+func (cmd *MergeCommand) checkPermissions(source Source) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	info, err := os.Stat(source.FilePath)
+	if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0044 == 0 {
+		return nil
+	}
+
+	if cmd.options.Strict {
+		return fmt.Errorf("'%s' contains secrets but is readable by group or others (mode %04o); --strict requires owner-only access", source.FilePath, info.Mode().Perm())
+	}
+	logging.Warnf("'%s' contains secrets but is readable by group or others (mode %04o); restrict it with chmod 600", source.FilePath, info.Mode().Perm())
+	return nil
+}
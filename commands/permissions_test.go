@@ -0,0 +1,136 @@
+//go:build !windows
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/notwillk/envvars-cli/logging"
+)
+
+func TestMergeCommand_Merge_PermissionCheck(t *testing.T) {
+	dir := t.TempDir()
+	secrets := filepath.Join(dir, "secrets.env")
+	if err := os.WriteFile(secrets, []byte("API_TOKEN=abc123\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Chmod(secrets, 0644); err != nil {
+		t.Fatalf("Failed to chmod file: %v", err)
+	}
+
+	var warnings []string
+	previous := logging.SetWarningHook(func(message string) { warnings = append(warnings, message) })
+	defer logging.SetWarningHook(previous)
+
+	sources := []Source{{FilePath: secrets, Type: "env"}}
+	if _, err := CreateMergeCommand(sources, Options{}).Merge(); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "readable by group or others (mode 0644)") {
+		t.Errorf("Expected a permission warning, got %v", warnings)
+	}
+
+	_, err := CreateMergeCommand(sources, Options{Strict: true}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "--strict requires owner-only access") {
+		t.Errorf("Expected a --strict error, got: %v", err)
+	}
+
+	warnings = nil
+	if err := os.Chmod(secrets, 0600); err != nil {
+		t.Fatalf("Failed to chmod file: %v", err)
+	}
+	if _, err := CreateMergeCommand(sources, Options{Strict: true}).Merge(); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", warnings)
+	}
+}
+
+func TestMergeCommand_Execute_StreamingPermissionCheck(t *testing.T) {
+	dir := t.TempDir()
+	secrets := filepath.Join(dir, "secrets.env")
+	if err := os.WriteFile(secrets, []byte("API_TOKEN=abc123\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Chmod(secrets, 0644); err != nil {
+		t.Fatalf("Failed to chmod file: %v", err)
+	}
+	outputPath := filepath.Join(dir, "out.env")
+
+	var warnings []string
+	previous := logging.SetWarningHook(func(message string) { warnings = append(warnings, message) })
+	defer logging.SetWarningHook(previous)
+
+	sources := []Source{{FilePath: secrets, Type: "env"}}
+	cmd := CreateMergeCommand(sources, Options{Format: "env", StreamThreshold: 1, Output: outputPath})
+	if !cmd.shouldStream() {
+		t.Fatal("Expected the merge to stream")
+	}
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "readable by group or others (mode 0644)") {
+		t.Errorf("Expected a permission warning, got %v", warnings)
+	}
+
+	os.Remove(outputPath)
+	err := CreateMergeCommand(sources, Options{Format: "env", StreamThreshold: 1, Output: outputPath, Strict: true}).Execute()
+	if err == nil || !strings.Contains(err.Error(), "--strict requires owner-only access") {
+		t.Errorf("Expected a --strict error, got: %v", err)
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no output to be written, got: %v", err)
+	}
+}
+
+func TestMergeCommand_Execute_SecretOutputMode(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content  string
+		options  Options
+		expected os.FileMode
+	}{
+		{"HOST=localhost\n", Options{}, 0644},
+		{"API_TOKEN=abc123\n", Options{}, 0600},
+		{"API_TOKEN=abc123\n", Options{Redact: RedactMask}, 0644},
+	}
+
+	for i, test := range tests {
+		input := filepath.Join(dir, "input.env")
+		if err := os.WriteFile(input, []byte(test.content), 0600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		test.options.Format = "env"
+		test.options.Output = filepath.Join(dir, fmt.Sprintf("out-%d.env", i))
+		if err := CreateMergeCommand([]Source{{FilePath: input, Type: "env"}}, test.options).Execute(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		info, err := os.Stat(test.options.Output)
+		if err != nil {
+			t.Fatalf("Failed to stat output: %v", err)
+		}
+		// The umask can only remove bits from 0644
+		if mode := info.Mode().Perm(); mode&^test.expected != 0 || (test.expected == 0600 && mode != 0600) {
+			t.Errorf("%q: expected mode %04o, got %04o", test.content, test.expected, mode)
+		}
+	}
+
+	// An existing output file is tightened when secrets are written to it
+	existing := filepath.Join(dir, "existing.env")
+	if err := os.WriteFile(existing, nil, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	input := filepath.Join(dir, "input.env")
+	options := Options{Format: "env", Output: existing}
+	if err := CreateMergeCommand([]Source{{FilePath: input, Type: "env"}}, options).Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if info, _ := os.Stat(existing); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %04o", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// SourcePlan describes what a single source contributes to a merge
+type SourcePlan struct {
+	Source     Source
+	Added      []string            // Keys the source defines that were not defined before it
+	Overridden []string            // Keys the source redefines
+	Removed    []string            // Keys that no longer exist after the source, e.g. due to #remove or #filter
+	Directives []sources.Directive // Directives the source applies
+}
+
+// Plan describes a merge: the sources in the order they are applied and the resulting keys
+type Plan struct {
+	Sources []SourcePlan
+	Keys    []string // Keys in the merged output, in definition order
+}
+
+// Plan runs the merge without producing output and returns the merge plan
+func (cmd *MergeCommand) Plan() (*Plan, error) {
+	plan := &Plan{}
+	if _, err := cmd.merge(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// planSource compares the merged variables before and after a source was applied
+func planSource(source Source, envFile sources.EnvFile, before map[string]string, after *sources.Variables) SourcePlan {
+	entry := SourcePlan{Source: source, Directives: envFile.Directives}
+
+	defined := make(map[string]bool)
+	for _, variable := range envFile.Variables {
+		if defined[variable.Key] {
+			continue
+		}
+		defined[variable.Key] = true
+		if _, exists := before[variable.Key]; exists {
+			entry.Overridden = append(entry.Overridden, variable.Key)
+		} else {
+			entry.Added = append(entry.Added, variable.Key)
+		}
+	}
+
+	candidates := make(map[string]bool, len(before)+len(defined))
+	for key := range before {
+		candidates[key] = true
+	}
+	for key := range defined {
+		candidates[key] = true
+	}
+	for key := range candidates {
+		if _, exists := after.Get(key); !exists {
+			entry.Removed = append(entry.Removed, key)
+		}
+	}
+	sort.Strings(entry.Removed)
+
+	return entry
+}
+
+// Write writes the plan as text: one block per source, followed by the resulting keys
+func (p *Plan) Write(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	fmt.Fprintf(writer, "Merge plan: %d source(s), applied in order (later sources override earlier ones)\n", len(p.Sources))
+	for i, entry := range p.Sources {
+		fmt.Fprintf(writer, "\n%d. %s %s (priority: %d)\n", i+1, entry.Source.Type, entry.Source.FilePath, entry.Source.Priority)
+		writePlanKeys(writer, "adds", entry.Added)
+		writePlanKeys(writer, "overrides", entry.Overridden)
+		writePlanKeys(writer, "removes", entry.Removed)
+		for _, directive := range entry.Directives {
+			fmt.Fprintf(writer, "   directive at line %d: #%s %s\n", directive.Line, directive.Name, strings.Join(directive.Arguments, " "))
+		}
+	}
+	fmt.Fprintf(writer, "\nResult: %d variable(s)\n", len(p.Keys))
+	writePlanKeys(writer, "keys", p.Keys)
+	return writer.Flush()
+}
+
+// writePlanKeys writes a labelled, comma-separated key list, skipping empty lists
+func writePlanKeys(w io.Writer, label string, keys []string) {
+	if len(keys) > 0 {
+		fmt.Fprintf(w, "   %s: %s\n", label, strings.Join(keys, ", "))
+	}
+}
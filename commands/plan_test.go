@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeCommand_Plan(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.env")
+	if err := os.WriteFile(basePath, []byte("HOST=localhost\nPORT=8080\nDEBUG=true\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	overridePath := filepath.Join(dir, "production.env")
+	if err := os.WriteFile(overridePath, []byte("#remove DEBUG\nHOST=example.com\nLOG_LEVEL=info\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	sources := []Source{
+		{FilePath: basePath, Type: "env", Priority: 0},
+		{FilePath: overridePath, Type: "env", Priority: 1},
+	}
+	plan, err := CreateMergeCommand(sources, Options{}).Plan()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(plan.Sources) != 2 {
+		t.Fatalf("Expected 2 sources in plan, got %d", len(plan.Sources))
+	}
+	if expected := []string{"HOST", "PORT", "DEBUG"}; !reflect.DeepEqual(plan.Sources[0].Added, expected) {
+		t.Errorf("Expected %v, got %v", expected, plan.Sources[0].Added)
+	}
+	production := plan.Sources[1]
+	if expected := []string{"LOG_LEVEL"}; !reflect.DeepEqual(production.Added, expected) {
+		t.Errorf("Expected %v, got %v", expected, production.Added)
+	}
+	if expected := []string{"HOST"}; !reflect.DeepEqual(production.Overridden, expected) {
+		t.Errorf("Expected %v, got %v", expected, production.Overridden)
+	}
+	if expected := []string{"DEBUG"}; !reflect.DeepEqual(production.Removed, expected) {
+		t.Errorf("Expected %v, got %v", expected, production.Removed)
+	}
+	if len(production.Directives) != 1 || production.Directives[0].Name != "remove" {
+		t.Errorf("Expected a single remove directive, got %v", production.Directives)
+	}
+	if expected := []string{"HOST", "PORT", "LOG_LEVEL"}; !reflect.DeepEqual(plan.Keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, plan.Keys)
+	}
+
+	var output bytes.Buffer
+	if err := plan.Write(&output); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	for _, expected := range []string{"2. env " + overridePath + " (priority: 1)", "   overrides: HOST", "   directive at line 1: #remove DEBUG", "Result: 3 variable(s)"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected plan output to contain %q, got:\n%s", expected, output.String())
+		}
+	}
+}
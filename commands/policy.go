@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/sources"
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyFileName is the policy file looked for by walking up from the working directory
+const PolicyFileName = "envvars-policy.yaml"
+
+// Policy is a set of guardrails, typically maintained by a platform team, that every command
+// enforces. Omitted rules allow everything.
+type Policy struct {
+	Path string `yaml:"-"` // Path the policy was loaded from
+	// Keys matching these patterns may only be stored in encrypted (SOPS) sources
+	DenyPlaintext []string `yaml:"deny_plaintext"`
+	// Output formats that may not be written while the output holds secrets
+	DenySecretFormats []string `yaml:"deny_secret_formats"`
+	// Source types that may be read
+	AllowedSourceTypes []string `yaml:"allowed_source_types"`
+	// Backends that commands writing secrets may push to
+	AllowedPushBackends []string `yaml:"allowed_push_backends"`
+}
+
+// LoadPolicy reads the policy at path. An empty path discovers PolicyFileName by walking up
+// from the working directory, returning nil when there is none.
+func LoadPolicy(path string) (*Policy, error) {
+	if path == "" {
+		workingDir, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		if path = findPolicy(workingDir); path == "" {
+			return nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy '%s': %w", path, err)
+	}
+
+	policy := &Policy{Path: path}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse policy '%s': %w", path, err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy '%s': %w", path, err)
+	}
+	return policy, nil
+}
+
+// findPolicy walks up from dir looking for PolicyFileName and returns its path, or "" if there is none
+func findPolicy(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, PolicyFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// validate checks that the policy names known source types and formats
+func (p *Policy) validate() error {
+	for _, sourceType := range slices.Concat(p.AllowedSourceTypes, p.AllowedPushBackends) {
+		if !slices.Contains(SourceTypes, sourceType) {
+			return fmt.Errorf("unknown source type '%s', expected one of %s", sourceType, strings.Join(SourceTypes, ", "))
+		}
+	}
+	for _, format := range p.DenySecretFormats {
+		if !slices.Contains(OutputFormats, format) {
+			return fmt.Errorf("unknown format '%s', expected one of %s", format, strings.Join(OutputFormats, ", "))
+		}
+	}
+	return nil
+}
+
+// CheckSources rejects sources whose type the policy does not allow
+func (p *Policy) CheckSources(sourceList []Source) error {
+	if p == nil || p.AllowedSourceTypes == nil {
+		return nil
+	}
+	for _, source := range sourceList {
+		if !slices.Contains(p.AllowedSourceTypes, source.Type) {
+			return fmt.Errorf("policy '%s' does not allow %s sources ('%s')", p.Path, source.Type, source.FilePath)
+		}
+	}
+	return nil
+}
+
+// PlaintextViolations returns the keys of a plaintext source that the policy requires to be encrypted
+func (p *Policy) PlaintextViolations(sourceType string, envFile sources.EnvFile) []sources.EnvVar {
+	if p == nil || sourceType == "sops" {
+		return nil
+	}
+	var violations []sources.EnvVar
+	for _, variable := range envFile.Variables {
+		if sources.MatchesAnyPattern(variable.Key, p.DenyPlaintext) {
+			violations = append(violations, variable)
+		}
+	}
+	return violations
+}
+
+// CheckPlaintext rejects a plaintext source holding keys the policy requires to be encrypted
+func (p *Policy) CheckPlaintext(source Source, envFile sources.EnvFile) error {
+	violations := p.PlaintextViolations(source.Type, envFile)
+	if len(violations) == 0 {
+		return nil
+	}
+	keys := make([]string, len(violations))
+	for i, violation := range violations {
+		keys[i] = violation.Key
+	}
+	return fmt.Errorf("policy '%s' requires %s to be stored encrypted, but '%s' is a plaintext %s source", p.Path, strings.Join(keys, ", "), source.FilePath, source.Type)
+}
+
+// CheckOutput rejects writing output that holds secrets in a format the policy denies
+func (p *Policy) CheckOutput(format string, secret bool) error {
+	if p == nil || !secret || !slices.Contains(p.DenySecretFormats, format) {
+		return nil
+	}
+	return fmt.Errorf("policy '%s' forbids %s output while it holds secrets; use another format or --redact", p.Path, format)
+}
+
+// CheckPush rejects pushing secrets to a backend the policy does not allow
+func (p *Policy) CheckPush(backend string) error {
+	if p == nil || p.AllowedPushBackends == nil || slices.Contains(p.AllowedPushBackends, backend) {
+		return nil
+	}
+	return fmt.Errorf("policy '%s' does not allow pushing to %s", p.Path, backend)
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, PolicyFileName)
+	content := "deny_plaintext: ['*_PROD_*']\ndeny_secret_formats: [env]\nallowed_source_types: [env, sops]\nallowed_push_backends: [sops]\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if policy.Path != path || len(policy.DenyPlaintext) != 1 || len(policy.AllowedSourceTypes) != 2 {
+		t.Errorf("Unexpected policy %+v", policy)
+	}
+	if findPolicy(filepath.Join(dir, "does", "not", "matter")) != path {
+		t.Errorf("Expected the policy to be found from a subdirectory")
+	}
+
+	invalid := []struct {
+		content string
+		errText string
+	}{
+		{"allowed_source_types: [vault]\n", "unknown source type 'vault'"},
+		{"deny_secret_formats: [xml]\n", "unknown format 'xml'"},
+		{"deny_plaintxt: ['*']\n", "field deny_plaintxt not found"},
+	}
+	for _, test := range invalid {
+		if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+			t.Fatalf("Failed to write policy: %v", err)
+		}
+		if _, err := LoadPolicy(path); err == nil || !strings.Contains(err.Error(), test.errText) {
+			t.Errorf("%q: expected error containing %q, got: %v", test.content, test.errText, err)
+		}
+	}
+}
+
+func TestMergeCommand_Execute_Policy(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		return path
+	}
+	prod := write("prod.env", "DB_PROD_HOST=db.internal\n")
+	secrets := write("secrets.env", "API_TOKEN=abc123\n")
+	plain := write("plain.env", "HOST=localhost\n")
+	config := write("config.json", `{"HOST": "localhost"}`)
+
+	policy := &Policy{
+		Path:               "policy.yaml",
+		DenyPlaintext:      []string{"*_PROD_*"},
+		DenySecretFormats:  []string{"env"},
+		AllowedSourceTypes: []string{"env", "sops"},
+	}
+	tests := []struct {
+		source  Source
+		options Options
+		errText string
+	}{
+		{Source{FilePath: plain, Type: "env"}, Options{Format: "env"}, ""},
+		{Source{FilePath: prod, Type: "env"}, Options{Format: "env"}, "requires DB_PROD_HOST to be stored encrypted"},
+		{Source{FilePath: config, Type: "json"}, Options{Format: "env"}, "does not allow json sources"},
+		{Source{FilePath: secrets, Type: "env"}, Options{Format: "env"}, "forbids env output while it holds secrets"},
+		{Source{FilePath: secrets, Type: "env"}, Options{Format: "json"}, ""},
+		{Source{FilePath: secrets, Type: "env"}, Options{Format: "env", Redact: RedactMask}, ""},
+	}
+
+	for _, test := range tests {
+		test.options.Policy = policy
+		test.options.Output = filepath.Join(dir, "out")
+		err := CreateMergeCommand([]Source{test.source}, test.options).Execute()
+		if test.errText == "" {
+			if err != nil {
+				t.Errorf("%s as %s: expected no error, got: %v", test.source.FilePath, test.options.Format, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), test.errText) {
+			t.Errorf("%s as %s: expected error containing %q, got: %v", test.source.FilePath, test.options.Format, test.errText, err)
+		}
+	}
+
+	if err := policy.CheckPush("sops"); err != nil {
+		t.Errorf("Expected pushing to be unrestricted, got: %v", err)
+	}
+	policy.AllowedPushBackends = []string{"sops"}
+	if err := policy.CheckPush("env"); err == nil || !strings.Contains(err.Error(), "does not allow pushing to env") {
+		t.Errorf("Expected a push error, got: %v", err)
+	}
+}
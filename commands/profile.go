@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// EnvNameChain returns the conventional env files for the environment name that exist in dir,
+// from lowest to highest precedence: .env, .env.<name>, .env.local, .env.<name>.local.
+// This is the order used by Next.js, so local overrides win over the shared environment files.
+func EnvNameChain(dir string, name string) ([]string, error) {
+	if name == "" {
+		return nil, fmt.Errorf("invalid environment name '%s'", name)
+	}
+	return envChain(dir, name)
+}
+
+// envChain returns the files of the conventional chain for name that exist in dir. Without a
+// name the chain is just .env and .env.local.
+func envChain(dir string, name string) ([]string, error) {
+	if strings.ContainsAny(name, `/\*?[`) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid environment name '%s'", name)
+	}
+
+	candidates := []string{".env", ".env.local"}
+	if name != "" {
+		candidates = []string{
+			".env",
+			".env." + name,
+			".env.local",
+			".env." + name + ".local",
+		}
+	}
+
+	var chain []string
+	for _, candidate := range candidates {
+		path := filepath.Join(dir, candidate)
+		info, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to check env file '%s': %w", path, err)
+		}
+		if !info.IsDir() {
+			chain = append(chain, path)
+		}
+	}
+	return chain, nil
+}
+
+// AutoDiscover finds the env files to merge for --auto: the conventional chain for name (see
+// EnvNameChain; without a name, .env and .env.local) in dir and, when parents is set, in each
+// directory above it up to the root of the enclosing git repository. Files in outer directories
+// come first so the ones nearest dir win. skipped lists the other .env* files that were found,
+// such as .env.example or the files of other environments, and chain files that are excluded
+// (see IsExcluded).
+func AutoDiscover(dir string, name string, parents bool, excludes []string) (files []string, skipped []string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve directory '%s': %w", dir, err)
+	}
+
+	dirs := []string{dir}
+	if parents {
+		dirs = parentDirsToGitRoot(dir)
+	}
+
+	for _, current := range dirs {
+		chain, err := envChain(current, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, file := range chain {
+			if IsExcluded(file, excludes) {
+				skipped = append(skipped, file)
+			} else {
+				files = append(files, file)
+			}
+		}
+
+		matches, err := filepath.Glob(filepath.Join(current, ".env*"))
+		if err != nil {
+			return nil, nil, err
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if info, err := os.Stat(match); err != nil || info.IsDir() || slices.Contains(chain, match) {
+				continue
+			}
+			skipped = append(skipped, match)
+		}
+	}
+	return files, skipped, nil
+}
+
+// parentDirsToGitRoot returns the directories from the git root enclosing dir down to dir.
+// When dir is not inside a git repository, only dir is returned.
+func parentDirsToGitRoot(dir string) []string {
+	dirs := []string{dir}
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return []string{dir}
+		}
+		current = parent
+		dirs = append(dirs, current)
+	}
+
+	// Outermost first
+	for i, j := 0, len(dirs)-1; i < j; i, j = i+1, j-1 {
+		dirs[i], dirs[j] = dirs[j], dirs[i]
+	}
+	return dirs
+}
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEnvNameChain(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{".env", ".env.local", ".env.production", ".env.production.local", ".env.staging"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("KEY=value\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	chain, err := EnvNameChain(dir, "production")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := []string{
+		filepath.Join(dir, ".env"),
+		filepath.Join(dir, ".env.production"),
+		filepath.Join(dir, ".env.local"),
+		filepath.Join(dir, ".env.production.local"),
+	}
+	if !reflect.DeepEqual(chain, expected) {
+		t.Errorf("Expected %v, got %v", expected, chain)
+	}
+
+	// Missing files are skipped
+	chain, err = EnvNameChain(dir, "development")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected = []string{filepath.Join(dir, ".env"), filepath.Join(dir, ".env.local")}
+	if !reflect.DeepEqual(chain, expected) {
+		t.Errorf("Expected %v, got %v", expected, chain)
+	}
+
+	for _, name := range []string{"", "../prod", ".hidden", "prod*"} {
+		if _, err := EnvNameChain(dir, name); err == nil {
+			t.Errorf("Expected error for environment name %q", name)
+		}
+	}
+}
+
+func TestEnvNameChain_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".env":                  "A=env\nB=env\nC=env\nD=env\n",
+		".env.production":       "B=production\nC=production\nD=production\n",
+		".env.local":            "C=local\nD=local\n",
+		".env.production.local": "D=production.local\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	chain, err := EnvNameChain(dir, "production")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	var sources []Source
+	for i, path := range chain {
+		sources = append(sources, Source{FilePath: path, Type: "env", Priority: i})
+	}
+
+	variables, err := CreateMergeCommand(sources, Options{}).Merge()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := map[string]string{"A": "env", "B": "production", "C": "local", "D": "production.local"}
+	if !reflect.DeepEqual(variables.Map(), expected) {
+		t.Errorf("Expected %v, got %v", expected, variables.Map())
+	}
+}
+
+func TestAutoDiscover(t *testing.T) {
+	root := t.TempDir()
+	app := filepath.Join(root, "services", "app")
+	for _, dir := range []string{filepath.Join(root, ".git"), app} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	for _, path := range []string{
+		filepath.Join(root, ".env"),
+		filepath.Join(app, ".env"),
+		filepath.Join(app, ".env.local"),
+		filepath.Join(app, ".env.example"),
+		filepath.Join(app, ".env.production"),
+	} {
+		if err := os.WriteFile(path, []byte("KEY=value\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	files, skipped, err := AutoDiscover(app, "", false, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := []string{filepath.Join(app, ".env"), filepath.Join(app, ".env.local")}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+	expectedSkipped := []string{filepath.Join(app, ".env.example"), filepath.Join(app, ".env.production")}
+	if !reflect.DeepEqual(skipped, expectedSkipped) {
+		t.Errorf("Expected skipped %v, got %v", expectedSkipped, skipped)
+	}
+
+	// Parent directories up to the git root come first, and --env-name selects the chain
+	files, _, err = AutoDiscover(app, "production", true, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected = []string{
+		filepath.Join(root, ".env"),
+		filepath.Join(app, ".env"),
+		filepath.Join(app, ".env.production"),
+		filepath.Join(app, ".env.local"),
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+
+	// Excluded chain files are skipped
+	files, skipped, err = AutoDiscover(app, "", false, []string{".env.local"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected = []string{filepath.Join(app, ".env")}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+	if len(skipped) != 3 || skipped[0] != filepath.Join(app, ".env.local") {
+		t.Errorf("Expected excluded .env.local to be reported as skipped, got %v", skipped)
+	}
+}
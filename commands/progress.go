@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/notwillk/envvars-cli/logging"
+)
+
+// fetchTiming is how long loading a remote-backed source took
+type fetchTiming struct {
+	source   Source
+	duration time.Duration
+	err      error
+}
+
+// fetchProgress reports remote fetches as they start and finish, and collects their timings
+type fetchProgress struct {
+	mu      sync.Mutex
+	out     io.Writer // Progress lines are written here (nil disables them)
+	total   int
+	timings []fetchTiming
+}
+
+// started reports that a fetch has begun
+func (p *fetchProgress) started(source Source) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.out != nil {
+		fmt.Fprintf(p.out, "Fetching %s source '%s'...\n", source.Type, source.FilePath)
+	}
+}
+
+// finished records a fetch's timing and reports it
+func (p *fetchProgress) finished(source Source, duration time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timings = append(p.timings, fetchTiming{source: source, duration: duration, err: err})
+	if p.out == nil {
+		return
+	}
+	status := "fetched"
+	if err != nil {
+		status = "failed"
+	}
+	fmt.Fprintf(p.out, "[%d/%d] %s %s source '%s' in %s\n", len(p.timings), p.total, status, source.Type, source.FilePath, duration.Round(time.Millisecond))
+}
+
+// summarize logs the fetch timings, slowest first, at info level
+func (p *fetchProgress) summarize(elapsed time.Duration) {
+	if len(p.timings) == 0 || !logging.Enabled(logging.LevelInfo) {
+		return
+	}
+
+	timings := append([]fetchTiming(nil), p.timings...)
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].duration > timings[j].duration
+	})
+
+	logging.Infof("Fetched %d remote source(s) in %s:", len(timings), elapsed.Round(time.Millisecond))
+	for _, timing := range timings {
+		suffix := ""
+		if timing.err != nil {
+			suffix = " (failed)"
+		}
+		logging.Infof("  %s '%s': %s%s", timing.source.Type, timing.source.FilePath, timing.duration.Round(time.Millisecond), suffix)
+	}
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+)
+
+// Redaction modes for --redact
+const (
+	RedactMask = "mask" // Replace sensitive values with RedactedValue
+	RedactHMAC = "hmac" // Replace sensitive values with a keyed hash, so equal values stay comparable
+)
+
+// RedactedValue replaces sensitive values in RedactMask mode
+const RedactedValue = "***"
+
+// RedactKeyEnv names the environment variable holding the --redact=hmac key
+const RedactKeyEnv = "ENVVARS_REDACT_KEY"
+
+// redact returns a copy of values with the sensitive ones (see isSecret) replaced
+func (cmd *MergeCommand) redact(values map[string]string) (map[string]string, error) {
+	var replace func(string) string
+	switch cmd.options.Redact {
+	case RedactMask:
+		replace = func(string) string { return RedactedValue }
+	case RedactHMAC:
+		if cmd.options.RedactKey == "" {
+			return nil, fmt.Errorf("--redact=hmac requires a key from --redact-key or %s", RedactKeyEnv)
+		}
+		replace = func(value string) string { return redactHMAC(cmd.options.RedactKey, value) }
+	default:
+		return nil, fmt.Errorf("unsupported redact mode: %s", cmd.options.Redact)
+	}
+
+	redacted := maps.Clone(values)
+	for key, value := range redacted {
+		if cmd.isSecret(key, value) {
+			redacted[key] = replace(value)
+		}
+	}
+	return redacted, nil
+}
+
+// redactHMAC returns a truncated HMAC-SHA256 of value, stable for a given key
+func redactHMAC(key string, value string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(value))
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"filippo.io/age"
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// remoteCache keeps decrypted remote sources on disk between runs, so they can be merged
+// without a network round trip while unchanged. Entries are encrypted to an age identity,
+// so the cache never holds secrets in plaintext.
+type remoteCache struct {
+	dir      string
+	identity *age.X25519Identity
+}
+
+// remoteCacheEntry is the payload of a cache file, along with the file state it was parsed from
+type remoteCacheEntry struct {
+	ModTime time.Time       `json:"mod_time"`
+	Size    int64           `json:"size"`
+	EnvFile sources.EnvFile `json:"env_file"`
+}
+
+// openRemoteCache creates a cache in dir encrypted with the first X25519 identity in the age
+// identity file at identityPath
+func openRemoteCache(dir string, identityPath string) (*remoteCache, error) {
+	if identityPath == "" {
+		return nil, fmt.Errorf("--cache-dir requires --cache-identity, so cached secrets are encrypted")
+	}
+
+	file, err := os.Open(identityPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache identity '%s': %w", identityPath, err)
+	}
+	defer file.Close()
+	identities, err := age.ParseIdentities(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cache identity '%s': %w", identityPath, err)
+	}
+
+	for _, identity := range identities {
+		if x25519, ok := identity.(*age.X25519Identity); ok {
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return nil, fmt.Errorf("failed to create cache directory '%s': %w", dir, err)
+			}
+			return &remoteCache{dir: dir, identity: x25519}, nil
+		}
+	}
+	return nil, fmt.Errorf("cache identity '%s' has no age X25519 identity", identityPath)
+}
+
+// path returns the cache file for source; the name is a hash, so it reveals neither the source
+// path nor its decryption key
+func (c *remoteCache) path(source Source) string {
+	sum := sha256.Sum256([]byte(cacheKey(source)))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".age")
+}
+
+// get returns the cached parse of source if the file is unchanged since it was cached.
+// Entries that cannot be decrypted, such as those written with another identity, are misses.
+func (c *remoteCache) get(source Source) (sources.EnvFile, bool) {
+	info, err := os.Stat(source.FilePath)
+	if err != nil {
+		return sources.EnvFile{}, false
+	}
+	file, err := os.Open(c.path(source))
+	if err != nil {
+		return sources.EnvFile{}, false
+	}
+	defer file.Close()
+
+	reader, err := age.Decrypt(file, c.identity)
+	if err != nil {
+		logging.Debugf("Ignoring cached copy of '%s': %v", source.FilePath, err)
+		return sources.EnvFile{}, false
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		logging.Debugf("Ignoring cached copy of '%s': %v", source.FilePath, err)
+		return sources.EnvFile{}, false
+	}
+
+	var entry remoteCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logging.Debugf("Ignoring cached copy of '%s': %v", source.FilePath, err)
+		return sources.EnvFile{}, false
+	}
+	if !entry.ModTime.Equal(info.ModTime()) || entry.Size != info.Size() {
+		return sources.EnvFile{}, false
+	}
+	logging.Infof("Using cached copy of %s file: %s", source.Type, source.FilePath)
+	return entry.EnvFile, true
+}
+
+// put encrypts the parse of source into its cache file; info must be the file state observed
+// before parsing. The file is replaced atomically, so concurrent runs never read a partial entry.
+func (c *remoteCache) put(source Source, info os.FileInfo, envFile sources.EnvFile) error {
+	if info == nil {
+		return nil
+	}
+	data, err := json.Marshal(remoteCacheEntry{ModTime: info.ModTime(), Size: info.Size(), EnvFile: envFile})
+	if err != nil {
+		return err
+	}
+
+	var encrypted bytes.Buffer
+	writer, err := age.Encrypt(&encrypted, c.identity.Recipient())
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(c.dir, "entry-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(encrypted.Bytes()); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), c.path(source))
+}
@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// writeIdentity writes a new age identity file to dir
+func writeIdentity(t *testing.T, dir string, name string) string {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write identity: %v", err)
+	}
+	return path
+}
+
+func TestRemoteCache(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	sourcePath := filepath.Join(dir, "secrets.enc.yaml")
+	if err := os.WriteFile(sourcePath, []byte("ciphertext"), 0600); err != nil {
+		t.Fatalf("Failed to write source: %v", err)
+	}
+	source := Source{FilePath: sourcePath, Type: "sops", DecryptionKey: "age1key"}
+	envFile := sources.EnvFile{Filename: sourcePath, Variables: []sources.EnvVar{{Key: "DB_PASSWORD", Value: "hunter2"}}}
+
+	cache, err := openRemoteCache(cacheDir, writeIdentity(t, dir, "key.txt"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	info, _ := os.Stat(sourcePath)
+	if err := cache.put(source, info, envFile); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	cached, ok := cache.get(source)
+	if !ok || !reflect.DeepEqual(cached, envFile) {
+		t.Errorf("Expected %v, got %v (hit %v)", envFile, cached, ok)
+	}
+
+	// The cache file holds neither the secret nor the source path
+	entries, _ := os.ReadDir(cacheDir)
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 cache file, got %d", len(entries))
+	}
+	data, _ := os.ReadFile(filepath.Join(cacheDir, entries[0].Name()))
+	if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), "DB_PASSWORD") {
+		t.Errorf("Expected the cache file to be encrypted")
+	}
+	if strings.Contains(entries[0].Name(), "secrets") {
+		t.Errorf("Expected the cache file name not to reveal the source, got %s", entries[0].Name())
+	}
+
+	// Another identity cannot read the entry
+	other, err := openRemoteCache(cacheDir, writeIdentity(t, dir, "other.txt"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, ok := other.get(source); ok {
+		t.Errorf("Expected a miss with another identity")
+	}
+
+	// A changed source invalidates the entry
+	if err := os.WriteFile(sourcePath, []byte("new ciphertext"), 0600); err != nil {
+		t.Fatalf("Failed to write source: %v", err)
+	}
+	if _, ok := cache.get(source); ok {
+		t.Errorf("Expected a miss after the source changed")
+	}
+}
+
+func TestOpenRemoteCache_RequiresIdentity(t *testing.T) {
+	_, err := openRemoteCache(t.TempDir(), "")
+	if err == nil || !strings.Contains(err.Error(), "requires --cache-identity") {
+		t.Errorf("Expected a missing identity error, got: %v", err)
+	}
+
+	_, err = CreateMergeCommand([]Source{{FilePath: "a.env", Type: "env"}}, Options{CacheDir: t.TempDir()}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "requires --cache-identity") {
+		t.Errorf("Expected a missing identity error, got: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// ReadRequireFile reads the keys listed in a --require-file: one or more keys per line,
+// separated by commas or whitespace, with blank lines and # comments ignored
+func ReadRequireFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open require file '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	var keys []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading require file '%s': %w", path, err)
+	}
+	return keys, nil
+}
+
+// checkRequired fails when any of the --require keys is missing from the merged variables,
+// listing every missing key. Empty values count as missing with --require-nonempty and are
+// warned about otherwise, as for #require directives.
+func (cmd *MergeCommand) checkRequired(variables *sources.Variables) error {
+	var missing []string
+	for _, key := range cmd.options.Require {
+		value, exists := variables.Get(key)
+		switch {
+		case !exists:
+			missing = append(missing, key)
+		case value == "" && cmd.options.RequireNonEmpty:
+			missing = append(missing, key+" (empty)")
+		case value == "":
+			logging.Warnf("required environment variable '%s' is empty", key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %d required environment variable(s): %s", len(missing), strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkKeyCount fails when the merged output is empty under --fail-if-empty, or has fewer
+// keys than --min-keys, which usually means a wrong path or an over-aggressive filter
+func (cmd *MergeCommand) checkKeyCount(variables *sources.Variables) error {
+	count := variables.Len()
+	if cmd.options.FailIfEmpty && count == 0 {
+		return fmt.Errorf("merged output is empty: the sources define no variables, or filters removed all of them")
+	}
+	if cmd.options.MinKeys > 0 && count < cmd.options.MinKeys {
+		return fmt.Errorf("merged output has %d variable(s), fewer than the minimum of %d", count, cmd.options.MinKeys)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeCommand_Merge_Require(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("DB_HOST=localhost\nDB_PASSWORD=\nAPI_KEY=secret\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	sources := []Source{{FilePath: tempFile.Name(), Type: "env", Priority: 0}}
+	tests := []struct {
+		options Options
+		errText string
+	}{
+		{Options{Require: []string{"DB_HOST", "API_KEY"}}, ""},
+		{Options{Require: []string{"DB_PASSWORD"}}, ""},
+		{Options{Require: []string{"DB_HOST", "DB_PORT", "REDIS_URL"}}, "missing 2 required environment variable(s): DB_PORT, REDIS_URL"},
+		{Options{Require: []string{"DB_PASSWORD"}, RequireNonEmpty: true}, "missing 1 required environment variable(s): DB_PASSWORD (empty)"},
+		{Options{Require: []string{"API_KEY"}, Except: []string{"API_*"}}, "missing 1 required environment variable(s): API_KEY"},
+	}
+
+	for _, test := range tests {
+		_, err := CreateMergeCommand(sources, test.options).Merge()
+		if test.errText == "" {
+			if err != nil {
+				t.Errorf("Options %+v: expected no error, got: %v", test.options, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.errText) {
+			t.Errorf("Options %+v: expected error containing %q, got %v", test.options, test.errText, err)
+		}
+	}
+}
+
+func TestReadRequireFile(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "required-*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("# database\nDB_HOST\n\n  DB_PORT, DB_USER\nAPI_KEY REDIS_URL\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	keys, err := ReadRequireFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := []string{"DB_HOST", "DB_PORT", "DB_USER", "API_KEY", "REDIS_URL"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+
+	if _, err := ReadRequireFile(tempFile.Name() + ".missing"); err == nil {
+		t.Error("Expected an error for a missing require file")
+	}
+}
+
+func TestMergeCommand_Merge_KeyCount(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("DB_HOST=localhost\nDB_PORT=5432\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	sources := []Source{{FilePath: tempFile.Name(), Type: "env", Priority: 0}}
+	tests := []struct {
+		options Options
+		errText string
+	}{
+		{Options{FailIfEmpty: true}, ""},
+		{Options{MinKeys: 2}, ""},
+		{Options{Only: []string{"API_*"}}, ""},
+		{Options{FailIfEmpty: true, Only: []string{"API_*"}}, "merged output is empty"},
+		{Options{MinKeys: 3}, "has 2 variable(s), fewer than the minimum of 3"},
+	}
+
+	for _, test := range tests {
+		_, err := CreateMergeCommand(sources, test.options).Merge()
+		if test.errText == "" {
+			if err != nil {
+				t.Errorf("Options %+v: expected no error, got: %v", test.options, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.errText) {
+			t.Errorf("Options %+v: expected error containing %q, got %v", test.options, test.errText, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/notwillk/envvars-cli/logging"
+)
+
+// RunCommand runs a program with the merged variables in its environment
+type RunCommand struct {
+	files   []string
+	args    []string
+	clean   bool
+	watch   bool
+	environ []string // The environment the merged variables are added to
+}
+
+// ExitError reports a result that envvars-cli exits with Code for, without an error message:
+// the failure of the program run by RunCommand, or the differences found by DiffCommand
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// CreateRunCommand creates a run command merging the files (typed by extension) and running
+// args[0] with the remaining arguments. The merged variables are added to the current
+// environment, overriding variables of the same name, or with clean, replace it. With watch,
+// the program is restarted with the new variables whenever one of the files changes.
+func CreateRunCommand(files []string, args []string, clean bool, watch bool) *RunCommand {
+	return &RunCommand{
+		files:   files,
+		args:    args,
+		clean:   clean,
+		watch:   watch,
+		environ: os.Environ(),
+	}
+}
+
+// Environment returns the program's environment as KEY=value entries
+func (cmd *RunCommand) Environment() ([]string, error) {
+	merge, err := cmd.mergeCommand()
+	if err != nil {
+		return nil, err
+	}
+	return cmd.environment(merge)
+}
+
+// mergeCommand creates the merge of the files
+func (cmd *RunCommand) mergeCommand() (*MergeCommand, error) {
+	if len(cmd.files) == 0 {
+		return nil, fmt.Errorf("run requires at least one file (-f)")
+	}
+
+	sourceList, err := SourcesForFiles(cmd.files)
+	if err != nil {
+		return nil, err
+	}
+	return CreateMergeCommand(sourceList, Options{Format: "env"}), nil
+}
+
+// environment merges the files and returns the program's environment
+func (cmd *RunCommand) environment(merge *MergeCommand) ([]string, error) {
+	variables, err := merge.Merge()
+	if err != nil {
+		return nil, err
+	}
+
+	var env []string
+	if !cmd.clean {
+		for _, entry := range cmd.environ {
+			key, _, _ := strings.Cut(entry, "=")
+			if _, overridden := variables.Get(key); !overridden {
+				env = append(env, entry)
+			}
+		}
+	}
+	for _, key := range variables.Keys() {
+		value, _ := variables.Get(key)
+		env = append(env, key+"="+value)
+	}
+	return env, nil
+}
+
+// Execute merges the files and runs the program. Where the platform allows, envvars-cli is
+// replaced by the program; otherwise it waits for the program and returns an *ExitError when
+// it fails. With watch, it runs until interrupted.
+func (cmd *RunCommand) Execute() error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("run requires a command to run after --")
+	}
+	path, err := exec.LookPath(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("failed to find command '%s': %w", cmd.args[0], err)
+	}
+	if cmd.watch {
+		return cmd.executeWatching(path, nil)
+	}
+
+	env, err := cmd.Environment()
+	if err != nil {
+		return err
+	}
+	return execProcess(path, cmd.args, env)
+}
+
+// executeWatching runs the program as a child process and restarts it with the newly merged
+// variables whenever one of the files changes, until done is closed. A program that exits is
+// started again on the next change, and a merge that fails is reported and retried then too.
+func (cmd *RunCommand) executeWatching(path string, done <-chan struct{}) error {
+	merge, err := cmd.mergeCommand()
+	if err != nil {
+		return err
+	}
+
+	watcher := newFileWatcher(0)
+	for {
+		watcher.watch(merge.watchFiles())
+		var child *childProcess
+		env, err := cmd.environment(merge)
+		if err == nil {
+			child, err = startProcess(path, cmd.args, env)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		watcher.watch(merge.watchFiles())
+
+		changed := watcher.wait(done)
+		if child != nil {
+			child.stop()
+		}
+		if changed == nil {
+			return nil
+		}
+		logging.Infof("Changed: %v; restarting %s", changed, cmd.args[0])
+		merge.Invalidate(changed...)
+	}
+}
+
+// stopTimeout is how long a watched program has to exit after being asked to before it is killed
+const stopTimeout = 10 * time.Second
+
+// childProcess is a program started by run --watch
+type childProcess struct {
+	process *exec.Cmd
+	exited  chan struct{} // Closed once the program has exited
+}
+
+// startProcess starts the program as a child process sharing envvars-cli's standard streams
+func startProcess(path string, args []string, env []string) (*childProcess, error) {
+	process := exec.Command(path, args[1:]...)
+	process.Env = env
+	process.Stdin = os.Stdin
+	process.Stdout = os.Stdout
+	process.Stderr = os.Stderr
+	if err := process.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run '%s': %w", path, err)
+	}
+
+	child := &childProcess{process: process, exited: make(chan struct{})}
+	go func() {
+		process.Wait()
+		close(child.exited)
+	}()
+	return child, nil
+}
+
+// stop asks the program to exit (see terminateProcess) and waits for it, killing it when it
+// has not exited within stopTimeout
+func (child *childProcess) stop() {
+	select {
+	case <-child.exited:
+		return
+	default:
+	}
+
+	if err := terminateProcess(child.process.Process); err != nil {
+		child.process.Process.Kill()
+	}
+	select {
+	case <-child.exited:
+	case <-time.After(stopTimeout):
+		child.process.Process.Kill()
+		<-child.exited
+	}
+}
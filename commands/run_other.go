@@ -0,0 +1,38 @@
+//go:build !unix
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// execProcess runs the program as a child process and waits for it. Interrupts reach the child
+// through the console, so envvars-cli ignores them and leaves the child to decide when to exit.
+func execProcess(path string, args []string, env []string) error {
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	process := exec.Command(path, args[1:]...)
+	process.Env = env
+	process.Stdin = os.Stdin
+	process.Stdout = os.Stdout
+	process.Stderr = os.Stderr
+	if err := process.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return &ExitError{Code: exitErr.ExitCode()}
+		}
+		return fmt.Errorf("failed to run '%s': %w", path, err)
+	}
+	return nil
+}
+
+// terminateProcess stops a program started by run --watch. Without signals to ask it to exit,
+// the program is killed.
+func terminateProcess(process *os.Process) error {
+	return process.Kill()
+}
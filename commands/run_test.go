@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRunCommand_Environment(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.env")
+	prodPath := filepath.Join(dir, "prod.yaml")
+	if err := os.WriteFile(basePath, []byte("PORT=8080\nMODE=dev\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	if err := os.WriteFile(prodPath, []byte("MODE: prod\n"), 0644); err != nil {
+		t.Fatalf("Failed to write YAML file: %v", err)
+	}
+
+	cmd := CreateRunCommand([]string{basePath, prodPath}, []string{"./server"}, false, false)
+	cmd.environ = []string{"HOME=/home/me", "MODE=inherited"}
+
+	env, err := cmd.Environment()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := []string{"HOME=/home/me", "PORT=8080", "MODE=prod"}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("Expected %v, got %v", expected, env)
+	}
+
+	cmd.clean = true
+	env, err = cmd.Environment()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected = []string{"PORT=8080", "MODE=prod"}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("Expected %v, got %v", expected, env)
+	}
+}
+
+func TestRunCommand_Errors(t *testing.T) {
+	if err := CreateRunCommand([]string{"base.env"}, nil, false, false).Execute(); err == nil {
+		t.Errorf("Expected an error without a command")
+	}
+	if err := CreateRunCommand(nil, []string{"true"}, false, false).Execute(); err == nil {
+		t.Errorf("Expected an error without files")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// execProcess replaces the current process with the program, so it receives signals directly
+// and its exit status is envvars-cli's
+func execProcess(path string, args []string, env []string) error {
+	if err := syscall.Exec(path, args, env); err != nil {
+		return fmt.Errorf("failed to run '%s': %w", path, err)
+	}
+	return nil
+}
+
+// terminateProcess asks a program started by run --watch to exit with SIGTERM
+func terminateProcess(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
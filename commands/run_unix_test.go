@@ -0,0 +1,56 @@
+//go:build unix
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunCommand_Watch(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "config.env")
+	logPath := filepath.Join(dir, "runs.log")
+	if err := os.WriteFile(envPath, []byte("KEY=first\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	// Each run of the program logs KEY and then waits to be stopped
+	cmd := CreateRunCommand([]string{envPath}, []string{"sh", "-c", `echo "$KEY" >> "$0"; exec sleep 60`, logPath}, false, true)
+	done := make(chan struct{})
+	finished := make(chan error)
+	go func() { finished <- cmd.executeWatching("/bin/sh", done) }()
+
+	waitForLog := func(expected string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			data, _ := os.ReadFile(logPath)
+			if string(data) == expected {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected the runs %q, got %q", expected, data)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	waitForLog("first\n")
+
+	if err := os.WriteFile(envPath, []byte("KEY=second\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	waitForLog("first\nsecond\n")
+
+	close(done)
+	select {
+	case err := <-finished:
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the program to be stopped")
+	}
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// ScanSecretsCommand looks for credentials in unencrypted source files
+type ScanSecretsCommand struct {
+	paths  []string
+	format string
+	policy *Policy
+	out    io.Writer
+}
+
+// CreateScanSecretsCommand creates a scan-secrets command for the given files or glob patterns,
+// reporting findings as "text" or "json". Keys the policy (which may be nil) requires to be
+// encrypted are reported too.
+func CreateScanSecretsCommand(paths []string, format string, policy *Policy) *ScanSecretsCommand {
+	return &ScanSecretsCommand{
+		paths:  paths,
+		format: format,
+		policy: policy,
+		out:    os.Stdout,
+	}
+}
+
+// Execute scans every file and writes the findings. Finding anything is an error, so that the
+// command can guard commits and CI runs.
+func (cmd *ScanSecretsCommand) Execute() error {
+	if cmd.format != "text" && cmd.format != "json" {
+		return fmt.Errorf("unsupported scan format: %s", cmd.format)
+	}
+	if len(cmd.paths) == 0 {
+		return fmt.Errorf("scan-secrets requires at least one file")
+	}
+
+	findings, err := cmd.Scan()
+	if err != nil {
+		return err
+	}
+	if err := cmd.writeFindings(findings); err != nil {
+		return err
+	}
+	if len(findings) > 0 {
+		return fmt.Errorf("found %d potential secret(s) in unencrypted sources", len(findings))
+	}
+	return nil
+}
+
+// Scan returns the findings in every file, skipping SOPS-encrypted files
+func (cmd *ScanSecretsCommand) Scan() ([]sources.SecretFinding, error) {
+	findings := []sources.SecretFinding{}
+	for _, pattern := range cmd.paths {
+		filePaths, err := ExpandSourcePath(pattern, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, filePath := range filePaths {
+			envFile, err := parseFileByExtension(filePath)
+			if err != nil {
+				return nil, err
+			}
+			if slices.ContainsFunc(envFile.Variables, func(variable sources.EnvVar) bool {
+				return sources.IsEncryptedValue(variable.Value)
+			}) {
+				logging.Infof("Skipping encrypted source '%s'", filePath)
+				continue
+			}
+			findings = append(findings, sources.ScanSecrets(envFile)...)
+			for _, variable := range cmd.policy.PlaintextViolations("", envFile) {
+				findings = append(findings, sources.SecretFinding{
+					File:        filePath,
+					Line:        variable.Line,
+					Key:         variable.Key,
+					Rule:        "policy",
+					Description: "must be encrypted under policy '" + cmd.policy.Path + "'",
+				})
+			}
+		}
+	}
+	return findings, nil
+}
+
+// parseFileByExtension parses filePath by its extension: .json and .yaml/.yml files as structured
+// documents and anything else as an env file
+func parseFileByExtension(filePath string) (sources.EnvFile, error) {
+	options := sources.Options{FilePath: filePath, InvalidKeys: sources.InvalidKeysKeep}
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return sources.CreateJSONProcessor().ParseFile(options)
+	case ".yaml", ".yml":
+		return sources.CreateYAMLProcessor().ParseFile(options)
+	default:
+		return sources.ParseFile(options)
+	}
+}
+
+// writeFindings writes one "file:line: KEY looks like ... [rule]" line per finding, or a JSON array
+func (cmd *ScanSecretsCommand) writeFindings(findings []sources.SecretFinding) error {
+	if cmd.format == "json" {
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode findings: %w", err)
+		}
+		_, err = fmt.Fprintln(cmd.out, string(data))
+		return err
+	}
+
+	for _, finding := range findings {
+		location := finding.File
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", finding.File, finding.Line)
+		}
+		if _, err := fmt.Fprintf(cmd.out, "%s: %s %s [%s]\n", location, finding.Key, finding.Description, finding.Rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/formatters"
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// SetCommand sets variables in an env file, editing it in place
+type SetCommand struct {
+	file        string
+	assignments []string
+}
+
+// CreateSetCommand creates a set command writing the KEY=value assignments to file
+func CreateSetCommand(file string, assignments []string) *SetCommand {
+	return &SetCommand{
+		file:        file,
+		assignments: assignments,
+	}
+}
+
+// Execute updates every existing assignment of each key in place, keeping its quoting style,
+// spacing, and inline comment, and appends the keys the file does not assign. The rest of the
+// file is left as it was; a missing file is created.
+func (cmd *SetCommand) Execute() error {
+	if len(cmd.assignments) == 0 {
+		return fmt.Errorf("set requires at least one KEY=value")
+	}
+	for _, assignment := range cmd.assignments {
+		key, _, ok := strings.Cut(assignment, "=")
+		if !ok {
+			return fmt.Errorf("invalid assignment '%s', expected KEY=value", assignment)
+		}
+		if !sources.IsValidKey(key) {
+			return fmt.Errorf("invalid key '%s': keys must be letters, digits, and underscores, not starting with a digit", key)
+		}
+	}
+
+	document, err := sources.ReadEnvDocument(cmd.file)
+	if errors.Is(err, fs.ErrNotExist) {
+		document, err = sources.ParseEnvDocument(nil), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, assignment := range cmd.assignments {
+		key, value, _ := strings.Cut(assignment, "=")
+		existing := slices.DeleteFunc(document.Assignments(), func(assignment sources.EnvAssignment) bool {
+			return assignment.Key != key
+		})
+		if len(existing) == 0 {
+			document.Append(formatters.FormatENVLine(key, value, formatters.Options{}))
+			logging.Infof("Added %s to '%s'", key, cmd.file)
+			continue
+		}
+		// From the last, so replacing a multi-line value does not move the earlier ones
+		for _, assignment := range slices.Backward(existing) {
+			document.SetValue(assignment, quoteLike(value, assignment.Quote()))
+		}
+		logging.Infof("Updated %s in '%s'", key, cmd.file)
+	}
+
+	if err := os.WriteFile(cmd.file, document.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write file '%s': %w", cmd.file, err)
+	}
+	return nil
+}
+
+// quoteLike renders value for an env file in the given quoting style (0 for unquoted), falling
+// back to the style the env format writes when the value cannot be written that way
+func quoteLike(value string, quote byte) string {
+	raw := strings.TrimPrefix(formatters.FormatENVLine("", value, formatters.Options{}), "=")
+	switch {
+	case quote == '\'' && !strings.ContainsAny(value, "'\n\r"):
+		return "'" + value + "'"
+	case quote == '"' && !strings.HasPrefix(raw, "\""):
+		// Values written bare need no escaping inside double quotes
+		return "\"" + raw + "\""
+	}
+	return raw
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetCommand_Execute(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	content := "# Database\r\n" +
+		"export HOST = 'localhost' # local only\r\n" +
+		"PORT=5432\r\n" +
+		"CERT=\"line one\r\nline two\"\r\n" +
+		"NAME=\"app\""
+	if err := os.WriteFile(envPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	assignments := []string{"HOST=db.internal", "PORT=6543", "CERT=single", "NAME=my app", "NEW=it's new"}
+	if err := CreateSetCommand(envPath, assignments).Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	data, err := os.ReadFile(envPath)
+	if err != nil {
+		t.Fatalf("Failed to read env file: %v", err)
+	}
+	expected := "# Database\r\n" +
+		"export HOST = 'db.internal' # local only\r\n" +
+		"PORT=6543\r\n" +
+		"CERT=\"single\"\r\n" +
+		"NAME=\"my app\"\r\n" +
+		"NEW=\"it's new\"\r\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+
+	// Missing files are created
+	newPath := filepath.Join(dir, "new.env")
+	if err := CreateSetCommand(newPath, []string{"KEY=value"}).Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if data, _ := os.ReadFile(newPath); string(data) != "KEY=value\n" {
+		t.Errorf("Expected a new file, got %q", data)
+	}
+
+	err = CreateSetCommand(envPath, []string{"1KEY=value"}).Execute()
+	if err == nil || !strings.Contains(err.Error(), "invalid key '1KEY'") {
+		t.Errorf("Expected an invalid key error, got %v", err)
+	}
+}
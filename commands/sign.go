@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// SignatureAlgorithm is the algorithm of bundle signatures
+const SignatureAlgorithm = "ed25519"
+
+// BundleSignature is a detached signature over the canonical form of an env bundle
+type BundleSignature struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`    // Identifies the public key that verifies the signature
+	Signature []byte `json:"signature"` // Base64 in JSON
+}
+
+// SignatureFile returns the default signature path for a bundle
+func SignatureFile(bundle string) string {
+	return bundle + ".sig"
+}
+
+// canonicalDirectives is the canonical form of a bundle with directives, which change the merge
+// result and so are signed along with the variables
+type canonicalDirectives struct {
+	Directives [][]string        `json:"directives"` // Lowercased name then arguments, in file order
+	Variables  map[string]string `json:"variables"`
+}
+
+// CanonicalBundle returns the canonical form of the bundle at path (an env, JSON, or YAML file):
+// its variables as a JSON object with sorted keys. Reordering, reformatting, or re-encoding the
+// bundle keeps the form unchanged, while changing any key, value, or directive changes it. A
+// bundle with directives (its own or those of files it #includes) has the form
+// {"directives": [[name, arguments...], ...], "variables": {...}}.
+func CanonicalBundle(path string) ([]byte, error) {
+	envFile, err := parseFileByExtension(path)
+	if err != nil {
+		return nil, err
+	}
+	variables := sources.NewVariables()
+	for _, variable := range envFile.Variables {
+		variables.Set(variable.Key, variable.Value)
+	}
+	if len(envFile.Directives) == 0 {
+		return json.Marshal(variables.Map())
+	}
+
+	canonical := canonicalDirectives{Variables: variables.Map()}
+	for _, directive := range envFile.Directives {
+		canonical.Directives = append(canonical.Directives, append([]string{strings.ToLower(directive.Name)}, directive.Arguments...))
+	}
+	return json.Marshal(canonical)
+}
+
+// SignCommand writes a detached signature for an env bundle
+type SignCommand struct {
+	bundle    string
+	keyPath   string
+	signature string
+}
+
+// CreateSignCommand creates a sign command signing bundle with the Ed25519 private key in the
+// PEM file at keyPath, writing the signature to signature (empty uses SignatureFile)
+func CreateSignCommand(bundle string, keyPath string, signature string) *SignCommand {
+	if signature == "" {
+		signature = SignatureFile(bundle)
+	}
+	return &SignCommand{bundle: bundle, keyPath: keyPath, signature: signature}
+}
+
+// Execute signs the bundle and writes the signature file
+func (cmd *SignCommand) Execute() error {
+	key, err := loadPrivateKey(cmd.keyPath)
+	if err != nil {
+		return err
+	}
+	canonical, err := CanonicalBundle(cmd.bundle)
+	if err != nil {
+		return err
+	}
+
+	signature := BundleSignature{
+		Algorithm: SignatureAlgorithm,
+		KeyID:     keyID(key.Public().(ed25519.PublicKey)),
+		Signature: ed25519.Sign(key, canonical),
+	}
+	data, err := json.MarshalIndent(signature, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode signature: %w", err)
+	}
+	if err := os.WriteFile(cmd.signature, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write signature '%s': %w", cmd.signature, err)
+	}
+	return nil
+}
+
+// VerifyCommand checks the detached signature of an env bundle
+type VerifyCommand struct {
+	bundle    string
+	keyPath   string
+	signature string
+}
+
+// CreateVerifyCommand creates a verify command checking bundle against the signature file
+// (empty uses SignatureFile) with the Ed25519 public key in the PEM file at keyPath
+func CreateVerifyCommand(bundle string, keyPath string, signature string) *VerifyCommand {
+	if signature == "" {
+		signature = SignatureFile(bundle)
+	}
+	return &VerifyCommand{bundle: bundle, keyPath: keyPath, signature: signature}
+}
+
+// Execute verifies the signature, returning an error unless it is valid for the bundle
+func (cmd *VerifyCommand) Execute() error {
+	key, err := loadPublicKey(cmd.keyPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(cmd.signature)
+	if err != nil {
+		return fmt.Errorf("failed to read signature '%s': %w", cmd.signature, err)
+	}
+	var signature BundleSignature
+	if err := json.Unmarshal(data, &signature); err != nil {
+		return fmt.Errorf("failed to parse signature '%s': %w", cmd.signature, err)
+	}
+	if signature.Algorithm != SignatureAlgorithm {
+		return fmt.Errorf("unsupported signature algorithm '%s' in '%s'", signature.Algorithm, cmd.signature)
+	}
+	if signature.KeyID != keyID(key) {
+		return fmt.Errorf("signature '%s' was made with key %s, not %s", cmd.signature, signature.KeyID, keyID(key))
+	}
+
+	canonical, err := CanonicalBundle(cmd.bundle)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, canonical, signature.Signature) {
+		return fmt.Errorf("signature '%s' does not match '%s'; the bundle was modified after signing", cmd.signature, cmd.bundle)
+	}
+	return nil
+}
+
+// keyID returns a short identifier for a public key
+func keyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// readPEM returns the first PEM block in the file at path
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key '%s': %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key '%s' is not PEM encoded", path)
+	}
+	return block, nil
+}
+
+// loadPrivateKey reads a PKCS #8 Ed25519 private key, as written by
+// "openssl genpkey -algorithm ed25519"
+func loadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key '%s': %w", path, err)
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key '%s' is not an Ed25519 key", path)
+	}
+	return private, nil
+}
+
+// loadPublicKey reads a PKIX Ed25519 public key, as written by "openssl pkey -pubout"
+func loadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key '%s': %w", path, err)
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key '%s' is not an Ed25519 key", path)
+	}
+	return public, nil
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSigningKeys writes a new Ed25519 key pair as PEM files to dir
+func writeSigningKeys(t *testing.T, dir string, name string) (string, string) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	privateDER, _ := x509.MarshalPKCS8PrivateKey(private)
+	publicDER, _ := x509.MarshalPKIXPublicKey(public)
+
+	privatePath := filepath.Join(dir, name+".pem")
+	publicPath := filepath.Join(dir, name+".pub.pem")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return privatePath, publicPath
+}
+
+func TestSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	privateKey, publicKey := writeSigningKeys(t, dir, "signing")
+	_, otherPublicKey := writeSigningKeys(t, dir, "other")
+
+	bundle := filepath.Join(dir, "bundle.env")
+	if err := os.WriteFile(bundle, []byte("B=2\nA=1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	if err := CreateSignCommand(bundle, privateKey, "").Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := os.Stat(SignatureFile(bundle)); err != nil {
+		t.Fatalf("Expected a signature file: %v", err)
+	}
+
+	if err := CreateVerifyCommand(bundle, publicKey, "").Execute(); err != nil {
+		t.Errorf("Expected the signature to verify, got: %v", err)
+	}
+
+	// Reordering and requoting keeps the canonical form
+	if err := os.WriteFile(bundle, []byte("A=\"1\"\nB='2'\n"), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	if err := CreateVerifyCommand(bundle, publicKey, "").Execute(); err != nil {
+		t.Errorf("Expected the reformatted bundle to verify, got: %v", err)
+	}
+
+	if err := CreateVerifyCommand(bundle, otherPublicKey, "").Execute(); err == nil || !strings.Contains(err.Error(), "was made with key") {
+		t.Errorf("Expected a key mismatch error, got: %v", err)
+	}
+
+	if err := os.WriteFile(bundle, []byte("A=1\nB=3\n"), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	if err := CreateVerifyCommand(bundle, publicKey, "").Execute(); err == nil || !strings.Contains(err.Error(), "modified after signing") {
+		t.Errorf("Expected a verification failure, got: %v", err)
+	}
+}
+
+func TestVerify_DirectivesAreSigned(t *testing.T) {
+	dir := t.TempDir()
+	privateKey, publicKey := writeSigningKeys(t, dir, "signing")
+
+	bundle := filepath.Join(dir, "bundle.env")
+	included := filepath.Join(dir, "shared.env")
+	if err := os.WriteFile(included, []byte("SHARED=1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write included file: %v", err)
+	}
+	content := "#include shared.env\nDB_HOST=db\nAPP=web\n"
+	if err := os.WriteFile(bundle, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	if err := CreateSignCommand(bundle, privateKey, "").Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := CreateVerifyCommand(bundle, publicKey, "").Execute(); err != nil {
+		t.Fatalf("Expected the signature to verify, got: %v", err)
+	}
+
+	// Appending a directive changes the merge result without changing any variable
+	if err := os.WriteFile(bundle, []byte(content+"#filter APP\n"), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	if err := CreateVerifyCommand(bundle, publicKey, "").Execute(); err == nil || !strings.Contains(err.Error(), "modified after signing") {
+		t.Errorf("Expected an added directive to fail verification, got: %v", err)
+	}
+
+	// So does changing a file the bundle includes
+	if err := os.WriteFile(bundle, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	if err := os.WriteFile(included, []byte("SHARED=2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write included file: %v", err)
+	}
+	if err := CreateVerifyCommand(bundle, publicKey, "").Execute(); err == nil || !strings.Contains(err.Error(), "modified after signing") {
+		t.Errorf("Expected a changed include to fail verification, got: %v", err)
+	}
+}
+
+func TestCanonicalBundle(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "bundle.env")
+	jsonPath := filepath.Join(dir, "bundle.json")
+	if err := os.WriteFile(envPath, []byte("PORT=8080\nHOST=localhost\n"), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	if err := os.WriteFile(jsonPath, []byte(`{"HOST": "localhost", "PORT": "8080"}`), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+
+	fromEnv, err := CanonicalBundle(envPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	fromJSON, err := CanonicalBundle(jsonPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := `{"HOST":"localhost","PORT":"8080"}`
+	if string(fromEnv) != expected || string(fromJSON) != expected {
+		t.Errorf("Expected %s, got %s and %s", expected, fromEnv, fromJSON)
+	}
+}
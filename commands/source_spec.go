@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// SourceTypes are the source types that can be merged
+var SourceTypes = []string{"env", "json", "yaml", "ini", "toml", "hcl", "properties", "sops", "k8s"}
+
+// SourceTypeForPath infers the type of an unencrypted source from its file extension: .json
+// files are JSON, .yaml/.yml files YAML, .ini files INI, .toml files TOML, .hcl/.tf/.tfvars
+// files HCL, .properties files Java properties, and anything else an env file. A k8s:// reference
+// is a Kubernetes source, and an http:// or https:// URL is typed by the extension of its path.
+func SourceTypeForPath(path string) string {
+	if strings.HasPrefix(path, KubernetesReferencePrefix) {
+		return "k8s"
+	}
+	if IsRemoteURL(path) {
+		// Type URLs by the extension of their path, ignoring any query or fragment
+		if u, err := url.Parse(path); err == nil {
+			path = u.Path
+		}
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".ini":
+		return "ini"
+	case ".toml":
+		return "toml"
+	case ".hcl", ".tf", ".tfvars":
+		return "hcl"
+	case ".properties":
+		return "properties"
+	default:
+		return "env"
+	}
+}
+
+// SourcesForFiles resolves the -f arguments of the subcommands into sources in the order given:
+// a file or glob pattern, typed by extension (see SourceTypeForPath), a directory of .env files,
+// or a --source spec such as "type=sops,path=secrets.yaml,key=age1..." for sources whose type
+// the extension cannot tell
+func SourcesForFiles(files []string) ([]Source, error) {
+	var sourceList []Source
+	for _, file := range files {
+		if !strings.HasPrefix(file, "type=") && !strings.Contains(file, ",type=") {
+			paths, err := ExpandSource(file, "env", DirectoryOptions{}, nil)
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range paths {
+				sourceList = append(sourceList, Source{FilePath: path, Type: SourceTypeForPath(path), Priority: len(sourceList)})
+			}
+			continue
+		}
+
+		spec, err := ParseSourceSpec(file)
+		if err != nil {
+			return nil, err
+		}
+		paths, err := ExpandSource(spec.Path, spec.Type, DirectoryOptions{}, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			source := Source{
+				FilePath:      path,
+				Type:          spec.Type,
+				Priority:      len(sourceList),
+				DecryptionKey: spec.DecryptionKey,
+				AWSProfile:    spec.AWSProfile,
+				AWSRole:       spec.AWSRole,
+			}
+			if spec.KeyService != "" {
+				source.KeyServices = []string{spec.KeyService}
+			}
+			sourceList = append(sourceList, source)
+		}
+	}
+	return sourceList, nil
+}
+
+// SourceSpec is a source given with --source as comma-separated fields, e.g.
+// "type=sops,path=secrets.yaml,priority=10,key=age1...,aws-role=arn:aws:iam::123:role/ci"
+type SourceSpec struct {
+	Type          string // One of SourceTypes
+	Path          string // File path or glob pattern
+	DecryptionKey string // Decryption key (sops sources only)
+	Priority      *int   // Explicit priority (nil uses the source's position on the command line)
+	// KMS and key service settings (sops sources only)
+	AWSProfile string
+	AWSRole    string
+	KeyService string
+}
+
+// sopsOnlyFields are the --source fields that only apply to sops sources
+var sopsOnlyFields = []string{"key", "aws-profile", "aws-role", "keyservice"}
+
+// ParseSourceSpec parses a --source value. The type and path fields are required, key is
+// required for sops sources, the key settings are not allowed for other sources, and
+// priority must be an integer.
+func ParseSourceSpec(value string) (SourceSpec, error) {
+	var spec SourceSpec
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(value, ",") {
+		name, fieldValue, ok := strings.Cut(field, "=")
+		name = strings.TrimSpace(name)
+		fieldValue = strings.TrimSpace(fieldValue)
+		if !ok || name == "" {
+			return SourceSpec{}, fmt.Errorf("invalid field '%s' in source '%s', expected name=value", field, value)
+		}
+		if seen[name] {
+			return SourceSpec{}, fmt.Errorf("duplicate field '%s' in source '%s'", name, value)
+		}
+		seen[name] = true
+
+		switch name {
+		case "type":
+			spec.Type = fieldValue
+		case "path":
+			spec.Path = fieldValue
+		case "key":
+			spec.DecryptionKey = fieldValue
+		case "priority":
+			priority, err := strconv.Atoi(fieldValue)
+			if err != nil {
+				return SourceSpec{}, fmt.Errorf("invalid priority '%s' in source '%s': must be an integer", fieldValue, value)
+			}
+			spec.Priority = &priority
+		case "aws-profile":
+			spec.AWSProfile = fieldValue
+		case "aws-role":
+			spec.AWSRole = fieldValue
+		case "keyservice":
+			if err := sources.ValidateKeyService(fieldValue); err != nil {
+				return SourceSpec{}, fmt.Errorf("source '%s': %w", value, err)
+			}
+			spec.KeyService = fieldValue
+		default:
+			return SourceSpec{}, fmt.Errorf("unknown field '%s' in source '%s', expected type, path, priority, key, aws-profile, aws-role, or keyservice", name, value)
+		}
+	}
+
+	switch {
+	case spec.Type == "":
+		return SourceSpec{}, fmt.Errorf("source '%s' is missing a type", value)
+	case !slices.Contains(SourceTypes, spec.Type):
+		return SourceSpec{}, fmt.Errorf("unsupported type '%s' in source '%s', expected one of %s", spec.Type, value, strings.Join(SourceTypes, ", "))
+	case spec.Path == "":
+		return SourceSpec{}, fmt.Errorf("source '%s' is missing a path", value)
+	case spec.Type == "sops" && spec.DecryptionKey == "":
+		return SourceSpec{}, fmt.Errorf("sops source '%s' is missing a key", value)
+	}
+	if spec.Type != "sops" {
+		for _, field := range sopsOnlyFields {
+			if seen[field] {
+				return SourceSpec{}, fmt.Errorf("source '%s' sets %s, which only applies to sops sources", value, field)
+			}
+		}
+	}
+	return spec, nil
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSourceSpec(t *testing.T) {
+	ten := 10
+	tests := []struct {
+		value    string
+		expected SourceSpec
+	}{
+		{"type=env,path=base.env", SourceSpec{Type: "env", Path: "base.env"}},
+		{"path=config/*.json, type=json", SourceSpec{Type: "json", Path: "config/*.json"}},
+		{"type=sops,path=secrets.yaml,priority=10,key=age1key123", SourceSpec{Type: "sops", Path: "secrets.yaml", DecryptionKey: "age1key123", Priority: &ten}},
+		{"type=sops,path=prod.yaml,key=k,aws-profile=prod,aws-role=arn:aws:iam::123:role/ci,keyservice=unix:///tmp/sops.sock", SourceSpec{Type: "sops", Path: "prod.yaml", DecryptionKey: "k", AWSProfile: "prod", AWSRole: "arn:aws:iam::123:role/ci", KeyService: "unix:///tmp/sops.sock"}},
+	}
+
+	for _, test := range tests {
+		spec, err := ParseSourceSpec(test.value)
+		if err != nil {
+			t.Errorf("ParseSourceSpec(%q): expected no error, got: %v", test.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(spec, test.expected) {
+			t.Errorf("ParseSourceSpec(%q): expected %+v, got %+v", test.value, test.expected, spec)
+		}
+	}
+
+	errorTests := []struct {
+		value   string
+		errText string
+	}{
+		{"path=base.env", "missing a type"},
+		{"type=env", "missing a path"},
+		{"type=xml,path=a.xml", "unsupported type 'xml'"},
+		{"type=env,path=a.env,priority=high", "invalid priority 'high'"},
+		{"type=env,path=a.env,mode=x", "unknown field 'mode'"},
+		{"type=env,path=a.env,path=b.env", "duplicate field 'path'"},
+		{"type=env,a.env", "expected name=value"},
+		{"type=sops,path=secrets.yaml", "missing a key"},
+		{"type=env,path=a.env,key=age1key123", "only applies to sops sources"},
+		{"type=json,path=a.json,aws-role=arn:aws:iam::123:role/ci", "sets aws-role, which only applies to sops sources"},
+		{"type=sops,path=a.yaml,key=k,keyservice=http://host", "invalid key service"},
+	}
+
+	for _, test := range errorTests {
+		_, err := ParseSourceSpec(test.value)
+		if err == nil || !strings.Contains(err.Error(), test.errText) {
+			t.Errorf("ParseSourceSpec(%q): expected error containing %q, got %v", test.value, test.errText, err)
+		}
+	}
+}
+
+func TestMergeCommand_SOPSKeys(t *testing.T) {
+	cmd := CreateMergeCommand(nil, Options{AWSProfile: "ci", AWSRole: "arn:aws:iam::1:role/default", KeyServices: []string{"tcp://localhost:5000"}})
+
+	keys := cmd.sopsKeys(Source{Type: "sops", AWSRole: "arn:aws:iam::2:role/prod"})
+	if keys.AWSProfile != "ci" || keys.AWSRole != "arn:aws:iam::2:role/prod" || !reflect.DeepEqual(keys.KeyServices, []string{"tcp://localhost:5000"}) {
+		t.Errorf("Expected the source's role over the command's defaults, got %+v", keys)
+	}
+
+	keys = cmd.sopsKeys(Source{Type: "sops", KeyServices: []string{"unix:///run/sops.sock"}})
+	if !reflect.DeepEqual(keys.KeyServices, []string{"unix:///run/sops.sock"}) {
+		t.Errorf("Expected the source's key services, got %v", keys.KeyServices)
+	}
+
+	_, err := CreateMergeCommand([]Source{{FilePath: "a.env", Type: "env"}}, Options{KeyServices: []string{"localhost:5000"}}).Merge()
+	if err == nil || !strings.Contains(err.Error(), "invalid key service") {
+		t.Errorf("Expected an invalid key service error, got: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/notwillk/envvars-cli/logging"
+)
+
+// captureStdout returns everything written to stdout while fn runs
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	tempFile, err := os.CreateTemp("", "stdout-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	originalStdout := os.Stdout
+	os.Stdout = tempFile
+	defer func() { os.Stdout = originalStdout }()
+	fn()
+
+	data, err := os.ReadFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to read captured stdout: %v", err)
+	}
+	return string(data)
+}
+
+func TestStdoutCarriesOnlyAssignments(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	// Directives, duplicates, invalid keys, and empty required values all produce diagnostics
+	_, err = tempFile.WriteString("#remove OLD\n#require EMPTY\nA=1\nA=2\nEMPTY=\n1BAD=x\nMULTI=\"line one\nline two\"\nnot an assignment\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	logging.SetLevel(logging.LevelDebug)
+	defer logging.SetLevel(logging.LevelWarn)
+
+	sources := []Source{{FilePath: tempFile.Name(), Type: "env", Priority: 0}}
+	assignment := regexp.MustCompile(`^(export )?[A-Za-z_][A-Za-z0-9_]*=`)
+	for _, export := range []bool{false, true} {
+		output := captureStdout(t, func() {
+			if err := CreateMergeCommand(sources, Options{Format: "env", Export: export}).Execute(); err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+		})
+
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		if len(lines) != 3 {
+			t.Errorf("Expected 3 assignments on stdout, got %q", output)
+		}
+		for _, line := range lines {
+			if !assignment.MatchString(line) {
+				t.Errorf("Expected only assignments on stdout, got line %q", line)
+			}
+		}
+	}
+
+	if output := captureStdout(t, func() { ShowHelp(); ShowVersion() }); output != "" {
+		t.Errorf("Expected help and version to write nothing to stdout, got %q", output)
+	}
+}
@@ -0,0 +1,275 @@
+package commands
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	formatters "github.com/notwillk/envvars-cli/formatters"
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// streamRunSize is the number of assignments buffered in memory before a sorted run is spilled to disk
+var streamRunSize = 50000
+
+// streamRecord is a single assignment in a sorted run; seq orders definitions across all sources
+type streamRecord struct {
+	key   string
+	value string
+	seq   uint64
+}
+
+// shouldStream reports whether the merge should use bounded-memory streaming: it is enabled
+// by a threshold, only applies to env sources rendered as env sorted by key with last-wins
+// duplicate handling within and across files, and kicks in when any source is at least the threshold in size
+func (cmd *MergeCommand) shouldStream() bool {
+	if cmd.options.StreamThreshold <= 0 || cmd.options.Format != "env" {
+		return false
+	}
+	if cmd.options.Sort != "" && cmd.options.Sort != "key" {
+		return false
+	}
+	if duplicates := cmd.duplicates(); duplicates != "" && duplicates != sources.DuplicatesLast {
+		return false
+	}
+	if cmd.options.Prefix != "" || cmd.options.StripPrefix != "" || len(cmd.options.Only) > 0 || len(cmd.options.Except) > 0 || len(cmd.options.Require) > 0 || cmd.options.Interactive || cmd.options.Redact != "" || cmd.options.Policy != nil || cmd.options.Direnv {
+		return false
+	}
+	// Source timeouts are enforced per parsed source, which streaming does not do
+	if cmd.options.FailIfEmpty || cmd.options.MinKeys > 0 || cmd.options.SourceTimeout > 0 {
+		return false
+	}
+
+	large := false
+	for _, source := range cmd.sources {
+		if source.Type != "env" || source.FilePath == sources.StdinPath {
+			return false
+		}
+		if info, err := os.Stat(source.FilePath); err == nil && info.Size() >= cmd.options.StreamThreshold {
+			large = true
+		}
+	}
+	return large
+}
+
+// executeStreaming merges env sources with bounded memory using an external sort: assignments
+// are spilled to sorted on-disk runs, which are then merged keeping the last definition of each key.
+// Output is sorted by key, matching the env formatter.
+func (cmd *MergeCommand) executeStreaming() error {
+	if err := cmd.checkPriorities(); err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "envvars-stream-*")
+	if err != nil {
+		return fmt.Errorf("failed to create streaming index directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var runs []string
+	batch := make([]streamRecord, 0, streamRunSize)
+	var seq uint64
+	var secret bool
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		sort.Slice(batch, func(i, j int) bool {
+			if batch[i].key != batch[j].key {
+				return batch[i].key < batch[j].key
+			}
+			return batch[i].seq < batch[j].seq
+		})
+		path := filepath.Join(dir, fmt.Sprintf("run-%d", len(runs)))
+		if err := writeRun(path, batch); err != nil {
+			return err
+		}
+		runs = append(runs, path)
+		batch = batch[:0]
+		return nil
+	}
+
+	for _, source := range cmd.sources {
+		logging.Infof("Streaming %s file: %s (priority: %d)", source.Type, source.FilePath, source.Priority)
+		if err := checkSourceSize(source, cmd.options.MaxSourceSize); err != nil {
+			return err
+		}
+		if IsRemoteURL(source.FilePath) {
+			if err := cmd.fetchURL(source.FilePath); err != nil {
+				return err
+			}
+		}
+
+		holdsSecrets := false
+		err := sources.StreamFile(cmd.sourceOptions(source.FilePath), func(key string, value string) error {
+			holdsSecrets = holdsSecrets || cmd.isSecret(key, value)
+			batch = append(batch, streamRecord{key: key, value: value, seq: seq})
+			seq++
+			if len(batch) == streamRunSize {
+				return flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Nothing is written until every source is read, so a failed check leaves the output alone
+		secret = secret || holdsSecrets
+		if isLocalPlaintext(source) && holdsSecrets {
+			if err := cmd.checkIgnored(source); err != nil {
+				return err
+			}
+			if err := cmd.checkPermissions(source); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	logging.Infof("Merging %d assignments from %d sorted runs", seq, len(runs))
+
+	output, finishOutput, err := cmd.openOutput(secret)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(output)
+	err = mergeRuns(runs, func(record streamRecord) error {
+		return formatters.WriteENVRecord(writer, record.key, record.value, cmd.formatterOptions())
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	return finishOutput(err)
+}
+
+// writeRun writes sorted records to path using length-prefixed encoding
+func writeRun(path string, records []streamRecord) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create streaming run: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	buf := make([]byte, binary.MaxVarintLen64)
+	for _, record := range records {
+		for _, field := range []string{record.key, record.value} {
+			n := binary.PutUvarint(buf, uint64(len(field)))
+			writer.Write(buf[:n])
+			writer.WriteString(field)
+		}
+		n := binary.PutUvarint(buf, record.seq)
+		writer.Write(buf[:n])
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write streaming run: %w", err)
+	}
+	return nil
+}
+
+// runReader reads records back from a run file
+type runReader struct {
+	file    *os.File
+	reader  *bufio.Reader
+	current streamRecord
+}
+
+// next advances to the next record, returning io.EOF at the end of the run
+func (r *runReader) next() error {
+	var fields [2]string
+	for i := range fields {
+		length, err := binary.ReadUvarint(r.reader)
+		if err != nil {
+			return err
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r.reader, data); err != nil {
+			return err
+		}
+		fields[i] = string(data)
+	}
+	seq, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return err
+	}
+
+	r.current = streamRecord{key: fields[0], value: fields[1], seq: seq}
+	return nil
+}
+
+// runHeap orders run readers by their current record's key, then definition order
+type runHeap []*runReader
+
+func (h runHeap) Len() int { return len(h) }
+func (h runHeap) Less(i, j int) bool {
+	if h[i].current.key != h[j].current.key {
+		return h[i].current.key < h[j].current.key
+	}
+	return h[i].current.seq < h[j].current.seq
+}
+func (h runHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x any)   { *h = append(*h, x.(*runReader)) }
+func (h *runHeap) Pop() any {
+	old := *h
+	reader := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return reader
+}
+
+// mergeRuns performs a k-way merge of sorted runs, calling emit once per key with its last definition
+func mergeRuns(paths []string, emit func(streamRecord) error) error {
+	h := &runHeap{}
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open streaming run: %w", err)
+		}
+		defer file.Close()
+
+		reader := &runReader{file: file, reader: bufio.NewReader(file)}
+		if err := reader.next(); err == nil {
+			*h = append(*h, reader)
+		} else if err != io.EOF {
+			return fmt.Errorf("failed to read streaming run: %w", err)
+		}
+	}
+	heap.Init(h)
+
+	var pending *streamRecord
+	for h.Len() > 0 {
+		reader := (*h)[0]
+		record := reader.current
+
+		// A new key means the pending record was the last definition of the previous key
+		if pending != nil && pending.key != record.key {
+			if err := emit(*pending); err != nil {
+				return err
+			}
+		}
+		pending = &record
+
+		if err := reader.next(); err == nil {
+			heap.Fix(h, 0)
+		} else if err == io.EOF {
+			heap.Pop(h)
+		} else {
+			return fmt.Errorf("failed to read streaming run: %w", err)
+		}
+	}
+
+	if pending != nil {
+		return emit(*pending)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeRuns_KeepsLastDefinitionInKeyOrder(t *testing.T) {
+	dir := t.TempDir()
+
+	first := filepath.Join(dir, "run-0")
+	if err := writeRun(first, []streamRecord{
+		{key: "A", value: "a1", seq: 0},
+		{key: "B", value: "b1", seq: 1},
+		{key: "B", value: "b2", seq: 2},
+	}); err != nil {
+		t.Fatalf("Failed to write run: %v", err)
+	}
+	second := filepath.Join(dir, "run-1")
+	if err := writeRun(second, []streamRecord{
+		{key: "A", value: "a2", seq: 3},
+		{key: "C", value: "c1", seq: 4},
+	}); err != nil {
+		t.Fatalf("Failed to write run: %v", err)
+	}
+
+	var result []streamRecord
+	err := mergeRuns([]string{first, second}, func(record streamRecord) error {
+		result = append(result, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []streamRecord{
+		{key: "A", value: "a2", seq: 3},
+		{key: "B", value: "b2", seq: 2},
+		{key: "C", value: "c1", seq: 4},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestMergeCommand_ShouldStream(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	tempFile.WriteString("KEY=value\n")
+	tempFile.Close()
+
+	sources := []Source{{FilePath: tempFile.Name(), Type: "env", Priority: 0}}
+
+	if CreateMergeCommand(sources, Options{Format: "env"}).shouldStream() {
+		t.Error("Expected streaming to be disabled without a threshold")
+	}
+	if !CreateMergeCommand(sources, Options{Format: "env", StreamThreshold: 5}).shouldStream() {
+		t.Error("Expected streaming for a file above the threshold")
+	}
+	if CreateMergeCommand(sources, Options{Format: "json", StreamThreshold: 5}).shouldStream() {
+		t.Error("Expected streaming to be limited to env output")
+	}
+	if CreateMergeCommand(sources, Options{Format: "env", StreamThreshold: 1 << 20}).shouldStream() {
+		t.Error("Expected no streaming for a file below the threshold")
+	}
+}
+
+func TestMergeCommand_Execute_StreamingAcrossRuns(t *testing.T) {
+	originalRunSize := streamRunSize
+	streamRunSize = 2
+	defer func() { streamRunSize = originalRunSize }()
+
+	tempFile, err := os.CreateTemp("", "test-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	tempFile.WriteString("B=1\nA=1\nB=2\nC=1\nA=2\n")
+	tempFile.Close()
+
+	sources := []Source{{FilePath: tempFile.Name(), Type: "env", Priority: 0}}
+	cmd := CreateMergeCommand(sources, Options{Format: "env", StreamThreshold: 1})
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestMergeCommand_Execute_StreamingURLSource(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("REMOTE=1\nSHARED=remote\n"))
+	}))
+	useTestServer(t, server)
+
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "local.env")
+	if err := os.WriteFile(localPath, []byte("SHARED=local\nLOCAL=1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", localPath, err)
+	}
+	outputPath := filepath.Join(dir, "out.env")
+
+	sources := []Source{
+		{FilePath: localPath, Type: "env", Priority: 0},
+		{FilePath: server.URL + "/app.env", Type: "env", Priority: 1},
+	}
+	cmd := CreateMergeCommand(sources, Options{Format: "env", StreamThreshold: 1, Output: outputPath})
+	if !cmd.shouldStream() {
+		t.Fatal("Expected the merge to stream")
+	}
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	expected := "LOCAL=1\nREMOTE=1\nSHARED=remote\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(data))
+	}
+}
+
+func TestMergeCommand_Execute_StreamingMatchesMergeOrFails(t *testing.T) {
+	dir := t.TempDir()
+	merge := func(content string, threshold int64) (string, error) {
+		path := filepath.Join(dir, "in.env")
+		outputPath := filepath.Join(dir, "out.env")
+		os.Remove(outputPath)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+		sources := []Source{{FilePath: path, Type: "env", Priority: 0}}
+		if err := CreateMergeCommand(sources, Options{Format: "env", StreamThreshold: threshold, Output: outputPath}).Execute(); err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		return string(data), nil
+	}
+
+	// References would be written unresolved, so streaming refuses them
+	withReferences := "A=1\nB=${A}\nC=${MISSING:-fallback}\n"
+	expected := "A=1\nB=1\nC=fallback\n"
+	if output, err := merge(withReferences, 0); err != nil || output != expected {
+		t.Errorf("Expected %q, got %q (error: %v)", expected, output, err)
+	}
+	if output, err := merge(withReferences, 1); err == nil || !strings.Contains(err.Error(), "reference to 'A' at line 2") {
+		t.Errorf("Expected a reference error when streaming, got %q (error: %v)", output, err)
+	}
+
+	// Literal ${...} text streams the same as it merges
+	literal := "A=1\nB='${A}'\nC=\"\\${A}\"\n"
+	normal, err := merge(literal, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	streamed, err := merge(literal, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error when streaming: %v", err)
+	}
+	if streamed != normal {
+		t.Errorf("Expected streamed output %q to match %q", streamed, normal)
+	}
+}
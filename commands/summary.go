@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/notwillk/envvars-cli/logging"
+)
+
+// RunSummary is the machine-readable report of a run written by --summary-json
+type RunSummary struct {
+	Status     string          `json:"status"` // "ok" or "error"
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+	Sources    []SummarySource `json:"sources"`
+	KeyCount   int             `json:"key_count"`
+	Keys       []string        `json:"keys"`
+	Overrides  int             `json:"overrides"` // Keys redefined by a later source, summed over sources
+	Warnings   []string        `json:"warnings"`
+}
+
+// SummarySource is what a single source contributed to the run
+type SummarySource struct {
+	Path       string   `json:"path"`
+	Type       string   `json:"type"`
+	Priority   int      `json:"priority"`
+	Added      []string `json:"added"`
+	Overridden []string `json:"overridden"`
+	Removed    []string `json:"removed"`
+}
+
+// executeWithSummary runs the command and writes a summary of the run to the --summary-json
+// path, whether or not the run succeeds
+func (cmd *MergeCommand) executeWithSummary() error {
+	start := time.Now()
+
+	var mu sync.Mutex
+	warnings := []string{}
+	defer logging.SetWarningHook(logging.SetWarningHook(func(message string) {
+		mu.Lock()
+		defer mu.Unlock()
+		warnings = append(warnings, message)
+	}))
+
+	plan := &Plan{}
+	err := cmd.execute(plan)
+
+	mu.Lock()
+	summary := newRunSummary(plan, warnings, time.Since(start), err)
+	mu.Unlock()
+	if summaryErr := writeRunSummary(cmd.options.SummaryJSON, summary); summaryErr != nil && err == nil {
+		err = summaryErr
+	}
+	return err
+}
+
+// newRunSummary builds the summary of a run from its merge plan
+func newRunSummary(plan *Plan, warnings []string, duration time.Duration, err error) RunSummary {
+	summary := RunSummary{
+		Status:     "ok",
+		DurationMs: duration.Milliseconds(),
+		Sources:    []SummarySource{},
+		KeyCount:   len(plan.Keys),
+		Keys:       plan.Keys,
+		Warnings:   warnings,
+	}
+	if summary.Keys == nil {
+		summary.Keys = []string{}
+	}
+	if err != nil {
+		summary.Status = "error"
+		summary.Error = err.Error()
+	}
+
+	for _, entry := range plan.Sources {
+		summary.Sources = append(summary.Sources, SummarySource{
+			Path:       entry.Source.FilePath,
+			Type:       entry.Source.Type,
+			Priority:   entry.Source.Priority,
+			Added:      nonNil(entry.Added),
+			Overridden: nonNil(entry.Overridden),
+			Removed:    nonNil(entry.Removed),
+		})
+		summary.Overrides += len(entry.Overridden)
+	}
+	return summary
+}
+
+// nonNil returns keys, or an empty list when it is nil, so the JSON has [] rather than null
+func nonNil(keys []string) []string {
+	if keys == nil {
+		return []string{}
+	}
+	return keys
+}
+
+// writeRunSummary writes the summary as indented JSON to path
+func writeRunSummary(path string, summary RunSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run summary: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write run summary '%s': %w", path, err)
+	}
+	return nil
+}
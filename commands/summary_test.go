@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeCommand_Execute_SummaryJSON(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.env")
+	if err := os.WriteFile(basePath, []byte("HOST=localhost\nPORT=8080\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	overridePath := filepath.Join(dir, "override.env")
+	if err := os.WriteFile(overridePath, []byte("HOST=example.com\nnot an assignment\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	sources := []Source{
+		{FilePath: basePath, Type: "env", Priority: 0},
+		{FilePath: overridePath, Type: "env", Priority: 1},
+	}
+	summaryPath := filepath.Join(dir, "summary.json")
+	options := Options{Format: "env", Output: filepath.Join(dir, "merged.env"), SummaryJSON: summaryPath}
+	if err := CreateMergeCommand(sources, options).Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	summary := readRunSummary(t, summaryPath)
+	if summary.Status != "ok" || summary.KeyCount != 2 || summary.Overrides != 1 {
+		t.Errorf("Expected status ok with 2 keys and 1 override, got %+v", summary)
+	}
+	if expected := []string{"HOST", "PORT"}; !reflect.DeepEqual(summary.Keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, summary.Keys)
+	}
+	if len(summary.Sources) != 2 || !reflect.DeepEqual(summary.Sources[1].Overridden, []string{"HOST"}) {
+		t.Errorf("Expected the second source to override HOST, got %+v", summary.Sources)
+	}
+	if len(summary.Warnings) != 1 || !strings.Contains(summary.Warnings[0], "line 2") {
+		t.Errorf("Expected a warning for the malformed line, got %v", summary.Warnings)
+	}
+
+	// A failed run still writes its summary
+	options.MinKeys = 5
+	if err := CreateMergeCommand(sources, options).Execute(); err == nil {
+		t.Fatal("Expected an error for too few keys")
+	}
+	summary = readRunSummary(t, summaryPath)
+	if summary.Status != "error" || !strings.Contains(summary.Error, "fewer than the minimum of 5") {
+		t.Errorf("Expected an error summary, got %+v", summary)
+	}
+}
+
+// readRunSummary decodes the run summary at path
+func readRunSummary(t *testing.T, path string) RunSummary {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read summary: %v", err)
+	}
+	var summary RunSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("Failed to decode summary: %v", err)
+	}
+	return summary
+}
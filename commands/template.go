@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// Template modes
+const (
+	TemplateModeGo    = "go"    // Go text/template, with the variables as {{.KEY}}
+	TemplateModeSubst = "subst" // envsubst-style $VAR and ${VAR} substitution
+)
+
+// substReferencePattern matches the ${...} and $VAR references of the subst mode
+var substReferencePattern = regexp.MustCompile(`\$\{([^}]+)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// TemplateCommand renders a file with the merged variables
+type TemplateCommand struct {
+	files        []string
+	templatePath string
+	output       string
+	mode         string
+	allowMissing bool
+	stdin        io.Reader
+}
+
+// CreateTemplateCommand creates a template command rendering the file at templatePath ("-" for
+// stdin) in mode with the variables merged from the files (typed by extension), to output or stdout
+func CreateTemplateCommand(files []string, templatePath string, output string, mode string, allowMissing bool) *TemplateCommand {
+	return &TemplateCommand{
+		files:        files,
+		templatePath: templatePath,
+		output:       output,
+		mode:         mode,
+		allowMissing: allowMissing,
+		stdin:        os.Stdin,
+	}
+}
+
+// Execute merges the files and renders the template. Variables the template references but the
+// merge does not set are an error, unless allowMissing renders them empty. Nothing is written
+// unless the whole template renders.
+func (cmd *TemplateCommand) Execute() error {
+	if len(cmd.files) == 0 {
+		return fmt.Errorf("template requires at least one file (-f)")
+	}
+	text, err := cmd.readTemplate()
+	if err != nil {
+		return err
+	}
+
+	sourceList, err := SourcesForFiles(cmd.files)
+	if err != nil {
+		return err
+	}
+	merge := CreateMergeCommand(sourceList, Options{Format: "env", Output: cmd.output})
+	variables, err := merge.Merge()
+	if err != nil {
+		return err
+	}
+	values := variables.Map()
+
+	var rendered string
+	switch cmd.mode {
+	case "", TemplateModeGo:
+		rendered, err = cmd.renderGo(text, values)
+	case TemplateModeSubst:
+		rendered, err = cmd.renderSubst(text, values)
+	default:
+		return fmt.Errorf("unsupported template mode: %s", cmd.mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The output is as sensitive as the secrets rendered into it
+	secret := false
+	for key, value := range values {
+		secret = secret || (merge.isSecret(key, value) && strings.Contains(rendered, value))
+	}
+	writer, finishOutput, err := merge.openOutput(secret)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(writer, rendered)
+	return finishOutput(err)
+}
+
+// readTemplate reads the template file, or stdin for "-"
+func (cmd *TemplateCommand) readTemplate() (string, error) {
+	if cmd.templatePath == "" {
+		return "", fmt.Errorf("template requires a template file")
+	}
+	if cmd.templatePath == sources.StdinPath {
+		data, err := io.ReadAll(cmd.stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read template from stdin: %w", err)
+		}
+		return string(data), nil
+	}
+	data, err := os.ReadFile(cmd.templatePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template '%s': %w", cmd.templatePath, err)
+	}
+	return string(data), nil
+}
+
+// renderGo executes text as a Go text/template with the variables as its data
+func (cmd *TemplateCommand) renderGo(text string, values map[string]string) (string, error) {
+	missingKey := "missingkey=error"
+	if cmd.allowMissing {
+		missingKey = "missingkey=zero"
+	}
+	tmpl, err := template.New(filepath.Base(cmd.templatePath)).Option(missingKey).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template '%s': %w", cmd.templatePath, err)
+	}
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, values); err != nil {
+		return "", fmt.Errorf("failed to render template '%s': %w", cmd.templatePath, err)
+	}
+	return buffer.String(), nil
+}
+
+// renderSubst replaces the $VAR and ${VAR} references in text, which support the :- and :?
+// operators of env files, and reports every unset variable at once
+func (cmd *TemplateCommand) renderSubst(text string, values map[string]string) (string, error) {
+	var missing []string
+	var renderErr error
+	rendered := substReferencePattern.ReplaceAllStringFunc(text, func(match string) string {
+		expression := strings.TrimPrefix(match, "$")
+		if strings.HasPrefix(expression, "{") {
+			expression = expression[1 : len(expression)-1]
+		}
+		value, exists, err := sources.ResolveReference(expression, values)
+		if err != nil && renderErr == nil {
+			renderErr = err
+		}
+		if !exists && err == nil && !slices.Contains(missing, expression) {
+			missing = append(missing, expression)
+		}
+		return value
+	})
+	if renderErr != nil {
+		return "", fmt.Errorf("failed to render template '%s': %w", cmd.templatePath, renderErr)
+	}
+	if len(missing) > 0 && !cmd.allowMissing {
+		return "", fmt.Errorf("template '%s' references unset variables: %s (use --allow-missing to render them empty)", cmd.templatePath, strings.Join(missing, ", "))
+	}
+	return rendered, nil
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateCommand_Execute(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "prod.env")
+	if err := os.WriteFile(envPath, []byte("HOST=example.com\nPORT=8080\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		mode         string
+		template     string
+		allowMissing bool
+		expected     string
+		err          string
+	}{
+		{"go", TemplateModeGo, "server {{.HOST}}:{{.PORT}};\n", false, "server example.com:8080;\n", ""},
+		{"go missing", TemplateModeGo, "{{.MISSING}}", false, "", "MISSING"},
+		{"go allow missing", TemplateModeGo, "[{{.MISSING}}]", true, "[]", ""},
+		{"subst", TemplateModeSubst, "http://$HOST:${PORT}/${PATH_PREFIX:-api}", false, "http://example.com:8080/api", ""},
+		{"subst missing", TemplateModeSubst, "$USER ${HOME} $USER", false, "", "unset variables: USER, HOME"},
+		{"subst allow missing", TemplateModeSubst, "[$USER]", true, "[]", ""},
+		{"subst required", TemplateModeSubst, "${TOKEN:?set a token}", true, "", "set a token"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			templatePath := filepath.Join(dir, "app.tmpl")
+			if err := os.WriteFile(templatePath, []byte(test.template), 0644); err != nil {
+				t.Fatalf("Failed to write template: %v", err)
+			}
+			outputPath := filepath.Join(dir, test.name+".out")
+			err := CreateTemplateCommand([]string{envPath}, templatePath, outputPath, test.mode, test.allowMissing).Execute()
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("Expected an error containing %q, got %v", test.err, err)
+				}
+				if _, statErr := os.Stat(outputPath); !os.IsNotExist(statErr) {
+					t.Errorf("Expected no output file after a failed render")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if data, _ := os.ReadFile(outputPath); string(data) != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, data)
+			}
+		})
+	}
+}
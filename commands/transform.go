@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// stripPrefix returns envFile with the configured --strip-prefix removed from its keys. Keys
+// without the prefix are kept as they are, or dropped with --drop-unprefixed. A key that is
+// exactly the prefix is left unchanged. The cached envFile itself is not modified.
+func (cmd *MergeCommand) stripPrefix(envFile sources.EnvFile) sources.EnvFile {
+	prefix := cmd.options.StripPrefix
+	if prefix == "" {
+		return envFile
+	}
+
+	variables := make([]sources.EnvVar, 0, len(envFile.Variables))
+	for _, variable := range envFile.Variables {
+		if stripped, found := strings.CutPrefix(variable.Key, prefix); found && stripped != "" {
+			variable.Key = stripped
+		} else if cmd.options.DropUnprefixed {
+			continue
+		}
+		variables = append(variables, variable)
+	}
+	envFile.Variables = variables
+	return envFile
+}
+
+// addPrefix returns variables with the configured --prefix added to every key, in the same order
+func (cmd *MergeCommand) addPrefix(variables *sources.Variables) *sources.Variables {
+	if cmd.options.Prefix == "" {
+		return variables
+	}
+
+	prefixed := sources.NewVariables()
+	for _, key := range variables.Keys() {
+		value, _ := variables.Get(key)
+		prefixed.Set(cmd.options.Prefix+key, value)
+	}
+	return prefixed
+}
+
+// selectKeys returns variables limited to the keys matching --only (when given) and not
+// matching --except, in the same order
+func (cmd *MergeCommand) selectKeys(variables *sources.Variables) *sources.Variables {
+	if len(cmd.options.Only) == 0 && len(cmd.options.Except) == 0 {
+		return variables
+	}
+
+	for _, key := range variables.Keys() {
+		if len(cmd.options.Only) > 0 && !sources.MatchesAnyPattern(key, cmd.options.Only) {
+			variables.Delete(key)
+		} else if sources.MatchesAnyPattern(key, cmd.options.Except) {
+			variables.Delete(key)
+		}
+	}
+	return variables
+}
@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMergeCommand_Merge_Prefixes(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("SHARED_PORT=8080\nSHARED_HOST=localhost\nOTHER=value\nSHARED_=edge\n#require PORT\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	sources := []Source{{FilePath: tempFile.Name(), Type: "env", Priority: 0}}
+	tests := []struct {
+		options  Options
+		expected map[string]string
+		keys     []string
+	}{
+		{
+			Options{StripPrefix: "SHARED_"},
+			map[string]string{"PORT": "8080", "HOST": "localhost", "OTHER": "value", "SHARED_": "edge"},
+			[]string{"PORT", "HOST", "OTHER", "SHARED_"},
+		},
+		{
+			Options{StripPrefix: "SHARED_", DropUnprefixed: true},
+			map[string]string{"PORT": "8080", "HOST": "localhost"},
+			[]string{"PORT", "HOST"},
+		},
+		{
+			Options{StripPrefix: "SHARED_", DropUnprefixed: true, Prefix: "API_"},
+			map[string]string{"API_PORT": "8080", "API_HOST": "localhost"},
+			[]string{"API_PORT", "API_HOST"},
+		},
+	}
+
+	for _, test := range tests {
+		variables, err := CreateMergeCommand(sources, test.options).Merge()
+		if err != nil {
+			t.Fatalf("Options %+v: expected no error, got: %v", test.options, err)
+		}
+		if !reflect.DeepEqual(variables.Map(), test.expected) {
+			t.Errorf("Options %+v: expected %v, got %v", test.options, test.expected, variables.Map())
+		}
+		if !reflect.DeepEqual(variables.Keys(), test.keys) {
+			t.Errorf("Options %+v: expected key order %v, got %v", test.options, test.keys, variables.Keys())
+		}
+	}
+}
+
+func TestMergeCommand_Merge_OnlyExcept(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("DB_HOST=db\nDB_SECRET=hunter2\nREDIS_URL=redis\nAPI_KEY=key\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	sources := []Source{{FilePath: tempFile.Name(), Type: "env", Priority: 0}}
+	tests := []struct {
+		options  Options
+		expected map[string]string
+	}{
+		{Options{Only: []string{"DB_*", "redis_*"}}, map[string]string{"DB_HOST": "db", "DB_SECRET": "hunter2", "REDIS_URL": "redis"}},
+		{Options{Except: []string{"*_SECRET"}}, map[string]string{"DB_HOST": "db", "REDIS_URL": "redis", "API_KEY": "key"}},
+		{Options{Only: []string{"DB_*", "REDIS_*"}, Except: []string{"*_SECRET"}}, map[string]string{"DB_HOST": "db", "REDIS_URL": "redis"}},
+		{Options{Only: []string{"MISSING_*"}}, map[string]string{}},
+	}
+
+	for _, test := range tests {
+		variables, err := CreateMergeCommand(sources, test.options).Merge()
+		if err != nil {
+			t.Fatalf("Options %+v: expected no error, got: %v", test.options, err)
+		}
+		if !reflect.DeepEqual(variables.Map(), test.expected) {
+			t.Errorf("Options %+v: expected %v, got %v", test.options, test.expected, variables.Map())
+		}
+	}
+}
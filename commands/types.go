@@ -1,16 +1,105 @@
 package commands
 
+import "time"
+
 // Source represents a single source file with its metadata
 type Source struct {
 	FilePath string
-	Type     string // "env", "json", "yaml", "sops"
-	Priority int    // Higher priority sources override lower ones
+	Type     string // "env", "json", "yaml", "ini", "toml", "hcl", "properties", "sops", "k8s"
+	Priority int    // Higher priority sources override lower ones (equal priorities keep their given order)
 	// For SOPS sources, additional metadata
 	DecryptionKey string // The key to use for decryption (only for SOPS type)
+	// Key settings for the file's KMS keys and key services, overriding the command-wide options
+	AWSProfile  string
+	AWSRole     string
+	KeyServices []string
 }
 
 // Options represents global options for the merge command
 type Options struct {
-	Verbose bool
-	Format  string // "json", "yaml", "env"
+	Format      string // "json", "yaml", "env"
+	Output      string // File to write the output to (empty writes to stdout)
+	DryRun      bool   // Print the merge plan instead of the merged output
+	SummaryJSON string // File to write a JSON summary of the run to (empty disables it)
+	Interactive bool   // Prompt to choose which value wins when sources set a key to different values
+	Strict      bool   // Turn ambiguities that are otherwise allowed, such as equal source priorities, into errors (implies StrictParse)
+	MaxLineSize int    // Maximum line length in bytes for env files (0 uses the default)
+	// Directory for caching remote JSON schemas on disk (empty disables the cache)
+	SchemaCacheDir string
+	// Directory for caching decrypted remote sources between runs (empty disables the cache),
+	// encrypted with the age identity in the CacheIdentity file
+	CacheDir      string
+	CacheIdentity string
+	// Key settings for decrypting SOPS sources that do not set their own: the AWS profile and role
+	// used for KMS keys, and key service addresses (unix:///path or tcp://host:port)
+	AWSProfile  string
+	AWSRole     string
+	KeyServices []string
+	// Limits applied per backend when fetching remote sources concurrently
+	FetchConcurrency int     // Maximum simultaneous fetches per backend (0 uses the default)
+	FetchRate        float64 // Maximum fetches started per second per backend (0 is unlimited)
+	// Limits applied to reading every source, whatever its type
+	SourceTimeout time.Duration // Maximum time to read one source (0 is unlimited)
+	MaxSourceSize int64         // Maximum size in bytes of a source file (0 is unlimited)
+	// Settings for fetching http:// and https:// sources
+	HTTPHeaders []string      // Extra request headers, as "Name: value"
+	HTTPToken   string        // Bearer token sent over https (empty uses $ENVVARS_HTTP_TOKEN)
+	HTTPTimeout time.Duration // Maximum time for one request (0 uses the default)
+	HTTPRetries int           // Retries after a connection failure or a 429 or 5xx response (0 uses the default, negative disables them)
+	// Merge and write the output again whenever a file read changes, checking every WatchInterval
+	// (0 uses the default)
+	Watch         bool
+	WatchInterval time.Duration
+	// Size in bytes at which env-to-env merges switch to bounded-memory streaming (0 disables it)
+	StreamThreshold int64
+	Export          bool   // Prefix env output lines with "export "
+	Print0          bool   // Write env output as raw KEY=value records terminated by NUL
+	Direnv          bool   // Write env output as shell code for direnv: watch_file declarations and exports
+	Duplicates      string // Handling of keys assigned twice in one env file: "warn", "error", "first", or "last"
+	// Handling of keys set more than once, within a file or across sources: "warn", "error",
+	// "first-wins", or "last-wins" (empty lets later sources override earlier ones and uses Duplicates)
+	OnDuplicate     string
+	Sort            string // Output order: "key" (default), or "source"/"none" for definition order
+	StrictParse     bool   // Fail on env file lines that are neither comments nor assignments
+	NoEscape        bool   // Keep the escape sequences of double-quoted env values as written
+	InvalidKeys     string // Handling of keys that are not valid variable names: "warn", "error", "keep", "relaxed", or "sanitize"
+	StrictEmpty     bool   // Fail on source files with no content instead of treating them as empty
+	RequireNonEmpty bool   // Treat #require'd keys set to an empty string as missing
+	// Key namespacing: StripPrefix is removed from incoming keys and Prefix is added to every output key
+	Prefix         string
+	StripPrefix    string
+	DropUnprefixed bool // With StripPrefix, drop incoming keys that do not have the prefix
+	// Output key selection with "*" wildcard patterns: keep keys matching Only (when given), then drop those matching Except
+	Only   []string
+	Except []string
+	// Keys that must be present in the merged output (checked after key selection)
+	Require     []string
+	FailIfEmpty bool // Fail when the merged output has no variables
+	MinKeys     int  // Fail when the merged output has fewer variables than this (0 disables the check)
+	// Keys matching these patterns hold secrets, in addition to sources.DefaultSecretPatterns and
+	// keys marked #secret; their values are masked in logs, prompts, and errors unless ShowSecrets is set
+	SecretPatterns []string
+	ShowSecrets    bool
+	// Guardrails from the policy file (nil when there is none)
+	Policy *Policy
+	// Append a record of the keys read from secret backends to this file, or AuditSyslog
+	AuditLog string
+	// Replace sensitive output values: RedactMask or RedactHMAC (keyed by RedactKey); empty disables
+	Redact    string
+	RedactKey string
+	// Directive names env files may use (nil allows every directive)
+	AllowedDirectives []string
+	// Flattening of nested JSON/YAML/SOPS structures
+	Delimiter    string // Joins nested keys (empty uses "_")
+	NestedAsJSON bool   // Emit nested values as JSON strings instead of flattening them
+	// Name and namespace (empty for the cluster default) of the object written by the
+	// k8s-configmap and k8s-secret formats
+	K8sName      string
+	K8sNamespace string
+	// Lowercase keys in tfvars output, for Terraform's usual variable naming
+	TFVarsLowercase bool
+	// Shell dialect of the shell format (see formatters.ShellDialects; empty for bash)
+	Shell string
+	// Append the output to the file named by $GITHUB_ENV instead of writing it to Output or stdout
+	GitHubEnv bool
 }
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
+)
+
+// UnsetCommand removes variables from env files, editing them in place
+type UnsetCommand struct {
+	files []string
+	keys  []string
+}
+
+// CreateUnsetCommand creates an unset command removing the keys from the files
+func CreateUnsetCommand(files []string, keys []string) *UnsetCommand {
+	return &UnsetCommand{
+		files: files,
+		keys:  keys,
+	}
+}
+
+// Execute removes every assignment of the keys from each file, leaving the rest of the file
+// byte for byte as it was. Keys a file does not assign are skipped, so unsetting is repeatable.
+func (cmd *UnsetCommand) Execute() error {
+	if len(cmd.keys) == 0 {
+		return fmt.Errorf("unset requires at least one key")
+	}
+	if len(cmd.files) == 0 {
+		return fmt.Errorf("unset requires at least one file (-f)")
+	}
+
+	for _, file := range cmd.files {
+		document, err := sources.ReadEnvDocument(file)
+		if err != nil {
+			return err
+		}
+
+		// From the last, so removing lines does not move the assignments before them
+		removed := 0
+		for _, assignment := range slices.Backward(document.Assignments()) {
+			if slices.Contains(cmd.keys, assignment.Key) {
+				document.Remove(assignment)
+				removed++
+			}
+		}
+		if removed == 0 {
+			logging.Infof("No keys to remove in '%s'", file)
+			continue
+		}
+
+		if err := os.WriteFile(file, document.Bytes(), 0600); err != nil {
+			return fmt.Errorf("failed to write file '%s': %w", file, err)
+		}
+		logging.Infof("Removed %d assignment(s) from '%s'", removed, file)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnsetCommand_Execute(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.env")
+	localPath := filepath.Join(dir, "local.env")
+	base := "# Settings\r\nA=1\r\nSECRET=\"line one\r\nline two\"\r\n\r\nB = 2 # keep\r\nSECRET=again"
+	if err := os.WriteFile(basePath, []byte(base), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	local := "B=3\n"
+	if err := os.WriteFile(localPath, []byte(local), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	if err := CreateUnsetCommand([]string{basePath, localPath}, []string{"SECRET", "MISSING"}).Execute(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := "# Settings\r\nA=1\r\n\r\nB = 2 # keep\r\n"
+	if data, _ := os.ReadFile(basePath); string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+	if data, _ := os.ReadFile(localPath); string(data) != local {
+		t.Errorf("Expected the file without the keys to be unchanged, got %q", data)
+	}
+
+	if err := CreateUnsetCommand([]string{filepath.Join(dir, "missing.env")}, []string{"A"}).Execute(); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
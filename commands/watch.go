@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/notwillk/envvars-cli/logging"
+)
+
+// DefaultWatchInterval is how often --watch checks its files for changes
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// fileState is what a fileWatcher compares to tell that a file changed
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// statFile returns the current state of the file at path
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{exists: true, modTime: info.ModTime(), size: info.Size()}
+}
+
+// fileWatcher polls files for being created, changed, or removed. Polling needs no platform
+// support and sees a file replaced by an editor's rename the same as one written in place.
+type fileWatcher struct {
+	interval time.Duration
+	states   map[string]fileState
+}
+
+// newFileWatcher creates a watcher checking its files every interval (0 uses the default)
+func newFileWatcher(interval time.Duration) *fileWatcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	return &fileWatcher{interval: interval, states: make(map[string]fileState)}
+}
+
+// watch sets the files to watch. Files already watched keep the state they were first seen
+// in, so a change made while the caller was busy is still reported by the next wait.
+func (w *fileWatcher) watch(paths []string) {
+	states := make(map[string]fileState, len(paths))
+	for _, path := range paths {
+		state, ok := w.states[path]
+		if !ok {
+			state = statFile(path)
+		}
+		states[path] = state
+	}
+	w.states = states
+}
+
+// changes returns the watched files whose state differs from the recorded one, recording
+// their new state
+func (w *fileWatcher) changes() []string {
+	var changed []string
+	for path, state := range w.states {
+		if current := statFile(path); current != state {
+			w.states[path] = current
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+// wait blocks until watched files change, returning them, or returns nil once done is closed.
+// Changes are collected until a check finds no more, so a burst of writes (an editor saving
+// several files, or writing one in steps) is reported once.
+func (w *fileWatcher) wait(done <-chan struct{}) []string {
+	var changed []string
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ticker.C:
+		}
+		more := w.changes()
+		if len(more) == 0 && len(changed) > 0 {
+			slices.Sort(changed)
+			return changed
+		}
+		changed = append(changed, more...)
+	}
+}
+
+// watch runs the merge and writes its output, then again whenever one of the files it read
+// changes, until done is closed. Failed runs are reported without stopping, so mistakes can
+// be fixed while watching.
+func (cmd *MergeCommand) watch(done <-chan struct{}) error {
+	watcher := newFileWatcher(cmd.options.WatchInterval)
+	for {
+		watcher.watch(cmd.watchFiles())
+		if err := cmd.execute(nil); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		// Files #include'd by this run are watched from now on
+		watcher.watch(cmd.watchFiles())
+
+		changed := watcher.wait(done)
+		if changed == nil {
+			return nil
+		}
+		logging.Infof("Changed: %v; merging again", changed)
+		cmd.Invalidate(changed...)
+	}
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileWatcher(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "config.env")
+	newPath := filepath.Join(dir, "new.env")
+	if err := os.WriteFile(envPath, []byte("KEY=value\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	watcher := newFileWatcher(10 * time.Millisecond)
+	watcher.watch([]string{envPath, newPath})
+	if changed := watcher.changes(); changed != nil {
+		t.Errorf("Expected no changes, got %v", changed)
+	}
+
+	if err := os.WriteFile(envPath, []byte("KEY=changed\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	if err := os.WriteFile(newPath, []byte("NEW=value\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	expected := []string{envPath, newPath}
+	if changed := watcher.wait(nil); !reflect.DeepEqual(changed, expected) {
+		t.Errorf("Expected %v, got %v", expected, changed)
+	}
+
+	done := make(chan struct{})
+	close(done)
+	if changed := watcher.wait(done); changed != nil {
+		t.Errorf("Expected no changes once done, got %v", changed)
+	}
+}
+
+func TestMergeCommand_Watch(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "config.env")
+	outputPath := filepath.Join(dir, "output.env")
+	if err := os.WriteFile(envPath, []byte("KEY=first\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	sources := []Source{{FilePath: envPath, Type: "env", Priority: 0}}
+	cmd := CreateMergeCommand(sources, Options{Format: "env", Output: outputPath, WatchInterval: 10 * time.Millisecond})
+	done := make(chan struct{})
+	finished := make(chan error)
+	go func() { finished <- cmd.watch(done) }()
+
+	waitForOutput := func(expected string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			data, _ := os.ReadFile(outputPath)
+			if strings.Contains(string(data), expected) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected the output to contain %s, got %q", expected, data)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitForOutput("KEY=first")
+
+	// Change the size too, in case the file system's timestamps are coarse
+	if err := os.WriteFile(envPath, []byte("KEY=second value\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	waitForOutput(`KEY="second value"`)
+
+	close(done)
+	if err := <-finished; err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package formatters
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// silenceStdout redirects stdout to the null device for the duration of the benchmark
+func silenceStdout(b *testing.B) {
+	b.Helper()
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatalf("Failed to open %s: %v", os.DevNull, err)
+	}
+	original := os.Stdout
+	os.Stdout = devNull
+	b.Cleanup(func() {
+		os.Stdout = original
+		devNull.Close()
+	})
+}
+
+// generateVariables builds n variables, some of which need quoting
+func generateVariables(n int) map[string]string {
+	variables := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			variables[fmt.Sprintf("KEY_%d", i)] = fmt.Sprintf("value_%d", i)
+		} else {
+			variables[fmt.Sprintf("KEY_%d", i)] = fmt.Sprintf("value with \"quotes\" %d", i)
+		}
+	}
+	return variables
+}
+
+func BenchmarkOutputAsENV_10k(b *testing.B) {
+	silenceStdout(b)
+	variables := generateVariables(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := OutputAsENV(variables); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkOutputAsJSON_10k(b *testing.B) {
+	silenceStdout(b)
+	variables := generateVariables(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := OutputAsJSON(variables); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkOutputAsYAML_10k(b *testing.B) {
+	silenceStdout(b)
+	variables := generateVariables(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := OutputAsYAML(variables); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package formatters
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputAsCompose outputs the key-value pairs as the environment: mapping of a docker compose
+// service, to paste or merge into docker-compose.yml. Compose interpolates ${VAR} references in
+// the file, so each $ is written as $$ to keep values literal.
+func OutputAsCompose(variables map[string]string, options Options) error {
+	keys := outputKeys(variables, options)
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		values[key] = strings.ReplaceAll(variables[key], "$", "$$")
+	}
+
+	document := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	document.Content = append(document.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "environment"},
+		stringMappingNode(keys, values),
+	)
+
+	encoder := yaml.NewEncoder(options.output())
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("failed to write compose environment: %w", err)
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,49 @@
+package formatters
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestOutputAsCompose(t *testing.T) {
+	variables := map[string]string{
+		"PORT":     "8080",
+		"DEBUG":    "yes",
+		"PASSWORD": "p$ss: #1",
+		"CERT":     "line1\nline2",
+	}
+
+	var out bytes.Buffer
+	if err := OutputAsCompose(variables, Options{Writer: &out, Order: []string{"PORT", "DEBUG", "PASSWORD", "CERT"}}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var parsed struct {
+		Environment yaml.Node `yaml:"environment"`
+	}
+	if err := yaml.Unmarshal(out.Bytes(), &parsed); err != nil {
+		t.Fatalf("Failed to parse output %q: %v", out.String(), err)
+	}
+	var keys []string
+	values := map[string]string{}
+	for i := 0; i+1 < len(parsed.Environment.Content); i += 2 {
+		key, value := parsed.Environment.Content[i], parsed.Environment.Content[i+1]
+		if value.Tag != "!!str" {
+			t.Errorf("Expected %s to be a string, got %s", key.Value, value.Tag)
+		}
+		keys = append(keys, key.Value)
+		values[key.Value] = value.Value
+	}
+
+	expectedKeys := []string{"PORT", "DEBUG", "PASSWORD", "CERT"}
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("Expected %v, got %v", expectedKeys, keys)
+	}
+	expected := map[string]string{"PORT": "8080", "DEBUG": "yes", "PASSWORD": "p$$ss: #1", "CERT": "line1\nline2"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+}
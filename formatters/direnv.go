@@ -0,0 +1,43 @@
+package formatters
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// OutputAsDirenv outputs the key-value pairs as the shell code direnv evaluates from an .envrc:
+// a watch_file declaration for each file the variables came from, so direnv reloads when one
+// changes, followed by an export statement per variable
+func OutputAsDirenv(variables map[string]string, watchFiles []string, options Options) error {
+	writer := bufio.NewWriter(options.output())
+
+	for _, path := range watchFiles {
+		if _, err := fmt.Fprintf(writer, "watch_file %s\n", ShellQuote(path)); err != nil {
+			return err
+		}
+	}
+	for _, key := range outputKeys(variables, options) {
+		if _, err := fmt.Fprintf(writer, "export %s=%s\n", key, ShellQuote(variables[key])); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// ShellQuote quotes value for a POSIX shell: values made only of characters without special
+// meaning are written bare, anything else in single quotes, which keep every character
+// (including newlines) literal; an embedded single quote closes the quotes, is escaped, and reopens them
+func ShellQuote(value string) string {
+	if value == "" {
+		return "''"
+	}
+	isSafe := func(r rune) bool {
+		return r < 0x80 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-./:@%+,=", r))
+	}
+	if strings.IndexFunc(value, func(r rune) bool { return !isSafe(r) }) == -1 {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,51 @@
+package formatters
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"", "''"},
+		{"plain", "plain"},
+		{"/path/to/file.env", "/path/to/file.env"},
+		{"two words", "'two words'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+		{"line1\nline2", "'line1\nline2'"},
+		{"héllo", "'héllo'"},
+	}
+
+	for _, test := range tests {
+		result := ShellQuote(test.value)
+		if result != test.expected {
+			t.Errorf("ShellQuote(%q) = %q, expected %q", test.value, result, test.expected)
+		}
+	}
+}
+
+func TestOutputAsDirenv_RoundTrip(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not available")
+	}
+
+	variables := map[string]string{"VALUE": "it's a \"$test\"\n\\`back`\ttab"}
+	var buf bytes.Buffer
+	if err := OutputAsDirenv(variables, nil, Options{Writer: &buf}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	out, err := exec.Command(sh, "-c", buf.String()+`printf '%s' "$VALUE"`).Output()
+	if err != nil {
+		t.Fatalf("Failed to evaluate %q: %v", buf.String(), err)
+	}
+	if string(out) != variables["VALUE"] {
+		t.Errorf("Expected %q, got %q", variables["VALUE"], string(out))
+	}
+}
@@ -0,0 +1,29 @@
+package formatters
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// OutputAsDockerEnv outputs the key-value pairs in the env-file syntax of docker run --env-file
+// and the env_file of docker compose. Docker takes everything after the first = literally, so
+// values are written without quotes or escapes, and values Docker cannot read back unchanged (those
+// spanning lines or containing a NUL byte) are rejected before anything is written.
+func OutputAsDockerEnv(variables map[string]string, options Options) error {
+	keys := outputKeys(variables, options)
+	for _, key := range keys {
+		if strings.ContainsAny(variables[key], "\n\r\x00") {
+			return fmt.Errorf("value of '%s' spans multiple lines or contains a NUL byte, which a Docker env file cannot represent", key)
+		}
+	}
+
+	writer := bufio.NewWriter(options.output())
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(writer, "%s=%s\n", key, variables[key]); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
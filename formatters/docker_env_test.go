@@ -0,0 +1,36 @@
+package formatters
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOutputAsDockerEnv(t *testing.T) {
+	variables := map[string]string{
+		"EMPTY":  "",
+		"QUOTED": `"kept" as is`,
+		"URL":    "postgres://user:p@ss@db/app?x=1#frag",
+		"SPACES": "  padded  ",
+	}
+
+	var out bytes.Buffer
+	if err := OutputAsDockerEnv(variables, Options{Writer: &out}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := "EMPTY=\nQUOTED=\"kept\" as is\nSPACES=  padded  \nURL=postgres://user:p@ss@db/app?x=1#frag\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestOutputAsDockerEnv_MultilineValue(t *testing.T) {
+	var out bytes.Buffer
+	err := OutputAsDockerEnv(map[string]string{"A": "1", "CERT": "line1\nline2"}, Options{Writer: &out})
+	if err == nil || !strings.Contains(err.Error(), "'CERT'") {
+		t.Errorf("Expected an error naming CERT, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no output, got %q", out.String())
+	}
+}
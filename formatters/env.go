@@ -1,44 +1,116 @@
 package formatters
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
 )
 
-// OutputAsENV outputs the key-value pairs in environment variable format to stdout
-func OutputAsENV(variables map[string]string) error {
+// Options controls optional formatter behavior
+type Options struct {
+	Export bool      // Prefix env lines with "export " so the output can be sourced by a shell
+	Order  []string  // Keys in output order (nil sorts keys alphabetically)
+	Writer io.Writer // Destination for the output (nil writes to stdout)
+	// Write env records as raw KEY=value terminated by NUL instead of escaped lines, like `env -0`
+	NullTerminated bool
+}
+
+// output returns the writer the formatter should write to
+func (o Options) output() io.Writer {
+	if o.Writer == nil {
+		return os.Stdout
+	}
+	return o.Writer
+}
+
+// outputKeys returns the keys to write, in order
+func outputKeys(variables map[string]string, options Options) []string {
+	if options.Order != nil {
+		return options.Order
+	}
+
 	// Sort keys for consistent output
 	keys := make([]string, 0, len(variables))
 	for k := range variables {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
+	return keys
+}
+
+// OutputAsENV outputs the key-value pairs in environment variable format to stdout
+func OutputAsENV(variables map[string]string) error {
+	return OutputAsENVWithOptions(variables, Options{})
+}
+
+// OutputAsENVWithOptions outputs the key-value pairs in environment variable format to
+// the options' writer (stdout by default)
+func OutputAsENVWithOptions(variables map[string]string, options Options) error {
+	// Buffer output to avoid a write per line
+	writer := bufio.NewWriter(options.output())
 
 	// Output as environment variables
-	for _, key := range keys {
-		value := variables[key]
-		// Escape the value if it contains special characters
-		escapedValue := escapeEnvValue(value)
-		fmt.Fprintf(os.Stdout, "%s=%s\n", key, escapedValue)
+	for _, key := range outputKeys(variables, options) {
+		if err := WriteENVRecord(writer, key, variables[key], options); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// WriteENVRecord writes a single variable: an escaped line, or with NullTerminated, the raw
+// KEY=value followed by a NUL byte, which is safe for values containing newlines
+func WriteENVRecord(w io.Writer, key string, value string, options Options) error {
+	if !options.NullTerminated {
+		_, err := fmt.Fprintln(w, FormatENVLine(key, value, options))
+		return err
 	}
 
-	return nil
+	if strings.ContainsRune(value, 0) {
+		return fmt.Errorf("value of '%s' contains a NUL byte and cannot be written NUL-separated", key)
+	}
+	_, err := io.WriteString(w, key+"="+value+"\x00")
+	return err
+}
+
+// FormatENVLine renders a single KEY=value line (without the trailing newline)
+func FormatENVLine(key string, value string, options Options) string {
+	// Escape the value if it contains special characters
+	line := key + "=" + escapeEnvValue(value)
+	if options.Export {
+		return "export " + line
+	}
+	return line
 }
 
-// escapeEnvValue escapes special characters in environment variable values
+// envValueEscapes escapes the characters that are special inside a double-quoted env value
+var envValueEscapes = strings.NewReplacer(
+	"\\", "\\\\",
+	"\"", "\\\"",
+	"$", "\\$",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\t", "\\t",
+)
+
+// escapeEnvValue escapes special characters in environment variable values.
+//
+// Quoting contract (the env parser reads every value back unchanged):
+//   - values made only of characters without special meaning are written bare
+//   - anything else is written in double quotes on a single line, with backslash, double
+//     quote, $, newline, carriage return, and tab written as \\, \", \$, \n, \r, and \t
 func escapeEnvValue(value string) string {
 	if value == "" {
 		return ""
 	}
 
-	// If the value contains spaces, quotes, or special characters, wrap it in quotes
-	if strings.ContainsAny(value, " \t\n\r\"'\\$`") {
-		// Escape backslashes and quotes
-		escaped := strings.ReplaceAll(value, "\\", "\\\\")
-		escaped = strings.ReplaceAll(escaped, "\"", "\\\"")
-		return "\"" + escaped + "\""
+	// Whitespace, quotes, escapes, references, and comment markers all need quoting
+	if strings.ContainsAny(value, " \t\n\r\v\f\"'\\$`#") {
+		return "\"" + envValueEscapes.Replace(value) + "\""
 	}
 
 	return value
@@ -0,0 +1,71 @@
+package formatters
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatENVLine(t *testing.T) {
+	tests := []struct {
+		key      string
+		value    string
+		options  Options
+		expected string
+	}{
+		{"KEY", "value", Options{}, "KEY=value"},
+		{"KEY", "two words", Options{}, `KEY="two words"`},
+		{"KEY", "value", Options{Export: true}, "export KEY=value"},
+		{"KEY", "two words", Options{Export: true}, `export KEY="two words"`},
+	}
+
+	for _, test := range tests {
+		result := FormatENVLine(test.key, test.value, test.options)
+		if result != test.expected {
+			t.Errorf("FormatENVLine(%q, %q, %+v) = %q, expected %q", test.key, test.value, test.options, result, test.expected)
+		}
+	}
+}
+
+func TestEscapeEnvValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"a=b,c", "a=b,c"},
+		{" padded ", `" padded "`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"it's", `"it's"`},
+		{`back\slash`, `"back\\slash"`},
+		{"line1\nline2", `"line1\nline2"`},
+		{"cr\rtab\t", `"cr\rtab\t"`},
+		{"${HOME}", `"\${HOME}"`},
+		{"value #comment", `"value #comment"`},
+		{"`cmd`", "\"`cmd`\""},
+	}
+
+	for _, test := range tests {
+		result := escapeEnvValue(test.value)
+		if result != test.expected {
+			t.Errorf("escapeEnvValue(%q) = %q, expected %q", test.value, result, test.expected)
+		}
+	}
+}
+
+func TestOutputAsENVWithOptions_NullTerminated(t *testing.T) {
+	var output bytes.Buffer
+	variables := map[string]string{"B": "line one\nline two", "A": "two words"}
+	if err := OutputAsENVWithOptions(variables, Options{NullTerminated: true, Writer: &output}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := "A=two words\x00B=line one\nline two\x00"
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
+	}
+
+	if err := OutputAsENVWithOptions(map[string]string{"A": "nul\x00byte"}, Options{NullTerminated: true, Writer: &output}); err == nil {
+		t.Error("Expected an error for a value containing a NUL byte")
+	}
+}
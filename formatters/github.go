@@ -0,0 +1,36 @@
+package formatters
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// OutputAsGitHub outputs the key-value pairs in the multi-line KEY<<DELIMITER syntax of the
+// $GITHUB_ENV and $GITHUB_OUTPUT files of GitHub Actions. Each value gets a random delimiter it
+// does not contain, so any value, including one spanning lines, is read back unchanged.
+func OutputAsGitHub(variables map[string]string, options Options) error {
+	writer := bufio.NewWriter(options.output())
+
+	for _, key := range outputKeys(variables, options) {
+		value := variables[key]
+		delimiter := "ghadelimiter_" + randomHex()
+		for strings.Contains(value, delimiter) {
+			delimiter = "ghadelimiter_" + randomHex()
+		}
+		if _, err := fmt.Fprintf(writer, "%s<<%s\n%s\n%s\n", key, delimiter, value, delimiter); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// randomHex returns 16 random hex digits for heredoc delimiters
+func randomHex() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,41 @@
+package formatters
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOutputAsGitHub(t *testing.T) {
+	variables := map[string]string{"SINGLE": "value", "MULTI": "line1\nline2", "EMPTY": ""}
+
+	var out bytes.Buffer
+	if err := OutputAsGitHub(variables, Options{Writer: &out}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// Read the records back the way the runner does
+	parsed := map[string]string{}
+	var keys []string
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		key, delimiter, ok := strings.Cut(lines[i], "<<")
+		if !ok || !strings.HasPrefix(delimiter, "ghadelimiter_") {
+			t.Fatalf("Expected a KEY<<DELIMITER line, got %q", lines[i])
+		}
+		var value []string
+		for i++; i < len(lines) && lines[i] != delimiter; i++ {
+			value = append(value, lines[i])
+		}
+		keys = append(keys, key)
+		parsed[key] = strings.Join(value, "\n")
+	}
+
+	if !reflect.DeepEqual(parsed, variables) {
+		t.Errorf("Expected %v, got %v", variables, parsed)
+	}
+	if expected := []string{"EMPTY", "MULTI", "SINGLE"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+}
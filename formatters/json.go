@@ -1,15 +1,55 @@
 package formatters
 
 import (
+	"bufio"
 	"encoding/json"
+	"io"
 	"os"
 )
 
 // OutputAsJSON outputs the given key-value pairs as JSON to stdout
 func OutputAsJSON(kvs map[string]string) error {
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(kvs)
+	return OutputAsJSONWithOptions(kvs, Options{})
+}
+
+// OutputAsJSONWithOptions outputs the given key-value pairs as JSON to the options' writer (stdout by default)
+func OutputAsJSONWithOptions(kvs map[string]string, options Options) error {
+	if options.Order == nil {
+		encoder := json.NewEncoder(options.output())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(kvs)
+	}
+
+	// Maps are always encoded with sorted keys, so write ordered objects by hand
+	if len(options.Order) == 0 {
+		_, err := io.WriteString(options.output(), "{}\n")
+		return err
+	}
+
+	writer := bufio.NewWriter(options.output())
+	writer.WriteString("{\n")
+	for i, key := range options.Order {
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		encodedValue, err := json.Marshal(kvs[key])
+		if err != nil {
+			return err
+		}
+
+		writer.WriteString("  ")
+		writer.Write(encodedKey)
+		writer.WriteString(": ")
+		writer.Write(encodedValue)
+		if i < len(options.Order)-1 {
+			writer.WriteString(",")
+		}
+		writer.WriteString("\n")
+	}
+	writer.WriteString("}\n")
+
+	return writer.Flush()
 }
 
 // OutputAsJSONCompact outputs the given key-value pairs as compact JSON to stdout
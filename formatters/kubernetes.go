@@ -0,0 +1,108 @@
+package formatters
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of Kubernetes objects the variables can be rendered as
+const (
+	KubernetesConfigMap = "ConfigMap"
+	KubernetesSecret    = "Secret"
+)
+
+// KubernetesObject is a ConfigMap or Secret manifest holding the variables as its data
+type KubernetesObject struct {
+	APIVersion string             `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string             `json:"kind" yaml:"kind"`
+	Metadata   KubernetesMetadata `json:"metadata" yaml:"metadata"`
+	Type       string             `json:"type,omitempty" yaml:"type,omitempty"` // "Opaque" for Secrets
+	Data       map[string]string  `json:"data" yaml:"data"`
+}
+
+// KubernetesMetadata names a Kubernetes object
+type KubernetesMetadata struct {
+	Name      string `json:"name" yaml:"name"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
+
+// KubernetesManifest builds a ConfigMap or Secret named name in namespace (empty leaves it to
+// the cluster's default) holding the variables. Secret values are base64-encoded, as the
+// Secret data field requires.
+func KubernetesManifest(variables map[string]string, kind string, name string, namespace string) (KubernetesObject, error) {
+	if kind != KubernetesConfigMap && kind != KubernetesSecret {
+		return KubernetesObject{}, fmt.Errorf("unsupported Kubernetes kind: %s", kind)
+	}
+	if name == "" {
+		return KubernetesObject{}, fmt.Errorf("a Kubernetes %s requires a name", kind)
+	}
+
+	object := KubernetesObject{
+		APIVersion: "v1",
+		Kind:       kind,
+		Metadata:   KubernetesMetadata{Name: name, Namespace: namespace},
+		Data:       make(map[string]string, len(variables)),
+	}
+	if kind == KubernetesSecret {
+		object.Type = "Opaque"
+	}
+	for key, value := range variables {
+		if kind == KubernetesSecret {
+			value = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+		object.Data[key] = value
+	}
+	return object, nil
+}
+
+// yaml11Keywords are the plain scalars YAML 1.1 does not read as strings, in lowercase
+var yaml11Keywords = map[string]bool{
+	"y": true, "n": true, "yes": true, "no": true, "on": true, "off": true,
+	"true": true, "false": true, "null": true, "~": true,
+}
+
+// stringMappingNode builds a YAML mapping of keys, in order, to their values as strings
+func stringMappingNode(keys []string, values map[string]string) *yaml.Node {
+	mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, key := range keys {
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: values[key]}
+		// YAML 1.1 readers such as kubectl take these words as booleans or null rather than strings
+		if yaml11Keywords[strings.ToLower(value.Value)] {
+			value.Style = yaml.DoubleQuotedStyle
+		}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	}
+	return mapping
+}
+
+// OutputAsKubernetes outputs the key-value pairs as a ConfigMap or Secret manifest (see
+// KubernetesManifest) in YAML to the options' writer (stdout by default), with the data keys
+// in the options' order
+func OutputAsKubernetes(variables map[string]string, kind string, name string, namespace string, options Options) error {
+	object, err := KubernetesManifest(variables, kind, name, namespace)
+	if err != nil {
+		return err
+	}
+
+	var document yaml.Node
+	if err := document.Encode(object); err != nil {
+		return fmt.Errorf("failed to encode %s manifest: %w", kind, err)
+	}
+	// Mappings are encoded with sorted keys, so rebuild the data in output order
+	data := stringMappingNode(outputKeys(variables, options), object.Data)
+	for i := 0; i+1 < len(document.Content); i += 2 {
+		if document.Content[i].Value == "data" {
+			document.Content[i+1] = data
+		}
+	}
+
+	encoder := yaml.NewEncoder(options.output())
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return fmt.Errorf("failed to write %s manifest: %w", kind, err)
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,85 @@
+package formatters
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestKubernetesManifest(t *testing.T) {
+	variables := map[string]string{"NAME": "demo", "PASSWORD": "hunter2"}
+
+	configMap, err := KubernetesManifest(variables, KubernetesConfigMap, "myapp", "prod")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := KubernetesObject{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   KubernetesMetadata{Name: "myapp", Namespace: "prod"},
+		Data:       variables,
+	}
+	if !reflect.DeepEqual(configMap, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, configMap)
+	}
+
+	secret, err := KubernetesManifest(variables, KubernetesSecret, "myapp", "")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected = KubernetesObject{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   KubernetesMetadata{Name: "myapp"},
+		Type:       "Opaque",
+		Data:       map[string]string{"NAME": "ZGVtbw==", "PASSWORD": "aHVudGVyMg=="},
+	}
+	if !reflect.DeepEqual(secret, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, secret)
+	}
+
+	if _, err := KubernetesManifest(variables, "Deployment", "myapp", ""); err == nil {
+		t.Errorf("Expected an error for an unsupported kind")
+	}
+	if _, err := KubernetesManifest(variables, KubernetesSecret, "", ""); err == nil {
+		t.Errorf("Expected an error for a missing name")
+	}
+}
+
+func TestOutputAsKubernetes(t *testing.T) {
+	variables := map[string]string{"ZETA": "yes", "ALPHA": "1"}
+
+	var buf bytes.Buffer
+	if err := OutputAsKubernetes(variables, KubernetesSecret, "myapp", "prod", Options{Writer: &buf, Order: []string{"ZETA", "ALPHA"}}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := `apiVersion: v1
+kind: Secret
+metadata:
+  name: myapp
+  namespace: prod
+type: Opaque
+data:
+  ZETA: eWVz
+  ALPHA: MQ==
+`
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := OutputAsKubernetes(variables, KubernetesConfigMap, "myapp", "", Options{Writer: &buf}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: myapp
+data:
+  ALPHA: "1"
+  ZETA: "yes"
+`
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,59 @@
+package formatters
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// OutputAsProperties outputs the key-value pairs as a Java .properties file, escaped the way
+// java.util.Properties.store escapes them so Properties.load reads every key and value back
+// unchanged
+func OutputAsProperties(variables map[string]string, options Options) error {
+	writer := bufio.NewWriter(options.output())
+
+	for _, key := range outputKeys(variables, options) {
+		if _, err := fmt.Fprintf(writer, "%s=%s\n", escapeProperty(key, true), escapeProperty(variables[key], false)); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// escapeProperty escapes a key or value of a .properties file: backslashes, the separators = and
+// :, the comment markers # and !, and whitespace are backslash-escaped, and characters outside
+// printable ASCII are written as \uXXXX UTF-16 escapes. Spaces are escaped throughout a key but
+// only at the start of a value, where load would otherwise skip them.
+func escapeProperty(text string, isKey bool) string {
+	var builder strings.Builder
+	for i, r := range text {
+		switch {
+		case r == ' ':
+			if isKey || i == 0 {
+				builder.WriteString(`\ `)
+			} else {
+				builder.WriteRune(r)
+			}
+		case r == '\t':
+			builder.WriteString(`\t`)
+		case r == '\n':
+			builder.WriteString(`\n`)
+		case r == '\r':
+			builder.WriteString(`\r`)
+		case r == '\f':
+			builder.WriteString(`\f`)
+		case strings.ContainsRune(`\=:#!`, r):
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&builder, `\u%04X`, unit)
+			}
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
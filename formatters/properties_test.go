@@ -0,0 +1,30 @@
+package formatters
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOutputAsProperties(t *testing.T) {
+	variables := map[string]string{
+		"db.url":     "jdbc:postgresql://db:5432/app?a=b",
+		"key with":   " leading and inner spaces",
+		"GREETING":   "héllo 😀",
+		"MULTI":      "line1\nline2\\",
+		"#comment:=": "!bang",
+	}
+
+	var out bytes.Buffer
+	if err := OutputAsProperties(variables, Options{Writer: &out}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := `\#comment\:\==\!bang
+GREETING=h\u00E9llo \uD83D\uDE00
+MULTI=line1\nline2\\
+db.url=jdbc\:postgresql\://db\:5432/app?a\=b
+key\ with=\ leading and inner spaces
+`
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,52 @@
+package formatters
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// ShellDialects are the shells OutputAsShell can write for
+var ShellDialects = []string{"bash", "zsh", "sh", "fish", "powershell"}
+
+// OutputAsShell outputs the key-value pairs as commands that set and export them in the given
+// shell, for eval "$(envvars-cli ...)" or its equivalent: export KEY='value' for bash, zsh, and
+// sh, set -gx KEY 'value' for fish, and $env:KEY = 'value' for powershell (or pwsh). Values are
+// single-quoted with each shell's escaping, so they are never expanded.
+func OutputAsShell(variables map[string]string, dialect string, options Options) error {
+	var line func(key string, value string) string
+	switch dialect {
+	case "bash", "zsh", "sh":
+		line = func(key string, value string) string {
+			return "export " + key + "=" + ShellQuote(value)
+		}
+	case "fish":
+		line = func(key string, value string) string {
+			// Inside fish single quotes only \ and ' are escaped
+			return "set -gx " + key + " '" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+		}
+	case "powershell", "pwsh":
+		line = func(key string, value string) string {
+			// Single-quoted strings are literal in PowerShell; a single quote is written twice
+			return "$env:" + key + " = '" + strings.ReplaceAll(value, "'", "''") + "'"
+		}
+	default:
+		return fmt.Errorf("unsupported shell '%s', expected one of %s", dialect, strings.Join(ShellDialects, ", "))
+	}
+
+	keys := outputKeys(variables, options)
+	for _, key := range keys {
+		if strings.ContainsRune(variables[key], 0) {
+			return fmt.Errorf("value of '%s' contains a NUL byte, which a shell variable cannot hold", key)
+		}
+	}
+
+	writer := bufio.NewWriter(options.output())
+	for _, key := range keys {
+		if _, err := fmt.Fprintln(writer, line(key, variables[key])); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
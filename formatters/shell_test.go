@@ -0,0 +1,62 @@
+package formatters
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+)
+
+func TestOutputAsShell(t *testing.T) {
+	variables := map[string]string{"A": "plain", "B": `it's $HOME \n`}
+	tests := []struct {
+		dialect  string
+		expected string
+	}{
+		{"bash", "export A=plain\nexport B='it'\\''s $HOME \\n'\n"},
+		{"fish", "set -gx A 'plain'\nset -gx B 'it\\'s $HOME \\\\n'\n"},
+		{"powershell", "$env:A = 'plain'\n$env:B = 'it''s $HOME \\n'\n"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := OutputAsShell(variables, test.dialect, Options{Writer: &buf}); err != nil {
+			t.Fatalf("Expected no error for %s, got: %v", test.dialect, err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("Expected %q for %s, got %q", test.expected, test.dialect, buf.String())
+		}
+	}
+
+	if err := OutputAsShell(variables, "csh", Options{Writer: &bytes.Buffer{}}); err == nil {
+		t.Error("Expected an error for an unsupported shell")
+	}
+}
+
+func TestOutputAsShell_RoundTrip(t *testing.T) {
+	variables := map[string]string{"VALUE": "it's a \"$test\"\n\\`back`\ttab"}
+	shells := map[string][]string{
+		"sh":   {"sh", "-c", `printf '%s' "$VALUE"`},
+		"fish": {"fish", "-c", `printf '%s' "$VALUE"`},
+		"pwsh": {"pwsh", "-NoProfile", "-Command", `[Console]::Out.Write($env:VALUE)`},
+	}
+
+	for dialect, command := range shells {
+		path, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := OutputAsShell(variables, dialect, Options{Writer: &buf}); err != nil {
+			t.Fatalf("Expected no error for %s, got: %v", dialect, err)
+		}
+
+		script := buf.String() + command[len(command)-1]
+		out, err := exec.Command(path, append(command[1:len(command)-1], script)...).Output()
+		if err != nil {
+			t.Fatalf("Failed to evaluate %q with %s: %v", buf.String(), dialect, err)
+		}
+		if string(out) != variables["VALUE"] {
+			t.Errorf("Expected %q from %s, got %q", variables["VALUE"], dialect, string(out))
+		}
+	}
+}
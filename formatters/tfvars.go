@@ -0,0 +1,57 @@
+package formatters
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tfvarsIdentifier matches the names Terraform accepts for variables
+var tfvarsIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// tfvarsEscapes escapes the characters that are special inside an HCL quoted string, including
+// the ${ and %{ template sequences
+var tfvarsEscapes = strings.NewReplacer(
+	"\\", "\\\\",
+	"\"", "\\\"",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\t", "\\t",
+	"${", "$${",
+	"%{", "%%{",
+)
+
+// OutputAsTFVars outputs the key-value pairs as Terraform variable assignments, key = "value",
+// for terraform plan -var-file. With lowercase, keys are lowercased to match the usual variable
+// naming; keys that are not valid variable names, or that collide once lowercased, are an error.
+func OutputAsTFVars(variables map[string]string, lowercase bool, options Options) error {
+	keys := outputKeys(variables, options)
+	names := make(map[string]string, len(keys))
+	for _, key := range keys {
+		name := key
+		if lowercase {
+			name = strings.ToLower(key)
+		}
+		if !tfvarsIdentifier.MatchString(name) {
+			return fmt.Errorf("'%s' is not a valid Terraform variable name", name)
+		}
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("'%s' and '%s' are both written as Terraform variable '%s'", other, key, name)
+		}
+		names[name] = key
+	}
+
+	writer := bufio.NewWriter(options.output())
+	for _, key := range keys {
+		name := key
+		if lowercase {
+			name = strings.ToLower(key)
+		}
+		if _, err := fmt.Fprintf(writer, "%s = \"%s\"\n", name, tfvarsEscapes.Replace(variables[key])); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,49 @@
+package formatters
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOutputAsTFVars(t *testing.T) {
+	variables := map[string]string{
+		"REGION":   "us-east-1",
+		"TEMPLATE": "${var.x} and %{if} \"quoted\" \\",
+		"MULTI":    "a\nb",
+	}
+
+	var out bytes.Buffer
+	if err := OutputAsTFVars(variables, false, Options{Writer: &out}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := "MULTI = \"a\\nb\"\nREGION = \"us-east-1\"\nTEMPLATE = \"$${var.x} and %%{if} \\\"quoted\\\" \\\\\"\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+
+	out.Reset()
+	if err := OutputAsTFVars(map[string]string{"AWS_REGION": "us-east-1"}, true, Options{Writer: &out}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "aws_region = \"us-east-1\"\n" {
+		t.Errorf("Expected %q, got %q", "aws_region = \"us-east-1\"\n", out.String())
+	}
+}
+
+func TestOutputAsTFVars_InvalidKeys(t *testing.T) {
+	tests := []struct {
+		variables map[string]string
+		message   string
+	}{
+		{map[string]string{"1KEY": "x"}, "not a valid Terraform variable name"},
+		{map[string]string{"KEY": "x", "key": "y"}, "are both written as Terraform variable 'key'"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		err := OutputAsTFVars(tt.variables, true, Options{Writer: &out})
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("Expected error containing %q, got %v", tt.message, err)
+		}
+	}
+}
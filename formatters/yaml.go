@@ -1,32 +1,32 @@
 package formatters
 
 import (
+	"bufio"
 	"fmt"
-	"os"
-	"sort"
 )
 
 // OutputAsYAML outputs the key-value pairs as YAML to stdout
 func OutputAsYAML(variables map[string]string) error {
-	// Sort keys for consistent output
-	keys := make([]string, 0, len(variables))
-	for k := range variables {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
+	return OutputAsYAMLWithOptions(variables, Options{})
+}
+
+// OutputAsYAMLWithOptions outputs the key-value pairs as YAML to the options' writer (stdout by default)
+func OutputAsYAMLWithOptions(variables map[string]string, options Options) error {
+	// Buffer output to avoid a write per line
+	writer := bufio.NewWriter(options.output())
 
 	// Output as YAML
-	for _, key := range keys {
+	for _, key := range outputKeys(variables, options) {
 		value := variables[key]
 		// Escape quotes and special characters if needed
 		if needsQuoting(value) {
-			fmt.Fprintf(os.Stdout, "%s: %q\n", key, value)
+			fmt.Fprintf(writer, "%s: %q\n", key, value)
 		} else {
-			fmt.Fprintf(os.Stdout, "%s: %s\n", key, value)
+			fmt.Fprintf(writer, "%s: %s\n", key, value)
 		}
 	}
 
-	return nil
+	return writer.Flush()
 }
 
 // needsQuoting determines if a value needs to be quoted in YAML
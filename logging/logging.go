@@ -0,0 +1,89 @@
+// Package logging writes the diagnostics shown on stderr: warnings, progress messages, and
+// debugging detail, filtered by a single process-wide verbosity level.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Verbosity levels, from least to most output
+const (
+	LevelQuiet = -1 // Errors only (-q)
+	LevelWarn  = 0  // Warnings (default)
+	LevelInfo  = 1  // Progress messages (-V)
+	LevelDebug = 2  // Per-variable and directive detail (-VV)
+)
+
+var (
+	mu          sync.Mutex
+	level                 = LevelWarn
+	output      io.Writer = os.Stderr
+	warningHook func(message string)
+)
+
+// SetLevel sets the verbosity level; levels above LevelDebug behave like LevelDebug
+func SetLevel(l int) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+// SetOutput redirects log output, returning the previous writer
+func SetOutput(w io.Writer) io.Writer {
+	mu.Lock()
+	defer mu.Unlock()
+	previous := output
+	output = w
+	return previous
+}
+
+// SetWarningHook registers fn to receive every warning message, whatever the level, returning
+// the previous hook. fn may be called from several goroutines at once.
+func SetWarningHook(fn func(message string)) func(message string) {
+	mu.Lock()
+	defer mu.Unlock()
+	previous := warningHook
+	warningHook = fn
+	return previous
+}
+
+// Enabled reports whether messages at level l are shown
+func Enabled(l int) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return level >= l
+}
+
+// Warnf writes a warning unless output is quiet
+func Warnf(format string, args ...any) {
+	mu.Lock()
+	hook := warningHook
+	mu.Unlock()
+	if hook != nil {
+		hook(fmt.Sprintf(format, args...))
+	}
+	logf(LevelWarn, "Warning: ", format, args...)
+}
+
+// Infof writes a progress message when running with -V
+func Infof(format string, args ...any) {
+	logf(LevelInfo, "", format, args...)
+}
+
+// Debugf writes debugging detail when running with -VV
+func Debugf(format string, args ...any) {
+	logf(LevelDebug, "DEBUG: ", format, args...)
+}
+
+// logf writes a line at level l with the given prefix
+func logf(l int, prefix string, format string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	if level < l {
+		return
+	}
+	fmt.Fprintf(output, prefix+format+"\n", args...)
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	var logs strings.Builder
+	defer SetOutput(SetOutput(&logs))
+	defer SetLevel(LevelWarn)
+
+	tests := []struct {
+		level    int
+		expected string
+	}{
+		{LevelQuiet, ""},
+		{LevelWarn, "Warning: careful\n"},
+		{LevelInfo, "Warning: careful\nprogress\n"},
+		{LevelDebug, "Warning: careful\nprogress\nDEBUG: detail\n"},
+		{LevelDebug + 1, "Warning: careful\nprogress\nDEBUG: detail\n"},
+	}
+
+	for _, test := range tests {
+		logs.Reset()
+		SetLevel(test.level)
+		Warnf("careful")
+		Infof("progress")
+		Debugf("detail")
+		if logs.String() != test.expected {
+			t.Errorf("Level %d: expected %q, got %q", test.level, test.expected, logs.String())
+		}
+	}
+}
+
+func TestWarningHook(t *testing.T) {
+	var logs strings.Builder
+	defer SetOutput(SetOutput(&logs))
+	defer SetLevel(LevelWarn)
+
+	var warnings []string
+	defer SetWarningHook(SetWarningHook(func(message string) {
+		warnings = append(warnings, message)
+	}))
+
+	// Warnings reach the hook even when quiet
+	SetLevel(LevelQuiet)
+	Warnf("key '%s' is empty", "A")
+	Infof("progress")
+
+	if len(warnings) != 1 || warnings[0] != "key 'A' is empty" {
+		t.Errorf("Expected [key 'A' is empty], got %v", warnings)
+	}
+	if logs.String() != "" {
+		t.Errorf("Expected no output when quiet, got %q", logs.String())
+	}
+}
@@ -7,40 +7,198 @@ package main
 // - gopkg.in/yaml.v3 (YAML processing)
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/notwillk/envvars-cli/commands"
+	"github.com/notwillk/envvars-cli/logging"
+	"github.com/notwillk/envvars-cli/sources"
 	"github.com/spf13/pflag"
+	"golang.org/x/term"
 )
 
 func main() {
+	// Installed as kubectl-envvars, the binary is a kubectl plugin: "kubectl envvars apply ..."
+	if strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe") == "kubectl-envvars" {
+		cmd, _ := findSubcommand("kubectl")
+		runSubcommand(cmd, "kubectl envvars", os.Args[1:])
+		return
+	}
+	if len(os.Args) > 1 {
+		if cmd, ok := findSubcommand(os.Args[1]); ok {
+			runSubcommand(cmd, os.Args[1], os.Args[2:])
+			return
+		}
+	}
+
 	// Define flags
 	var help bool
 	var version bool
-	var filePaths []string
 	var format string
-	var jsonFile string
-	var yamlFile string
+	var output string
+	var dryRun bool
+	var summaryJSON string
+	var print0 bool
+	var excludeFiles []string
+	var directoryOptions commands.DirectoryOptions
+	var secretPatterns []string
+	var showSecrets bool
+	var redact string
+	var auditLog string
+	var redactKey string
+	var sourceArgs []sourceArg
 	var sopsSources []string
-	var verbose bool
+	var awsProfile string
+	var awsRole string
+	var keyServices []string
+	var verbosity int
+	var quiet bool
+	var maxLineSize int
+	var schemaCacheDir string
+	var cacheDir string
+	var cacheIdentity string
+	var fetchConcurrency int
+	var fetchRate float64
+	var streamThreshold int64
+	var watch bool
+	var watchInterval time.Duration
+	var sourceTimeout time.Duration
+	var maxSourceSize int64
+	var httpHeaders []string
+	var httpToken string
+	var httpTimeout time.Duration
+	var httpRetries int
+	var export bool
+	var direnv bool
+	var k8sName string
+	var k8sNamespace string
+	var tfvarsLowercase bool
+	var shell string
+	var githubEnv bool
+	var duplicates string
+	var onDuplicate string
+	var sortOrder string
+	var preserveOrder bool
+	var strictParse bool
+	var noEscape bool
+	var strict bool
+	var interactive bool
+	var errorFormat string
+	var invalidKeys string
+	var strictEmpty bool
+	var delimiter string
+	var nestedAsJSON bool
+	var requireNonEmpty bool
+	var configPath string
+	var policyPath string
+	var noConfig bool
+	var profile string
+	var envName string
+	var auto bool
+	var prefix string
+	var stripPrefix string
+	var dropUnprefixed bool
+	var only []string
+	var except []string
+	var require []string
+	var requireFiles []string
+	var failIfEmpty bool
+	var minKeys int
+	var autoParents bool
 
 	// Set up flags
 	pflag.BoolVarP(&help, "help", "h", false, "Show this help message")
 	pflag.BoolVarP(&version, "version", "v", false, "Show version information")
-	pflag.StringSliceVarP(&filePaths, "env", "e", []string{}, "Read and parse environment variable files (can be specified multiple times)")
-	pflag.StringVarP(&format, "format", "f", "env", "Output format: json, yaml, or env (default: env)")
-	pflag.StringVarP(&jsonFile, "json", "j", "", "Process a JSON file")
-	pflag.StringVarP(&yamlFile, "yaml", "y", "", "Process a YAML file")
+	defineSourceFlags(pflag.CommandLine, &sourceArgs)
+	pflag.StringVarP(&format, "format", "f", "env", "Output format: json, yaml, env, docker-env, compose, tfvars, shell, github, properties, k8s-configmap, or k8s-secret (default: env)")
+	pflag.StringVar(&k8sName, "k8s-name", "", "Name of the ConfigMap or Secret written by --format k8s-configmap or k8s-secret")
+	pflag.StringVar(&k8sNamespace, "k8s-namespace", "", "Namespace of the ConfigMap or Secret (default: none, leaving it to kubectl)")
+	pflag.BoolVar(&tfvarsLowercase, "tfvars-lowercase", false, "Lowercase the keys of tfvars output")
+	pflag.StringVar(&shell, "shell", "", "Shell of the shell format: bash, zsh, sh, fish, or powershell (implies --format shell)")
+	pflag.BoolVar(&githubEnv, "github-env", false, "Append the output to the file named by $GITHUB_ENV (implies --format github)")
+	pflag.StringSliceVar(&secretPatterns, "secret-pattern", []string{}, "Treat keys matching these wildcard patterns as secrets, masking their values in logs, prompts, and errors (comma-separated, adds to the defaults)")
+	pflag.BoolVar(&showSecrets, "show-secrets", false, "Show secret values in logs, prompts, and errors instead of masking them")
+	pflag.StringVar(&auditLog, "audit-log", "", "Append a JSON record of the keys read from secret backends (SOPS) to this file, or send it to syslog")
+	pflag.StringVar(&redact, "redact", "", "Replace sensitive output values: mask (***, the default) or hmac (a stable keyed hash)")
+	pflag.Lookup("redact").NoOptDefVal = commands.RedactMask
+	pflag.StringVar(&redactKey, "redact-key", "", "Key for --redact=hmac (default: $"+commands.RedactKeyEnv+")")
+	pflag.BoolVar(&directoryOptions.Recursive, "dir-recursive", false, "Also load the files in subdirectories of directory sources")
+	pflag.StringSliceVar(&directoryOptions.Extensions, "dir-ext", nil, "Extensions of the files loaded from directory sources, e.g. '.env,.conf' (default: the source type's, such as .env for --env)")
+	pflag.StringSliceVar(&excludeFiles, "exclude-file", []string{}, "Skip files matching these patterns (e.g. '.env.test', '*~', '*.bak') in glob sources and discovered env files (can be specified multiple times)")
+	pflag.BoolVarP(&print0, "print0", "0", false, "Write env output as raw KEY=value records terminated by NUL (for xargs -0) instead of escaped lines")
+	pflag.StringVar(&summaryJSON, "summary-json", "", "Write a JSON report of the run (sources read, keys merged, overrides, warnings, duration) to this file")
+	pflag.BoolVar(&dryRun, "dry-run", false, "Print the merge plan (sources in order, the keys each adds, overrides, or removes, and their directives) instead of the output")
+	pflag.StringVarP(&output, "output", "o", "", "Write the output to this file instead of stdout; its extension sets the format unless --format is given")
 	pflag.StringSliceVarP(&sopsSources, "sops", "s", []string{}, "Process SOPS-encrypted files in format [key_name]@[path-to-file] (can be specified multiple times)")
-	pflag.BoolVarP(&verbose, "verbose", "V", false, "Enable verbose output")
+	pflag.StringVar(&awsProfile, "aws-profile", "", "AWS profile used to decrypt the KMS keys of SOPS sources")
+	pflag.StringVar(&awsRole, "aws-role", "", "Role ARN assumed to decrypt the KMS keys of SOPS sources")
+	pflag.StringArrayVar(&keyServices, "keyservice", []string{}, "SOPS key service to decrypt with (unix:///path or tcp://host:port, can be specified multiple times)")
+	pflag.CountVarP(&verbosity, "verbose", "V", "Increase output on stderr: -V shows progress, -VV adds per-variable detail")
+	pflag.BoolVarP(&quiet, "quiet", "q", false, "Suppress warnings; only errors are written to stderr")
+	pflag.StringVar(&schemaCacheDir, "schema-cache-dir", "", "Cache remote JSON schemas on disk in this directory")
+	pflag.StringVar(&cacheDir, "cache-dir", "", "Cache decrypted remote (SOPS) sources between runs in this directory, encrypted with --cache-identity")
+	pflag.StringVar(&cacheIdentity, "cache-identity", "", "age identity file used to encrypt the --cache-dir cache")
+	pflag.IntVar(&fetchConcurrency, "fetch-concurrency", 0, "Maximum simultaneous fetches per remote backend (default: 4)")
+	pflag.Float64Var(&fetchRate, "fetch-rate", 0, "Maximum remote fetches started per second per backend (default: unlimited)")
+	pflag.BoolVar(&export, "export", false, "Prefix env output lines with 'export ' so they can be sourced by a shell")
+	pflag.BoolVar(&direnv, "direnv", false, "Write the output as shell code for direnv: watch_file for each source, then an export per variable")
+	pflag.DurationVar(&sourceTimeout, "source-timeout", 0, "Fail when reading any one source takes longer than this, e.g. 30s (default: no limit)")
+	pflag.Int64Var(&maxSourceSize, "max-source-size", 0, "Fail when a source file is larger than this many bytes (default: no limit)")
+	pflag.StringArrayVar(&httpHeaders, "http-header", nil, "Header sent when fetching http(s):// sources, as 'Name: value' (repeatable)")
+	pflag.StringVar(&httpToken, "http-token", "", "Bearer token sent when fetching https:// sources (default: $"+commands.HTTPTokenEnv+")")
+	pflag.DurationVar(&httpTimeout, "http-timeout", 0, "Timeout for each request fetching an http(s):// source (default: 30s)")
+	pflag.IntVar(&httpRetries, "http-retries", 0, "Retries after a failed request for an http(s):// source (default: 2, -1 disables them)")
+	pflag.BoolVar(&watch, "watch", false, "Merge and write the output again whenever an input file changes, until interrupted")
+	pflag.DurationVar(&watchInterval, "watch-interval", 0, "How often --watch checks the input files for changes (default: 500ms)")
+	pflag.Int64Var(&streamThreshold, "stream-threshold", 0, "Stream env files of at least this many bytes with bounded memory (default: disabled)")
+	pflag.StringVar(&duplicates, "duplicates", "last", "Handling of keys assigned twice in one env file: warn, error, first, or last")
+	pflag.StringVar(&onDuplicate, "on-duplicate", "", "Handling of keys set more than once, within a file or across sources: warn, error, first-wins, or last-wins")
+	pflag.StringVar(&sortOrder, "sort", "key", "Output order: key, or source/none to keep the order variables are defined in")
+	pflag.BoolVar(&preserveOrder, "preserve-order", false, "Write keys in the order they appear in the source files (same as --sort source)")
+	pflag.BoolVar(&interactive, "interactive", false, "Prompt to choose which value wins when sources set the same key to different values")
+	pflag.BoolVar(&strict, "strict", false, "Treat ambiguities that are otherwise allowed as errors, such as sources with equal priorities or malformed env file lines")
+	pflag.BoolVar(&strictParse, "strict-parse", false, "Fail on env file lines that are neither comments nor KEY=value assignments, or whose keys are invalid")
+	pflag.BoolVar(&noEscape, "no-escape", false, "Keep escape sequences such as \\n in double-quoted env values as written instead of expanding them")
+	pflag.BoolVar(&strictEmpty, "strict-empty", false, "Fail on source files with no content instead of treating them as empty")
+	pflag.StringVar(&delimiter, "delimiter", "_", "Delimiter joining the keys of nested JSON, YAML, and SOPS structures")
+	pflag.BoolVar(&nestedAsJSON, "nested-as-json", false, "Emit nested JSON, YAML, and SOPS values as JSON strings instead of flattening them")
+	pflag.BoolVar(&requireNonEmpty, "require-nonempty", false, "Treat #require'd keys that are set to an empty string as missing")
+	pflag.StringVar(&errorFormat, "error-format", "text", "Error output format: text or json")
+	pflag.StringVar(&invalidKeys, "invalid-keys", "warn", "Handling of keys that are not valid variable names: warn, error, keep, relaxed, or sanitize")
+	pflag.IntVar(&maxLineSize, "max-line-size", 0, "Maximum line length in bytes for env files (default: 1MB)")
+	pflag.StringVar(&configPath, "config", "", "Project config file (default: envvars.yaml or .envvarsrc found by walking up from the working directory)")
+	pflag.BoolVar(&noConfig, "no-config", false, "Ignore any project config file")
+	pflag.StringVar(&policyPath, "policy", "", "Policy file (default: "+commands.PolicyFileName+" found by walking up from the working directory)")
+	pflag.StringVar(&profile, "profile", "", "Apply the named profile from the project config")
+	pflag.StringVar(&prefix, "prefix", "", "Add this prefix to every output key")
+	pflag.StringVar(&stripPrefix, "strip-prefix", "", "Remove this prefix from incoming keys")
+	pflag.BoolVar(&dropUnprefixed, "drop-unprefixed", false, "With --strip-prefix, drop incoming keys that do not have the prefix")
+	pflag.StringSliceVar(&only, "only", []string{}, "Output only keys matching these wildcard patterns (comma-separated, can be specified multiple times)")
+	pflag.StringSliceVar(&except, "except", []string{}, "Omit keys matching these wildcard patterns from the output (comma-separated, can be specified multiple times)")
+	pflag.StringSliceVar(&require, "require", []string{}, "Fail unless these keys are in the merged output (comma-separated, can be specified multiple times)")
+	pflag.StringSliceVar(&requireFiles, "require-file", []string{}, "Fail unless the keys listed in this file are in the merged output (can be specified multiple times)")
+	pflag.BoolVar(&failIfEmpty, "fail-if-empty", false, "Fail when the merged output has no variables")
+	pflag.IntVar(&minKeys, "min-keys", 0, "Fail when the merged output has fewer than this many variables")
+	pflag.BoolVar(&auto, "auto", false, "Merge the .env files found in the working directory, following the --env-name chain")
+	pflag.BoolVar(&autoParents, "auto-parents", false, "Like --auto, also searching parent directories up to the git root")
+	pflag.StringVar(&envName, "env-name", "", "Load .env, .env.<name>, .env.local, and .env.<name>.local from the working directory, in that precedence order")
+
+	// Completion lists the flags above, so it is dispatched once they are defined
+	if len(os.Args) > 1 && (os.Args[1] == "completion" || os.Args[1] == commands.CompleteCommand) {
+		runCompletion(os.Args[1], os.Args[2:], pflag.CommandLine)
+		return
+	}
 
 	// Parse flags
 	pflag.Parse()
 
 	// Handle help flag
-	if help || (len(os.Args) == 1 && pflag.NFlag() == 0) {
+	if help {
 		commands.ShowHelp()
 		return
 	}
@@ -51,88 +209,316 @@ func main() {
 		return
 	}
 
+	if dropUnprefixed && stripPrefix == "" {
+		fmt.Fprintf(os.Stderr, "Error: --drop-unprefixed requires --strip-prefix\n")
+		os.Exit(1)
+	}
+	if redactKey == "" {
+		redactKey = os.Getenv(commands.RedactKeyEnv)
+	}
+	if minKeys < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --min-keys must not be negative\n")
+		os.Exit(1)
+	}
+	if interactive && !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintf(os.Stderr, "Error: --interactive requires a terminal on stdin\n")
+		os.Exit(1)
+	}
+	if quiet && verbosity > 0 {
+		fmt.Fprintf(os.Stderr, "Error: --quiet and --verbose cannot be combined\n")
+		os.Exit(1)
+	}
+	if quiet {
+		logging.SetLevel(logging.LevelQuiet)
+	} else {
+		logging.SetLevel(verbosity)
+	}
+
+	if errorFormat != "text" && errorFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unsupported error format: %s\n", errorFormat)
+		os.Exit(1)
+	}
+
+	// Load the policy, if there is one; unlike the project config it cannot be skipped
+	policy, err := commands.LoadPolicy(policyPath)
+	if err != nil {
+		commands.PrintError(err, errorFormat)
+		os.Exit(1)
+	}
+
+	// Load the project config, if there is one
+	var config *commands.ProjectConfig
+	if !noConfig {
+		config, err = commands.LoadProjectConfig(configPath)
+		if err != nil {
+			commands.PrintError(err, errorFormat)
+			os.Exit(1)
+		}
+	}
+	if profile != "" && config == nil {
+		commands.PrintError(fmt.Errorf("--profile requires a project config"), errorFormat)
+		os.Exit(1)
+	}
+
+	// Without a project config, running with no arguments shows help
+	if len(os.Args) == 1 && config == nil {
+		commands.ShowHelp()
+		return
+	}
+
 	// Handle env, json, yaml, or sops flags (environment processor command)
-	if len(filePaths) > 0 || jsonFile != "" || yamlFile != "" || len(sopsSources) > 0 {
+	if len(sourceArgs) > 0 || len(sopsSources) > 0 || envName != "" || auto || autoParents || config != nil {
 		// Create sources array with metadata
 		var sources []commands.Source
 		priority := 0
 
-		// Process flags in the order they appear in the command line
-		// This preserves the user's intended priority order
-		for i := 1; i < len(os.Args); i++ {
-			arg := os.Args[i]
-
-			switch arg {
-			case "--env", "-e":
-				// Find the corresponding file path
-				if i+1 < len(os.Args) && !strings.HasPrefix(os.Args[i+1], "-") {
-					sources = append(sources, commands.Source{
-						FilePath: os.Args[i+1],
-						Type:     "env",
-						Priority: priority,
-					})
-					priority++
-					i++ // Skip the file path in next iteration
-				}
-			case "--json", "-j":
-				// Find the corresponding file path
-				if i+1 < len(os.Args) && !strings.HasPrefix(os.Args[i+1], "-") {
-					sources = append(sources, commands.Source{
-						FilePath: os.Args[i+1],
-						Type:     "json",
-						Priority: priority,
-					})
-					priority++
-					i++ // Skip the file path in next iteration
-				}
-			case "--yaml", "-y":
-				// Find the corresponding file path
-				if i+1 < len(os.Args) && !strings.HasPrefix(os.Args[i+1], "-") {
-					sources = append(sources, commands.Source{
-						FilePath: os.Args[i+1],
-						Type:     "yaml",
-						Priority: priority,
-					})
-					priority++
-					i++ // Skip the file path in next iteration
+		// The project config supplies defaults: its sources are used when none are given on the
+		// command line, and its options apply unless the corresponding flag was set
+		var settings commands.ConfigSettings
+		if config != nil {
+			var err error
+			settings, err = config.Settings(profile)
+			if err != nil {
+				commands.PrintError(err, errorFormat)
+				os.Exit(1)
+			}
+		}
+
+		// Excludes given on the command line add to the project config's
+		if err := commands.ValidateExcludes(excludeFiles); err != nil {
+			commands.PrintError(err, errorFormat)
+			os.Exit(1)
+		}
+		settings.Excludes = slices.Concat(settings.Excludes, excludeFiles)
+
+		// addSources appends the files matched by path (which may be a glob pattern or a directory) in lexical order
+		addSources := func(path string, sourceType string, decryptionKey string) {
+			paths, err := commands.ExpandSource(path, sourceType, directoryOptions, settings.Excludes)
+			if err != nil {
+				commands.PrintError(err, errorFormat)
+				os.Exit(1)
+			}
+			for _, filePath := range paths {
+				sources = append(sources, commands.Source{
+					FilePath:      filePath,
+					Type:          sourceType,
+					Priority:      priority,
+					DecryptionKey: decryptionKey,
+				})
+				priority++
+			}
+		}
+
+		// addSpec appends the sources given by a --source value, which may set their priority explicitly
+		addSpec := func(value string) {
+			spec, err := commands.ParseSourceSpec(value)
+			if err != nil {
+				commands.PrintError(err, errorFormat)
+				os.Exit(1)
+			}
+			start := len(sources)
+			addSources(spec.Path, spec.Type, spec.DecryptionKey)
+			for j := start; j < len(sources); j++ {
+				if spec.Priority != nil {
+					sources[j].Priority = *spec.Priority
 				}
-			case "--sops", "-s":
-				// Skip SOPS sources here - they're processed separately below
-				if i+1 < len(os.Args) && !strings.HasPrefix(os.Args[i+1], "-") {
-					i++ // Skip the file path in next iteration
+				sources[j].AWSProfile = spec.AWSProfile
+				sources[j].AWSRole = spec.AWSRole
+				if spec.KeyService != "" {
+					sources[j].KeyServices = []string{spec.KeyService}
 				}
 			}
 		}
 
+		// Discovered files and the conventional chain for --env-name come first, so explicit sources override them
+		if auto || autoParents {
+			files, skipped, err := commands.AutoDiscover(".", envName, autoParents, settings.Excludes)
+			if err != nil {
+				commands.PrintError(err, errorFormat)
+				os.Exit(1)
+			}
+			if len(files) == 0 {
+				commands.PrintError(fmt.Errorf("no env files found"), errorFormat)
+				os.Exit(1)
+			}
+			logging.Infof("Auto-discovered: %s", strings.Join(files, ", "))
+			if len(skipped) > 0 {
+				logging.Infof("Skipped: %s", strings.Join(skipped, ", "))
+			}
+			for _, filePath := range files {
+				addSources(filePath, "env", "")
+			}
+		} else if envName != "" {
+			chain, err := commands.EnvNameChain(".", envName)
+			if err != nil {
+				commands.PrintError(err, errorFormat)
+				os.Exit(1)
+			}
+			chain = slices.DeleteFunc(chain, func(file string) bool {
+				return commands.IsExcluded(file, settings.Excludes)
+			})
+			if len(chain) == 0 {
+				commands.PrintError(fmt.Errorf("no env files found for environment '%s'", envName), errorFormat)
+				os.Exit(1)
+			}
+			logging.Infof("Environment '%s' loads: %s", envName, strings.Join(chain, ", "))
+			for _, filePath := range chain {
+				addSources(filePath, "env", "")
+			}
+		}
+
+		// Add the sources in the order their flags were given, which sets their priority
+		for _, arg := range sourceArgs {
+			if arg.sourceType == "" {
+				addSpec(arg.value)
+			} else {
+				addSources(arg.value, arg.sourceType, "")
+			}
+		}
+
 		// Process SOPS sources in the format [key_name]@[path-to-file]
 		for _, sopsSource := range sopsSources {
 			parts := strings.SplitN(sopsSource, "@", 2)
 			if len(parts) != 2 {
-				fmt.Fprintf(os.Stderr, "Warning: Invalid SOPS source format '%s'. Expected format: [key_name]@[path-to-file]\n", sopsSource)
+				logging.Warnf("Invalid SOPS source format '%s'. Expected format: [key_name]@[path-to-file]", sopsSource)
 				continue
 			}
 
 			decryptionKey := parts[0]
 			filePath := parts[1]
 
-			sources = append(sources, commands.Source{
-				FilePath:      filePath,
-				Type:          "sops",
-				Priority:      priority,
-				DecryptionKey: decryptionKey,
-			})
-			priority++
+			addSources(filePath, "sops", decryptionKey)
+		}
+
+		if config != nil {
+			if len(sources) == 0 {
+				var err error
+				sources, err = config.ResolveSources(settings)
+				if err != nil {
+					commands.PrintError(err, errorFormat)
+					os.Exit(1)
+				}
+			}
+			logging.Infof("Using project config: %s", config.Path)
+		}
+		if len(sources) == 0 {
+			commands.PrintError(fmt.Errorf("no sources given"), errorFormat)
+			os.Exit(1)
+		}
+
+		for _, requireFile := range requireFiles {
+			keys, err := commands.ReadRequireFile(requireFile)
+			if err != nil {
+				commands.PrintError(err, errorFormat)
+				os.Exit(1)
+			}
+			require = append(require, keys...)
 		}
 
 		// Create global options
 		options := commands.Options{
-			Verbose: verbose,
-			Format:  format,
+			Format:           format,
+			Output:           output,
+			DryRun:           dryRun,
+			SummaryJSON:      summaryJSON,
+			Print0:           print0,
+			MaxLineSize:      maxLineSize,
+			SchemaCacheDir:   schemaCacheDir,
+			CacheDir:         cacheDir,
+			CacheIdentity:    cacheIdentity,
+			AWSProfile:       awsProfile,
+			AWSRole:          awsRole,
+			KeyServices:      keyServices,
+			FetchConcurrency: fetchConcurrency,
+			FetchRate:        fetchRate,
+			StreamThreshold:  streamThreshold,
+			Watch:            watch,
+			WatchInterval:    watchInterval,
+			SourceTimeout:    sourceTimeout,
+			MaxSourceSize:    maxSourceSize,
+			HTTPHeaders:      httpHeaders,
+			HTTPToken:        httpToken,
+			HTTPTimeout:      httpTimeout,
+			HTTPRetries:      httpRetries,
+			Export:           export,
+			Direnv:           direnv,
+			K8sName:          k8sName,
+			K8sNamespace:     k8sNamespace,
+			TFVarsLowercase:  tfvarsLowercase,
+			Shell:            shell,
+			GitHubEnv:        githubEnv,
+			Duplicates:       duplicates,
+			OnDuplicate:      onDuplicate,
+			Sort:             sortOrder,
+			StrictParse:      strictParse,
+			NoEscape:         noEscape,
+			Strict:           strict,
+			Interactive:      interactive,
+			InvalidKeys:      invalidKeys,
+			StrictEmpty:      strictEmpty,
+			Delimiter:        delimiter,
+			NestedAsJSON:     nestedAsJSON,
+			RequireNonEmpty:  requireNonEmpty,
+			Prefix:           prefix,
+			StripPrefix:      stripPrefix,
+			DropUnprefixed:   dropUnprefixed,
+			Only:             only,
+			Except:           except,
+			Require:          require,
+			SecretPatterns:   secretPatterns,
+			ShowSecrets:      showSecrets,
+			Policy:           policy,
+			AuditLog:         auditLog,
+			Redact:           redact,
+			RedactKey:        redactKey,
+			FailIfEmpty:      failIfEmpty,
+			MinKeys:          minKeys,
+		}
+		settings.ApplyTo(&options, pflag.CommandLine.Changed)
+		if preserveOrder {
+			if pflag.CommandLine.Changed("sort") && sortOrder != "source" && sortOrder != "none" {
+				commands.PrintError(fmt.Errorf("--preserve-order conflicts with --sort %s", sortOrder), errorFormat)
+				os.Exit(1)
+			}
+			options.Sort = "source"
+		}
+		if options.OnDuplicate != "" && pflag.CommandLine.Changed("duplicates") {
+			commands.PrintError(fmt.Errorf("--on-duplicate also sets how keys assigned twice in one file are handled, so it cannot be combined with --duplicates"), errorFormat)
+			os.Exit(1)
+		}
+		if githubEnv && output != "" {
+			commands.PrintError(fmt.Errorf("--github-env and --output both set where the output goes; use one"), errorFormat)
+			os.Exit(1)
+		}
+		if githubEnv && !pflag.CommandLine.Changed("format") {
+			options.Format = "github"
+		}
+		if shell != "" && !pflag.CommandLine.Changed("format") {
+			options.Format = "shell"
+		}
+		if output != "" && !pflag.CommandLine.Changed("format") && options.Format != "shell" {
+			if detected, ok := commands.FormatForPath(output); ok {
+				options.Format = detected
+			}
+		}
+		if options.Print0 && (options.Format != "env" || options.Export) {
+			commands.PrintError(fmt.Errorf("--print0 only applies to env output without --export"), errorFormat)
+			os.Exit(1)
+		}
+
+		if options.Export && options.Format == "docker-env" {
+			commands.PrintError(fmt.Errorf("--export does not apply to docker-env output, which Docker reads without a shell"), errorFormat)
+			os.Exit(1)
+		}
+
+		if options.Direnv && (options.Format != "env" || options.Print0) {
+			commands.PrintError(fmt.Errorf("--direnv writes env output and cannot be combined with another --format or --print0"), errorFormat)
+			os.Exit(1)
 		}
 
 		mergeCmd := commands.CreateMergeCommand(sources, options)
 		if err := mergeCmd.Execute(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			commands.PrintError(err, errorFormat)
 			os.Exit(1)
 		}
 		return
@@ -141,3 +527,313 @@ func main() {
 	// If no valid command provided, show help
 	commands.ShowHelp()
 }
+
+// scanSecretsCommand defines the scan-secrets flags; the command fails when it finds anything
+func scanSecretsCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var format string
+	var verbosity int
+	var policyPath string
+	flags.StringVarP(&format, "format", "f", "text", "Report format: text or json")
+	flags.StringVar(&policyPath, "policy", "", "Policy file (default: "+commands.PolicyFileName+" found by walking up from the working directory)")
+	flags.CountVarP(&verbosity, "verbose", "V", "Increase output on stderr: -V reports skipped encrypted files")
+	return func(args []string) error {
+		logging.SetLevel(verbosity)
+		policy, err := commands.LoadPolicy(policyPath)
+		if err != nil {
+			return err
+		}
+		return commands.CreateScanSecretsCommand(args, format, policy).Execute()
+	}
+}
+
+// lintCommand defines the lint flags; the command fails when issues remain
+func lintCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var format string
+	var fix bool
+	var verbosity int
+	flags.StringVarP(&format, "format", "f", "text", "Report format: text or json")
+	flags.BoolVar(&fix, "fix", false, "Fix trailing whitespace and inconsistent quoting in place, reporting only the remaining issues")
+	flags.CountVarP(&verbosity, "verbose", "V", "Increase output on stderr: -V reports the files fixed")
+	return func(args []string) error {
+		logging.SetLevel(verbosity)
+		return commands.CreateLintCommand(args, format, fix).Execute()
+	}
+}
+
+// getCommand defines the get flags; the command fails when the key is not set
+func getCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var files []string
+	flags.StringArrayVarP(&files, "file", "f", nil, "Env, JSON, or YAML file, directory, or glob pattern to merge (can be specified multiple times)")
+	return func(args []string) error {
+		if len(args) != 1 {
+			return errors.New("usage: envvars-cli get <KEY> -f <file>...")
+		}
+		return commands.CreateGetCommand(args[0], files).Execute()
+	}
+}
+
+// setCommand defines the set flags
+func setCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var files []string
+	var verbosity int
+	flags.StringArrayVarP(&files, "file", "f", nil, "Env file to edit")
+	flags.CountVarP(&verbosity, "verbose", "V", "Increase output on stderr: -V reports the keys added and updated")
+	return func(args []string) error {
+		if len(files) != 1 || len(args) == 0 {
+			return errors.New("usage: envvars-cli set <KEY=value>... -f <file>")
+		}
+		logging.SetLevel(verbosity)
+		return commands.CreateSetCommand(files[0], args).Execute()
+	}
+}
+
+// unsetCommand defines the unset flags
+func unsetCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var files []string
+	var allFiles bool
+	var verbosity int
+	flags.StringArrayVarP(&files, "file", "f", nil, "Env file to edit")
+	flags.BoolVar(&allFiles, "all-files", false, "Remove the keys from every file given with -f")
+	flags.CountVarP(&verbosity, "verbose", "V", "Increase output on stderr: -V reports the assignments removed")
+	return func(args []string) error {
+		if len(files) == 0 || len(args) == 0 {
+			return errors.New("usage: envvars-cli unset <KEY>... -f <file> [-f <file> --all-files]")
+		}
+		if len(files) > 1 && !allFiles {
+			return fmt.Errorf("unset edits a single file; add --all-files to remove the keys from all %d files", len(files))
+		}
+		logging.SetLevel(verbosity)
+		return commands.CreateUnsetCommand(files, args).Execute()
+	}
+}
+
+// convertCommand defines the convert flags
+func convertCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var options commands.Options
+	var preserveComments bool
+	flags.StringVar(&options.Format, "to", "", "Output format: "+strings.Join(commands.OutputFormats, ", ")+" (default: implied by the output file's name, otherwise env)")
+	flags.StringVarP(&options.Output, "output", "o", "", "Write the output to this file instead of stdout")
+	flags.BoolVar(&preserveComments, "preserve-comments", false, "When converting an env file to env, keep its comments, blank lines, and layout")
+	flags.StringVar(&options.Shell, "shell", "", "Shell of the shell format: bash, zsh, sh, fish, or powershell")
+	flags.StringVar(&options.K8sName, "k8s-name", "", "Name of the ConfigMap or Secret written by --to k8s-configmap or k8s-secret")
+	flags.StringVar(&options.K8sNamespace, "k8s-namespace", "", "Namespace of the ConfigMap or Secret")
+	return func(args []string) error {
+		if len(args) != 1 {
+			return errors.New("usage: envvars-cli convert <file> [--to <format>] [-o <file>]")
+		}
+		return commands.CreateConvertCommand(args[0], options, preserveComments).Execute()
+	}
+}
+
+// encryptCommand defines the encrypt flags
+func encryptCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var files []string
+	var output, format string
+	var keys sources.SOPSEncryptionKeys
+	flags.StringArrayVarP(&files, "file", "f", nil, "Env, JSON, or YAML file, directory, or glob pattern to merge (can be specified multiple times)")
+	flags.StringVarP(&output, "output", "o", "", "Write the encrypted file here instead of stdout")
+	flags.StringVar(&format, "format", "", "Encrypted file format: "+strings.Join(sources.SOPSFormats(), ", ")+" (default: implied by the output file's name, otherwise yaml)")
+	flags.StringSliceVar(&keys.Age, "age", nil, "age recipient to encrypt for (comma-separated, can be specified multiple times)")
+	flags.StringSliceVar(&keys.PGP, "pgp", nil, "PGP fingerprint to encrypt for (comma-separated, can be specified multiple times)")
+	flags.StringSliceVar(&keys.KMS, "kms", nil, "AWS KMS key ARN to encrypt for (comma-separated, can be specified multiple times)")
+	flags.StringVar(&keys.AWSProfile, "aws-profile", "", "AWS profile used for the KMS keys")
+	return func(args []string) error {
+		if len(files) == 0 || len(args) > 0 {
+			return errors.New("usage: envvars-cli encrypt -f <file>... [--age <recipient>] [-o <file>]")
+		}
+		return commands.CreateEncryptCommand(files, output, format, keys).Execute()
+	}
+}
+
+// decryptCommand defines the decrypt flags
+func decryptCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var options commands.Options
+	flags.StringVarP(&options.Format, "format", "f", "", "Output format: "+strings.Join(commands.OutputFormats, ", ")+" (default: implied by the output file's name, otherwise env)")
+	flags.StringVarP(&options.Output, "output", "o", "", "Write the output to this file instead of stdout")
+	flags.StringVar(&options.AWSProfile, "aws-profile", "", "AWS profile used to decrypt the file's KMS keys")
+	flags.StringVar(&options.AWSRole, "aws-role", "", "Role ARN assumed (through STS) to decrypt the file's KMS keys")
+	flags.StringArrayVar(&options.KeyServices, "keyservice", nil, "SOPS key service to decrypt with (unix:///path or tcp://host:port, can be specified multiple times)")
+	flags.StringVar(&options.Shell, "shell", "", "Shell of the shell format: bash, zsh, sh, fish, or powershell")
+	flags.StringVar(&options.K8sName, "k8s-name", "", "Name of the ConfigMap or Secret written by --format k8s-configmap or k8s-secret")
+	flags.StringVar(&options.K8sNamespace, "k8s-namespace", "", "Namespace of the ConfigMap or Secret")
+	return func(args []string) error {
+		if len(args) != 1 {
+			return errors.New("usage: envvars-cli decrypt <file> [--format <format>] [-o <file>]")
+		}
+		for _, address := range options.KeyServices {
+			if err := sources.ValidateKeyService(address); err != nil {
+				return err
+			}
+		}
+		return commands.CreateDecryptCommand(args[0], options).Execute()
+	}
+}
+
+// templateCommand defines the template flags
+func templateCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var files []string
+	var output, mode string
+	var allowMissing bool
+	flags.StringArrayVarP(&files, "file", "f", nil, "Env, JSON, or YAML file, directory, or glob pattern to merge (can be specified multiple times)")
+	flags.StringVarP(&output, "output", "o", "", "Write the rendered template to this file instead of stdout")
+	flags.StringVar(&mode, "mode", commands.TemplateModeGo, "Template syntax: go (text/template, {{.KEY}}) or subst ($KEY and ${KEY}, like envsubst)")
+	flags.BoolVar(&allowMissing, "allow-missing", false, "Render variables the files do not set as empty instead of failing")
+	return func(args []string) error {
+		if len(files) == 0 || len(args) != 1 {
+			return errors.New("usage: envvars-cli template -f <file>... <template> [-o <file>]")
+		}
+		return commands.CreateTemplateCommand(files, args[0], output, mode, allowMissing).Execute()
+	}
+}
+
+// initCommand defines the init flags; it asks for what the flags leave out when run on a terminal
+func initCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var dir string
+	var variables []string
+	var yes, noGitignore, force bool
+	flags.StringVar(&dir, "dir", ".", "Directory to create the files in")
+	flags.StringArrayVar(&variables, "var", nil, "Variable to start with, as KEY=value (can be specified multiple times)")
+	flags.BoolVarP(&yes, "yes", "y", false, "Do not ask anything, using the flags and defaults")
+	flags.BoolVar(&noGitignore, "no-gitignore", false, "Leave .gitignore alone instead of adding the env file patterns")
+	flags.BoolVar(&force, "force", false, "Overwrite files that already exist")
+	return func(args []string) error {
+		if len(args) > 0 {
+			return errors.New("usage: envvars-cli init [--dir <dir>] [--var KEY=value]... [--yes]")
+		}
+		interactive := !yes && term.IsTerminal(int(os.Stdin.Fd()))
+		return commands.CreateInitCommand(dir, variables, interactive, !noGitignore, force).Execute()
+	}
+}
+
+// doctorCommand defines the doctor flags; the command fails when a check fails
+func doctorCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var files []string
+	var configPath string
+	flags.StringArrayVarP(&files, "file", "f", nil, "Env, JSON, or YAML file, directory, or glob pattern to check (can be specified multiple times)")
+	flags.StringVar(&configPath, "config", "", "Project config whose sources to check when no files are given (default: envvars.yaml or .envvarsrc found by walking up from the working directory)")
+	return func(args []string) error {
+		return commands.CreateDoctorCommand(append(files, args...), configPath).Execute()
+	}
+}
+
+// dockerArgsCommand defines the docker-args flags
+func dockerArgsCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var files []string
+	var as string
+	var secretsDir string
+	var print0 bool
+	flags.StringArrayVarP(&files, "file", "f", nil, "Env, JSON, or YAML file, directory, or glob pattern to merge (can be specified multiple times)")
+	flags.StringVar(&as, "as", commands.DockerBuildArgs, "Pass the variables as build-args (--build-arg) or secrets (--secret files)")
+	flags.StringVar(&secretsDir, "secrets-dir", "", "Directory to write one file per variable to with --as secrets")
+	flags.BoolVarP(&print0, "print0", "0", false, "Write the arguments NUL-terminated (for xargs -0) instead of shell-quoted")
+	return func(args []string) error {
+		return commands.CreateDockerArgsCommand(append(files, args...), as, secretsDir, print0).Execute()
+	}
+}
+
+// runCommand defines the run flags; the command exits with the program's status when it fails
+func runCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	// Everything from the program name on belongs to the program
+	flags.SetInterspersed(false)
+	var files []string
+	var clean bool
+	var watch bool
+	var verbosity int
+	flags.StringArrayVarP(&files, "file", "f", nil, "Env, JSON, or YAML file, directory, or glob pattern to merge (can be specified multiple times)")
+	flags.BoolVar(&clean, "clean", false, "Run the program with only the merged variables instead of adding them to the current environment")
+	flags.BoolVar(&watch, "watch", false, "Restart the program with the new variables whenever a file changes, until interrupted")
+	flags.CountVarP(&verbosity, "verbose", "V", "Increase output on stderr: -V shows progress, -VV adds per-variable detail")
+	return func(args []string) error {
+		logging.SetLevel(verbosity)
+		return commands.CreateRunCommand(files, args, clean, watch).Execute()
+	}
+}
+
+// diffCommand defines the diff flags; the command exits 1 when the sides differ and 2 on
+// errors, like diff(1)
+func diffCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var files []string
+	var from []string
+	var to []string
+	var format string
+	var showSecrets bool
+	flags.StringArrayVarP(&files, "file", "f", nil, "File to compare; give exactly two to compare them with each other")
+	flags.StringArrayVar(&from, "from", nil, "File or source spec merged into the first side (can be specified multiple times)")
+	flags.StringArrayVar(&to, "to", nil, "File or source spec merged into the second side (can be specified multiple times)")
+	flags.StringVar(&format, "format", "text", "Report format: text or json")
+	flags.BoolVar(&showSecrets, "show-secrets", false, "Show changed secret values instead of masking them")
+	return func(args []string) error {
+		files = append(files, args...)
+		if len(files) > 0 {
+			if len(files) != 2 || len(from) > 0 || len(to) > 0 {
+				return errors.New("usage: envvars-cli diff <first> <second>, or diff --from <file>... --to <file>...")
+			}
+			from, to = files[:1], files[1:]
+		}
+		return commands.CreateDiffCommand(from, to, format, showSecrets).Execute()
+	}
+}
+
+// kubectlCommand defines the flags of the kubectl plugin's subcommands; only apply exists
+func kubectlCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var files []string
+	var target string
+	var namespace string
+	var kubeContext string
+	var prune bool
+	var verbosity int
+	flags.StringArrayVarP(&files, "file", "f", nil, "Env, JSON, or YAML file, directory, or glob pattern to merge (can be specified multiple times)")
+	flags.StringVar(&target, "as", "", "Object to write the variables to: secret/NAME or configmap/NAME")
+	flags.StringVarP(&namespace, "namespace", "n", "", "Namespace of the object (default: the context's namespace)")
+	flags.StringVar(&kubeContext, "context", "", "kubeconfig context to use")
+	flags.BoolVar(&prune, "prune", false, "Remove keys from the object that the files no longer set")
+	flags.CountVarP(&verbosity, "verbose", "V", "Increase output on stderr: -VV shows the kubectl commands run")
+	return func(args []string) error {
+		if len(args) == 0 || args[0] != "apply" {
+			return errors.New("usage: kubectl envvars apply -f <file> --as secret/NAME|configmap/NAME [-n <namespace>] [--prune]")
+		}
+		logging.SetLevel(verbosity)
+		return commands.CreateKubectlApplyCommand(append(files, args[1:]...), target, namespace, kubeContext, prune).Execute()
+	}
+}
+
+// signatureCommand defines the sign and verify flags; the command fails when signing fails or
+// the signature does not verify
+func signatureCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	var keyPath string
+	var signature string
+	flags.StringVarP(&keyPath, "key", "k", "", "PEM file with the Ed25519 private key (sign) or public key (verify)")
+	flags.StringVar(&signature, "signature", "", "Signature file (default: <bundle>.sig)")
+	return func(args []string) error {
+		if keyPath == "" || len(args) != 1 {
+			return fmt.Errorf("usage: envvars-cli %s --key <key.pem> [--signature <file>] <bundle>", name)
+		}
+		if name == "sign" {
+			return commands.CreateSignCommand(args[0], keyPath, signature).Execute()
+		}
+		if err := commands.CreateVerifyCommand(args[0], keyPath, signature).Execute(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Verified '%s'\n", args[0])
+		return nil
+	}
+}
+
+// direnvCommand writes the direnv snippet defining use_envvars
+func direnvCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		return commands.WriteDirenvStdlib(os.Stdout)
+	}
+}
+
+// gitHubActionCommand runs the merge as a GitHub Actions step. All of its settings come from
+// the step's INPUT_* variables, so it takes no other arguments.
+func gitHubActionCommand(name string, flags *pflag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) > 0 {
+			return errors.New("--github-action takes its settings from INPUT_* variables, not arguments")
+		}
+		logging.SetLevel(logging.LevelInfo)
+		return commands.CreateGitHubActionCommand(os.Getenv).Execute()
+	}
+}
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/spf13/pflag"
 )
 
 func TestMain(t *testing.T) {
@@ -13,6 +19,37 @@ func TestMain(t *testing.T) {
 	})
 }
 
+func TestComplete(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envPath, []byte("DATABASE_URL=postgres://db\nDEBUG=true\nPORT=80\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	root := pflag.NewFlagSet("envvars-cli", pflag.ContinueOnError)
+	root.StringP("format", "f", "env", "")
+	root.Bool("fail-if-empty", false, "")
+
+	tests := []struct {
+		words    []string
+		expected []string
+	}{
+		{[]string{"un"}, []string{"unset"}},
+		{[]string{"e"}, []string{"encrypt", "exec"}},
+		{[]string{"--f"}, []string{"--fail-if-empty", "--format"}},
+		{[]string{"completion", "p"}, []string{"powershell"}},
+		{[]string{"get", "-f", envPath, "D"}, []string{"DATABASE_URL", "DEBUG"}},
+		{[]string{"set", "--file=" + envPath, "P"}, []string{"PORT="}},
+		{[]string{"unset", "--al"}, []string{"--all-files"}},
+		{[]string{"get", "-f", ""}, nil},
+		{[]string{"lint", ""}, nil},
+	}
+	for _, test := range tests {
+		if candidates := complete(test.words, root); !reflect.DeepEqual(candidates, test.expected) {
+			t.Errorf("Expected %v for %q, got %v", test.expected, test.words, candidates)
+		}
+	}
+}
+
 // Example test function
 func ExampleMain() {
 	// This is an example test that demonstrates usage
@@ -20,3 +57,47 @@ func ExampleMain() {
 	// Add your example usage here
 }
 
+func TestDefineSourceFlags(t *testing.T) {
+	var args []sourceArg
+	flags := pflag.NewFlagSet("envvars-cli", pflag.ContinueOnError)
+	defineSourceFlags(flags, &args)
+	flags.StringP("format", "f", "env", "")
+
+	err := flags.Parse([]string{
+		"--env", "a.env", "--ini=b.ini", "-ec.env", "-f", "json", "-j", "d.json",
+		"--source=type=yaml,path=e.yaml", "--toml=f.toml", "--hcl", "g.tfvars", "--properties=h.properties",
+		"--k8s=k8s://app/configmap/web", "-e", "-", "--yaml=i.yaml",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := []sourceArg{
+		{"env", "a.env"},
+		{"ini", "b.ini"},
+		{"env", "c.env"},
+		{"json", "d.json"},
+		{"", "type=yaml,path=e.yaml"},
+		{"toml", "f.toml"},
+		{"hcl", "g.tfvars"},
+		{"properties", "h.properties"},
+		{"k8s", "k8s://app/configmap/web"},
+		{"env", "-"},
+		{"yaml", "i.yaml"},
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected %v, got %v", expected, args)
+	}
+}
+
+func TestDefineSourceFlags_SourceUsageListsTypes(t *testing.T) {
+	var args []sourceArg
+	flags := pflag.NewFlagSet("envvars-cli", pflag.ContinueOnError)
+	defineSourceFlags(flags, &args)
+
+	usage := flags.Lookup("source").Usage
+	expected := "type=env|json|yaml|ini|toml|hcl|properties|sops|k8s,"
+	if !strings.Contains(usage, expected) {
+		t.Errorf("Expected --source usage to contain %q, got: %s", expected, usage)
+	}
+}
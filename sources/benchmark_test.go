@@ -0,0 +1,161 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// writeBenchmarkFile writes content to a temporary file and returns its path
+func writeBenchmarkFile(b *testing.B, pattern string, content string) string {
+	b.Helper()
+
+	tempFile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		b.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer tempFile.Close()
+
+	if _, err := tempFile.WriteString(content); err != nil {
+		b.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	b.Cleanup(func() { os.Remove(tempFile.Name()) })
+	return tempFile.Name()
+}
+
+// generateEnvContent builds an env file with n keys, quoted values, and variable references
+func generateEnvContent(n int) string {
+	var builder strings.Builder
+	builder.WriteString("# Generated benchmark file\n")
+	for i := 0; i < n; i++ {
+		switch i % 3 {
+		case 0:
+			fmt.Fprintf(&builder, "KEY_%d=value_%d\n", i, i)
+		case 1:
+			fmt.Fprintf(&builder, "KEY_%d=\"quoted value %d\"\n", i, i)
+		default:
+			fmt.Fprintf(&builder, "KEY_%d=${KEY_%d}_suffix\n", i, i-2)
+		}
+	}
+	return builder.String()
+}
+
+// silenceStderr redirects stderr to the null device for the duration of the benchmark
+func silenceStderr(b *testing.B) {
+	b.Helper()
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatalf("Failed to open %s: %v", os.DevNull, err)
+	}
+	original := os.Stderr
+	os.Stderr = devNull
+	b.Cleanup(func() {
+		os.Stderr = original
+		devNull.Close()
+	})
+}
+
+func BenchmarkParseEnvFile_10k(b *testing.B) {
+	path := writeBenchmarkFile(b, "bench-*.env", generateEnvContent(10000))
+	options := Options{FilePath: path}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseEnvFile(options); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessFileWithMerge_10k(b *testing.B) {
+	path := writeBenchmarkFile(b, "bench-*.env", generateEnvContent(10000))
+	options := Options{FilePath: path}
+
+	existing := make(map[string]string, 10000)
+	for i := 0; i < 10000; i++ {
+		existing[fmt.Sprintf("EXISTING_%d", i)] = "value"
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ProcessFileWithMerge(existing, options); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMergeFile_10k(b *testing.B) {
+	path := writeBenchmarkFile(b, "bench-*.env", generateEnvContent(10000))
+	options := Options{FilePath: path}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		kvs := NewVariables()
+		if err := MergeFile(kvs, options); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkApplyFilterDirectives_10k(b *testing.B) {
+	silenceStderr(b)
+
+	directives := []Directive{
+		{Name: "filter", Arguments: []string{"KEY_1*", "*_5"}, Line: 1},
+		{Name: "filter-unless", Arguments: []string{"KEY_*"}, Line: 2},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		kvs := make(map[string]string, 10000)
+		for j := 0; j < 10000; j++ {
+			kvs[fmt.Sprintf("KEY_%d", j)] = "value"
+		}
+		b.StartTimer()
+
+		applyFilterDirectives(kvs, directives)
+		applyFilterUnlessDirectives(kvs, directives)
+	}
+}
+
+func BenchmarkJSONProcessor_ProcessFile_10k(b *testing.B) {
+	data := make(map[string]interface{}, 10000)
+	for i := 0; i < 10000; i++ {
+		data[fmt.Sprintf("KEY_%d", i)] = fmt.Sprintf("value_%d", i)
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	path := writeBenchmarkFile(b, "bench-*.json", string(content))
+	processor := CreateJSONProcessor()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := processor.ProcessFile(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStringifyValue(b *testing.B) {
+	values := []interface{}{"text", true, 42, int64(1 << 40), 3.14159, uint(7)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, value := range values {
+			_ = stringifyValue(value)
+		}
+	}
+}
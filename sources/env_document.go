@@ -0,0 +1,176 @@
+package sources
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// EnvDocument is an env file as written, for editing its assignments without disturbing
+// anything else: comments, blank lines, order, line endings, and a byte order mark are kept
+type EnvDocument struct {
+	bom   string
+	lines []envLine
+}
+
+// envLine is a physical line of an EnvDocument
+type envLine struct {
+	text   string // The line without its ending
+	ending string // "\n", "\r\n", or "" for a last line without one
+}
+
+// EnvAssignment locates a KEY=value assignment in an EnvDocument; a double-quoted value may
+// span several lines
+type EnvAssignment struct {
+	Key    string // The key, without an "export" keyword
+	Line   int    // Line the assignment starts on
+	Value  string // Raw value as written, with its quotes but without an inline comment
+	Export bool   // Whether the assignment starts with "export"
+
+	first     int    // Index of the first line
+	last      int    // Index of the last line
+	text      string // The assignment's lines joined with newlines
+	keyOffset int    // Offset of the key in text
+	offset    int    // Offset of the value in text
+}
+
+// Quote returns the quote character the value is written with, or 0 when it is unquoted or
+// its quoting is malformed
+func (a EnvAssignment) Quote() byte {
+	token, err := scanValue(a.Value)
+	if err != nil {
+		return 0
+	}
+	return token.quote
+}
+
+// ParseEnvDocument splits env file content into lines
+func ParseEnvDocument(data []byte) *EnvDocument {
+	document := &EnvDocument{}
+	content := string(data)
+	if rest, found := strings.CutPrefix(content, utf8BOM); found {
+		document.bom, content = utf8BOM, rest
+	}
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		text, ending := strings.TrimSuffix(line, "\n"), ""
+		if len(text) < len(line) {
+			ending = "\n"
+			if rest, found := strings.CutSuffix(text, "\r"); found {
+				text, ending = rest, "\r\n"
+			}
+		}
+		document.lines = append(document.lines, envLine{text: text, ending: ending})
+	}
+	return document
+}
+
+// ReadEnvDocument reads the env file at filePath
+func ReadEnvDocument(filePath string) (*EnvDocument, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file '%s': %w", filePath, err)
+	}
+	return ParseEnvDocument(data), nil
+}
+
+// Assignments returns the document's assignments in order. Lines that are blank, comments, or
+// have no "=" are not assignments; the parser reports the malformed ones.
+func (d *EnvDocument) Assignments() []EnvAssignment {
+	var assignments []EnvAssignment
+	for i := 0; i < len(d.lines); i++ {
+		text := d.lines[i].text
+		trimmed := strings.TrimSpace(text)
+		eq := strings.IndexByte(text, '=')
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || eq < 0 {
+			continue
+		}
+
+		// A double-quoted value that is not closed continues onto the following lines
+		last := i
+		joined := text
+		for last+1 < len(d.lines) {
+			if _, value, _ := splitAssignment(strings.TrimSpace(joined)); !isOpenDoubleQuote(value) {
+				break
+			}
+			last++
+			joined += "\n" + d.lines[last].text
+		}
+
+		written := strings.TrimSpace(joined[:eq])
+		keyEnd := strings.LastIndex(joined[:eq], written) + len(written)
+		key := stripExport(written)
+		rest := joined[eq+1:]
+		value := strings.TrimLeft(rest, " \t")
+		assignments = append(assignments, EnvAssignment{
+			Key:       key,
+			Line:      i + 1,
+			Value:     strings.TrimRight(stripInlineComment(value), " \t"),
+			Export:    key != written,
+			first:     i,
+			last:      last,
+			text:      joined,
+			keyOffset: keyEnd - len(key),
+			offset:    eq + 1 + len(rest) - len(value),
+		})
+		i = last
+	}
+	return assignments
+}
+
+// SetValue replaces the raw value of an assignment, keeping the rest of its line (the key,
+// spacing, and any inline comment). The document's assignments must be listed again afterwards.
+func (d *EnvDocument) SetValue(assignment EnvAssignment, raw string) {
+	text := assignment.text[:assignment.offset] + raw + assignment.text[assignment.offset+len(assignment.Value):]
+	d.replaceLines(assignment.first, assignment.last, strings.Split(text, "\n"))
+}
+
+// Remove deletes an assignment's lines. The document's assignments must be listed again
+// afterwards.
+func (d *EnvDocument) Remove(assignment EnvAssignment) {
+	d.replaceLines(assignment.first, assignment.last, nil)
+}
+
+// Append adds a line at the end of the document, using the line ending of its other lines
+func (d *EnvDocument) Append(text string) {
+	ending := "\n"
+	if len(d.lines) > 0 {
+		if last := &d.lines[len(d.lines)-1]; last.ending == "" {
+			last.ending = ending
+			if len(d.lines) > 1 {
+				last.ending = d.lines[len(d.lines)-2].ending
+			}
+		}
+		ending = d.lines[len(d.lines)-1].ending
+	}
+	d.lines = append(d.lines, envLine{text: text, ending: ending})
+}
+
+// replaceLines replaces the lines first through last with texts, which end like the last one
+func (d *EnvDocument) replaceLines(first int, last int, texts []string) {
+	replacement := make([]envLine, len(texts))
+	for i, text := range texts {
+		replacement[i] = envLine{text: text, ending: "\n"}
+		if d.lines[last].ending == "\r\n" {
+			replacement[i].ending = "\r\n"
+		}
+	}
+	if len(replacement) > 0 {
+		replacement[len(replacement)-1].ending = d.lines[last].ending
+	}
+	d.lines = slices.Replace(d.lines, first, last+1, replacement...)
+}
+
+// Bytes returns the document's content
+func (d *EnvDocument) Bytes() []byte {
+	var builder strings.Builder
+	builder.WriteString(d.bom)
+	for _, line := range d.lines {
+		builder.WriteString(line.text)
+		builder.WriteString(line.ending)
+	}
+	return []byte(builder.String())
+}
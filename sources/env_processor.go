@@ -3,10 +3,18 @@ package sources
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/notwillk/envvars-cli/logging"
 )
 
 // Directive represents a processing directive
@@ -14,11 +22,48 @@ type Directive struct {
 	Name      string   `json:"name"`
 	Arguments []string `json:"arguments"`
 	Line      int      `json:"line"`
+	File      string   `json:"file"`
 }
 
+// DefaultMaxLineSize is the longest line (in bytes) the env parser accepts by default
+const DefaultMaxLineSize = 1024 * 1024
+
+// Modes for handling a key assigned more than once in the same file
+const (
+	DuplicatesLast  = "last"  // The last assignment wins (default)
+	DuplicatesFirst = "first" // The first assignment wins
+	DuplicatesWarn  = "warn"  // Warn on stderr; the last assignment wins
+	DuplicatesError = "error" // Fail to parse the file
+)
+
 // Options contains configuration for file operations
 type Options struct {
-	FilePath string `json:"file_path"`
+	FilePath    string `json:"file_path"`
+	MaxLineSize int    `json:"max_line_size"` // Maximum line length in bytes (0 uses DefaultMaxLineSize)
+	Duplicates  string `json:"duplicates"`    // How to handle duplicate keys within the file (empty uses DuplicatesLast)
+	StrictParse bool   `json:"strict_parse"`  // Fail on lines that are neither comments nor KEY=value assignments
+	NoEscape    bool   `json:"no_escape"`     // Keep the escape sequences of double-quoted values as written
+	InvalidKeys string `json:"invalid_keys"`  // How to handle keys that are not valid variable names (empty uses InvalidKeysWarn)
+	StrictEmpty bool   `json:"strict_empty"`  // Fail on source files with no content instead of treating them as empty
+	// Treat #require'd keys that are set to an empty string as missing (by default they pass with a warning)
+	RequireNonEmpty bool `json:"require_nonempty"`
+	// Directive names the file may use, in any case (nil allows every directive)
+	AllowedDirectives []string `json:"allowed_directives"`
+	// Flattening of nested JSON/YAML/SOPS structures
+	Delimiter    string `json:"delimiter"`      // Joins nested keys (empty uses DefaultDelimiter)
+	NestedAsJSON bool   `json:"nested_as_json"` // Emit nested values as JSON strings instead of flattening them
+	// Masking of secret values in messages: keys matching these patterns hold secrets in addition
+	// to DefaultSecretPatterns and #secret keys, and ShowSecrets disables masking
+	SecretPatterns []string `json:"secret_patterns"`
+	ShowSecrets    bool     `json:"show_secrets"`
+	// Key settings for decrypting SOPS files
+	SOPSKeys SOPSKeyOptions `json:"sops_keys"`
+	// Content to parse instead of the file at FilePath, such as standard input; FilePath still
+	// names the source in messages
+	Reader io.Reader `json:"-"`
+
+	// Absolute paths of the files being included, outermost first, for detecting cycles
+	includeChain []string
 }
 
 // EnvVar represents a single environment variable
@@ -26,6 +71,7 @@ type EnvVar struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
 	File  string `json:"file"`
+	Line  int    `json:"line"`
 }
 
 // EnvFile represents a parsed environment file
@@ -33,74 +79,98 @@ type EnvFile struct {
 	Filename   string      `json:"filename"`
 	Variables  []EnvVar    `json:"variables"`
 	Directives []Directive `json:"directives"`
+	Includes   []string    `json:"includes,omitempty"` // Files inlined by #include, in the order read
 }
 
 // ProcessFileWithMerge takes existing key-value pairs and options,
 // then outputs merged key-value pairs with file values taking precedence
 func ProcessFileWithMerge(existingKVs map[string]string, options Options) (map[string]string, error) {
+	// Copy once so the caller's map is left untouched
+	mergedVars := VariablesFromMap(existingKVs)
+
+	if err := MergeFile(mergedVars, options); err != nil {
+		return nil, err
+	}
+
+	return mergedVars.Map(), nil
+}
+
+// MergeFile parses the environment file from options and merges it into kvs in place,
+// applying the file's directives. It avoids the intermediate copies made by ProcessFileWithMerge
+// and is intended for callers that merge many sources into a single map.
+func MergeFile(kvs *Variables, options Options) error {
 	// Parse the environment file from options
-	envFile, err := parseEnvFile(options.FilePath)
+	envFile, err := ParseFile(options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse file '%s': %w", options.FilePath, err)
+		return err
 	}
 
-	// First, apply remove directives to existing key-value pairs
-	processedKVs := applyRemoveDirectives(existingKVs, envFile.Directives)
+	return ApplyEnvFileWithOptions(kvs, envFile, options)
+}
 
-	// Merge variables (file values take precedence over existing values)
-	mergedVars := make(map[string]string)
+// ParseFile parses the environment file from options without merging it.
+// The result can be cached and later applied with ApplyEnvFile.
+func ParseFile(options Options) (EnvFile, error) {
+	return parseEnvFile(options)
+}
 
-	// First, add existing variables (after remove directive processing)
-	for key, value := range processedKVs {
-		mergedVars[key] = value
-	}
+// ApplyEnvFile merges a parsed environment file into kvs in place, applying its directives
+func ApplyEnvFile(kvs *Variables, envFile EnvFile) error {
+	return ApplyEnvFileWithOptions(kvs, envFile, Options{})
+}
+
+// ApplyEnvFileWithOptions merges a parsed environment file into kvs in place, applying its
+// directives as configured by options
+func ApplyEnvFileWithOptions(kvs *Variables, envFile EnvFile, options Options) error {
+	// First, apply remove directives to existing key-value pairs
+	applyRemoveDirectives(kvs.Map(), envFile.Directives)
 
 	// Then, add file variables (overriding existing ones)
 	for _, variable := range envFile.Variables {
-		mergedVars[variable.Key] = variable.Value
+		kvs.Set(variable.Key, variable.Value)
 	}
 
 	// Apply filter directives to remove variables based on patterns
-	mergedVars = applyFilterDirectives(mergedVars, envFile.Directives)
+	applyFilterDirectives(kvs.Map(), envFile.Directives)
 
 	// Apply filter-unless directives to keep only variables matching patterns
-	mergedVars = applyFilterUnlessDirectives(mergedVars, envFile.Directives)
+	applyFilterUnlessDirectives(kvs.Map(), envFile.Directives)
 
 	// Finally, apply require directives to the final merged result
-	if err := applyRequireDirectives(mergedVars, envFile.Directives); err != nil {
-		return nil, err
-	}
-
-	return mergedVars, nil
+	return applyRequireDirectives(kvs.Map(), envFile.Directives, options.RequireNonEmpty)
 }
 
-// applyRemoveDirectives applies only remove directives to the key-value pairs
+// applyRemoveDirectives applies only remove directives to the key-value pairs in place
 func applyRemoveDirectives(kvs map[string]string, directives []Directive) map[string]string {
-	result := make(map[string]string)
-
-	// Copy existing key-value pairs
-	for key, value := range kvs {
-		result[key] = value
-	}
-
-	// Apply only remove directives
 	for _, directive := range directives {
 		if strings.ToLower(directive.Name) == "remove" {
-			applyRemoveDirective(result, directive)
+			applyRemoveDirective(kvs, directive)
 		}
 	}
 
-	return result
+	return kvs
 }
 
-// applyRequireDirectives applies only require directives to the key-value pairs
-func applyRequireDirectives(kvs map[string]string, directives []Directive) error {
+// applyRequireDirectives applies only require directives to the key-value pairs.
+// Required keys set to an empty string fail when nonEmpty is set and are warned about otherwise.
+func applyRequireDirectives(kvs map[string]string, directives []Directive, nonEmpty bool) error {
 	// Apply only require directives
 	for _, directive := range directives {
-		if strings.ToLower(directive.Name) == "require" {
-			if err := applyRequireDirective(kvs, directive); err != nil {
-				return err
+		if strings.ToLower(directive.Name) != "require" {
+			continue
+		}
+		if err := applyRequireDirective(kvs, directive); err != nil {
+			return newParseError(directive.File, directive.Line, fmt.Errorf("%w (required at line %d of '%s')", err, directive.Line, directive.File))
+		}
+
+		for _, arg := range directive.Arguments {
+			if kvs[arg] != "" {
+				continue
+			}
+			if nonEmpty {
+				return newParseError(directive.File, directive.Line, fmt.Errorf("required environment variable '%s' is empty (required at line %d of '%s')", arg, directive.Line, directive.File))
 			}
+			logging.Warnf("required environment variable '%s' is empty (required at line %d of '%s')", arg, directive.Line, directive.File)
 		}
 	}
 
@@ -149,9 +219,45 @@ func parseDirective(line string, lineNumber int) (Directive, error) {
 	return directive, nil
 }
 
+// newLineScanner creates a line scanner that accepts lines up to maxLineSize bytes
+func newLineScanner(r io.Reader, maxLineSize int) *bufio.Scanner {
+	if maxLineSize <= 0 {
+		maxLineSize = DefaultMaxLineSize
+	}
+	scanner := bufio.NewScanner(r)
+	initialSize := bufio.MaxScanTokenSize
+	if maxLineSize < initialSize {
+		initialSize = maxLineSize
+	}
+	scanner.Buffer(make([]byte, 0, initialSize), maxLineSize)
+	return scanner
+}
+
+// scanError converts a scanner error into a descriptive error naming the file and line
+func scanError(err error, filePath string, lineNumber int, maxLineSize int) error {
+	if errors.Is(err, bufio.ErrTooLong) {
+		if maxLineSize <= 0 {
+			maxLineSize = DefaultMaxLineSize
+		}
+		return newParseError(filePath, lineNumber, fmt.Errorf("line too long in file '%s' at line %d (limit is %d bytes)", filePath, lineNumber, maxLineSize))
+	}
+	return fmt.Errorf("error reading file '%s': %w", filePath, err)
+}
+
 // parseEnvFile reads and parses an environment variable file
-func parseEnvFile(filePath string) (EnvFile, error) {
-	file, err := os.Open(filePath)
+func parseEnvFile(options Options) (EnvFile, error) {
+	filePath := options.FilePath
+	switch options.Duplicates {
+	case "", DuplicatesLast, DuplicatesFirst, DuplicatesWarn, DuplicatesError:
+	default:
+		return EnvFile{}, fmt.Errorf("unsupported duplicates mode: %s", options.Duplicates)
+	}
+	keys, err := newKeyValidator(options)
+	if err != nil {
+		return EnvFile{}, err
+	}
+
+	file, err := openInput(options)
 	if err != nil {
 		return EnvFile{}, fmt.Errorf("failed to open file '%s': %w", filePath, err)
 	}
@@ -162,32 +268,58 @@ func parseEnvFile(filePath string) (EnvFile, error) {
 	envFile.Variables = []EnvVar{}
 	envFile.Directives = []Directive{}
 
-	scanner := bufio.NewScanner(file)
-	lineNumber := 0
-	variables := make(map[string]string) // For variable reference resolution
-
-	// First pass: collect all variables and directives
-	for scanner.Scan() {
-		lineNumber++
-		line := strings.TrimSpace(scanner.Text())
+	reader := newEnvReader(file, options)
+	firstLines := make(map[string]int) // Line of each key's first assignment, for duplicate reports
+	var rawValues []string             // Values as written, parallel to envFile.Variables
+	inlined := make(map[int]bool)      // Indexes of variables from included files, already resolved
+	blank := true
+
+	// Collect all variables and directives in a single pass
+	for {
+		line, lineNumber, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return EnvFile{}, err
+		}
 
 		// Skip empty lines
 		if line == "" {
 			continue
 		}
+		blank = false
 
 		// Handle directives
-		if strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "# ") {
-			// Check if it's a directive (not a regular comment)
-			directiveText := strings.TrimSpace(strings.TrimPrefix(line, "#"))
-			if directiveText != "" && !strings.HasPrefix(directiveText, " ") {
-				directive, err := parseDirective(line, lineNumber)
+		if isDirectiveLine(line) {
+			directive, err := parseDirective(line, lineNumber)
+			if err != nil {
+				return EnvFile{}, newParseError(filePath, lineNumber, fmt.Errorf("failed to parse directive at line %d of '%s': %w", lineNumber, filePath, err))
+			}
+			if !directiveAllowed(options, directive.Name) {
+				return EnvFile{}, newParseError(filePath, lineNumber, fmt.Errorf("directive '%s' at line %d of '%s' is not allowed by the directive policy", directive.Name, lineNumber, filePath))
+			}
+			directive.File = filePath
+			envFile.Directives = append(envFile.Directives, directive)
+			// Keys marked #secret are masked in messages about the rest of the file
+			options.SecretPatterns = append(slices.Clip(options.SecretPatterns), SecretPatterns([]Directive{directive})...)
+
+			// Included variables and directives are inlined where the directive appears
+			if strings.EqualFold(directive.Name, "include") {
+				included, err := includeFile(options, directive)
 				if err != nil {
-					return EnvFile{}, fmt.Errorf("failed to parse directive at line %d: %w", lineNumber, err)
+					return EnvFile{}, err
 				}
-				envFile.Directives = append(envFile.Directives, directive)
-				continue
+				for _, variable := range included.Variables {
+					inlined[len(envFile.Variables)] = true
+					rawValues = append(rawValues, "")
+					envFile.Variables = append(envFile.Variables, variable)
+				}
+				envFile.Directives = append(envFile.Directives, included.Directives...)
+				envFile.Includes = append(envFile.Includes, included.Includes...)
+				options.SecretPatterns = append(slices.Clip(options.SecretPatterns), SecretPatterns(included.Directives)...)
 			}
+			continue
 		}
 
 		// Skip regular comments
@@ -195,153 +327,417 @@ func parseEnvFile(filePath string) (EnvFile, error) {
 			continue
 		}
 
-		// Parse key=value pairs
-		if strings.Contains(line, "=") {
-			parts := strings.SplitN(line, "=", 2)
-			key := strings.TrimSpace(parts[0])
-			value := ""
-			if len(parts) > 1 {
-				value = strings.TrimSpace(parts[1])
+		if !strings.Contains(line, "=") {
+			if err := malformedLine(options, lineNumber, reader.column); err != nil {
+				return EnvFile{}, err
 			}
+			continue
+		}
 
-			if key != "" && isValidKey(key) {
-				// Unquote the value
-				value = unquoteValue(value)
-				variables[key] = value
+		// Parse key=value pairs
+		key, raw, ok, err := parseAssignment(line, keys, options, lineNumber, reader.column)
+		if err != nil {
+			return EnvFile{}, err
+		}
+		if ok {
+			if firstLine, seen := firstLines[key]; seen {
+				switch options.Duplicates {
+				case DuplicatesError:
+					return EnvFile{}, newParseError(filePath, lineNumber, fmt.Errorf("duplicate key '%s' at lines %d and %d of '%s'", key, firstLine, lineNumber, filePath))
+				case DuplicatesWarn:
+					logging.Warnf("duplicate key '%s' at lines %d and %d of '%s'", key, firstLine, lineNumber, filePath)
+				case DuplicatesFirst:
+					continue
+				}
+			} else {
+				firstLines[key] = lineNumber
 			}
+
+			value := unquoteRaw(raw, options.NoEscape)
+			rawValues = append(rawValues, raw)
+			envFile.Variables = append(envFile.Variables, EnvVar{
+				Key:   key,
+				Value: value,
+				File:  filePath,
+				Line:  lineNumber,
+			})
 		}
 	}
 
-	// Second pass: resolve variable references and create EnvVar structs
-	file.Seek(0, 0) // Reset file pointer
-	scanner = bufio.NewScanner(file)
-	lineNumber = 0
-
-	for scanner.Scan() {
-		lineNumber++
-		line := strings.TrimSpace(scanner.Text())
+	keys.warn()
+	if blank {
+		return emptySource(options)
+	}
 
-		// Skip empty lines
-		if line == "" {
-			continue
+	// Resolve variable references once every variable in the file is known
+	resolver := newReferenceResolver(envFile.Variables, rawValues, inlined, options)
+	for i := range envFile.Variables {
+		value, err := resolver.resolve(i)
+		if err != nil {
+			return EnvFile{}, err
 		}
+		envFile.Variables[i].Value = value
+	}
 
-		// Handle directives (skip in second pass as they're already collected)
-		if strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "# ") {
-			directiveText := strings.TrimSpace(strings.TrimPrefix(line, "#"))
-			if directiveText != "" && !strings.HasPrefix(directiveText, " ") {
-				continue
-			}
+	return envFile, nil
+}
+
+// malformedLine reports a line that is neither a comment nor an assignment, starting at column:
+// an error in strict mode, otherwise a warning so typos like DEBUGtrue don't vanish silently
+func malformedLine(options Options, lineNumber int, column int) error {
+	if options.StrictParse {
+		return newParseErrorAt(options.FilePath, lineNumber, column, fmt.Errorf("malformed line %d, column %d in '%s': expected KEY=value", lineNumber, column, options.FilePath))
+	}
+	logging.Warnf("ignoring malformed line %d in '%s': expected KEY=value", lineNumber, options.FilePath)
+	return nil
+}
+
+// directiveNames lists the recognized directives, in lowercase
+var directiveNames = map[string]bool{
+	"remove":        true,
+	"require":       true,
+	"filter":        true,
+	"filter-unless": true,
+	"secret":        true,
+	"include":       true,
+}
+
+// IsDirectiveName reports whether name (in any case) is a recognized directive
+func IsDirectiveName(name string) bool {
+	return directiveNames[strings.ToLower(name)]
+}
+
+// isDirectiveLine reports whether a trimmed line is a directive rather than a regular comment.
+// A directive is "#" immediately followed by a recognized directive name (in any case) and then
+// whitespace or the end of the line, so "#remove KEY" is a directive while "# remove KEY",
+// "#TODO fix", and commented-out assignments like "#KEY=value" are all plain comments.
+func isDirectiveLine(line string) bool {
+	text, found := strings.CutPrefix(line, "#")
+	if !found {
+		return false
+	}
+	name := text
+	if end := strings.IndexAny(text, " \t"); end >= 0 {
+		name = text[:end]
+	}
+	return IsDirectiveName(name)
+}
+
+// directiveAllowed reports whether options permit the named directive
+func directiveAllowed(options Options, name string) bool {
+	if options.AllowedDirectives == nil {
+		return true
+	}
+	for _, allowed := range options.AllowedDirectives {
+		if strings.EqualFold(allowed, name) {
+			return true
 		}
+	}
+	return false
+}
 
-		// Skip regular comments
-		if strings.HasPrefix(line, "#") {
-			continue
+// parseAssignment parses a trimmed KEY=value line of the options' file, which starts at column, into
+// its key and raw (still quoted) value, checking the key with keys and the value's quoting. It returns
+// ok=false when the line is not an assignment or the key is dropped. In strict mode, keys that would be
+// dropped with a warning are errors.
+func parseAssignment(line string, keys *keyValidator, options Options, lineNumber int, column int) (key string, value string, ok bool, err error) {
+	key, value, ok = splitAssignment(line)
+	if !ok {
+		return "", "", false, nil
+	}
+
+	filePath := options.FilePath
+	if _, err := scanValue(value); err != nil {
+		// The error may quote part of the value, so only describe it for secrets
+		if !options.ShowSecrets && IsSecretKey(key, options.SecretPatterns) {
+			err = fmt.Errorf("malformed quoted value (details hidden for secret)")
 		}
+		column += valueOffset(line)
+		return "", "", false, newParseErrorAt(filePath, lineNumber, column, fmt.Errorf("%w for '%s' at line %d, column %d of '%s'", err, key, lineNumber, column, filePath))
+	}
 
-		// Parse key=value pairs
-		if strings.Contains(line, "=") {
-			parts := strings.SplitN(line, "=", 2)
-			key := strings.TrimSpace(parts[0])
-			value := ""
-			if len(parts) > 1 {
-				value = strings.TrimSpace(parts[1])
-			}
+	if options.StrictParse && !isValidKey(key) && (options.InvalidKeys == "" || options.InvalidKeys == InvalidKeysWarn) {
+		written := strings.TrimRight(line[:strings.IndexByte(line, '=')], " \t")
+		column += utf8.RuneCountInString(written) - utf8.RuneCountInString(key)
+		return "", "", false, newParseErrorAt(filePath, lineNumber, column, fmt.Errorf("invalid key '%s' at line %d, column %d of '%s'", key, lineNumber, column, filePath))
+	}
 
-			if key != "" && isValidKey(key) {
-				// Unquote and resolve variable references
-				value = unquoteValue(value)
-				value = resolveVariableReferences(value, variables)
+	key, ok, err = keys.check(key, lineNumber)
+	if !ok {
+		return "", "", false, err
+	}
 
-				envVar := EnvVar{
-					Key:   key,
-					Value: value,
-					File:  filePath,
-				}
-				envFile.Variables = append(envFile.Variables, envVar)
+	return key, value, true, nil
+}
+
+// splitAssignment splits a KEY=value line into its trimmed key and raw value.
+// A leading shell "export" keyword is dropped so shell-sourceable files can be read directly,
+// and a trailing inline comment is removed from the value.
+func splitAssignment(line string) (key string, value string, ok bool) {
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return stripExport(strings.TrimSpace(parts[0])), strings.TrimSpace(stripInlineComment(parts[1])), true
+}
+
+// valueOffset returns the column offset, in characters, of the raw value in a KEY=value line
+func valueOffset(line string) int {
+	eq := strings.IndexByte(line, '=')
+	rest := line[eq+1:]
+	return utf8.RuneCountInString(line[:eq+1]) + len(rest) - len(strings.TrimLeft(rest, " \t"))
+}
+
+// stripInlineComment removes a trailing "# comment" from a raw value. For unquoted values the
+// "#" must follow whitespace (so KEY=#fff is kept); a "#" inside a quoted value is never a comment.
+func stripInlineComment(value string) string {
+	trimmed := strings.TrimLeft(value, " \t")
+	if trimmed == "" {
+		return value
+	}
+
+	// Quoted values end at their closing quote; only a comment may follow it
+	if quote := trimmed[0]; quote == '"' || quote == '\'' {
+		if end := closingQuote(trimmed); end >= 0 {
+			if rest := strings.TrimSpace(trimmed[end+1:]); strings.HasPrefix(rest, "#") {
+				return trimmed[:end+1]
 			}
 		}
+		return value
 	}
 
-	if err := scanner.Err(); err != nil {
-		return EnvFile{}, fmt.Errorf("error reading file '%s': %w", filePath, err)
+	for i := 0; i < len(value); i++ {
+		if value[i] == '#' && i > 0 && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return value[:i]
+		}
 	}
+	return value
+}
 
-	return envFile, nil
+// stripExport removes a leading "export" keyword from a key
+func stripExport(key string) string {
+	if rest, found := strings.CutPrefix(key, "export"); found && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+		return strings.TrimSpace(rest)
+	}
+	return key
+}
+
+// isOpenDoubleQuote reports whether a raw value starts a double-quoted string that is not closed on the same line
+func isOpenDoubleQuote(value string) bool {
+	return strings.HasPrefix(value, "\"") && closingQuote(value) < 0
 }
 
+// validKeyPattern matches POSIX-style environment variable names
+var validKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// variableReferencePattern matches ${VAR_NAME} references
+var variableReferencePattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// wildcardPatterns caches compiled wildcard patterns, keyed by lowercased pattern
+var wildcardPatterns sync.Map
+
 // isValidKey checks if a key matches the required regex pattern
 func isValidKey(key string) bool {
-	matched, _ := regexp.MatchString(`^[A-Za-z_][A-Za-z0-9_]*$`, key)
-	return matched
+	return validKeyPattern.MatchString(key)
+}
+
+// IsValidKey reports whether key is a valid variable name: letters, digits, and underscores,
+// not starting with a digit
+func IsValidKey(key string) bool {
+	return isValidKey(key)
 }
 
 // unquoteValue removes quotes and handles escape sequences
 func unquoteValue(value string) string {
 	value = strings.TrimSpace(value)
 
-	// Handle single quotes
-	if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
-		value = strings.Trim(value, "'")
-		// Replace escaped single quotes
-		value = strings.ReplaceAll(value, "\\'", "'")
-		return value
+	// A lone quote character has no content
+	if value == "'" || value == "\"" {
+		return ""
 	}
 
-	// Handle double quotes
-	if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-		value = strings.Trim(value, "\"")
-		// Replace escaped double quotes
-		value = strings.ReplaceAll(value, "\\\"", "\"")
+	// Malformed quoting is reported by the parser; such values are kept as written
+	token, err := scanValue(value)
+	if err != nil {
 		return value
 	}
 
-	return value
+	switch token.quote {
+	case '\'':
+		// Single-quoted values are taken literally
+		return token.body
+	case '"':
+		return unescapeDoubleQuoted(token.body)
+	}
+	return token.body
+}
+
+// unquoteRaw unquotes a raw value like unquoteValue, but keeps the escape sequences of a
+// double-quoted value as written when noEscape is set
+func unquoteRaw(raw string, noEscape bool) string {
+	if token, err := scanValue(strings.TrimSpace(raw)); noEscape && err == nil && token.quote == '"' {
+		return token.body
+	}
+	return unquoteValue(raw)
+}
+
+// unescapeDoubleQuoted expands the escape sequences recognized inside double-quoted values:
+// \n, \t, \r, \\, \", \$ and \uXXXX. Any other backslash is kept as written.
+func unescapeDoubleQuoted(value string) string {
+	// Without variables there are no references to fail
+	unescaped, _ := expandDoubleQuoted(value, nil)
+	return unescaped
 }
 
-// resolveVariableReferences replaces ${VAR_NAME} with actual values
-func resolveVariableReferences(value string, variables map[string]string) string {
-	// Use regex to find and replace variable references
-	re := regexp.MustCompile(`\$\{([^}]+)\}`)
-	return re.ReplaceAllStringFunc(value, func(match string) string {
-		// Extract variable name from ${VAR_NAME}
-		varName := match[2 : len(match)-1]
-		if val, exists := variables[varName]; exists {
+// expandValue unquotes a raw value and resolves its ${VAR} references against variables.
+// Single-quoted values are taken literally, and in double-quoted values a reference can be
+// escaped as \${VAR}, unless noEscape keeps its escape sequences as written. It fails when a
+// ${VAR:?message} reference names an unset variable.
+func expandValue(raw string, variables map[string]string, noEscape bool) (string, error) {
+	token, err := scanValue(strings.TrimSpace(raw))
+	if err != nil {
+		return unquoteValue(raw), nil
+	}
+
+	switch token.quote {
+	case '\'':
+		return token.body, nil
+	case '"':
+		if noEscape {
+			return resolveVariableReferences(token.body, variables)
+		}
+		return expandDoubleQuoted(token.body, variables)
+	}
+	return resolveVariableReferences(token.body, variables)
+}
+
+// expandDoubleQuoted expands the escape sequences of a double-quoted value and, when variables
+// is not nil, resolves its unescaped ${VAR} references
+func expandDoubleQuoted(value string, variables map[string]string) (string, error) {
+	if !strings.Contains(value, "\\") && (variables == nil || !strings.Contains(value, "${")) {
+		return value, nil
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(value))
+	for i := 0; i < len(value); i++ {
+		if variables != nil && strings.HasPrefix(value[i:], "${") {
+			if end := strings.IndexByte(value[i+2:], '}'); end > 0 {
+				resolved, exists, err := ResolveReference(value[i+2:i+2+end], variables)
+				if err != nil {
+					return "", err
+				}
+				if exists {
+					builder.WriteString(resolved)
+				} else {
+					// If variable not found, keep the original reference
+					builder.WriteString(value[i : i+3+end])
+				}
+				i += 2 + end
+				continue
+			}
+		}
+
+		if value[i] != '\\' || i+1 == len(value) {
+			builder.WriteByte(value[i])
+			continue
+		}
+
+		switch value[i+1] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 't':
+			builder.WriteByte('\t')
+		case 'r':
+			builder.WriteByte('\r')
+		case '\\', '"', '$':
+			builder.WriteByte(value[i+1])
+		case 'u':
+			if i+6 <= len(value) {
+				if code, err := strconv.ParseUint(value[i+2:i+6], 16, 32); err == nil {
+					builder.WriteRune(rune(code))
+					i += 5
+					continue
+				}
+			}
+			builder.WriteString(value[i : i+2])
+		default:
+			builder.WriteString(value[i : i+2])
+		}
+		i++
+	}
+
+	return builder.String(), nil
+}
+
+// ResolveReference resolves the expression inside a ${...} reference: a variable name;
+// NAME:-fallback, which resolves to fallback when the variable is unset or empty; or
+// NAME:?message, which fails with message in that case. It reports false when the reference
+// is left as written.
+func ResolveReference(expression string, variables map[string]string) (string, bool, error) {
+	name, operator, operand := splitReference(expression)
+	value, exists := variables[name]
+	if value == "" {
+		switch operator {
+		case ":-":
+			return operand, true, nil
+		case ":?":
+			if operand == "" {
+				return "", false, fmt.Errorf("variable '%s' is unset or empty", name)
+			}
+			return "", false, fmt.Errorf("variable '%s' is unset or empty: %s", name, operand)
+		}
+	}
+	return value, exists, nil
+}
+
+// splitReference splits the expression inside a ${...} reference into the variable's name and
+// any :- or :? operator with its operand
+func splitReference(expression string) (name string, operator string, operand string) {
+	if i := strings.Index(expression, ":"); i >= 0 && i+1 < len(expression) && (expression[i+1] == '-' || expression[i+1] == '?') {
+		return expression[:i], expression[i : i+2], expression[i+2:]
+	}
+	return expression, "", ""
+}
+
+// resolveVariableReferences replaces ${VAR_NAME}, ${VAR_NAME:-fallback}, and ${VAR_NAME:?message}
+// with actual values
+func resolveVariableReferences(value string, variables map[string]string) (string, error) {
+	// Skip the regex entirely for values without references
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	// Use regex to find and replace variable references, keeping the first failure
+	var resolveErr error
+	resolved := variableReferencePattern.ReplaceAllStringFunc(value, func(match string) string {
+		// Extract the expression from ${...}
+		val, exists, err := ResolveReference(match[2:len(match)-1], variables)
+		if err != nil && resolveErr == nil {
+			resolveErr = err
+		}
+		if exists {
 			return val
 		}
 		// If variable not found, return the original match
 		return match
 	})
+	return resolved, resolveErr
 }
 
-// applyFilterDirectives applies filter directives to remove variables based on patterns
+// applyFilterDirectives applies filter directives in place to remove variables based on patterns
 func applyFilterDirectives(kvs map[string]string, directives []Directive) map[string]string {
-	result := make(map[string]string)
-
-	// Copy existing key-value pairs
-	for key, value := range kvs {
-		result[key] = value
-	}
-
-	// Apply filter directives
 	for _, directive := range directives {
 		if strings.ToLower(directive.Name) == "filter" {
-			applyFilterDirective(result, directive)
+			applyFilterDirective(kvs, directive)
 		}
 	}
 
-	return result
+	return kvs
 }
 
-// applyFilterUnlessDirectives applies filter-unless directives to keep only variables matching patterns
+// applyFilterUnlessDirectives applies filter-unless directives in place to keep only variables matching patterns
 func applyFilterUnlessDirectives(kvs map[string]string, directives []Directive) map[string]string {
-	result := make(map[string]string)
-
-	// Copy existing key-value pairs
-	for key, value := range kvs {
-		result[key] = value
-	}
-
 	// Collect all patterns from all filter-unless directives
 	var allPatterns []string
 	for _, directive := range directives {
@@ -352,47 +748,54 @@ func applyFilterUnlessDirectives(kvs map[string]string, directives []Directive)
 
 	// If no patterns, keep all keys (no filtering)
 	if len(allPatterns) == 0 {
-		return result
+		return kvs
 	}
 
-	// Find keys to keep (those matching any of the patterns)
-	keysToKeep := make(map[string]bool)
-	for key := range result {
+	// Remove keys that don't match any of the patterns
+	for key := range kvs {
+		keep := false
 		for _, pattern := range allPatterns {
 			if matchesPattern(key, pattern) {
-				keysToKeep[key] = true
-				fmt.Fprintf(os.Stderr, "DEBUG: Keeping key %q (matches pattern %q)\n", key, pattern)
+				keep = true
+				logging.Debugf("Keeping key %q (matches pattern %q)", key, pattern)
 				break // Key matches at least one pattern, so keep it
 			}
 		}
-	}
-
-	// Remove keys that don't match any pattern
-	for key := range result {
-		if !keysToKeep[key] {
-			fmt.Fprintf(os.Stderr, "DEBUG: Removing key %q (doesn't match any pattern)\n", key)
-			delete(result, key)
+		if !keep {
+			logging.Debugf("Removing key %q (doesn't match any pattern)", key)
+			delete(kvs, key)
 		}
 	}
 
-	return result
+	return kvs
 }
 
 // applyFilterDirective removes environment variables based on the filter directive
 func applyFilterDirective(kvs map[string]string, directive Directive) {
-	fmt.Fprintf(os.Stderr, "DEBUG: Applying filter directive: %+v\n", directive)
+	logging.Debugf("Applying filter directive: %+v", directive)
 	for _, arg := range directive.Arguments {
-		fmt.Fprintf(os.Stderr, "DEBUG: Filtering with pattern: %q\n", arg)
+		logging.Debugf("Filtering with pattern: %q", arg)
 		// Remove keys matching the pattern (case-insensitive)
 		for key := range kvs {
 			if matchesPattern(key, arg) {
-				fmt.Fprintf(os.Stderr, "DEBUG: Removing key %q (matches pattern %q)\n", key, arg)
+				logging.Debugf("Removing key %q (matches pattern %q)", key, arg)
 				delete(kvs, key)
 			}
 		}
 	}
 }
 
+// MatchesAnyPattern reports whether key matches any of the patterns, using the same
+// case-insensitive "*" wildcards as the filter directives
+func MatchesAnyPattern(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchesPattern(key, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
 // matchesPattern checks if a key matches a pattern with wildcard support
 func matchesPattern(key, pattern string) bool {
 	// Convert both to lowercase for case-insensitive matching
@@ -410,17 +813,26 @@ func matchesPattern(key, pattern string) bool {
 
 // matchesWildcardPattern checks if a key matches a wildcard pattern
 func matchesWildcardPattern(key, pattern string) bool {
+	if cached, ok := wildcardPatterns.Load(pattern); ok {
+		if cached == nil {
+			return key == pattern
+		}
+		return cached.(*regexp.Regexp).MatchString(key)
+	}
+
 	// Convert wildcard pattern to regex
 	regexPattern := strings.ReplaceAll(pattern, "*", ".*")
 	regexPattern = "^" + regexPattern + "$"
 
-	matched, err := regexp.MatchString(regexPattern, key)
+	re, err := regexp.Compile(regexPattern)
 	if err != nil {
 		// If regex compilation fails, fall back to exact match
+		wildcardPatterns.Store(pattern, nil)
 		return key == pattern
 	}
+	wildcardPatterns.Store(pattern, re)
 
-	return matched
+	return re.MatchString(key)
 }
 
 // parseOptionsFile reads and parses a JSON options file
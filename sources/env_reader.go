@@ -0,0 +1,77 @@
+package sources
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// envReader yields the logical lines of an env file, ignoring a UTF-8 BOM and CRLF line endings.
+// A double-quoted value left open at the end of a line continues onto the following lines, which
+// are joined with newlines, so PEM keys and JSON blobs can be written verbatim.
+type envReader struct {
+	scanner     *bufio.Scanner
+	filePath    string
+	maxLineSize int
+	lineNumber  int
+	column      int // Column the last logical line starts at, after its indentation
+}
+
+// newEnvReader creates a reader over r for the file described by options
+func newEnvReader(r io.Reader, options Options) *envReader {
+	return &envReader{
+		scanner:     newLineScanner(r, options.MaxLineSize),
+		filePath:    options.FilePath,
+		maxLineSize: options.MaxLineSize,
+	}
+}
+
+// next returns the next trimmed logical line and the line number it starts on,
+// or io.EOF once the input is exhausted
+func (r *envReader) next() (string, int, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", r.lineNumber + 1, scanError(err, r.filePath, r.lineNumber+1, r.maxLineSize)
+		}
+		return "", r.lineNumber, io.EOF
+	}
+	r.lineNumber++
+	startLine := r.lineNumber
+	text := normalizeLine(r.scanner.Text())
+	if startLine == 1 {
+		text = strings.TrimPrefix(text, utf8BOM)
+	}
+	line := strings.TrimSpace(text)
+	r.column = utf8.RuneCountInString(text[:len(text)-len(strings.TrimLeft(text, " \t"))]) + 1
+
+	// Comments never continue onto the next line
+	if strings.HasPrefix(line, "#") {
+		return line, startLine, nil
+	}
+
+	key, value, ok := splitAssignment(line)
+	if !ok || !isOpenDoubleQuote(value) {
+		return line, startLine, nil
+	}
+
+	// Keep reading until the double-quoted value is closed
+	var builder strings.Builder
+	builder.WriteString(line)
+	for isOpenDoubleQuote(value) {
+		if !r.scanner.Scan() {
+			if err := r.scanner.Err(); err != nil {
+				return "", r.lineNumber + 1, scanError(err, r.filePath, r.lineNumber+1, r.maxLineSize)
+			}
+			column := r.column + valueOffset(line)
+			return "", startLine, newParseErrorAt(r.filePath, startLine, column, fmt.Errorf("unterminated double-quoted value for '%s' starting at line %d, column %d of '%s'", key, startLine, column, r.filePath))
+		}
+		r.lineNumber++
+		builder.WriteString("\n")
+		builder.WriteString(normalizeLine(r.scanner.Text()))
+		_, value, _ = splitAssignment(builder.String())
+	}
+
+	return strings.TrimSpace(builder.String()), startLine, nil
+}
@@ -0,0 +1,69 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+)
+
+// ParseError is a parse, directive, or validation error tied to a location in a source file
+type ParseError struct {
+	File   string // Path of the file the error was found in
+	Line   int    // 1-based line number (0 when the error applies to the whole file)
+	Column int    // 1-based column, counted in characters (0 when not known)
+	Err    error
+}
+
+// Error returns the underlying message, which already names the location
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError attaches a file and line to err
+func newParseError(file string, line int, err error) error {
+	return &ParseError{File: file, Line: line, Err: err}
+}
+
+// newParseErrorAt attaches a file, line, and column to err
+func newParseErrorAt(file string, line int, column int, err error) error {
+	return &ParseError{File: file, Line: line, Column: column, Err: err}
+}
+
+// yamlErrorLinePattern extracts the line number from yaml.v3 error messages
+var yamlErrorLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// jsonErrorLine returns the line a JSON decoding error occurred on, or 0 if unknown
+func jsonErrorLine(data []byte, err error) int {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return 0
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// yamlErrorLine returns the line a YAML decoding error occurred on, or 0 if unknown
+func yamlErrorLine(err error) int {
+	match := yamlErrorLinePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(match[1])
+	return line
+}
@@ -0,0 +1,126 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultDelimiter joins the keys of nested structures when they are flattened
+const DefaultDelimiter = "_"
+
+// flattener turns decoded JSON/YAML values into variables. Nested maps become one variable per
+// leaf, named by joining the keys along the path with the delimiter and emitted in document
+// order; arrays of scalars become comma-separated values, and other arrays are encoded as JSON.
+type flattener struct {
+	delimiter string
+	asJSON    bool // Emit nested maps and arrays as JSON strings instead of flattening them
+	upper     bool // Uppercase variable names
+	filePath  string
+	keys      *keyValidator
+	order     keyOrder // Key order of the document's objects, or nil to sort keys
+}
+
+// newFlattener creates a flattener configured by options, checking keys with keys and
+// following the key order of order
+func newFlattener(options Options, keys *keyValidator, order keyOrder) *flattener {
+	delimiter := options.Delimiter
+	if delimiter == "" {
+		delimiter = DefaultDelimiter
+	}
+	return &flattener{
+		delimiter: delimiter,
+		asJSON:    options.NestedAsJSON,
+		filePath:  options.FilePath,
+		keys:      keys,
+		order:     order,
+	}
+}
+
+// flattenMap appends the variables for every entry of data, the object at path in the document,
+// with names prefixed by prefix. The joined names are checked, as the delimiter may not be
+// valid in variable names.
+func (f *flattener) flattenMap(prefix string, path []string, data map[string]interface{}, variables *[]EnvVar) error {
+	for _, name := range orderedKeys(data, f.order.keys(path)) {
+		key := name
+		if prefix != "" {
+			key = prefix + f.delimiter + name
+		}
+		key, ok, err := f.keys.check(key, 0)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if err := f.add(key, append(path[:len(path):len(path)], name), data[name], variables); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add appends the variables for value, found at path in the document, under the (already
+// checked) name key
+func (f *flattener) add(key string, path []string, value interface{}, variables *[]EnvVar) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if f.asJSON {
+			return f.addJSON(key, v, variables)
+		}
+		return f.flattenMap(key, path, v, variables)
+	case map[interface{}]interface{}:
+		return f.add(key, path, stringKeys(v), variables)
+	case []interface{}:
+		if f.asJSON || !allScalars(v) {
+			return f.addJSON(key, v, variables)
+		}
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, stringifyValue(item))
+		}
+		f.append(key, strings.Join(values, ","), variables)
+	default:
+		f.append(key, stringifyValue(v), variables)
+	}
+	return nil
+}
+
+// addJSON appends value encoded as a JSON string
+func (f *flattener) addJSON(key string, value interface{}, variables *[]EnvVar) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return newParseError(f.filePath, 0, fmt.Errorf("failed to encode '%s' in '%s' as JSON: %w", key, f.filePath, err))
+	}
+	f.append(key, string(encoded), variables)
+	return nil
+}
+
+// append adds a single variable
+func (f *flattener) append(key string, value string, variables *[]EnvVar) {
+	if f.upper {
+		key = strings.ToUpper(key)
+	}
+	*variables = append(*variables, EnvVar{Key: key, Value: value, File: f.filePath})
+}
+
+// allScalars reports whether an array holds no maps or nested arrays
+func allScalars(values []interface{}) bool {
+	for _, value := range values {
+		switch value.(type) {
+		case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+// stringKeys converts a map with arbitrary keys (as YAML may produce) to one keyed by strings
+func stringKeys(data map[interface{}]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		result[stringifyValue(key)] = value
+	}
+	return result
+}
@@ -0,0 +1,555 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// hclNumberPattern matches HCL number literals
+var hclNumberPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// hclParser decodes the literal values of an HCL document (such as a Terraform .tfvars file)
+// into the values the JSON decoder would produce: objects become maps and numbers json.Number.
+// Expressions that need evaluating, such as references and function calls, are rejected.
+type hclParser struct {
+	data     []byte
+	pos      int
+	filePath string
+	root     map[string]interface{}
+	order    keyOrder        // Keys of every object in the order they appear
+	seen     map[string]bool // Top-level keys, including those set to null
+	path     []string        // Path of the value being parsed, which objects are found at
+}
+
+// parseHCL decodes data, returning its top-level attributes and the defaults of its variable
+// blocks, along with the order of the keys in every object. Other blocks, and null values (which Terraform treats
+// as unset), are skipped.
+func parseHCL(data []byte, filePath string) (map[string]interface{}, keyOrder, error) {
+	p := &hclParser{data: data, filePath: filePath, root: make(map[string]interface{}), order: keyOrder{}, seen: make(map[string]bool)}
+	if err := p.parseBody(); err != nil {
+		return nil, nil, err
+	}
+	return p.root, p.order, nil
+}
+
+// errorf returns a parse error for the current position
+func (p *hclParser) errorf(format string, args ...interface{}) error {
+	line := bytes.Count(p.data[:min(p.pos, len(p.data))], []byte("\n")) + 1
+	return newParseError(p.filePath, line, fmt.Errorf("%s at line %d of '%s'", fmt.Sprintf(format, args...), line, p.filePath))
+}
+
+// set records a top-level value, failing when the key is already set
+func (p *hclParser) set(key string, value interface{}) error {
+	if p.seen[key] {
+		return p.errorf("'%s' is defined more than once", key)
+	}
+	p.seen[key] = true
+	if value == nil {
+		return nil
+	}
+	p.root[key] = value
+	p.order.add(nil, key)
+	return nil
+}
+
+// peek returns the byte offset bytes ahead, or 0 past the end
+func (p *hclParser) peek(offset int) byte {
+	if p.pos+offset < len(p.data) {
+		return p.data[p.pos+offset]
+	}
+	return 0
+}
+
+// consume advances past prefix if the input continues with it
+func (p *hclParser) consume(prefix string) bool {
+	if bytes.HasPrefix(p.data[p.pos:], []byte(prefix)) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// skipSpace advances past whitespace and comments, and past newlines too when newlines is set.
+// Line comments end at their newline, which is left in place.
+func (p *hclParser) skipSpace(newlines bool) error {
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c == ' ' || c == '\t':
+			p.pos++
+		case c == '\n' && newlines:
+			p.pos++
+		case c == '#' || c == '/' && p.peek(1) == '/':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == '/' && p.peek(1) == '*':
+			end := bytes.Index(p.data[p.pos+2:], []byte("*/"))
+			if end == -1 {
+				return p.errorf("unterminated comment")
+			}
+			p.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseIdentifier reads an identifier, returning "" when there is none
+func (p *hclParser) parseIdentifier() string {
+	start := p.pos
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '_' || p.pos > start && (c >= '0' && c <= '9' || c == '-')) {
+			break
+		}
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+// parseBody reads the top-level attributes and blocks of the document
+func (p *hclParser) parseBody() error {
+	for {
+		if err := p.skipSpace(true); err != nil {
+			return err
+		}
+		if p.pos >= len(p.data) {
+			return nil
+		}
+
+		name := p.parseIdentifier()
+		if name == "" {
+			return p.errorf("unexpected '%c'", p.data[p.pos])
+		}
+		if err := p.skipSpace(false); err != nil {
+			return err
+		}
+
+		if p.consume("=") {
+			p.path = []string{name}
+			value, err := p.parseExpression()
+			if err != nil {
+				return err
+			}
+			if err := p.set(name, value); err != nil {
+				return err
+			}
+		} else if err := p.parseBlock(name, true); err != nil {
+			return err
+		}
+
+		if err := p.skipLineEnd(); err != nil {
+			return err
+		}
+	}
+}
+
+// parseBlock reads a block after its type name. The default of a top-level variable block is
+// recorded under the variable's name; the contents of other blocks are skipped.
+func (p *hclParser) parseBlock(blockType string, top bool) error {
+	var labels []string
+	for !p.consume("{") {
+		var label string
+		switch {
+		case p.consume(`"`):
+			value, err := p.parseQuotedString()
+			if err != nil {
+				return err
+			}
+			label = value
+		default:
+			label = p.parseIdentifier()
+			if label == "" {
+				return p.errorf("expected = or a block after '%s'", blockType)
+			}
+		}
+		labels = append(labels, label)
+		if err := p.skipSpace(false); err != nil {
+			return err
+		}
+	}
+
+	if !top || blockType != "variable" || len(labels) != 1 {
+		return p.skipBlock()
+	}
+
+	// Read the attributes of the variable block, keeping its default
+	for {
+		if err := p.skipSpace(true); err != nil {
+			return err
+		}
+		if p.consume("}") {
+			return nil
+		}
+		if p.pos >= len(p.data) {
+			return p.errorf("expected } to close the block")
+		}
+		name := p.parseIdentifier()
+		if name == "" {
+			return p.errorf("unexpected '%c'", p.data[p.pos])
+		}
+		if err := p.skipSpace(false); err != nil {
+			return err
+		}
+		if !p.consume("=") {
+			// A nested block, such as validation
+			if err := p.parseBlock(name, false); err != nil {
+				return err
+			}
+		} else if name == "default" {
+			p.path = labels[:1]
+			value, err := p.parseExpression()
+			if err != nil {
+				return err
+			}
+			if err := p.set(labels[0], value); err != nil {
+				return err
+			}
+		} else if err := p.skipExpression(); err != nil {
+			return err
+		}
+		if err := p.skipLineEnd(); err != nil {
+			return err
+		}
+	}
+}
+
+// skipBlock advances past the contents of a block after its opening brace, however they nest
+func (p *hclParser) skipBlock() error {
+	depth := 1
+	for depth > 0 {
+		if err := p.skipSpace(true); err != nil {
+			return err
+		}
+		if p.pos >= len(p.data) {
+			return p.errorf("expected } to close the block")
+		}
+		switch p.data[p.pos] {
+		case '{':
+			depth++
+			p.pos++
+		case '}':
+			depth--
+			p.pos++
+		case '"':
+			p.pos++
+			if _, err := p.parseQuotedString(); err != nil {
+				return err
+			}
+		case '<':
+			if p.peek(1) == '<' {
+				if _, err := p.parseHeredoc(); err != nil {
+					return err
+				}
+				continue
+			}
+			p.pos++
+		default:
+			p.pos++
+		}
+	}
+	return nil
+}
+
+// skipExpression advances past an attribute value that is not needed, which may be an
+// expression the parser cannot evaluate, such as a type constraint
+func (p *hclParser) skipExpression() error {
+	depth := 0
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c == '\n' && depth == 0:
+			return nil
+		case c == '#' || c == '/' && (p.peek(1) == '/' || p.peek(1) == '*'):
+			if err := p.skipSpace(false); err != nil {
+				return err
+			}
+		case c == '"':
+			p.pos++
+			if _, err := p.parseQuotedString(); err != nil {
+				return err
+			}
+		case c == '<' && p.peek(1) == '<':
+			if _, err := p.parseHeredoc(); err != nil {
+				return err
+			}
+		case c == '(' || c == '[' || c == '{':
+			depth++
+			p.pos++
+		case c == ')' || c == ']' || c == '}':
+			if depth == 0 {
+				return nil
+			}
+			depth--
+			p.pos++
+		default:
+			p.pos++
+		}
+	}
+	return nil
+}
+
+// skipLineEnd advances past trailing whitespace and comments to the end of the line, failing
+// when anything else follows
+func (p *hclParser) skipLineEnd() error {
+	if err := p.skipSpace(false); err != nil {
+		return err
+	}
+	if p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '}' {
+		return p.errorf("unexpected '%c' after value", p.data[p.pos])
+	}
+	return nil
+}
+
+// parseExpression reads a literal value: a string, heredoc, number, boolean, null, tuple, or object
+func (p *hclParser) parseExpression() (interface{}, error) {
+	if err := p.skipSpace(false); err != nil {
+		return nil, err
+	}
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("expected a value")
+	}
+
+	switch c := p.data[p.pos]; {
+	case c == '"':
+		p.pos++
+		return p.parseQuotedString()
+	case c == '<' && p.peek(1) == '<':
+		return p.parseHeredoc()
+	case c == '[':
+		p.pos++
+		return p.parseTuple()
+	case c == '{':
+		p.pos++
+		return p.parseObject()
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.data) && (strings.IndexByte("0123456789.eE", p.data[p.pos]) >= 0 || (p.data[p.pos] == '+' || p.data[p.pos] == '-') && (p.data[p.pos-1] == 'e' || p.data[p.pos-1] == 'E')) {
+			p.pos++
+		}
+		token := string(p.data[start:p.pos])
+		if !hclNumberPattern.MatchString(strings.TrimPrefix(token, "-")) {
+			p.pos = start
+			return nil, p.errorf("invalid number '%s'", token)
+		}
+		return json.Number(token), nil
+	}
+
+	start := p.pos
+	switch name := p.parseIdentifier(); name {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	default:
+		p.pos = start
+		return nil, p.errorf("unsupported expression; only literal values (strings, numbers, booleans, null, lists, and objects) can be read")
+	}
+}
+
+// parseTuple reads the elements of a list after its opening bracket
+func (p *hclParser) parseTuple() (interface{}, error) {
+	values := []interface{}{}
+	for {
+		if err := p.skipSpace(true); err != nil {
+			return nil, err
+		}
+		if p.consume("]") {
+			return values, nil
+		}
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		if err := p.skipSpace(true); err != nil {
+			return nil, err
+		}
+		if p.consume("]") {
+			return values, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or ] in list")
+		}
+	}
+}
+
+// parseObject reads the attributes of an object after its opening brace; they are separated
+// by commas or newlines, and their keys may be identifiers or strings followed by = or :
+func (p *hclParser) parseObject() (interface{}, error) {
+	object := make(map[string]interface{})
+	base := p.path
+	for {
+		if err := p.skipSpace(true); err != nil {
+			return nil, err
+		}
+		if p.consume("}") {
+			return object, nil
+		}
+
+		var key string
+		if p.consume(`"`) {
+			value, err := p.parseQuotedString()
+			if err != nil {
+				return nil, err
+			}
+			key = value
+		} else if key = p.parseIdentifier(); key == "" {
+			return nil, p.errorf("expected an object key")
+		}
+		if err := p.skipSpace(false); err != nil {
+			return nil, err
+		}
+		if !p.consume("=") && !p.consume(":") {
+			return nil, p.errorf("expected = or : after object key '%s'", key)
+		}
+		p.path = append(base[:len(base):len(base)], key)
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		p.path = base
+		object[key] = value
+		p.order.add(base, key)
+
+		if err := p.skipSpace(false); err != nil {
+			return nil, err
+		}
+		if p.consume("}") {
+			return object, nil
+		}
+		if !p.consume(",") && !p.consume("\n") {
+			return nil, p.errorf("expected , or } in object")
+		}
+	}
+}
+
+// parseQuotedString reads a double-quoted string after its opening quote. Template sequences
+// are kept as written, except that the escapes $${ and %%{ become ${ and %{.
+func (p *hclParser) parseQuotedString() (string, error) {
+	var builder strings.Builder
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c == '"':
+			p.pos++
+			return builder.String(), nil
+		case c == '\n':
+			return "", p.errorf("unterminated string")
+		case p.consume("$${"):
+			builder.WriteString("${")
+		case p.consume("%%{"):
+			builder.WriteString("%{")
+		case c == '\\':
+			if err := p.parseEscape(&builder); err != nil {
+				return "", err
+			}
+		default:
+			builder.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// parseEscape reads the escape sequence at the current position into builder
+func (p *hclParser) parseEscape(builder *strings.Builder) error {
+	if p.pos+1 >= len(p.data) {
+		return p.errorf("unterminated string")
+	}
+	escape := p.data[p.pos+1]
+	p.pos += 2
+	switch escape {
+	case 'n':
+		builder.WriteByte('\n')
+	case 'r':
+		builder.WriteByte('\r')
+	case 't':
+		builder.WriteByte('\t')
+	case '"':
+		builder.WriteByte('"')
+	case '\\':
+		builder.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if escape == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.data) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(string(p.data[p.pos:p.pos+size]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape '\\%c%s'", escape, p.data[p.pos:p.pos+size])
+		}
+		builder.WriteRune(rune(code))
+		p.pos += size
+	default:
+		p.pos -= 2
+		return p.errorf("invalid escape '\\%c'", escape)
+	}
+	return nil
+}
+
+// parseHeredoc reads a <<MARKER or indented <<-MARKER heredoc, whose value includes the final
+// newline; the indented form strips the indentation common to its lines
+func (p *hclParser) parseHeredoc() (string, error) {
+	p.pos += 2
+	indented := p.consume("-")
+	marker := p.parseIdentifier()
+	if marker == "" || !p.consume("\n") {
+		return "", p.errorf("expected a heredoc marker followed by a newline")
+	}
+
+	var lines []string
+	for p.pos < len(p.data) {
+		end := bytes.IndexByte(p.data[p.pos:], '\n')
+		if end == -1 {
+			end = len(p.data) - p.pos
+		}
+		line := string(p.data[p.pos : p.pos+end])
+		if strings.TrimSpace(line) == marker {
+			p.pos += len(line)
+			if indented {
+				lines = dedent(lines)
+			}
+			if len(lines) == 0 {
+				return "", nil
+			}
+			return strings.Join(lines, "\n") + "\n", nil
+		}
+		lines = append(lines, line)
+		p.pos += min(end+1, len(p.data)-p.pos)
+	}
+	return "", p.errorf("unterminated heredoc, expected %s", marker)
+}
+
+// dedent removes the leading whitespace common to the non-blank lines
+func dedent(lines []string) []string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == -1 || width < indent {
+			indent = width
+		}
+	}
+	if indent <= 0 {
+		return lines
+	}
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = line[min(indent, len(line)-len(strings.TrimLeft(line, " \t"))):]
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package sources
+
+import (
+	"fmt"
+)
+
+// HCLProcessor handles processing of HCL files
+type HCLProcessor struct{}
+
+// CreateHCLProcessor creates a new HCL processor instance
+func CreateHCLProcessor() *HCLProcessor {
+	return &HCLProcessor{}
+}
+
+// isValidKey checks if a key matches the required regex pattern
+func (hp *HCLProcessor) isValidKey(key string) bool {
+	return isValidKey(key)
+}
+
+// ProcessFile reads an HCL file and extracts key-value pairs
+func (hp *HCLProcessor) ProcessFile(filePath string) (map[string]string, error) {
+	envFile, err := hp.ParseFile(Options{FilePath: filePath})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(envFile.Variables))
+	for _, variable := range envFile.Variables {
+		result[variable.Key] = variable.Value
+	}
+
+	return result, nil
+}
+
+// ParseFile reads the HCL file from options, such as a Terraform .tfvars file, and extracts its
+// top-level attributes in document order, along with the defaults of variable blocks in .tf
+// files. Objects are flattened like nested JSON, so db = { port = 5432 } becomes db_port.
+func (hp *HCLProcessor) ParseFile(options Options) (EnvFile, error) {
+	filePath := options.FilePath
+	keys, err := newKeyValidator(options)
+	if err != nil {
+		return EnvFile{}, err
+	}
+
+	data, err := readInput(options)
+	if err != nil {
+		return EnvFile{}, fmt.Errorf("failed to open HCL file '%s': %w", filePath, err)
+	}
+	// Files saved on Windows may carry a BOM and CRLF line endings
+	data = normalizeContent(data)
+	if isBlank(data) {
+		return emptySource(options)
+	}
+
+	rawData, order, err := parseHCL(data, filePath)
+	if err != nil {
+		return EnvFile{}, err
+	}
+
+	// Convert to string key-value pairs in document order, filtering invalid keys and
+	// flattening objects
+	envFile := EnvFile{
+		Filename:  filePath,
+		Variables: make([]EnvVar, 0, len(rawData)),
+	}
+	flattener := newFlattener(options, keys, order)
+	for _, key := range orderedKeys(rawData, order.keys(nil)) {
+		name, ok, err := keys.check(key, 0)
+		if err != nil {
+			return EnvFile{}, err
+		}
+		if ok {
+			if err := flattener.add(name, []string{key}, rawData[key], &envFile.Variables); err != nil {
+				return EnvFile{}, err
+			}
+		}
+	}
+	keys.warn()
+
+	return envFile, nil
+}
+
+// ProcessFileWithMerge merges existing key-value pairs with those from an HCL file
+func (hp *HCLProcessor) ProcessFileWithMerge(existingKVs map[string]string, options Options) (map[string]string, error) {
+	// Process the HCL file
+	fileVars, err := hp.ProcessFile(options.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge: file values take precedence
+	mergedVars := make(map[string]string)
+
+	// First, add existing variables
+	for key, value := range existingKVs {
+		mergedVars[key] = value
+	}
+
+	// Then, add file variables (overriding existing ones)
+	for key, value := range fileVars {
+		mergedVars[key] = value
+	}
+
+	return mergedVars, nil
+}
@@ -0,0 +1,100 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHCLProcessor_ParseFile(t *testing.T) {
+	content := `# Production settings
+region        = "us-east-1"
+instance_count = 3
+ratio = 0.5
+enabled = true
+zones = ["a", "b"] // trailing comment
+tags = {
+  Team = "platform"
+  "cost-center": 42,
+}
+template = "$${name} stays literal"
+skipped = null
+/* block
+   comment */
+motd = <<-EOT
+    Welcome
+      to prod
+    EOT
+
+variable "db_port" {
+  type        = number
+  description = "Port of the database"
+  default     = 5432
+
+  validation {
+    condition     = var.db_port > 0
+    error_message = "Must be positive."
+  }
+}
+
+resource "aws_instance" "web" {
+  ami = data.aws_ami.id
+}
+`
+	path := filepath.Join(t.TempDir(), "prod.tfvars")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write HCL file: %v", err)
+	}
+
+	envFile, err := CreateHCLProcessor().ParseFile(Options{FilePath: path, InvalidKeys: InvalidKeysRelaxed})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	var keys []string
+	values := map[string]string{}
+	for _, variable := range envFile.Variables {
+		keys = append(keys, variable.Key)
+		values[variable.Key] = variable.Value
+	}
+
+	expectedKeys := []string{"region", "instance_count", "ratio", "enabled", "zones", "tags_Team", "tags_cost-center", "template", "motd", "db_port"}
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("Expected %v, got %v", expectedKeys, keys)
+	}
+	expected := map[string]string{
+		"region":           "us-east-1",
+		"instance_count":   "3",
+		"ratio":            "0.5",
+		"enabled":          "true",
+		"zones":            "a,b",
+		"tags_Team":        "platform",
+		"tags_cost-center": "42",
+		"template":         "${name} stays literal",
+		"motd":             "Welcome\n  to prod\n",
+		"db_port":          "5432",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+}
+
+func TestHCLProcessor_ParseFile_Errors(t *testing.T) {
+	tests := []struct {
+		content string
+		message string
+	}{
+		{"a = 1\na = 2\n", "'a' is defined more than once at line 2"},
+		{"a = var.other\n", "unsupported expression"},
+		{"a = \"unterminated\n", "unterminated string at line 1"},
+		{"a = 1 b = 2\n", "unexpected 'b' after value at line 1"},
+		{"a = <<EOT\nno end\n", "unterminated heredoc, expected EOT"},
+	}
+	for _, test := range tests {
+		_, err := CreateHCLProcessor().ParseFile(Options{FilePath: StdinPath, Reader: strings.NewReader(test.content)})
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("Expected error containing %q for %q, got %v", test.message, test.content, err)
+		}
+	}
+}
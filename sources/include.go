@@ -0,0 +1,62 @@
+package sources
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// MaxIncludeDepth is how deeply #include directives may nest
+const MaxIncludeDepth = 16
+
+// includeFile parses the env file named by an #include directive in the options' file. A
+// relative path is resolved against the including file's directory (or the working directory
+// for stdin). Including a file that is already being included is an error, as is nesting
+// includes more than MaxIncludeDepth deep.
+func includeFile(options Options, directive Directive) (EnvFile, error) {
+	filePath := options.FilePath
+	if len(directive.Arguments) != 1 {
+		return EnvFile{}, newParseError(filePath, directive.Line, fmt.Errorf("#include at line %d of '%s' takes exactly one file", directive.Line, filePath))
+	}
+
+	// Included paths may use forward slashes on every platform, so the same file works on Windows
+	path := filepath.FromSlash(directive.Arguments[0])
+	if !filepath.IsAbs(path) && filePath != StdinPath {
+		path = filepath.Join(filepath.Dir(filePath), path)
+	}
+
+	chain := options.includeChain
+	if len(chain) == 0 {
+		chain = []string{absolutePath(filePath)}
+	}
+	resolved := absolutePath(path)
+	if slices.Contains(chain, resolved) {
+		return EnvFile{}, newParseError(filePath, directive.Line, fmt.Errorf("include cycle at line %d of '%s': %s", directive.Line, filePath, strings.Join(append(slices.Clip(chain), resolved), " -> ")))
+	}
+	if len(chain) > MaxIncludeDepth {
+		return EnvFile{}, newParseError(filePath, directive.Line, fmt.Errorf("#include at line %d of '%s' nests more than %d files deep", directive.Line, filePath, MaxIncludeDepth))
+	}
+
+	included := options
+	included.FilePath = path
+	included.Reader = nil
+	included.includeChain = append(slices.Clip(chain), resolved)
+	envFile, err := parseEnvFile(included)
+	if err != nil {
+		return EnvFile{}, fmt.Errorf("failed to include '%s' at line %d of '%s': %w", path, directive.Line, filePath, err)
+	}
+	envFile.Includes = append([]string{path}, envFile.Includes...)
+	return envFile, nil
+}
+
+// absolutePath returns path made absolute, or path itself when that fails
+func absolutePath(path string) string {
+	if path == StdinPath {
+		return path
+	}
+	if absolute, err := filepath.Abs(path); err == nil {
+		return absolute
+	}
+	return path
+}
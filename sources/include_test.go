@@ -0,0 +1,106 @@
+package sources
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFile_Include(t *testing.T) {
+	dir := t.TempDir()
+	sharedDir := filepath.Join(dir, "shared")
+	if err := os.Mkdir(sharedDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	files := map[string]string{
+		filepath.Join(sharedDir, "base.env"):  "HOST=localhost\nPORT=80\n#include ports.env\n",
+		filepath.Join(sharedDir, "ports.env"): "#secret TOKEN\nPORT=8080\nTOKEN=abc\n",
+		filepath.Join(dir, "prod.env"):        "#include shared/base.env\nHOST=example.com\nURL=${HOST}:${PORT}\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	envFile, err := ParseFile(Options{FilePath: filepath.Join(dir, "prod.env")})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	kvs := NewVariables()
+	if err := ApplyEnvFile(kvs, envFile); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := map[string]string{"HOST": "example.com", "PORT": "8080", "TOKEN": "abc", "URL": "example.com:8080"}
+	if !reflect.DeepEqual(kvs.Map(), expected) {
+		t.Errorf("Expected %v, got %v", expected, kvs.Map())
+	}
+
+	expectedIncludes := []string{filepath.Join(dir, "shared/base.env"), filepath.Join(sharedDir, "ports.env")}
+	if !reflect.DeepEqual(envFile.Includes, expectedIncludes) {
+		t.Errorf("Expected includes %v, got %v", expectedIncludes, envFile.Includes)
+	}
+	if variable := envFile.Variables[0]; variable.File != filepath.Join(dir, "shared/base.env") || variable.Line != 1 {
+		t.Errorf("Expected included variables to keep their file and line, got %+v", variable)
+	}
+	if patterns := SecretPatterns(envFile.Directives); !reflect.DeepEqual(patterns, []string{"TOKEN"}) {
+		t.Errorf("Expected included #secret directives, got %v", patterns)
+	}
+}
+
+func TestParseFile_IncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.env":       "#include b.env\n",
+		"b.env":       "#include a.env\n",
+		"missing.env": "#include nowhere.env\n",
+		"args.env":    "#include one.env two.env\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		file     string
+		contains string
+	}{
+		{"a.env", "include cycle"},
+		{"missing.env", "failed to include"},
+		{"args.env", "takes exactly one file"},
+	}
+	for _, tt := range tests {
+		_, err := ParseFile(Options{FilePath: filepath.Join(dir, tt.file)})
+		if err == nil || !strings.Contains(err.Error(), tt.contains) {
+			t.Errorf("Expected error containing %q for %s, got: %v", tt.contains, tt.file, err)
+			continue
+		}
+		var parseErr *ParseError
+		if tt.file != "missing.env" && (!errors.As(err, &parseErr) || parseErr.Line != 1) {
+			t.Errorf("Expected a ParseError at line 1 for %s, got: %v", tt.file, err)
+		}
+	}
+}
+
+func TestParseFile_IncludeDepth(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i <= MaxIncludeDepth+1; i++ {
+		content := "KEY=value\n"
+		if i <= MaxIncludeDepth {
+			content = "#include " + strings.Repeat("n", i+1) + ".env\n"
+		}
+		if err := os.WriteFile(filepath.Join(dir, strings.Repeat("n", i)+".env"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	_, err := ParseFile(Options{FilePath: filepath.Join(dir, ".env")})
+	if err == nil || !strings.Contains(err.Error(), "nests more than") {
+		t.Errorf("Expected a depth error, got: %v", err)
+	}
+}
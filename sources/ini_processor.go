@@ -0,0 +1,149 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// INIProcessor handles processing of INI files
+type INIProcessor struct{}
+
+// CreateINIProcessor creates a new INI processor instance
+func CreateINIProcessor() *INIProcessor {
+	return &INIProcessor{}
+}
+
+// isValidKey checks if a key matches the required regex pattern
+func (ip *INIProcessor) isValidKey(key string) bool {
+	return isValidKey(key)
+}
+
+// ProcessFile reads an INI file and extracts key-value pairs
+func (ip *INIProcessor) ProcessFile(filePath string) (map[string]string, error) {
+	envFile, err := ip.ParseFile(Options{FilePath: filePath})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(envFile.Variables))
+	for _, variable := range envFile.Variables {
+		result[variable.Key] = variable.Value
+	}
+
+	return result, nil
+}
+
+// ParseFile reads the INI file from options and extracts its variables in document order.
+// Keys under a [section] are prefixed with the section name and the delimiter (SECTION_KEY by
+// default), with the dots of nested sections such as [server.http] also becoming delimiters.
+// Lines starting with ; or # are comments, as is the rest of an unquoted value after " ;" or
+// " #"; values in single or double quotes are taken as written.
+func (ip *INIProcessor) ParseFile(options Options) (EnvFile, error) {
+	filePath := options.FilePath
+	keys, err := newKeyValidator(options)
+	if err != nil {
+		return EnvFile{}, err
+	}
+
+	data, err := readInput(options)
+	if err != nil {
+		return EnvFile{}, fmt.Errorf("failed to open INI file '%s': %w", filePath, err)
+	}
+	// Files saved on Windows may carry a BOM and CRLF line endings
+	data = normalizeContent(data)
+	if isBlank(data) {
+		return emptySource(options)
+	}
+
+	delimiter := options.Delimiter
+	if delimiter == "" {
+		delimiter = DefaultDelimiter
+	}
+
+	envFile := EnvFile{Filename: filePath, Variables: []EnvVar{}}
+	prefix := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return EnvFile{}, newParseError(filePath, lineNumber, fmt.Errorf("unterminated section header at line %d of '%s'", lineNumber, filePath))
+			}
+			section := strings.TrimSpace(line[1 : len(line)-1])
+			if section == "" {
+				return EnvFile{}, newParseError(filePath, lineNumber, fmt.Errorf("empty section name at line %d of '%s'", lineNumber, filePath))
+			}
+			parts := strings.Split(section, ".")
+			for i, part := range parts {
+				parts[i] = strings.TrimSpace(part)
+			}
+			prefix = strings.Join(parts, delimiter) + delimiter
+			continue
+		}
+
+		separator := strings.IndexAny(line, "=:")
+		if separator <= 0 {
+			return EnvFile{}, newParseError(filePath, lineNumber, fmt.Errorf("expected key = value at line %d of '%s'", lineNumber, filePath))
+		}
+
+		name, ok, err := keys.check(prefix+strings.TrimSpace(line[:separator]), lineNumber)
+		if err != nil {
+			return EnvFile{}, err
+		}
+		if ok {
+			envFile.Variables = append(envFile.Variables, EnvVar{Key: name, Value: iniValue(line[separator+1:]), File: filePath, Line: lineNumber})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return EnvFile{}, fmt.Errorf("failed to read INI file '%s': %w", filePath, err)
+	}
+	keys.warn()
+
+	return envFile, nil
+}
+
+// iniValue returns the value of an INI entry: the text between matching quotes, or the
+// unquoted text up to an inline comment
+func iniValue(raw string) string {
+	value := strings.TrimSpace(raw)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	for _, marker := range []string{" ;", " #", "\t;", "\t#"} {
+		if index := strings.Index(value, marker); index >= 0 {
+			value = value[:index]
+		}
+	}
+	return strings.TrimSpace(value)
+}
+
+// ProcessFileWithMerge merges existing key-value pairs with those from an INI file
+func (ip *INIProcessor) ProcessFileWithMerge(existingKVs map[string]string, options Options) (map[string]string, error) {
+	// Process the INI file
+	fileVars, err := ip.ProcessFile(options.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge: file values take precedence
+	mergedVars := make(map[string]string)
+
+	// First, add existing variables
+	for key, value := range existingKVs {
+		mergedVars[key] = value
+	}
+
+	// Then, add file variables (overriding existing ones)
+	for key, value := range fileVars {
+		mergedVars[key] = value
+	}
+
+	return mergedVars, nil
+}
@@ -0,0 +1,89 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestINIProcessor_ParseFile(t *testing.T) {
+	content := `; global settings
+name = myapp
+debug: true
+
+[database]
+host = localhost   ; inline comment
+password = "p;ss #1"
+
+[server.http]
+port=8080
+# comment
+greeting = 'hello world'
+`
+	path := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write INI file: %v", err)
+	}
+
+	envFile, err := CreateINIProcessor().ParseFile(Options{FilePath: path})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	var keys []string
+	values := map[string]string{}
+	for _, variable := range envFile.Variables {
+		keys = append(keys, variable.Key)
+		values[variable.Key] = variable.Value
+	}
+
+	expectedKeys := []string{"name", "debug", "database_host", "database_password", "server_http_port", "server_http_greeting"}
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("Expected %v, got %v", expectedKeys, keys)
+	}
+	expected := map[string]string{
+		"name":                 "myapp",
+		"debug":                "true",
+		"database_host":        "localhost",
+		"database_password":    "p;ss #1",
+		"server_http_port":     "8080",
+		"server_http_greeting": "hello world",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+}
+
+func TestINIProcessor_ParseFile_Errors(t *testing.T) {
+	tests := []struct {
+		content string
+		message string
+	}{
+		{"[section\nkey=value\n", "unterminated section header at line 1"},
+		{"key=value\njust text\n", "expected key = value at line 2"},
+		{"[my-section]\nkey=value\n", "invalid key 'my-section_key' at line 2"},
+	}
+	for _, test := range tests {
+		_, err := CreateINIProcessor().ParseFile(Options{FilePath: StdinPath, Reader: strings.NewReader(test.content), InvalidKeys: InvalidKeysError})
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("Expected error containing %q, got %v", test.message, err)
+		}
+	}
+}
+
+func TestINIProcessor_ProcessFileWithMerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(path, []byte("[app]\nname = new\nport = 80\n"), 0644); err != nil {
+		t.Fatalf("Failed to write INI file: %v", err)
+	}
+
+	result, err := CreateINIProcessor().ProcessFileWithMerge(map[string]string{"app_name": "old", "OTHER": "kept"}, Options{FilePath: path})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := map[string]string{"app_name": "new", "app_port": "80", "OTHER": "kept"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
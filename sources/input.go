@@ -0,0 +1,42 @@
+package sources
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// StdinPath is the file path that stands for standard input in source lists
+const StdinPath = "-"
+
+// openInput opens the content to parse: options.Reader when set (FilePath then only names the
+// source in messages), otherwise the file at options.FilePath
+func openInput(options Options) (io.ReadCloser, error) {
+	if options.Reader != nil {
+		return io.NopCloser(options.Reader), nil
+	}
+	file, err := os.Open(options.FilePath)
+	if err != nil {
+		return nil, withoutPath(err)
+	}
+	return file, nil
+}
+
+// readInput reads all of the content to parse (see openInput)
+func readInput(options Options) ([]byte, error) {
+	if options.Reader != nil {
+		return io.ReadAll(options.Reader)
+	}
+	data, err := os.ReadFile(options.FilePath)
+	return data, withoutPath(err)
+}
+
+// withoutPath drops the operation and path of an *fs.PathError, which callers already name
+func withoutPath(err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
@@ -1,6 +1,7 @@
 package sources
 
 import (
+	"errors"
 	"os"
 	"reflect"
 	"testing"
@@ -242,3 +243,145 @@ func TestJSONProcessor_ProcessFile_FiltersInvalidKeys(t *testing.T) {
 		}
 	}
 }
+
+func TestJSONProcessor_ProcessFile_CRLFWithBOM(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("\xef\xbb\xbf{\r\n  \"KEY1\": \"value1\",\r\n  \"KEY2\": \"value2\"\r\n}\r\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	processor := CreateJSONProcessor()
+	result, err := processor.ProcessFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := map[string]string{
+		"KEY1": "value1",
+		"KEY2": "value2",
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestJSONProcessor_ParseFile_KeepsDocumentOrder(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString(`{"ZETA": "1", "nested": {"B": 1, "A": 2}, "ALPHA": "2", "invalid-key": "x", "MIDDLE": [1, 2]}`)
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	processor := CreateJSONProcessor()
+	envFile, err := processor.ParseFile(Options{FilePath: tempFile.Name()})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var keys []string
+	for _, variable := range envFile.Variables {
+		keys = append(keys, variable.Key)
+	}
+
+	expected := []string{"ZETA", "nested_B", "nested_A", "ALPHA", "MIDDLE"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+}
+
+func TestJSONProcessor_ProcessFile_SyntaxErrorLine(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("{\n  \"KEY1\": \"value1\",\n  \"KEY2\" \"value2\"\n}\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	processor := CreateJSONProcessor()
+	_, err = processor.ProcessFile(tempFile.Name())
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a ParseError, got: %v", err)
+	}
+	if parseErr.Line != 3 {
+		t.Errorf("Expected error on line 3, got %d", parseErr.Line)
+	}
+}
+
+func TestJSONProcessor_ProcessFile_EmptyFile(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("  \n\t\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	processor := CreateJSONProcessor()
+	result, err := processor.ProcessFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Expected no error for empty JSON file, got: %v", err)
+	}
+	if len(result) != 0 {
+		t.Errorf("Expected empty map, got %v", result)
+	}
+
+	_, err = processor.ParseFile(Options{FilePath: tempFile.Name(), StrictEmpty: true})
+	if err == nil {
+		t.Error("Expected error for empty JSON file in strict mode")
+	}
+}
+
+func TestJSONProcessor_ProcessFile_KeepsNumbersAsWritten(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString(`{"ACCOUNT_ID": 123456789012345678, "RATIO": 0.10, "LIMIT": 1e6, "HUGE": 100000000000000000000000}`)
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	processor := CreateJSONProcessor()
+	result, err := processor.ProcessFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := map[string]string{
+		"ACCOUNT_ID": "123456789012345678",
+		"RATIO":      "0.10",
+		"LIMIT":      "1e6",
+		"HUGE":       "100000000000000000000000",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
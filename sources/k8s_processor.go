@@ -0,0 +1,116 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KubernetesProcessor reads the data of ConfigMap and Secret manifests
+type KubernetesProcessor struct{}
+
+// CreateKubernetesProcessor creates a new Kubernetes manifest processor instance
+func CreateKubernetesProcessor() *KubernetesProcessor {
+	return &KubernetesProcessor{}
+}
+
+// kubernetesObject holds the fields of a manifest that carry variables
+type kubernetesObject struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Data       map[string]string  `yaml:"data"`
+	StringData map[string]string  `yaml:"stringData"`
+	BinaryData map[string]string  `yaml:"binaryData"`
+	Items      []kubernetesObject `yaml:"items"`
+}
+
+// ParseFile reads the manifest from options, in YAML or JSON as kubectl writes them: a
+// ConfigMap, a Secret, a List of them, or several documents. Secret data and ConfigMap
+// binaryData are base64-decoded; a Secret's stringData is taken as written and overrides its
+// data. Objects are read in order, and the keys of each one sorted.
+func (p *KubernetesProcessor) ParseFile(options Options) (EnvFile, error) {
+	filePath := options.FilePath
+	keys, err := newKeyValidator(options)
+	if err != nil {
+		return EnvFile{}, err
+	}
+
+	data, err := readInput(options)
+	if err != nil {
+		return EnvFile{}, fmt.Errorf("failed to open Kubernetes manifest '%s': %w", filePath, err)
+	}
+	data = normalizeContent(data)
+	if isBlank(data) {
+		return emptySource(options)
+	}
+
+	envFile := EnvFile{Filename: filePath, Variables: []EnvVar{}}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var object kubernetesObject
+		if err := decoder.Decode(&object); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return EnvFile{}, newParseError(filePath, yamlErrorLine(err), fmt.Errorf("failed to parse Kubernetes manifest '%s': %w", filePath, err))
+		}
+		if err := p.addObject(object, keys, &envFile.Variables); err != nil {
+			return EnvFile{}, newParseError(filePath, 0, fmt.Errorf("in Kubernetes manifest '%s': %w", filePath, err))
+		}
+	}
+	keys.warn()
+
+	return envFile, nil
+}
+
+// addObject appends the variables of a ConfigMap or Secret, or of each object in a List
+func (p *KubernetesProcessor) addObject(object kubernetesObject, keys *keyValidator, variables *[]EnvVar) error {
+	values := make(map[string]string)
+	switch object.Kind {
+	case "List", "ConfigMapList", "SecretList":
+		for _, item := range object.Items {
+			if err := p.addObject(item, keys, variables); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "ConfigMap":
+		maps.Copy(values, object.Data)
+		for key, encoded := range object.BinaryData {
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return fmt.Errorf("invalid base64 binaryData '%s' in ConfigMap '%s': %w", key, object.Metadata.Name, err)
+			}
+			values[key] = string(decoded)
+		}
+	case "Secret":
+		for key, encoded := range object.Data {
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return fmt.Errorf("invalid base64 data '%s' in Secret '%s': %w", key, object.Metadata.Name, err)
+			}
+			values[key] = string(decoded)
+		}
+		maps.Copy(values, object.StringData)
+	default:
+		return fmt.Errorf("unsupported kind '%s', expected ConfigMap, Secret, or a List of them", object.Kind)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		name, ok, err := keys.check(key, 0)
+		if err != nil {
+			return err
+		}
+		if ok {
+			*variables = append(*variables, EnvVar{Key: name, Value: values[key]})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKubernetesProcessor_ParseFile(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Secret
+metadata:
+  name: myapp
+data:
+  PASSWORD: aHVudGVyMg==
+  USER: YWRtaW4=
+stringData:
+  USER: root
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: myapp
+data:
+  LOG_LEVEL: debug
+  PORT: "8080"
+binaryData:
+  BLOB: aGVsbG8=
+`
+	path := filepath.Join(t.TempDir(), "myapp.yaml")
+	if err := os.WriteFile(path, []byte(manifest), 0600); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	envFile, err := CreateKubernetesProcessor().ParseFile(Options{FilePath: path})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := []EnvVar{
+		{Key: "PASSWORD", Value: "hunter2"},
+		{Key: "USER", Value: "root"},
+		{Key: "BLOB", Value: "hello"},
+		{Key: "LOG_LEVEL", Value: "debug"},
+		{Key: "PORT", Value: "8080"},
+	}
+	if !reflect.DeepEqual(envFile.Variables, expected) {
+		t.Errorf("Expected %v, got %v", expected, envFile.Variables)
+	}
+}
+
+func TestKubernetesProcessor_ParseFile_JSONList(t *testing.T) {
+	manifest := `{"apiVersion": "v1", "kind": "List", "items": [
+		{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "a"}, "data": {"TOKEN": "c2VjcmV0"}}
+	]}`
+	envFile, err := CreateKubernetesProcessor().ParseFile(Options{FilePath: StdinPath, Reader: strings.NewReader(manifest)})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := []EnvVar{{Key: "TOKEN", Value: "secret"}}
+	if !reflect.DeepEqual(envFile.Variables, expected) {
+		t.Errorf("Expected %v, got %v", expected, envFile.Variables)
+	}
+}
+
+func TestKubernetesProcessor_ParseFile_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		message  string
+	}{
+		{"unsupported kind", "kind: Deployment\n", "unsupported kind 'Deployment'"},
+		{"invalid base64", "kind: Secret\nmetadata:\n  name: a\ndata:\n  KEY: '%%%'\n", "invalid base64 data 'KEY' in Secret 'a'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CreateKubernetesProcessor().ParseFile(Options{FilePath: StdinPath, Reader: strings.NewReader(tt.manifest)})
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
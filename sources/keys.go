@@ -0,0 +1,118 @@
+package sources
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/notwillk/envvars-cli/logging"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Modes for handling keys that are not valid environment variable names
+const (
+	InvalidKeysWarn     = "warn"     // Drop the key and warn on stderr (default)
+	InvalidKeysError    = "error"    // Fail to parse the file
+	InvalidKeysKeep     = "keep"     // Keep the key as written
+	InvalidKeysRelaxed  = "relaxed"  // Keep keys made of unicode letters, digits, underscores, dots, and dashes
+	InvalidKeysSanitize = "sanitize" // Transliterate to a POSIX name, dropping keys that are still invalid
+)
+
+// relaxedKeyPattern matches the keys accepted in relaxed mode, such as Spring's server.port or café_NAME
+var relaxedKeyPattern = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_.\-]*$`)
+
+// sanitizeKey converts key to a POSIX variable name: accents are stripped from letters
+// (é becomes e), and every other character that is not a letter, digit, or underscore
+// becomes an underscore. Keys with nothing left but underscores sanitize to "".
+func sanitizeKey(key string) string {
+	var builder strings.Builder
+	named := false
+	for _, r := range norm.NFD.String(key) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Drop the combining marks split off by decomposition
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			builder.WriteRune(r)
+			named = true
+		default:
+			builder.WriteByte('_')
+		}
+	}
+	if !named {
+		return ""
+	}
+	return builder.String()
+}
+
+// keyValidator applies an invalid-key mode to the keys of a single file, collecting the
+// keys it drops so they can be reported together. A nil validator drops invalid keys silently.
+type keyValidator struct {
+	mode     string
+	filePath string
+	dropped  []string
+	renamed  []string // "'from' -> 'to'" for every key sanitized to a new name
+}
+
+// newKeyValidator creates a validator for the file and invalid-key mode in options
+func newKeyValidator(options Options) (*keyValidator, error) {
+	switch options.InvalidKeys {
+	case "", InvalidKeysWarn, InvalidKeysError, InvalidKeysKeep, InvalidKeysRelaxed, InvalidKeysSanitize:
+	default:
+		return nil, fmt.Errorf("unsupported invalid keys mode: %s", options.InvalidKeys)
+	}
+	return &keyValidator{mode: options.InvalidKeys, filePath: options.FilePath}, nil
+}
+
+// check returns the key to use for key, or ok=false when the key should be dropped.
+// line is the line the key was found on, or 0 when it is not known.
+func (v *keyValidator) check(key string, line int) (string, bool, error) {
+	if isValidKey(key) {
+		return key, true, nil
+	}
+	if v == nil {
+		return "", false, nil
+	}
+
+	switch v.mode {
+	case InvalidKeysError:
+		if line > 0 {
+			return "", false, newParseError(v.filePath, line, fmt.Errorf("invalid key '%s' at line %d of '%s'", key, line, v.filePath))
+		}
+		return "", false, newParseError(v.filePath, 0, fmt.Errorf("invalid key '%s' in '%s'", key, v.filePath))
+	case InvalidKeysKeep:
+		if key != "" {
+			return key, true, nil
+		}
+	case InvalidKeysRelaxed:
+		if relaxedKeyPattern.MatchString(key) {
+			return key, true, nil
+		}
+	case InvalidKeysSanitize:
+		if sanitized := sanitizeKey(key); isValidKey(sanitized) {
+			v.renamed = append(v.renamed, fmt.Sprintf("'%s' -> '%s'", key, sanitized))
+			return sanitized, true, nil
+		}
+	}
+
+	v.dropped = append(v.dropped, key)
+	return "", false, nil
+}
+
+// warn reports the keys renamed and dropped from the file, if any
+func (v *keyValidator) warn() {
+	if v == nil {
+		return
+	}
+	if len(v.renamed) > 0 {
+		logging.Warnf("renamed %d invalid key(s) in '%s': %s", len(v.renamed), v.filePath, strings.Join(v.renamed, ", "))
+	}
+	if len(v.dropped) == 0 {
+		return
+	}
+	quoted := make([]string, len(v.dropped))
+	for i, key := range v.dropped {
+		quoted[i] = fmt.Sprintf("'%s'", key)
+	}
+	logging.Warnf("dropped %d invalid key(s) from '%s': %s", len(v.dropped), v.filePath, strings.Join(quoted, ", "))
+}
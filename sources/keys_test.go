@@ -0,0 +1,115 @@
+package sources
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestKeyValidator_Check(t *testing.T) {
+	tests := []struct {
+		mode     string
+		key      string
+		expected string
+		ok       bool
+	}{
+		{InvalidKeysWarn, "VALID_KEY", "VALID_KEY", true},
+		{InvalidKeysWarn, "invalid-key", "", false},
+		{InvalidKeysKeep, "invalid-key", "invalid-key", true},
+		{InvalidKeysKeep, "", "", false},
+		{InvalidKeysSanitize, "app.db-host", "app_db_host", true},
+		{InvalidKeysSanitize, "1KEY", "", false},
+		{InvalidKeysRelaxed, "server.port", "server.port", true},
+		{InvalidKeysRelaxed, "café_NAME", "café_NAME", true},
+		{InvalidKeysRelaxed, "has space", "", false},
+		{InvalidKeysSanitize, "café.Größe", "cafe_Gro_e", true},
+		{InvalidKeysSanitize, "日本", "", false},
+	}
+
+	for _, tt := range tests {
+		keys, err := newKeyValidator(Options{FilePath: "test.env", InvalidKeys: tt.mode})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		key, ok, err := keys.check(tt.key, 1)
+		if err != nil {
+			t.Errorf("Mode %q, key %q: unexpected error: %v", tt.mode, tt.key, err)
+		}
+		if key != tt.expected || ok != tt.ok {
+			t.Errorf("Mode %q, key %q: expected (%q, %v), got (%q, %v)", tt.mode, tt.key, tt.expected, tt.ok, key, ok)
+		}
+	}
+}
+
+func TestKeyValidator_ErrorMode(t *testing.T) {
+	keys, err := newKeyValidator(Options{FilePath: "test.env", InvalidKeys: InvalidKeysError})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, _, err = keys.check("bad-key", 4)
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a ParseError, got: %v", err)
+	}
+	if parseErr.Line != 4 {
+		t.Errorf("Expected line 4, got %d", parseErr.Line)
+	}
+
+	if _, err := newKeyValidator(Options{InvalidKeys: "ignore"}); err == nil {
+		t.Error("Expected error for unsupported invalid keys mode")
+	}
+}
+
+func TestJSONProcessor_ParseFile_SanitizesKeys(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString(`{"db.host": "localhost", "api-key": "secret", "PORT": 8080}`)
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	processor := CreateJSONProcessor()
+	envFile, err := processor.ParseFile(Options{FilePath: tempFile.Name(), InvalidKeys: InvalidKeysSanitize})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	result := make(map[string]string)
+	for _, variable := range envFile.Variables {
+		result[variable.Key] = variable.Value
+	}
+
+	expected := map[string]string{
+		"db_host": "localhost",
+		"api_key": "secret",
+		"PORT":    "8080",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestKeyValidator_ReportsRenames(t *testing.T) {
+	keys, err := newKeyValidator(Options{FilePath: "test.env", InvalidKeys: InvalidKeysSanitize})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, key := range []string{"VALID", "app.name", "naïve"} {
+		if _, _, err := keys.check(key, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	expected := []string{"'app.name' -> 'app_name'", "'naïve' -> 'naive'"}
+	if !reflect.DeepEqual(keys.renamed, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys.renamed)
+	}
+}
@@ -0,0 +1,280 @@
+package sources
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// LintIssue is a hygiene problem found in an env file by LintEnvFile
+type LintIssue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Fixable bool   `json:"fixable"` // Whether LintEnvFile's fixed content resolves the issue
+}
+
+// Lint rule IDs
+const (
+	LintDuplicateKey        = "duplicate-key"
+	LintInvalidKey          = "invalid-key"
+	LintUnresolvedReference = "unresolved-reference"
+	LintTrailingWhitespace  = "trailing-whitespace"
+	LintInconsistentQuoting = "inconsistent-quoting"
+	LintUnknownDirective    = "unknown-directive"
+)
+
+// LintRuleIDs returns the IDs of the lint rules
+func LintRuleIDs() []string {
+	return []string{
+		LintDuplicateKey,
+		LintInvalidKey,
+		LintUnresolvedReference,
+		LintTrailingWhitespace,
+		LintInconsistentQuoting,
+		LintUnknownDirective,
+	}
+}
+
+// envLinter checks the lines of an env file
+type envLinter struct {
+	filePath string
+	document *EnvDocument
+	issues   []LintIssue
+}
+
+// LintEnvFile checks the env file at filePath for duplicate keys, invalid key names, ${VAR}
+// references to variables the file does not define, trailing whitespace, values quoted unlike
+// the rest of the file, and comments that look like misspelled directives. It returns the issues
+// in line and column order, and the file's content with the fixable issues fixed.
+func LintEnvFile(filePath string) ([]LintIssue, []byte, error) {
+	document, err := ReadEnvDocument(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	linter := &envLinter{filePath: filePath, document: document}
+	linter.lint()
+	slices.SortStableFunc(linter.issues, func(a, b LintIssue) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return linter.issues, document.Bytes(), nil
+}
+
+// lint runs every check. Values are requoted before whitespace is trimmed, since requoting
+// rewrites lines as they were read.
+func (l *envLinter) lint() {
+	assignments := l.document.Assignments()
+	l.checkKeys(assignments)
+	l.checkQuoting(assignments)
+	l.checkReferences(assignments)
+
+	// Whitespace inside a multi-line value is part of the value
+	inside := make(map[int]bool)
+	for _, assignment := range assignments {
+		for i := assignment.first; i < assignment.last; i++ {
+			inside[i] = true
+		}
+	}
+	for i, line := range l.document.lines {
+		if inside[i] {
+			continue
+		}
+		l.checkTrailingWhitespace(i)
+		if strings.HasPrefix(strings.TrimSpace(line.text), "#") {
+			l.checkDirective(i)
+		}
+	}
+}
+
+// report records an issue at offset in text, which starts at the line with index line
+func (l *envLinter) report(line int, text string, offset int, rule string, fixable bool, message string) {
+	before := text[:offset]
+	if newline := strings.LastIndexByte(before, '\n'); newline >= 0 {
+		line += strings.Count(before, "\n")
+		before = before[newline+1:]
+	}
+	l.issues = append(l.issues, LintIssue{
+		File:    l.filePath,
+		Line:    line + 1,
+		Column:  utf8.RuneCountInString(before) + 1,
+		Rule:    rule,
+		Message: message,
+		Fixable: fixable,
+	})
+}
+
+// checkTrailingWhitespace reports and removes whitespace at the end of a line
+func (l *envLinter) checkTrailingWhitespace(i int) {
+	text := l.document.lines[i].text
+	trimmed := strings.TrimRight(text, " \t")
+	if len(trimmed) == len(text) {
+		return
+	}
+	l.report(i, text, len(trimmed), LintTrailingWhitespace, true, "trailing whitespace")
+	l.document.lines[i].text = trimmed
+}
+
+// checkDirective reports comments written like a directive whose name is close to, but not,
+// a recognized one, such as "#incude" or "#secrets"
+func (l *envLinter) checkDirective(i int) {
+	text := l.document.lines[i].text
+	name := strings.TrimPrefix(strings.TrimSpace(text), "#")
+	if end := strings.IndexAny(name, " \t"); end >= 0 {
+		name = name[:end]
+	}
+	if name == "" || IsDirectiveName(name) || strings.Contains(name, "=") {
+		return
+	}
+
+	for _, directive := range slices.Sorted(maps.Keys(directiveNames)) {
+		if editDistance(strings.ToLower(name), directive) <= 2 {
+			l.report(i, text, strings.IndexByte(text, '#'), LintUnknownDirective, false,
+				fmt.Sprintf("unknown directive '#%s'; did you mean '#%s'?", name, directive))
+			return
+		}
+	}
+}
+
+// checkKeys reports keys that are not valid names and keys assigned more than once
+func (l *envLinter) checkKeys(assignments []EnvAssignment) {
+	firstLines := make(map[string]int)
+	for _, assignment := range assignments {
+		if !isValidKey(assignment.Key) {
+			l.report(assignment.first, assignment.text, assignment.keyOffset, LintInvalidKey, false,
+				fmt.Sprintf("invalid key '%s': keys must be letters, digits, and underscores, not starting with a digit", assignment.Key))
+		}
+		if firstLine, seen := firstLines[assignment.Key]; seen {
+			l.report(assignment.first, assignment.text, assignment.keyOffset, LintDuplicateKey, false,
+				fmt.Sprintf("duplicate key '%s', first assigned at line %d", assignment.Key, firstLine+1))
+			continue
+		}
+		firstLines[assignment.Key] = assignment.first
+	}
+}
+
+// checkQuoting reports quoted values that use the quote character most of the file's quoted
+// values do not (the first one used on a tie). A value is converted when that keeps its meaning:
+// it has no backslashes, quotes of the other kind, or references.
+func (l *envLinter) checkQuoting(assignments []EnvAssignment) {
+	counts := make(map[byte]int)
+	var first byte
+	for _, assignment := range assignments {
+		if token, err := scanValue(assignment.Value); err == nil && token.quote != 0 {
+			counts[token.quote]++
+			if first == 0 {
+				first = token.quote
+			}
+		}
+	}
+	preferred, other := first, byte('"')
+	if counts['"'] != counts['\''] {
+		preferred = '"'
+		if counts['\''] > counts['"'] {
+			preferred = '\''
+		}
+	}
+	if preferred == '"' {
+		other = '\''
+	}
+	if counts[other] == 0 {
+		return
+	}
+
+	for _, assignment := range assignments {
+		token, err := scanValue(assignment.Value)
+		if err != nil || token.quote != other {
+			continue
+		}
+		fixable := !strings.ContainsAny(token.body, "\\\n'\"$")
+		l.report(assignment.first, assignment.text, assignment.offset, LintInconsistentQuoting, fixable,
+			fmt.Sprintf("value of '%s' uses %s quotes; most values in the file use %s quotes", assignment.Key, quoteName(other), quoteName(preferred)))
+		if fixable {
+			l.document.SetValue(assignment, string(preferred)+token.body+string(preferred))
+		}
+	}
+}
+
+// checkReferences reports ${VAR} references to variables that neither the file nor the files it
+// #include define, which are left unresolved; references with a :- fallback or :? message are
+// deliberate, and single-quoted values are literal
+func (l *envLinter) checkReferences(assignments []EnvAssignment) {
+	defined := make(map[string]bool)
+	for _, assignment := range assignments {
+		defined[assignment.Key] = true
+	}
+	if slices.ContainsFunc(l.document.lines, func(line envLine) bool {
+		trimmed := strings.TrimSpace(line.text)
+		return isDirectiveLine(trimmed) && strings.EqualFold(strings.Fields(trimmed[1:])[0], "include")
+	}) {
+		envFile, err := ParseFile(Options{FilePath: l.filePath, InvalidKeys: InvalidKeysKeep, Duplicates: DuplicatesLast})
+		if err == nil {
+			for _, variable := range envFile.Variables {
+				defined[variable.Key] = true
+			}
+		}
+	}
+
+	for _, assignment := range assignments {
+		token, err := scanValue(assignment.Value)
+		if err != nil || token.quote == '\'' {
+			continue
+		}
+		start := assignment.offset
+		if token.quote != 0 {
+			start++
+		}
+		body := token.body
+		for i := 0; i+1 < len(body); i++ {
+			if token.quote == '"' && body[i] == '\\' {
+				i++ // Skip the escaped character, so \${VAR} is literal
+				continue
+			}
+			if !strings.HasPrefix(body[i:], "${") {
+				continue
+			}
+			end := strings.IndexByte(body[i+2:], '}')
+			if end <= 0 {
+				continue
+			}
+			expression := body[i+2 : i+2+end]
+			if j := strings.Index(expression, ":"); j >= 0 && j+1 < len(expression) && (expression[j+1] == '-' || expression[j+1] == '?') {
+				i += 2 + end
+				continue
+			}
+			if !defined[expression] {
+				l.report(assignment.first, assignment.text, start+i, LintUnresolvedReference, false,
+					fmt.Sprintf("'%s' references '%s', which is not defined in the file", assignment.Key, expression))
+			}
+			i += 2 + end
+		}
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
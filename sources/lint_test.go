@@ -0,0 +1,93 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLintEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	content := "#secrets API_KEY\n" +
+		"# plain comment \n" +
+		"NAME=\"app\"\n" +
+		"HOST=\"${NAME}.internal\"   \n" +
+		"MODE='dev'\n" +
+		"export URL=\"${SCHEME}://${HOST}/${PATH:-api}\" # comment\n" +
+		"1BAD=x\n" +
+		"NAME=other\n" +
+		"CERT=\"line one\n" +
+		"line two ${MISSING}\"\n" +
+		"LITERAL='${NOT_A_REFERENCE}'\n"
+	if err := os.WriteFile(envPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	issues, fixed, err := LintEnvFile(envPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	type found struct {
+		Line, Column int
+		Rule         string
+		Fixable      bool
+	}
+	var actual []found
+	for _, issue := range issues {
+		actual = append(actual, found{issue.Line, issue.Column, issue.Rule, issue.Fixable})
+	}
+	expected := []found{
+		{1, 1, LintUnknownDirective, false},
+		{2, 16, LintTrailingWhitespace, true},
+		{4, 24, LintTrailingWhitespace, true},
+		{5, 6, LintInconsistentQuoting, true},
+		{6, 13, LintUnresolvedReference, false},
+		{7, 1, LintInvalidKey, false},
+		{8, 1, LintDuplicateKey, false},
+		{10, 10, LintUnresolvedReference, false},
+		{11, 9, LintInconsistentQuoting, false},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	expectedContent := "#secrets API_KEY\n" +
+		"# plain comment\n" +
+		"NAME=\"app\"\n" +
+		"HOST=\"${NAME}.internal\"\n" +
+		"MODE=\"dev\"\n" +
+		"export URL=\"${SCHEME}://${HOST}/${PATH:-api}\" # comment\n" +
+		"1BAD=x\n" +
+		"NAME=other\n" +
+		"CERT=\"line one\n" +
+		"line two ${MISSING}\"\n" +
+		"LITERAL='${NOT_A_REFERENCE}'\n"
+	if string(fixed) != expectedContent {
+		t.Errorf("Expected %q, got %q", expectedContent, fixed)
+	}
+}
+
+func TestLintEnvFile_Clean(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "base.env")
+	content := "\xef\xbb\xbf#include shared.env\r\nURL=\"${SHARED_HOST}/api\"\r\n#remove DEBUG\r\n"
+	if err := os.WriteFile(filepath.Join(dir, "shared.env"), []byte("SHARED_HOST=example.com\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	if err := os.WriteFile(envPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	issues, fixed, err := LintEnvFile(envPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+	if string(fixed) != content {
+		t.Errorf("Expected the content to be unchanged, got %q", fixed)
+	}
+}
@@ -0,0 +1,40 @@
+package sources
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// utf8BOM is the byte order mark some Windows editors write at the start of UTF-8 files
+const utf8BOM = "\xef\xbb\xbf"
+
+// normalizeContent strips a leading UTF-8 BOM and converts CRLF line endings to LF
+func normalizeContent(data []byte) []byte {
+	data = bytes.TrimPrefix(data, []byte(utf8BOM))
+	if bytes.Contains(data, []byte("\r\n")) {
+		data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	}
+	return data
+}
+
+// normalizeLine strips a trailing carriage return left by CRLF line endings
+func normalizeLine(line string) string {
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		return line[:n-1]
+	}
+	return line
+}
+
+// isBlank reports whether data contains only whitespace
+func isBlank(data []byte) bool {
+	return len(bytes.TrimSpace(data)) == 0
+}
+
+// emptySource returns the result for a source file with no content: an empty variable set,
+// or an error when options.StrictEmpty is set
+func emptySource(options Options) (EnvFile, error) {
+	if options.StrictEmpty {
+		return EnvFile{}, newParseError(options.FilePath, 0, fmt.Errorf("source file '%s' is empty", options.FilePath))
+	}
+	return EnvFile{Filename: options.FilePath, Variables: []EnvVar{}, Directives: []Directive{}}, nil
+}
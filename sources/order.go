@@ -0,0 +1,126 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// keyOrder records the keys of each object in a document in the order they appear, by the path
+// of keys leading to the object (see pathKey). The elements of arrays share the array's path.
+type keyOrder map[string][]string
+
+// add records key as the next key of the object at path
+func (o keyOrder) add(path []string, key string) {
+	o[pathKey(path)] = append(o[pathKey(path)], key)
+}
+
+// keys returns the keys of the object at path in the order they appear
+func (o keyOrder) keys(path []string) []string {
+	return o[pathKey(path)]
+}
+
+// pathKey identifies the object at path
+func pathKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// jsonKeyOrder returns the order of the keys in every object of a JSON document
+func jsonKeyOrder(data []byte) keyOrder {
+	order := keyOrder{}
+	// A malformed document is reported by the JSON decoder; the order found so far is kept
+	_ = jsonValueOrder(json.NewDecoder(bytes.NewReader(data)), nil, order)
+	return order
+}
+
+// jsonValueOrder records the key order of the objects in the next value from decoder, which is
+// found at path
+func jsonValueOrder(decoder *json.Decoder, path []string, order keyOrder) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := token.(string)
+			order.add(path, key)
+			if err := jsonValueOrder(decoder, append(path[:len(path):len(path)], key), order); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for decoder.More() {
+			if err := jsonValueOrder(decoder, path, order); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	// Consume the closing delimiter
+	_, err = decoder.Token()
+	return err
+}
+
+// yamlKeyOrder returns the order of the keys in every mapping of a decoded YAML document
+func yamlKeyOrder(document *yaml.Node) keyOrder {
+	order := keyOrder{}
+	if len(document.Content) > 0 {
+		yamlNodeOrder(document.Content[0], nil, order)
+	}
+	return order
+}
+
+// yamlNodeOrder records the key order of the mappings in node, which is found at path. Aliases
+// are followed, so a mapping reused through an anchor keeps the anchor's order.
+func yamlNodeOrder(node *yaml.Node, path []string, order keyOrder) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			yamlNodeOrder(node.Alias, path, order)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			order.add(path, key)
+			yamlNodeOrder(node.Content[i+1], append(path[:len(path):len(path)], key), order)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			yamlNodeOrder(item, path, order)
+		}
+	}
+}
+
+// orderedKeys returns the keys of data following order, with any keys missing from
+// order (such as those introduced by YAML merge keys) appended alphabetically
+func orderedKeys(data map[string]interface{}, order []string) []string {
+	keys := make([]string, 0, len(data))
+	seen := make(map[string]bool, len(data))
+	for _, key := range order {
+		if _, exists := data[key]; exists && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	var rest []string
+	for key := range data {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(keys, rest...)
+}
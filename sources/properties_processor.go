@@ -0,0 +1,204 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PropertiesProcessor handles processing of Java .properties files
+type PropertiesProcessor struct{}
+
+// CreatePropertiesProcessor creates a new properties processor instance
+func CreatePropertiesProcessor() *PropertiesProcessor {
+	return &PropertiesProcessor{}
+}
+
+// isValidKey checks if a key matches the required regex pattern
+func (pp *PropertiesProcessor) isValidKey(key string) bool {
+	return isValidKey(key)
+}
+
+// ProcessFile reads a properties file and extracts key-value pairs
+func (pp *PropertiesProcessor) ProcessFile(filePath string) (map[string]string, error) {
+	envFile, err := pp.ParseFile(Options{FilePath: filePath})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(envFile.Variables))
+	for _, variable := range envFile.Variables {
+		result[variable.Key] = variable.Value
+	}
+
+	return result, nil
+}
+
+// ParseFile reads the properties file from options and extracts its entries in document order,
+// as java.util.Properties.load reads them: a key ends at the first unescaped =, :, or
+// whitespace; lines ending in an odd number of backslashes continue on the next line, without
+// its leading whitespace; lines starting with # or ! are comments; and \t, \n, \r, \f, and
+// \uXXXX escapes are decoded, with a backslash before any other character dropped. Dotted keys
+// such as server.port are kept by --invalid-keys relaxed.
+func (pp *PropertiesProcessor) ParseFile(options Options) (EnvFile, error) {
+	filePath := options.FilePath
+	keys, err := newKeyValidator(options)
+	if err != nil {
+		return EnvFile{}, err
+	}
+
+	data, err := readInput(options)
+	if err != nil {
+		return EnvFile{}, fmt.Errorf("failed to open properties file '%s': %w", filePath, err)
+	}
+	// Files saved on Windows may carry a BOM and CRLF line endings
+	data = normalizeContent(data)
+	if isBlank(data) {
+		return emptySource(options)
+	}
+
+	envFile := EnvFile{Filename: filePath, Variables: []EnvVar{}}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		// Join continuation lines into the logical line
+		start := lineNumber
+		for endsWithContinuation(line) && scanner.Scan() {
+			lineNumber++
+			line = line[:len(line)-1] + strings.TrimLeft(scanner.Text(), " \t\f")
+		}
+		if endsWithContinuation(line) {
+			line = line[:len(line)-1]
+		}
+
+		rawKey, rawValue := splitProperty(line)
+		key, err := unescapeProperty(rawKey)
+		if err != nil {
+			return EnvFile{}, newParseError(filePath, start, fmt.Errorf("%w in key at line %d of '%s'", err, start, filePath))
+		}
+		value, err := unescapeProperty(rawValue)
+		if err != nil {
+			return EnvFile{}, newParseError(filePath, start, fmt.Errorf("%w in value of '%s' at line %d of '%s'", err, key, start, filePath))
+		}
+
+		name, ok, err := keys.check(key, start)
+		if err != nil {
+			return EnvFile{}, err
+		}
+		if ok {
+			envFile.Variables = append(envFile.Variables, EnvVar{Key: name, Value: value, File: filePath, Line: start})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return EnvFile{}, fmt.Errorf("failed to read properties file '%s': %w", filePath, err)
+	}
+	keys.warn()
+
+	return envFile, nil
+}
+
+// endsWithContinuation reports whether line ends in an odd number of backslashes
+func endsWithContinuation(line string) bool {
+	count := len(line) - len(strings.TrimRight(line, `\`))
+	return count%2 == 1
+}
+
+// splitProperty splits a logical line into its still-escaped key and value
+func splitProperty(line string) (string, string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+	key, rest := line[:end], strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return key, rest
+}
+
+// unescapeProperty decodes the escape sequences of a properties key or value
+func unescapeProperty(text string) (string, error) {
+	if !strings.Contains(text, `\`) {
+		return text, nil
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i+1 == len(text) {
+			builder.WriteByte(text[i])
+			continue
+		}
+		i++
+		switch text[i] {
+		case 't':
+			builder.WriteByte('\t')
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'f':
+			builder.WriteByte('\f')
+		case 'u':
+			if i+5 > len(text) {
+				return "", fmt.Errorf("malformed \\u escape")
+			}
+			unit, err := strconv.ParseUint(text[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\u escape '\\u%s'", text[i+1:i+5])
+			}
+			i += 4
+			// Characters outside the basic plane are written as a UTF-16 surrogate pair
+			if unit >= 0xD800 && unit < 0xDC00 && i+7 <= len(text) && text[i+1:i+3] == `\u` {
+				if low, err := strconv.ParseUint(text[i+3:i+7], 16, 16); err == nil && low >= 0xDC00 && low < 0xE000 {
+					builder.WriteRune(rune((unit-0xD800)<<10 + (low - 0xDC00) + 0x10000))
+					i += 6
+					continue
+				}
+			}
+			builder.WriteRune(rune(unit))
+		default:
+			builder.WriteByte(text[i])
+		}
+	}
+	return builder.String(), nil
+}
+
+// ProcessFileWithMerge merges existing key-value pairs with those from a properties file
+func (pp *PropertiesProcessor) ProcessFileWithMerge(existingKVs map[string]string, options Options) (map[string]string, error) {
+	// Process the properties file
+	fileVars, err := pp.ProcessFile(options.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge: file values take precedence
+	mergedVars := make(map[string]string)
+
+	// First, add existing variables
+	for key, value := range existingKVs {
+		mergedVars[key] = value
+	}
+
+	// Then, add file variables (overriding existing ones)
+	for key, value := range fileVars {
+		mergedVars[key] = value
+	}
+
+	return mergedVars, nil
+}
@@ -0,0 +1,72 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPropertiesProcessor_ParseFile(t *testing.T) {
+	content := `# Database settings
+! also a comment
+db.url = jdbc:postgresql://db:5432/app
+db.user:admin
+db.password   s3cret
+greeting = h\u00E9llo \uD83D\uDE00
+multi = first, \
+        second, \
+        third
+key\ with\ spaces = value\twith\ttabs
+escaped\:colon = a\=b
+empty
+trailing = ends with backslash\\
+`
+	path := filepath.Join(t.TempDir(), "application.properties")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write properties file: %v", err)
+	}
+
+	envFile, err := CreatePropertiesProcessor().ParseFile(Options{FilePath: path, InvalidKeys: InvalidKeysKeep})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := []EnvVar{
+		{Key: "db.url", Value: "jdbc:postgresql://db:5432/app", File: path, Line: 3},
+		{Key: "db.user", Value: "admin", File: path, Line: 4},
+		{Key: "db.password", Value: "s3cret", File: path, Line: 5},
+		{Key: "greeting", Value: "héllo 😀", File: path, Line: 6},
+		{Key: "multi", Value: "first, second, third", File: path, Line: 7},
+		{Key: "key with spaces", Value: "value\twith\ttabs", File: path, Line: 10},
+		{Key: "escaped:colon", Value: "a=b", File: path, Line: 11},
+		{Key: "empty", Value: "", File: path, Line: 12},
+		{Key: "trailing", Value: `ends with backslash\`, File: path, Line: 13},
+	}
+	if !reflect.DeepEqual(envFile.Variables, expected) {
+		t.Errorf("Expected %v, got %v", expected, envFile.Variables)
+	}
+}
+
+func TestPropertiesProcessor_ParseFile_InvalidEscape(t *testing.T) {
+	_, err := CreatePropertiesProcessor().ParseFile(Options{FilePath: StdinPath, Reader: strings.NewReader("a=ok\nb=\\u12\n")})
+	if err == nil || !strings.Contains(err.Error(), "malformed \\u escape in value of 'b' at line 2") {
+		t.Errorf("Expected a malformed escape error, got %v", err)
+	}
+}
+
+func TestPropertiesProcessor_ProcessFileWithMerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.properties")
+	if err := os.WriteFile(path, []byte("NAME=new\nPORT: 80\n"), 0644); err != nil {
+		t.Fatalf("Failed to write properties file: %v", err)
+	}
+
+	result, err := CreatePropertiesProcessor().ProcessFileWithMerge(map[string]string{"NAME": "old", "OTHER": "kept"}, Options{FilePath: path})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := map[string]string{"NAME": "new", "PORT": "80", "OTHER": "kept"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
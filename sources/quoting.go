@@ -0,0 +1,56 @@
+package sources
+
+import (
+	"fmt"
+	"strings"
+)
+
+// quotedValue is a raw value split into its quoting and contents
+type quotedValue struct {
+	quote byte   // '"' or '\'' for quoted values, 0 for unquoted ones
+	body  string // Contents between the quotes, with escapes still as written
+}
+
+// scanValue tokenizes a trimmed raw value. A value is quoted only when it starts with a quote;
+// quotes appearing later in an unquoted value (it's, a"b) are taken literally. A quoted value
+// must be closed, and nothing but whitespace may follow its closing quote.
+func scanValue(raw string) (quotedValue, error) {
+	if raw == "" || (raw[0] != '"' && raw[0] != '\'') {
+		return quotedValue{body: raw}, nil
+	}
+
+	quote := raw[0]
+	end := closingQuote(raw)
+	if end < 0 {
+		return quotedValue{}, fmt.Errorf("unterminated %s-quoted value", quoteName(quote))
+	}
+	if rest := strings.TrimSpace(raw[end+1:]); rest != "" {
+		return quotedValue{}, fmt.Errorf("unexpected text %q after closing %s quote", rest, quoteName(quote))
+	}
+	return quotedValue{quote: quote, body: raw[1:end]}, nil
+}
+
+// closingQuote returns the index of the quote closing the quoted value at the start of raw,
+// or -1 if it is never closed. Backslash escapes are honored in double-quoted values only.
+func closingQuote(raw string) int {
+	quote := raw[0]
+	for i := 1; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			if quote == '"' {
+				i++ // Skip the escaped character
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// quoteName names a quote character in error messages
+func quoteName(quote byte) string {
+	if quote == '\'' {
+		return "single"
+	}
+	return "double"
+}
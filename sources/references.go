@@ -0,0 +1,125 @@
+package sources
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// referenceResolver resolves the ${VAR} references of an env file's values once every
+// assignment is known, following chains of references in dependency order. A reference names
+// the key's last assignment in the file, except that a key referencing itself (PATH=${PATH}:/bin)
+// names its previous assignment, or is unset when there is none, so PORT=${PORT:-80} takes its
+// fallback.
+type referenceResolver struct {
+	filePath  string
+	noEscape  bool
+	variables []EnvVar
+	raw       []string     // Values as written, parallel to variables
+	inlined   map[int]bool // Indexes of variables from included files, already resolved
+	resolved  map[int]string
+	visiting  []int // Indexes being resolved, innermost last, for reporting cycles
+}
+
+// newReferenceResolver creates a resolver for the variables of the file at options' path
+func newReferenceResolver(variables []EnvVar, raw []string, inlined map[int]bool, options Options) *referenceResolver {
+	return &referenceResolver{
+		filePath:  options.FilePath,
+		noEscape:  options.NoEscape,
+		variables: variables,
+		raw:       raw,
+		inlined:   inlined,
+		resolved:  make(map[int]string),
+	}
+}
+
+// resolve returns the value of the variable at index i with its references resolved. It fails
+// on a circular reference, naming the chain of keys, and on a ${VAR:?message} reference to an
+// unset variable.
+func (r *referenceResolver) resolve(i int) (string, error) {
+	if r.inlined[i] {
+		return r.variables[i].Value, nil
+	}
+	if value, done := r.resolved[i]; done {
+		return value, nil
+	}
+
+	variable := r.variables[i]
+	if at := slices.Index(r.visiting, i); at >= 0 {
+		var chain []string
+		for _, index := range r.visiting[at:] {
+			chain = append(chain, r.variables[index].Key)
+		}
+		first := r.variables[r.visiting[at]]
+		return "", newParseError(r.filePath, first.Line, fmt.Errorf("circular reference %s -> %s at line %d of '%s'", strings.Join(chain, " -> "), variable.Key, first.Line, r.filePath))
+	}
+
+	r.visiting = append(r.visiting, i)
+	defer func() { r.visiting = r.visiting[:len(r.visiting)-1] }()
+
+	lookup := make(map[string]string)
+	for _, name := range referencedNames(r.raw[i], r.noEscape) {
+		target := r.definition(name, i)
+		if target < 0 {
+			continue
+		}
+		value, err := r.resolve(target)
+		if err != nil {
+			return "", err
+		}
+		lookup[name] = value
+	}
+
+	value, err := expandValue(r.raw[i], lookup, r.noEscape)
+	if err != nil {
+		return "", newParseError(r.filePath, variable.Line, fmt.Errorf("%w (referenced by '%s' at line %d of '%s')", err, variable.Key, variable.Line, r.filePath))
+	}
+	r.resolved[i] = value
+	return value, nil
+}
+
+// definition returns the index of the assignment a reference to name from the variable at index
+// from resolves to, or -1 when the file does not assign name (before from, for a self-reference)
+func (r *referenceResolver) definition(name string, from int) int {
+	last := len(r.variables)
+	if r.variables[from].Key == name {
+		last = from
+	}
+	for i := last - 1; i >= 0; i-- {
+		if r.variables[i].Key == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// referencedNames returns the names of the variables a raw value references, in order: none for
+// single-quoted values, and in double-quoted values not those escaped as \${VAR}
+func referencedNames(raw string, noEscape bool) []string {
+	token, err := scanValue(strings.TrimSpace(raw))
+	if err != nil || token.quote == '\'' || !strings.Contains(token.body, "${") {
+		return nil
+	}
+
+	var names []string
+	body := token.body
+	for i := 0; i+1 < len(body); i++ {
+		if token.quote == '"' && !noEscape && body[i] == '\\' {
+			i++ // Skip the escaped character
+			continue
+		}
+		if !strings.HasPrefix(body[i:], "${") {
+			continue
+		}
+		end := strings.IndexByte(body[i+2:], '}')
+		if end <= 0 {
+			continue
+		}
+		name, _, _ := splitReference(body[i+2 : i+2+end])
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+		i += 2 + end
+	}
+	return names
+}
@@ -0,0 +1,82 @@
+package sources
+
+import (
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/notwillk/envvars-cli/formatters"
+)
+
+// roundTripAlphabet mixes plain characters with everything the quoting contract has to handle
+var roundTripAlphabet = []rune("abcXYZ019_-./:=,@% \t\n\r\"'\\$`#{}()!*?~é日😀")
+
+func TestEnvRoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		"plain",
+		"two words",
+		" leading and trailing ",
+		`say "hi"`,
+		"it's",
+		`back\slash`,
+		`\n`,
+		"line1\nline2",
+		"cr\r\nlf",
+		"tab\there",
+		"$HOME",
+		"${HOME}",
+		"${UNDEFINED}",
+		"cost: $5",
+		"`cmd`",
+		"value # not a comment",
+		"#hash",
+		"'single'",
+		`"double"`,
+		"unicode é 日本 😀",
+	}
+
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		runes := make([]rune, random.Intn(20))
+		for j := range runes {
+			runes[j] = roundTripAlphabet[random.Intn(len(roundTripAlphabet))]
+		}
+		values = append(values, string(runes))
+	}
+
+	for _, export := range []bool{false, true} {
+		var content strings.Builder
+		expected := make(map[string]string, len(values))
+		for i, value := range values {
+			key := "KEY_" + strings.Repeat("X", i%3) + string(rune('A'+i%26)) + "_" + strings.Repeat("0", i/26)
+			expected[key] = value
+			content.WriteString(formatters.FormatENVLine(key, value, formatters.Options{Export: export}))
+			content.WriteString("\n")
+		}
+
+		tempFile, err := os.CreateTemp("", "test-*.env")
+		if err != nil {
+			t.Fatalf("Failed to create temp file: %v", err)
+		}
+		defer os.Remove(tempFile.Name())
+		defer tempFile.Close()
+
+		_, err = tempFile.WriteString(content.String())
+		if err != nil {
+			t.Fatalf("Failed to write to temp file: %v", err)
+		}
+
+		result, err := ProcessFileWithMerge(map[string]string{}, Options{FilePath: tempFile.Name(), StrictParse: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		for key, value := range expected {
+			if result[key] != value {
+				t.Errorf("Expected %s=%q to round-trip, got %q (written as %s)", key, value, result[key], formatters.FormatENVLine(key, value, formatters.Options{}))
+			}
+		}
+	}
+}
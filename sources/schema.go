@@ -0,0 +1,219 @@
+package sources
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// schemaCache holds compiled schemas so each schema is compiled at most once per run
+type schemaCache struct {
+	mu       sync.Mutex
+	schemas  map[string]*jsonschema.Schema
+	cacheDir string // Directory for on-disk caching of remote schemas (empty disables it)
+}
+
+// schemas is shared by all processors
+var schemas = &schemaCache{schemas: make(map[string]*jsonschema.Schema)}
+
+// SetSchemaCacheDir enables on-disk caching of remote schemas in dir, keyed by URL and
+// revalidated with the server's ETag. An empty dir disables the disk cache.
+func SetSchemaCacheDir(dir string) {
+	schemas.mu.Lock()
+	defer schemas.mu.Unlock()
+	schemas.cacheDir = dir
+}
+
+// isLocalSchema reports whether a $schema reference points to a local file
+func isLocalSchema(schemaURL string) bool {
+	scheme := strings.ToLower(schemaURL[:min(len(schemaURL), len("https://"))])
+	return !strings.HasPrefix(scheme, "http://") && !strings.HasPrefix(scheme, "https://")
+}
+
+// schemaSchemePattern matches the scheme of a URL. A single letter is a Windows drive letter
+// (C:\schemas\app.json) rather than a scheme.
+var schemaSchemePattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]+):`)
+
+// CheckSchemaScheme fails for a $schema reference that is a URL with a scheme other than file,
+// http, or https, rather than treating it as a local path
+func CheckSchemaScheme(schemaURL string) error {
+	match := schemaSchemePattern.FindStringSubmatch(schemaURL)
+	if match == nil {
+		return nil
+	}
+	switch strings.ToLower(match[1]) {
+	case "file", "http", "https":
+		return nil
+	}
+	return fmt.Errorf("unsupported schema scheme '%s' in '%s': use a local path or a file, http, or https URL", match[1], schemaURL)
+}
+
+// LocalSchemaPath resolves a local $schema reference to an absolute path. References may be
+// relative to the directory of documentPath, absolute, or file:// URLs, and may use forward
+// slashes on every platform so the same document works on Windows.
+func LocalSchemaPath(schemaURL string, documentPath string) string {
+	location := schemaURL
+	if parsed, err := url.Parse(schemaURL); err == nil && parsed.Scheme == "file" {
+		location = parsed.Path
+		// file:///C:/schemas/app.json has the path /C:/schemas/app.json
+		if runtime.GOOS == "windows" && len(location) > 2 && location[0] == '/' && location[2] == ':' {
+			location = location[1:]
+		}
+	}
+
+	location = filepath.FromSlash(location)
+	if !filepath.IsAbs(location) {
+		location = filepath.Join(filepath.Dir(documentPath), location)
+	}
+	if absolute, err := filepath.Abs(location); err == nil {
+		location = absolute
+	}
+	return location
+}
+
+// compile returns the compiled schema for schemaURL, resolving local schemas relative to documentPath
+func (c *schemaCache) compile(schemaURL string, documentPath string) (*jsonschema.Schema, error) {
+	if err := CheckSchemaScheme(schemaURL); err != nil {
+		return nil, err
+	}
+
+	location := schemaURL
+	if isLocalSchema(schemaURL) {
+		// For local schemas, resolve the path relative to the file being processed
+		location = LocalSchemaPath(schemaURL, documentPath)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if schema, ok := c.schemas[location]; ok {
+		return schema, nil
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if !isLocalSchema(schemaURL) {
+		loader := &httpSchemaLoader{cacheDir: c.cacheDir}
+		compiler.UseLoader(jsonschema.SchemeURLLoader{
+			"file":  jsonschema.FileLoader{},
+			"http":  loader,
+			"https": loader,
+		})
+	}
+
+	schema, err := compiler.Compile(location)
+	if err != nil {
+		if isLocalSchema(schemaURL) {
+			return nil, fmt.Errorf("failed to compile local schema from '%s': %w", location, err)
+		}
+		return nil, fmt.Errorf("failed to compile remote schema: %w", err)
+	}
+
+	c.schemas[location] = schema
+	return schema, nil
+}
+
+// validateDocument validates decoded document data against the schema referenced by its $schema field
+func validateDocument(data map[string]interface{}, schemaURL string, documentPath string) error {
+	schema, err := schemas.compile(schemaURL, documentPath)
+	if err != nil {
+		return err
+	}
+
+	// Validate the data against the schema
+	if err := schema.Validate(data); err != nil {
+		if isLocalSchema(schemaURL) {
+			return fmt.Errorf("data does not match local schema: %w", err)
+		}
+		return fmt.Errorf("data does not match remote schema: %w", err)
+	}
+
+	return nil
+}
+
+// httpSchemaLoader fetches remote schemas, optionally caching them on disk
+type httpSchemaLoader struct {
+	cacheDir string
+}
+
+// Load fetches the schema at url, reusing the on-disk copy when the server reports it unchanged
+func (l *httpSchemaLoader) Load(url string) (any, error) {
+	var bodyPath, etagPath string
+	if l.cacheDir != "" {
+		sum := sha256.Sum256([]byte(url))
+		name := hex.EncodeToString(sum[:])
+		bodyPath = filepath.Join(l.cacheDir, name+".json")
+		etagPath = filepath.Join(l.cacheDir, name+".etag")
+	}
+
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if etagPath != "" {
+		if etag, err := os.ReadFile(etagPath); err == nil {
+			if _, err := os.Stat(bodyPath); err == nil {
+				request.Header.Set("If-None-Match", string(etag))
+			}
+		}
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		// Fall back to the cached copy when the server is unreachable
+		if bodyPath != "" {
+			if cached, cacheErr := l.loadCached(bodyPath); cacheErr == nil {
+				return cached, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to fetch schema '%s': %w", url, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified && bodyPath != "" {
+		return l.loadCached(bodyPath)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch schema '%s': %s", url, response.Status)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema '%s': %w", url, err)
+	}
+
+	if bodyPath != "" {
+		if err := os.MkdirAll(l.cacheDir, 0o755); err == nil {
+			if err := os.WriteFile(bodyPath, body, 0o644); err == nil {
+				if etag := response.Header.Get("ETag"); etag != "" {
+					os.WriteFile(etagPath, []byte(etag), 0o644)
+				} else {
+					os.Remove(etagPath)
+				}
+			}
+		}
+	}
+
+	return jsonschema.UnmarshalJSON(bytes.NewReader(body))
+}
+
+// loadCached reads a previously cached schema document
+func (l *httpSchemaLoader) loadCached(bodyPath string) (any, error) {
+	file, err := os.Open(bodyPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return jsonschema.UnmarshalJSON(file)
+}
@@ -0,0 +1,172 @@
+package sources
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+func TestSchemaCache_CompilesLocalSchemaOnce(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "schema.json")
+	if err := os.WriteFile(schemaPath, []byte(`{"type": "object", "required": ["NAME"]}`), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	cache := &schemaCache{schemas: make(map[string]*jsonschema.Schema)}
+	first, err := cache.compile("./schema.json", filepath.Join(dir, "a.json"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	second, err := cache.compile("schema.json", filepath.Join(dir, "b.yaml"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if first != second {
+		t.Error("Expected the same compiled schema to be reused for both documents")
+	}
+}
+
+func TestJSONProcessor_ProcessFile_ValidatesAgainstLocalSchema(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "schema.json"), []byte(`{"type": "object", "required": ["NAME"]}`), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	validPath := filepath.Join(dir, "valid.json")
+	if err := os.WriteFile(validPath, []byte(`{"$schema": "./schema.json", "NAME": "app"}`), 0o644); err != nil {
+		t.Fatalf("Failed to write JSON file: %v", err)
+	}
+	invalidPath := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalidPath, []byte(`{"$schema": "./schema.json", "OTHER": "app"}`), 0o644); err != nil {
+		t.Fatalf("Failed to write JSON file: %v", err)
+	}
+
+	processor := CreateJSONProcessor()
+	result, err := processor.ProcessFile(validPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result["NAME"] != "app" {
+		t.Errorf("Expected NAME=app, got %q", result["NAME"])
+	}
+
+	if _, err := processor.ProcessFile(invalidPath); err == nil {
+		t.Error("Expected schema validation error for document missing a required key")
+	}
+}
+
+func TestHTTPSchemaLoader_UsesDiskCacheWithETag(t *testing.T) {
+	requests := 0
+	notModified := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"type": "object"}`))
+	}))
+	defer server.Close()
+
+	loader := &httpSchemaLoader{cacheDir: t.TempDir()}
+
+	if _, err := loader.Load(server.URL + "/schema.json"); err != nil {
+		t.Fatalf("Expected no error on first load, got: %v", err)
+	}
+	doc, err := loader.Load(server.URL + "/schema.json")
+	if err != nil {
+		t.Fatalf("Expected no error on cached load, got: %v", err)
+	}
+
+	if requests != 2 || notModified != 1 {
+		t.Errorf("Expected a revalidation request answered with 304, got %d requests and %d not-modified", requests, notModified)
+	}
+	if schema, ok := doc.(map[string]any); !ok || schema["type"] != "object" {
+		t.Errorf("Expected cached schema document, got %v", doc)
+	}
+}
+
+func TestLocalSchemaPath(t *testing.T) {
+	dir := t.TempDir()
+	documentPath := filepath.Join(dir, "config", "app.json")
+	schemaPath := filepath.Join(dir, "schemas", "app.json")
+
+	tests := []struct {
+		schemaURL string
+		expected  string
+	}{
+		{"../schemas/app.json", schemaPath},
+		{"schema.json", filepath.Join(dir, "config", "schema.json")},
+		{schemaPath, schemaPath},
+		{"file://" + filepath.ToSlash(schemaPath), schemaPath},
+	}
+	if runtime.GOOS == "windows" {
+		tests[3].schemaURL = "file:///" + filepath.ToSlash(schemaPath)
+	}
+
+	for _, test := range tests {
+		result := LocalSchemaPath(test.schemaURL, documentPath)
+		if result != test.expected {
+			t.Errorf("LocalSchemaPath(%q) = %q, expected %q", test.schemaURL, result, test.expected)
+		}
+	}
+}
+
+func TestCheckSchemaScheme(t *testing.T) {
+	tests := []struct {
+		schemaURL string
+		valid     bool
+	}{
+		{"schema.json", true},
+		{"../schemas/app.json", true},
+		{`C:\schemas\app.json`, true},
+		{"C:/schemas/app.json", true},
+		{"file:///schemas/app.json", true},
+		{"https://example.com/schema.json", true},
+		{"HTTP://example.com/schema.json", true},
+		{"urn:example:schema", false},
+		{"ftp://example.com/schema.json", false},
+	}
+
+	for _, test := range tests {
+		err := CheckSchemaScheme(test.schemaURL)
+		if test.valid && err != nil {
+			t.Errorf("CheckSchemaScheme(%q): expected no error, got: %v", test.schemaURL, err)
+		}
+		if !test.valid && (err == nil || !strings.Contains(err.Error(), "unsupported schema scheme")) {
+			t.Errorf("CheckSchemaScheme(%q): expected an unsupported scheme error, got: %v", test.schemaURL, err)
+		}
+	}
+}
+
+func TestParseFile_SchemaMustBeString(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "config.json")
+	yamlPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(jsonPath, []byte(`{"$schema": 1, "PORT": "80"}`), 0644); err != nil {
+		t.Fatalf("Failed to write JSON file: %v", err)
+	}
+	if err := os.WriteFile(yamlPath, []byte("$schema: [a]\nPORT: 80\n"), 0644); err != nil {
+		t.Fatalf("Failed to write YAML file: %v", err)
+	}
+
+	_, jsonErr := CreateJSONProcessor().ParseFile(Options{FilePath: jsonPath})
+	_, yamlErr := CreateYAMLProcessor().ParseFile(Options{FilePath: yamlPath})
+	for path, err := range map[string]error{jsonPath: jsonErr, yamlPath: yamlErr} {
+		expected := fmt.Sprintf("$schema in '%s' must be a string", path)
+		if err == nil || err.Error() != expected {
+			t.Errorf("Expected %q, got: %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package sources
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// SecretFinding is a value that looks like a credential. The value itself is never recorded.
+type SecretFinding struct {
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Key         string `json:"key"`
+	Rule        string `json:"rule"`
+	Description string `json:"description"` // What the value looks like, e.g. "looks like a private key"
+}
+
+// secretRule detects one kind of credential in a value
+type secretRule struct {
+	id          string
+	description string
+	matches     func(value string) bool
+}
+
+var (
+	awsAccessKeyPattern   = regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)
+	jwtPattern            = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`)
+	privateKeyPattern     = regexp.MustCompile(`-----BEGIN ([A-Z0-9]+ )*PRIVATE KEY( BLOCK)?-----`)
+	githubTokenPattern    = regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)
+	urlCredentialsPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:[^/\s@]+@`)
+	base64TokenPattern    = regexp.MustCompile(`^[A-Za-z0-9+/=_-]+$`)
+	hexTokenPattern       = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+)
+
+// Thresholds for the high-entropy rule, in bits per character
+const (
+	base64EntropyThreshold = 4.0
+	base64MinLength        = 20
+	hexEntropyThreshold    = 3.0
+	hexMinLength           = 32
+)
+
+// secretRules are checked in order; the first match is reported
+var secretRules = []secretRule{
+	{"private-key", "looks like a private key", privateKeyPattern.MatchString},
+	{"aws-access-key", "looks like an AWS access key ID", awsAccessKeyPattern.MatchString},
+	{"github-token", "looks like a GitHub token", githubTokenPattern.MatchString},
+	{"jwt", "looks like a JSON Web Token", jwtPattern.MatchString},
+	{"url-credentials", "looks like a URL with an embedded password", urlCredentialsPattern.MatchString},
+	{"high-entropy", "looks like a high-entropy string", isHighEntropy},
+}
+
+// SecretRuleIDs returns the IDs of the secret detection rules
+func SecretRuleIDs() []string {
+	ids := make([]string, len(secretRules))
+	for i, rule := range secretRules {
+		ids[i] = rule.id
+	}
+	return ids
+}
+
+// DetectSecret reports the rule ID and description of the first rule the value matches
+func DetectSecret(value string) (string, string, bool) {
+	for _, rule := range secretRules {
+		if rule.matches(value) {
+			return rule.id, rule.description, true
+		}
+	}
+	return "", "", false
+}
+
+// ScanSecrets returns a finding for each variable in the file whose value looks like a credential
+func ScanSecrets(envFile EnvFile) []SecretFinding {
+	var findings []SecretFinding
+	for _, variable := range envFile.Variables {
+		rule, description, found := DetectSecret(variable.Value)
+		if !found {
+			continue
+		}
+		file := variable.File
+		if file == "" {
+			file = envFile.Filename
+		}
+		findings = append(findings, SecretFinding{
+			File:        file,
+			Line:        variable.Line,
+			Key:         variable.Key,
+			Rule:        rule,
+			Description: description,
+		})
+	}
+	return findings
+}
+
+// IsEncryptedValue reports whether the value is SOPS ciphertext
+func IsEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, "ENC[")
+}
+
+// isHighEntropy reports whether a single-token value is long and random enough to be a generated
+// key: hex strings and base64-like strings are held to different thresholds
+func isHighEntropy(value string) bool {
+	switch {
+	case hexTokenPattern.MatchString(value):
+		return len(value) >= hexMinLength && shannonEntropy(value) >= hexEntropyThreshold
+	case base64TokenPattern.MatchString(value):
+		// Words joined by separators (SOME_LONG_IDENTIFIER) are not keys; require mixed character classes
+		if len(value) < base64MinLength || !strings.ContainsAny(value, "0123456789") {
+			return false
+		}
+		return shannonEntropy(value) >= base64EntropyThreshold
+	default:
+		return false
+	}
+}
+
+// shannonEntropy returns the Shannon entropy of value in bits per character
+func shannonEntropy(value string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
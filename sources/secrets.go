@@ -0,0 +1,34 @@
+package sources
+
+import "strings"
+
+// DefaultSecretPatterns match the keys whose values are always treated as secrets
+var DefaultSecretPatterns = []string{"*_SECRET", "*_SECRET_*", "*_TOKEN", "*PASSWORD*", "*PASSWD*", "*_KEY", "*PRIVATE_KEY*", "*CREDENTIAL*"}
+
+// SecretMask is shown in place of a secret value
+const SecretMask = "********"
+
+// IsSecretKey reports whether key holds a secret: it matches one of the DefaultSecretPatterns
+// or one of the extra patterns (case-insensitive, "*" matches anything)
+func IsSecretKey(key string, extra []string) bool {
+	return MatchesAnyPattern(key, DefaultSecretPatterns) || MatchesAnyPattern(key, extra)
+}
+
+// MaskSecret returns SecretMask in place of a non-empty value whose key holds a secret
+func MaskSecret(key string, value string, extra []string) string {
+	if value != "" && IsSecretKey(key, extra) {
+		return SecretMask
+	}
+	return value
+}
+
+// SecretPatterns returns the key patterns marked with #secret directives
+func SecretPatterns(directives []Directive) []string {
+	var patterns []string
+	for _, directive := range directives {
+		if strings.EqualFold(directive.Name, "secret") {
+			patterns = append(patterns, directive.Arguments...)
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,61 @@
+package sources
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestIsSecretKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		extra    []string
+		expected bool
+	}{
+		{"API_TOKEN", nil, true},
+		{"db_password", nil, true},
+		{"AWS_SECRET_ACCESS_KEY", nil, true},
+		{"STRIPE_KEY", nil, true},
+		{"HOST", nil, false},
+		{"KEYBOARD_LAYOUT", nil, false},
+		{"DATABASE_URL", []string{"DATABASE_URL"}, true},
+		{"SENTRY_DSN", []string{"*_DSN"}, true},
+	}
+
+	for _, test := range tests {
+		if result := IsSecretKey(test.key, test.extra); result != test.expected {
+			t.Errorf("IsSecretKey(%q, %v): expected %v, got %v", test.key, test.extra, test.expected, result)
+		}
+	}
+
+	if masked := MaskSecret("API_TOKEN", "abc", nil); masked != SecretMask {
+		t.Errorf("Expected %q, got %q", SecretMask, masked)
+	}
+	if masked := MaskSecret("API_TOKEN", "", nil); masked != "" {
+		t.Errorf("Expected empty secrets to stay empty, got %q", masked)
+	}
+}
+
+func TestParseFile_SecretParseErrors(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("#secret DATABASE_URL\nDATABASE_URL=\"postgres://hunter2\"trailing-hunter2\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	_, err = ParseFile(Options{FilePath: tempFile.Name()})
+	if err == nil || strings.Contains(err.Error(), "hunter2") || !strings.Contains(err.Error(), "details hidden for secret") {
+		t.Errorf("Expected a parse error without the secret value, got %v", err)
+	}
+
+	_, err = ParseFile(Options{FilePath: tempFile.Name(), ShowSecrets: true})
+	if err == nil || !strings.Contains(err.Error(), "trailing-hunter2") {
+		t.Errorf("Expected a detailed parse error with ShowSecrets, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package sources
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/aes"
+	"github.com/getsops/sops/v3/age"
+	"github.com/getsops/sops/v3/cmd/sops/common"
+	"github.com/getsops/sops/v3/cmd/sops/formats"
+	"github.com/getsops/sops/v3/config"
+	"github.com/getsops/sops/v3/keyservice"
+	"github.com/getsops/sops/v3/kms"
+	"github.com/getsops/sops/v3/pgp"
+	"github.com/getsops/sops/v3/version"
+)
+
+// SOPSAgeRecipientsEnv lists age recipients to encrypt for when no keys are given, as with sops
+const SOPSAgeRecipientsEnv = "SOPS_AGE_RECIPIENTS"
+
+// SOPSEncryptionKeys are the master keys a SOPS file is encrypted for. With none, the age
+// recipients in $SOPS_AGE_RECIPIENTS are used, then the matching creation rule of the nearest
+// .sops.yaml.
+type SOPSEncryptionKeys struct {
+	Age        []string // age recipients
+	PGP        []string // PGP fingerprints
+	KMS        []string // AWS KMS key ARNs
+	AWSProfile string   // AWS profile used for the KMS keys
+}
+
+// isZero reports whether no keys are set
+func (k SOPSEncryptionKeys) isZero() bool {
+	return len(k.Age) == 0 && len(k.PGP) == 0 && len(k.KMS) == 0
+}
+
+// sopsFormats maps the formats SOPS files can be written in to their SOPS store
+var sopsFormats = map[string]formats.Format{
+	"yaml": formats.Yaml,
+	"json": formats.Json,
+	"env":  formats.Dotenv,
+}
+
+// SOPSFormats returns the formats SOPS files can be written in
+func SOPSFormats() []string {
+	return []string{"yaml", "json", "env"}
+}
+
+// EncryptSOPSData encrypts the variables, in order, into a SOPS document in format ("yaml",
+// "json", or "env"). filePath is where the document will be written, which selects the
+// .sops.yaml creation rule when no keys are given.
+func EncryptSOPSData(variables []EnvVar, format string, filePath string, keys SOPSEncryptionKeys) ([]byte, error) {
+	sopsFormat, ok := sopsFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported SOPS format '%s', expected one of: %s", format, strings.Join(SOPSFormats(), ", "))
+	}
+	path, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := sopsMetadata(path, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	branch := make(sops.TreeBranch, 0, len(variables))
+	for _, variable := range variables {
+		branch = append(branch, sops.TreeItem{Key: variable.Key, Value: variable.Value})
+	}
+	tree := sops.Tree{Branches: sops.TreeBranches{branch}, Metadata: metadata, FilePath: path}
+
+	dataKey, errs := tree.GenerateDataKeyWithKeyServices([]keyservice.KeyServiceClient{keyservice.NewLocalClient()})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to generate SOPS data key: %v", errs)
+	}
+	if err := common.EncryptTree(common.EncryptTreeOpts{DataKey: dataKey, Tree: &tree, Cipher: aes.NewCipher()}); err != nil {
+		return nil, fmt.Errorf("failed to encrypt SOPS data: %w", err)
+	}
+
+	store := common.StoreForFormat(sopsFormat, config.NewStoresConfig())
+	encrypted, err := store.EmitEncryptedFile(tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write SOPS data: %w", err)
+	}
+	return encrypted, nil
+}
+
+// sopsMetadata builds the metadata of a new SOPS file at path from the keys, or from the
+// creation rule for path in the nearest .sops.yaml when there are none
+func sopsMetadata(path string, encryptionKeys SOPSEncryptionKeys) (sops.Metadata, error) {
+	if encryptionKeys.isZero() {
+		if recipients := os.Getenv(SOPSAgeRecipientsEnv); recipients != "" {
+			encryptionKeys.Age = strings.Split(recipients, ",")
+		}
+	}
+
+	if encryptionKeys.isZero() {
+		confPath, err := config.FindConfigFile(filepath.Dir(path))
+		if err != nil {
+			return sops.Metadata{}, fmt.Errorf("no SOPS keys to encrypt for: give age, PGP, or KMS keys, or add a .sops.yaml creation rule")
+		}
+		rule, err := config.LoadCreationRuleForFile(confPath, path, nil)
+		if err != nil {
+			return sops.Metadata{}, fmt.Errorf("failed to load SOPS config '%s': %w", confPath, err)
+		}
+		if rule == nil || len(rule.KeyGroups) == 0 {
+			return sops.Metadata{}, fmt.Errorf("SOPS config '%s' has no creation rule with keys for '%s'", confPath, path)
+		}
+		metadata := sops.Metadata{
+			KeyGroups:               rule.KeyGroups,
+			ShamirThreshold:         rule.ShamirThreshold,
+			UnencryptedSuffix:       rule.UnencryptedSuffix,
+			EncryptedSuffix:         rule.EncryptedSuffix,
+			UnencryptedRegex:        rule.UnencryptedRegex,
+			EncryptedRegex:          rule.EncryptedRegex,
+			UnencryptedCommentRegex: rule.UnencryptedCommentRegex,
+			EncryptedCommentRegex:   rule.EncryptedCommentRegex,
+			MACOnlyEncrypted:        rule.MACOnlyEncrypted,
+			Version:                 version.Version,
+		}
+		if metadata.UnencryptedSuffix == "" && metadata.EncryptedSuffix == "" && metadata.UnencryptedRegex == "" &&
+			metadata.EncryptedRegex == "" && metadata.UnencryptedCommentRegex == "" && metadata.EncryptedCommentRegex == "" {
+			metadata.UnencryptedSuffix = sops.DefaultUnencryptedSuffix
+		}
+		return metadata, nil
+	}
+
+	var group sops.KeyGroup
+	for _, recipient := range encryptionKeys.Age {
+		ageKeys, err := age.MasterKeysFromRecipients(recipient)
+		if err != nil {
+			return sops.Metadata{}, fmt.Errorf("invalid age recipient '%s': %w", recipient, err)
+		}
+		for _, key := range ageKeys {
+			group = append(group, key)
+		}
+	}
+	for _, fingerprint := range encryptionKeys.PGP {
+		for _, key := range pgp.MasterKeysFromFingerprintString(fingerprint) {
+			group = append(group, key)
+		}
+	}
+	for _, arn := range encryptionKeys.KMS {
+		for _, key := range kms.MasterKeysFromArnString(arn, nil, encryptionKeys.AWSProfile) {
+			group = append(group, key)
+		}
+	}
+	return sops.Metadata{
+		KeyGroups:         []sops.KeyGroup{group},
+		UnencryptedSuffix: sops.DefaultUnencryptedSuffix,
+		Version:           version.Version,
+	}, nil
+}
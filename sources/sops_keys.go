@@ -0,0 +1,126 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/getsops/sops/v3/aes"
+	"github.com/getsops/sops/v3/cmd/sops/common"
+	"github.com/getsops/sops/v3/cmd/sops/formats"
+	"github.com/getsops/sops/v3/config"
+	"github.com/getsops/sops/v3/decrypt"
+	"github.com/getsops/sops/v3/keyservice"
+	"github.com/getsops/sops/v3/kms"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// SOPSKeyOptions configure how the data key of a SOPS file is decrypted. The zero value uses
+// the key settings recorded in the file and ambient credentials.
+type SOPSKeyOptions struct {
+	AWSProfile  string   `json:"aws_profile"`  // AWS profile used for the file's KMS keys
+	AWSRole     string   `json:"aws_role"`     // Role ARN assumed (through STS) for the file's KMS keys
+	KeyServices []string `json:"key_services"` // Key service addresses (unix:///path or tcp://host:port), tried after local decryption
+}
+
+// isZero reports whether no key options are set
+func (o SOPSKeyOptions) isZero() bool {
+	return o.AWSProfile == "" && o.AWSRole == "" && len(o.KeyServices) == 0
+}
+
+// ValidateKeyService checks that address is a unix:// or tcp:// key service address
+func ValidateKeyService(address string) error {
+	parsed, err := url.Parse(address)
+	if err != nil || (parsed.Scheme != "unix" && parsed.Scheme != "tcp") {
+		return fmt.Errorf("invalid key service '%s', expected unix:///path or tcp://host:port", address)
+	}
+	if (parsed.Scheme == "unix" && parsed.Path == "") || (parsed.Scheme == "tcp" && parsed.Host == "") {
+		return fmt.Errorf("invalid key service '%s', expected unix:///path or tcp://host:port", address)
+	}
+	return nil
+}
+
+// decryptSOPSData decrypts a SOPS document in format ("yaml", "json", or "env") into YAML,
+// overriding the AWS profile and role of its KMS keys and consulting the key services given in
+// keyOptions
+func decryptSOPSData(data []byte, format string, keyOptions SOPSKeyOptions) ([]byte, error) {
+	if keyOptions.isZero() && format == "yaml" {
+		return decrypt.Data(data, "yaml")
+	}
+
+	store := common.StoreForFormat(sopsFormats[format], config.NewStoresConfig())
+	tree, err := store.LoadEncryptedFile(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range tree.Metadata.KeyGroups {
+		for _, key := range group {
+			kmsKey, ok := key.(*kms.MasterKey)
+			if !ok {
+				continue
+			}
+			if keyOptions.AWSProfile != "" {
+				kmsKey.AwsProfile = keyOptions.AWSProfile
+			}
+			if keyOptions.AWSRole != "" {
+				kmsKey.Role = keyOptions.AWSRole
+			}
+		}
+	}
+
+	services := []keyservice.KeyServiceClient{keyservice.NewLocalClient()}
+	for _, address := range keyOptions.KeyServices {
+		conn, err := dialKeyService(address)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		services = append(services, keyservice.NewKeyServiceClient(conn))
+	}
+
+	if _, err := common.DecryptTree(common.DecryptTreeOpts{Tree: &tree, KeyServices: services, Cipher: aes.NewCipher()}); err != nil {
+		return nil, err
+	}
+	return common.StoreForFormat(formats.Yaml, config.NewStoresConfig()).EmitPlainFile(tree.Branches)
+}
+
+// SOPSFormatForPath returns the format of the SOPS file at filePath: "json" or "env" by its
+// extension (or a dotenv-style name such as ".env.enc"), otherwise "yaml"
+func SOPSFormatForPath(filePath string) string {
+	name := strings.ToLower(filepath.Base(filePath))
+	switch {
+	case filepath.Ext(name) == ".json":
+		return "json"
+	case filepath.Ext(name) == ".env" || name == ".env" || strings.HasPrefix(name, ".env."):
+		return "env"
+	}
+	return "yaml"
+}
+
+// dialKeyService creates a client connection to the key service at address. Like the sops CLI,
+// it connects without TLS, so remote key services should be reached through a local socket or tunnel.
+func dialKeyService(address string) (*grpc.ClientConn, error) {
+	if err := ValidateKeyService(address); err != nil {
+		return nil, err
+	}
+	parsed, _ := url.Parse(address)
+	target := parsed.Host
+	if parsed.Scheme == "unix" {
+		target = parsed.Path
+	}
+
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, target string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, parsed.Scheme, target)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to key service '%s': %w", address, err)
+	}
+	return conn, nil
+}
@@ -1,12 +1,9 @@
 package sources
 
 import (
+	"bytes"
 	"fmt"
-	"os"
-	"regexp"
-	"strings"
 
-	"github.com/getsops/sops/v3/decrypt"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,35 +17,64 @@ func CreateSOPSProcessor() *SOPSProcessor {
 
 // isValidKey checks if a key matches the required regex pattern
 func (p *SOPSProcessor) isValidKey(key string) bool {
-	matched, _ := regexp.MatchString(`^[A-Za-z_][A-Za-z0-9_]*$`, key)
-	return matched
+	return isValidKey(key)
 }
 
 // ProcessFile decrypts a SOPS-encrypted file and returns the key-value pairs
 func (p *SOPSProcessor) ProcessFile(filePath string, decryptionKey string) ([]EnvVar, error) {
+	envFile, err := p.ParseFile(Options{FilePath: filePath}, decryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return envFile.Variables, nil
+}
+
+// ParseFile decrypts the SOPS-encrypted file from options, a YAML, JSON, or dotenv document (see
+// SOPSFormatForPath), and returns its flattened variables
+func (p *SOPSProcessor) ParseFile(options Options, decryptionKey string) (EnvFile, error) {
+	filePath := options.FilePath
+	keys, err := newKeyValidator(options)
+	if err != nil {
+		return EnvFile{}, err
+	}
+
 	// Read the encrypted file
-	encryptedData, err := os.ReadFile(filePath)
+	encryptedData, err := readInput(options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read SOPS file: %w", err)
+		return EnvFile{}, fmt.Errorf("failed to read SOPS file '%s': %w", filePath, err)
+	}
+
+	// Strip a BOM but leave line endings alone, since they are covered by the file's MAC
+	encryptedData = bytes.TrimPrefix(encryptedData, []byte(utf8BOM))
+	if isBlank(encryptedData) {
+		return emptySource(options)
 	}
 
 	// Decrypt the file using SOPS
-	decryptedData, err := decrypt.Data(encryptedData, "yaml")
+	decryptedData, err := decryptSOPSData(encryptedData, SOPSFormatForPath(filePath), options.SOPSKeys)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt SOPS file: %w", err)
+		return EnvFile{}, newParseError(filePath, 0, fmt.Errorf("failed to decrypt SOPS file '%s': %w", filePath, err))
 	}
 
-	// Parse the decrypted YAML content
-	var yamlData map[string]interface{}
-	if err := yaml.Unmarshal(decryptedData, &yamlData); err != nil {
-		return nil, fmt.Errorf("failed to parse decrypted YAML: %w", err)
+	// Parse the decrypted YAML content, keeping numbers and timestamps as written
+	var document yaml.Node
+	if err := yaml.Unmarshal(decryptedData, &document); err != nil {
+		return EnvFile{}, newParseError(filePath, yamlErrorLine(err), fmt.Errorf("failed to parse decrypted YAML of '%s': %w", filePath, err))
+	}
+	converted, err := yamlNodeValue(&document)
+	if err != nil {
+		return EnvFile{}, newParseError(filePath, yamlErrorLine(err), fmt.Errorf("failed to parse decrypted YAML of '%s': %w", filePath, err))
 	}
+	yamlData, _ := converted.(map[string]interface{})
 
 	// Convert to key-value pairs
 	var variables []EnvVar
-	p.flattenMap("", yamlData, &variables)
+	if err := p.flatten("", yamlData, &variables, options, keys, yamlKeyOrder(&document)); err != nil {
+		return EnvFile{}, err
+	}
+	keys.warn()
 
-	return variables, nil
+	return EnvFile{Filename: filePath, Variables: variables}, nil
 }
 
 // ProcessFileWithMerge merges existing key-value pairs with those from a SOPS file
@@ -75,58 +101,16 @@ func (p *SOPSProcessor) ProcessFileWithMerge(existingKVs map[string]string, opti
 	return mergedVars, nil
 }
 
-// flattenMap recursively flattens a nested map into key-value pairs
+// flattenMap recursively flattens a nested map into key-value pairs, visiting keys alphabetically
+// so the result is deterministic. Invalid keys are dropped.
 func (p *SOPSProcessor) flattenMap(prefix string, data map[string]interface{}, variables *[]EnvVar) {
-	for key, value := range data {
-		// Skip keys that don't match the required pattern
-		if !p.isValidKey(key) {
-			continue
-		}
-
-		fullKey := key
-		if prefix != "" {
-			fullKey = prefix + "_" + key
-		}
-
-		switch v := value.(type) {
-		case string:
-			*variables = append(*variables, EnvVar{
-				Key:   strings.ToUpper(fullKey),
-				Value: v,
-			})
-		case bool:
-			*variables = append(*variables, EnvVar{
-				Key:   strings.ToUpper(fullKey),
-				Value: fmt.Sprintf("%t", v),
-			})
-		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-			*variables = append(*variables, EnvVar{
-				Key:   strings.ToUpper(fullKey),
-				Value: fmt.Sprintf("%v", v),
-			})
-		case float32, float64:
-			*variables = append(*variables, EnvVar{
-				Key:   strings.ToUpper(fullKey),
-				Value: fmt.Sprintf("%v", v),
-			})
-		case map[string]interface{}:
-			p.flattenMap(fullKey, v, variables)
-		case []interface{}:
-			// Convert arrays to comma-separated strings
-			var strValues []string
-			for _, item := range v {
-				strValues = append(strValues, fmt.Sprintf("%v", item))
-			}
-			*variables = append(*variables, EnvVar{
-				Key:   strings.ToUpper(fullKey),
-				Value: strings.Join(strValues, ","),
-			})
-		default:
-			// For any other type, convert to string
-			*variables = append(*variables, EnvVar{
-				Key:   strings.ToUpper(fullKey),
-				Value: fmt.Sprintf("%v", v),
-			})
-		}
-	}
+	p.flatten(prefix, data, variables, Options{}, nil, nil)
+}
+
+// flatten is flattenMap with the delimiter and nesting options from options, keys checked by
+// the given validator, and keys visited in order. Variable names are uppercased.
+func (p *SOPSProcessor) flatten(prefix string, data map[string]interface{}, variables *[]EnvVar, options Options, keys *keyValidator, order keyOrder) error {
+	flattener := newFlattener(options, keys, order)
+	flattener.upper = true
+	return flattener.flattenMap(prefix, nil, data, variables)
 }
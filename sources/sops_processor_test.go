@@ -213,3 +213,21 @@ func TestSOPSProcessor_flattenMap_NilMap(t *testing.T) {
 		t.Errorf("Expected 0 variables for nil map, got %d", len(variables))
 	}
 }
+
+func TestSOPSFormatForPath(t *testing.T) {
+	tests := map[string]string{
+		"secrets.enc.yaml":    "yaml",
+		"secrets.yml":         "yaml",
+		"config/secrets.JSON": "json",
+		"secrets.enc.env":     "env",
+		".env":                "env",
+		"deploy/.env.enc":     "env",
+		"secrets":             "yaml",
+		StdinPath:             "yaml",
+	}
+	for path, expected := range tests {
+		if format := SOPSFormatForPath(path); format != expected {
+			t.Errorf("Expected %v for %s, got %v", expected, path, format)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package sources
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// StreamFile reads the environment file from options (see openInput) line by line and calls fn for every
+// assignment, without holding the file's variables in memory. Because values are emitted as
+// soon as they are read, directives and ${VAR} references (other than escaped or single-quoted
+// ones, which are literal) are rejected rather than left unapplied.
+func StreamFile(options Options, fn func(key string, value string) error) error {
+	filePath := options.FilePath
+	file, err := openInput(options)
+	if err != nil {
+		return fmt.Errorf("failed to open file '%s': %w", filePath, err)
+	}
+	defer file.Close()
+
+	keys, err := newKeyValidator(options)
+	if err != nil {
+		return err
+	}
+	reader := newEnvReader(file, options)
+
+	for {
+		line, lineNumber, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if line == "" {
+			continue
+		}
+
+		if isDirectiveLine(line) {
+			return newParseError(filePath, lineNumber, fmt.Errorf("directive at line %d of '%s' is not supported when streaming", lineNumber, filePath))
+		}
+
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if !strings.Contains(line, "=") {
+			if err := malformedLine(options, lineNumber, reader.column); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key, value, ok, err := parseAssignment(line, keys, options, lineNumber, reader.column)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if names := referencedNames(value, options.NoEscape); len(names) > 0 {
+				return newParseErrorAt(filePath, lineNumber, reader.column, fmt.Errorf("reference to '%s' at line %d of '%s' is not supported when streaming", names[0], lineNumber, filePath))
+			}
+			if err := fn(key, unquoteRaw(value, options.NoEscape)); err != nil {
+				return err
+			}
+		}
+	}
+
+	keys.warn()
+	return nil
+}
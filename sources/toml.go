@@ -0,0 +1,629 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tomlDatePattern matches the date part of a TOML date-time, which may be followed by a space
+// and the time
+var tomlDatePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// tomlDateTimePattern matches TOML dates, times, and date-times, which are kept as written
+var tomlDateTimePattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?([Zz]|[+-]\d{2}:\d{2})?)?|\d{2}:\d{2}(:\d{2}(\.\d+)?)?)$`)
+
+// tomlParser decodes a TOML document into the values the JSON decoder would produce: tables
+// become maps, numbers json.Number (integers in decimal), and dates and times their original text
+type tomlParser struct {
+	data     []byte
+	pos      int
+	filePath string
+	root     map[string]interface{}
+	order    keyOrder        // Keys of every table in the order they appear
+	defined  map[string]bool // Tables defined by a header or dotted keys, keyed by path
+	fixed    map[string]bool // Inline tables and static arrays, which cannot be extended
+	path     []string        // Path of the value being parsed, which inline tables are found at
+}
+
+// parseTOML decodes data, returning the document and the order of the keys in its tables
+func parseTOML(data []byte, filePath string) (map[string]interface{}, keyOrder, error) {
+	p := &tomlParser{
+		data:     data,
+		filePath: filePath,
+		root:     make(map[string]interface{}),
+		order:    keyOrder{},
+		defined:  make(map[string]bool),
+		fixed:    make(map[string]bool),
+	}
+	if err := p.parse(); err != nil {
+		return nil, nil, err
+	}
+	return p.root, p.order, nil
+}
+
+// errorf returns a parse error for the current position
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	line := bytes.Count(p.data[:min(p.pos, len(p.data))], []byte("\n")) + 1
+	return newParseError(p.filePath, line, fmt.Errorf("%s at line %d of '%s'", fmt.Sprintf(format, args...), line, p.filePath))
+}
+
+// parse reads the document's tables and key/value pairs
+func (p *tomlParser) parse() error {
+	current, currentPath := p.root, []string(nil)
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil
+		}
+
+		switch c := p.data[p.pos]; {
+		case c == '#' || c == '\n':
+			// Blank and comment lines are handled by skipLineEnd
+		case c == '[' && p.peek(1) == '[':
+			p.pos += 2
+			path, err := p.parseKey()
+			if err != nil {
+				return err
+			}
+			if !p.consume("]]") {
+				return p.errorf("expected ]] to close the array of tables")
+			}
+			if current, err = p.appendArrayTable(path); err != nil {
+				return err
+			}
+			currentPath = path
+		case c == '[':
+			p.pos++
+			path, err := p.parseKey()
+			if err != nil {
+				return err
+			}
+			if !p.consume("]") {
+				return p.errorf("expected ] to close the table header")
+			}
+			if current, err = p.defineTable(path); err != nil {
+				return err
+			}
+			currentPath = path
+		default:
+			if err := p.parseKeyValue(current, currentPath); err != nil {
+				return err
+			}
+		}
+
+		if err := p.skipLineEnd(); err != nil {
+			return err
+		}
+	}
+}
+
+// peek returns the byte offset bytes ahead, or 0 past the end
+func (p *tomlParser) peek(offset int) byte {
+	if p.pos+offset < len(p.data) {
+		return p.data[p.pos+offset]
+	}
+	return 0
+}
+
+// consume advances past prefix if the input continues with it
+func (p *tomlParser) consume(prefix string) bool {
+	if bytes.HasPrefix(p.data[p.pos:], []byte(prefix)) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// skipSpace advances past spaces and tabs
+func (p *tomlParser) skipSpace() {
+	for p.pos < len(p.data) && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment advances past a comment, up to the end of its line
+func (p *tomlParser) skipComment() {
+	if p.pos < len(p.data) && p.data[p.pos] == '#' {
+		for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipLineEnd advances past trailing whitespace, a comment, and the newline ending a line,
+// failing when anything else follows
+func (p *tomlParser) skipLineEnd() error {
+	p.skipSpace()
+	p.skipComment()
+	if p.pos < len(p.data) {
+		if p.data[p.pos] != '\n' {
+			return p.errorf("unexpected '%c' after value", p.data[p.pos])
+		}
+		p.pos++
+	}
+	return nil
+}
+
+// skipBlank advances past whitespace, newlines, and comments, as allowed inside arrays
+func (p *tomlParser) skipBlank() {
+	for {
+		p.skipSpace()
+		p.skipComment()
+		if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+			p.pos++
+			continue
+		}
+		return
+	}
+}
+
+// parseKey reads a bare, quoted, or dotted key
+func (p *tomlParser) parseKey() ([]string, error) {
+	var path []string
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("expected a key")
+		}
+
+		var part string
+		switch c := p.data[p.pos]; {
+		case c == '"':
+			p.pos++
+			value, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			part = value
+		case c == '\'':
+			p.pos++
+			value, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			part = value
+		default:
+			start := p.pos
+			for p.pos < len(p.data) && isTOMLBareKeyChar(p.data[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, p.errorf("invalid character '%c' in key", c)
+			}
+			part = string(p.data[start:p.pos])
+		}
+		path = append(path, part)
+
+		p.skipSpace()
+		if !p.consume(".") {
+			return path, nil
+		}
+	}
+}
+
+// isTOMLBareKeyChar reports whether c may appear in a bare key
+func isTOMLBareKeyChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// descend returns the table at path below table, creating tables that do not exist yet. The
+// last element of an array of tables is descended into, as later headers extend it.
+func (p *tomlParser) descend(table map[string]interface{}, base []string, path []string) (map[string]interface{}, error) {
+	full := append([]string(nil), base...)
+	for _, key := range path {
+		full = append(full, key)
+		if p.fixed[pathKey(full)] {
+			return nil, p.errorf("cannot extend '%s', which is defined inline", strings.Join(full, "."))
+		}
+		switch existing := table[key].(type) {
+		case nil:
+			next := make(map[string]interface{})
+			p.set(table, full, next)
+			table = next
+		case map[string]interface{}:
+			table = existing
+		case []interface{}:
+			last, ok := existing[len(existing)-1].(map[string]interface{})
+			if !ok {
+				return nil, p.errorf("'%s' is an array, not a table", strings.Join(full, "."))
+			}
+			table = last
+		default:
+			return nil, p.errorf("'%s' is already set to a value", strings.Join(full, "."))
+		}
+	}
+	return table, nil
+}
+
+// set stores a new value under key in the table at path, tracking the order of keys
+func (p *tomlParser) set(table map[string]interface{}, path []string, value interface{}) {
+	p.order.add(path[:len(path)-1], path[len(path)-1])
+	table[path[len(path)-1]] = value
+}
+
+// defineTable handles a [table] header, returning the table it opens
+func (p *tomlParser) defineTable(path []string) (map[string]interface{}, error) {
+	key := pathKey(path)
+	if p.defined[key] {
+		return nil, p.errorf("table '%s' is defined more than once", strings.Join(path, "."))
+	}
+	parent, err := p.descend(p.root, nil, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	table, err := p.descend(parent, path[:len(path)-1], path[len(path)-1:])
+	if err != nil {
+		return nil, err
+	}
+	if _, isArray := parent[path[len(path)-1]].([]interface{}); isArray {
+		return nil, p.errorf("'%s' is an array of tables, not a table", strings.Join(path, "."))
+	}
+	p.defined[key] = true
+	return table, nil
+}
+
+// appendArrayTable handles a [[table]] header, returning the new table appended to the array
+func (p *tomlParser) appendArrayTable(path []string) (map[string]interface{}, error) {
+	parent, err := p.descend(p.root, nil, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	name := path[len(path)-1]
+	if p.fixed[pathKey(path)] {
+		return nil, p.errorf("cannot extend '%s', which is defined inline", strings.Join(path, "."))
+	}
+
+	table := make(map[string]interface{})
+	switch existing := parent[name].(type) {
+	case nil:
+		p.set(parent, path, []interface{}{table})
+	case []interface{}:
+		parent[name] = append(existing, table)
+	default:
+		return nil, p.errorf("'%s' is already defined as a table or value", strings.Join(path, "."))
+	}
+	// Headers of subtables are allowed again within each new element
+	prefix := pathKey(path) + "\x00"
+	for defined := range p.defined {
+		if strings.HasPrefix(defined, prefix) {
+			delete(p.defined, defined)
+		}
+	}
+	return table, nil
+}
+
+// parseKeyValue reads a key = value pair into table, whose path is tablePath
+func (p *tomlParser) parseKeyValue(table map[string]interface{}, tablePath []string) error {
+	path, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if !p.consume("=") {
+		return p.errorf("expected = after key '%s'", strings.Join(path, "."))
+	}
+	p.skipSpace()
+
+	full := append(append([]string(nil), tablePath...), path...)
+	p.path = full
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	// Dotted keys define the intermediate tables
+	parent, err := p.descend(table, tablePath, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	for i := range path[:len(path)-1] {
+		p.defined[pathKey(append(append([]string(nil), tablePath...), path[:i+1]...))] = true
+	}
+
+	name := path[len(path)-1]
+	if _, exists := parent[name]; exists {
+		return p.errorf("key '%s' is defined more than once", strings.Join(path, "."))
+	}
+	p.set(parent, full, value)
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		p.fixed[pathKey(full)] = true
+	}
+	return nil
+}
+
+// parseValue reads a string, number, boolean, date-time, array, or inline table
+func (p *tomlParser) parseValue() (interface{}, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("expected a value")
+	}
+
+	switch p.data[p.pos] {
+	case '"':
+		if p.consume(`"""`) {
+			return p.parseMultilineBasicString()
+		}
+		p.pos++
+		return p.parseBasicString()
+	case '\'':
+		if p.consume(`'''`) {
+			return p.parseMultilineLiteralString()
+		}
+		p.pos++
+		return p.parseLiteralString()
+	case '[':
+		p.pos++
+		return p.parseArray()
+	case '{':
+		p.pos++
+		return p.parseInlineTable()
+	}
+
+	start := p.pos
+	for p.pos < len(p.data) && !strings.ContainsRune(" \t\n,]}#", rune(p.data[p.pos])) {
+		p.pos++
+	}
+	token := string(p.data[start:p.pos])
+	// A date may be separated from its time by a space
+	if tomlDatePattern.MatchString(token) && p.peek(0) == ' ' && p.peek(1) >= '0' && p.peek(1) <= '9' {
+		p.pos++
+		for p.pos < len(p.data) && !strings.ContainsRune(" \t\n,]}#", rune(p.data[p.pos])) {
+			p.pos++
+		}
+		token = string(p.data[start:p.pos])
+	}
+
+	switch {
+	case token == "true":
+		return true, nil
+	case token == "false":
+		return false, nil
+	case tomlDateTimePattern.MatchString(token):
+		return token, nil
+	case strings.TrimLeft(token, "+-") == "inf" || strings.TrimLeft(token, "+-") == "nan":
+		return token, nil
+	}
+	if value, err := strconv.ParseInt(token, 0, 64); err == nil && !isLeadingZeroNumber(token) {
+		return json.Number(strconv.FormatInt(value, 10)), nil
+	}
+	if _, err := strconv.ParseFloat(token, 64); err == nil && !isLeadingZeroNumber(token) && !strings.ContainsAny(token, "xX") {
+		return json.Number(strings.TrimPrefix(strings.ReplaceAll(token, "_", ""), "+")), nil
+	}
+	p.pos = start
+	if token == "" {
+		return nil, p.errorf("expected a value")
+	}
+	return nil, p.errorf("invalid value '%s'", token)
+}
+
+// isLeadingZeroNumber reports whether a decimal number has a leading zero, which TOML forbids
+// (and Go would read as octal)
+func isLeadingZeroNumber(token string) bool {
+	digits := strings.TrimLeft(token, "+-")
+	return len(digits) > 1 && digits[0] == '0' && digits[1] >= '0' && digits[1] <= '9'
+}
+
+// parseArray reads the elements of an array after its opening bracket
+func (p *tomlParser) parseArray() (interface{}, error) {
+	values := []interface{}{}
+	for {
+		p.skipBlank()
+		if p.consume("]") {
+			return values, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		p.skipBlank()
+		if p.consume("]") {
+			return values, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or ] in array")
+		}
+	}
+}
+
+// parseInlineTable reads the key/value pairs of an inline table after its opening brace
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+	table := make(map[string]interface{})
+	base := p.path
+	p.skipSpace()
+	if p.consume("}") {
+		return table, nil
+	}
+	for {
+		path, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume("=") {
+			return nil, p.errorf("expected = after key '%s'", strings.Join(path, "."))
+		}
+		p.skipSpace()
+		full := append(base[:len(base):len(base)], path...)
+		p.path = full
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		p.path = base
+
+		parent := table
+		for i, key := range path[:len(path)-1] {
+			next, ok := parent[key].(map[string]interface{})
+			if !ok {
+				if _, exists := parent[key]; exists {
+					return nil, p.errorf("'%s' is already set to a value", key)
+				}
+				next = make(map[string]interface{})
+				parent[key] = next
+				p.order.add(full[:len(base)+i], key)
+			}
+			parent = next
+		}
+		if _, exists := parent[path[len(path)-1]]; exists {
+			return nil, p.errorf("key '%s' is defined more than once", strings.Join(path, "."))
+		}
+		parent[path[len(path)-1]] = value
+		p.order.add(full[:len(full)-1], path[len(path)-1])
+
+		p.skipSpace()
+		if p.consume("}") {
+			return table, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or } in inline table")
+		}
+		p.skipSpace()
+	}
+}
+
+// parseBasicString reads a double-quoted string after its opening quote
+func (p *tomlParser) parseBasicString() (string, error) {
+	var builder strings.Builder
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; c {
+		case '"':
+			p.pos++
+			return builder.String(), nil
+		case '\n':
+			return "", p.errorf("unterminated string")
+		case '\\':
+			if err := p.parseEscape(&builder); err != nil {
+				return "", err
+			}
+		default:
+			builder.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// parseMultilineBasicString reads a multi-line basic string after its opening quotes
+func (p *tomlParser) parseMultilineBasicString() (string, error) {
+	// A newline right after the opening quotes is not part of the string
+	p.consume("\n")
+	var builder strings.Builder
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case p.consume(`"""`):
+			// Up to two quotes may directly precede the closing ones
+			for i := 0; i < 2 && p.consume(`"`); i++ {
+				builder.WriteByte('"')
+			}
+			return builder.String(), nil
+		case c == '\\' && p.isLineEndingBackslash():
+			// A line-ending backslash trims the whitespace and newlines that follow
+			p.pos++
+			for p.pos < len(p.data) && strings.ContainsRune(" \t\n", rune(p.data[p.pos])) {
+				p.pos++
+			}
+		case c == '\\':
+			if err := p.parseEscape(&builder); err != nil {
+				return "", err
+			}
+		default:
+			builder.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated multi-line string")
+}
+
+// isLineEndingBackslash reports whether the backslash at the current position is followed by
+// only whitespace up to the end of its line
+func (p *tomlParser) isLineEndingBackslash() bool {
+	for i := p.pos + 1; i < len(p.data); i++ {
+		switch p.data[i] {
+		case ' ', '\t':
+		case '\n':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// parseEscape reads the escape sequence at the current position into builder
+func (p *tomlParser) parseEscape(builder *strings.Builder) error {
+	if p.pos+1 >= len(p.data) {
+		return p.errorf("unterminated string")
+	}
+	escape := p.data[p.pos+1]
+	p.pos += 2
+	switch escape {
+	case 'b':
+		builder.WriteByte('\b')
+	case 't':
+		builder.WriteByte('\t')
+	case 'n':
+		builder.WriteByte('\n')
+	case 'f':
+		builder.WriteByte('\f')
+	case 'r':
+		builder.WriteByte('\r')
+	case 'e':
+		builder.WriteByte('\x1b')
+	case '"':
+		builder.WriteByte('"')
+	case '\\':
+		builder.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if escape == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.data) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(string(p.data[p.pos:p.pos+size]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape '\\%c%s'", escape, p.data[p.pos:p.pos+size])
+		}
+		builder.WriteRune(rune(code))
+		p.pos += size
+	default:
+		p.pos -= 2
+		return p.errorf("invalid escape '\\%c'", escape)
+	}
+	return nil
+}
+
+// parseLiteralString reads a single-quoted string after its opening quote
+func (p *tomlParser) parseLiteralString() (string, error) {
+	end := bytes.IndexAny(p.data[p.pos:], "'\n")
+	if end == -1 || p.data[p.pos+end] != '\'' {
+		return "", p.errorf("unterminated string")
+	}
+	value := string(p.data[p.pos : p.pos+end])
+	p.pos += end + 1
+	return value, nil
+}
+
+// parseMultilineLiteralString reads a multi-line literal string after its opening quotes
+func (p *tomlParser) parseMultilineLiteralString() (string, error) {
+	p.consume("\n")
+	end := bytes.Index(p.data[p.pos:], []byte("'''"))
+	if end == -1 {
+		return "", p.errorf("unterminated multi-line string")
+	}
+	// Up to two quotes may directly precede the closing ones
+	for extra := 0; extra < 2 && p.pos+end+3 < len(p.data) && p.data[p.pos+end+3] == '\''; extra++ {
+		end++
+	}
+	value := string(p.data[p.pos : p.pos+end])
+	p.pos += end + 3
+	return value, nil
+}
@@ -0,0 +1,105 @@
+package sources
+
+import (
+	"fmt"
+)
+
+// TOMLProcessor handles processing of TOML files
+type TOMLProcessor struct{}
+
+// CreateTOMLProcessor creates a new TOML processor instance
+func CreateTOMLProcessor() *TOMLProcessor {
+	return &TOMLProcessor{}
+}
+
+// isValidKey checks if a key matches the required regex pattern
+func (tp *TOMLProcessor) isValidKey(key string) bool {
+	return isValidKey(key)
+}
+
+// ProcessFile reads a TOML file and extracts key-value pairs
+func (tp *TOMLProcessor) ProcessFile(filePath string) (map[string]string, error) {
+	envFile, err := tp.ParseFile(Options{FilePath: filePath})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(envFile.Variables))
+	for _, variable := range envFile.Variables {
+		result[variable.Key] = variable.Value
+	}
+
+	return result, nil
+}
+
+// ParseFile reads the TOML file from options and extracts its variables in document order.
+// Tables are flattened like nested JSON objects, so [database] port = 5432 becomes
+// database_port; dates and times are kept as written.
+func (tp *TOMLProcessor) ParseFile(options Options) (EnvFile, error) {
+	filePath := options.FilePath
+	keys, err := newKeyValidator(options)
+	if err != nil {
+		return EnvFile{}, err
+	}
+
+	data, err := readInput(options)
+	if err != nil {
+		return EnvFile{}, fmt.Errorf("failed to open TOML file '%s': %w", filePath, err)
+	}
+	// Files saved on Windows may carry a BOM and CRLF line endings
+	data = normalizeContent(data)
+	if isBlank(data) {
+		return emptySource(options)
+	}
+
+	rawData, order, err := parseTOML(data, filePath)
+	if err != nil {
+		return EnvFile{}, err
+	}
+
+	// Convert to string key-value pairs in document order, filtering invalid keys and
+	// flattening tables
+	envFile := EnvFile{
+		Filename:  filePath,
+		Variables: make([]EnvVar, 0, len(rawData)),
+	}
+	flattener := newFlattener(options, keys, order)
+	for _, key := range orderedKeys(rawData, order.keys(nil)) {
+		name, ok, err := keys.check(key, 0)
+		if err != nil {
+			return EnvFile{}, err
+		}
+		if ok {
+			if err := flattener.add(name, []string{key}, rawData[key], &envFile.Variables); err != nil {
+				return EnvFile{}, err
+			}
+		}
+	}
+	keys.warn()
+
+	return envFile, nil
+}
+
+// ProcessFileWithMerge merges existing key-value pairs with those from a TOML file
+func (tp *TOMLProcessor) ProcessFileWithMerge(existingKVs map[string]string, options Options) (map[string]string, error) {
+	// Process the TOML file
+	fileVars, err := tp.ProcessFile(options.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge: file values take precedence
+	mergedVars := make(map[string]string)
+
+	// First, add existing variables
+	for key, value := range existingKVs {
+		mergedVars[key] = value
+	}
+
+	// Then, add file variables (overriding existing ones)
+	for key, value := range fileVars {
+		mergedVars[key] = value
+	}
+
+	return mergedVars, nil
+}
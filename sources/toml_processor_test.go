@@ -0,0 +1,129 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTOMLProcessor_ParseFile(t *testing.T) {
+	content := `# Service settings
+title = "My \"app\"\tv1"
+port = 8_080
+hex = 0xff
+ratio = +1.5e3
+enabled = true
+tags = ["web", 'api']
+created = 1979-05-27 07:32:00Z
+path = 'C:\Users\app'
+motd = """
+Roses are red \
+    violets are blue"""
+raw = '''
+line1
+line2'''
+owner.name = "Tom"
+
+[database]
+host = "localhost" # inline comment
+ports = [
+  5432, # primary
+  5433,
+]
+credentials = { user = "admin", password = "s3cret" }
+
+[database.replica]
+host = "replica"
+
+[[servers]]
+name = "alpha"
+
+[[servers]]
+name = "beta"
+`
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write TOML file: %v", err)
+	}
+
+	envFile, err := CreateTOMLProcessor().ParseFile(Options{FilePath: path})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	var keys []string
+	values := map[string]string{}
+	for _, variable := range envFile.Variables {
+		keys = append(keys, variable.Key)
+		values[variable.Key] = variable.Value
+	}
+
+	expectedKeys := []string{
+		"title", "port", "hex", "ratio", "enabled", "tags", "created", "path", "motd", "raw", "owner_name",
+		"database_host", "database_ports", "database_credentials_user", "database_credentials_password", "database_replica_host",
+		"servers",
+	}
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("Expected %v, got %v", expectedKeys, keys)
+	}
+	expected := map[string]string{
+		"title":                         "My \"app\"\tv1",
+		"port":                          "8080",
+		"hex":                           "255",
+		"ratio":                         "1.5e3",
+		"enabled":                       "true",
+		"tags":                          "web,api",
+		"created":                       "1979-05-27 07:32:00Z",
+		"path":                          `C:\Users\app`,
+		"motd":                          "Roses are red violets are blue",
+		"raw":                           "line1\nline2",
+		"owner_name":                    "Tom",
+		"database_credentials_password": "s3cret",
+		"database_credentials_user":     "admin",
+		"database_host":                 "localhost",
+		"database_ports":                "5432,5433",
+		"database_replica_host":         "replica",
+		"servers":                       `[{"name":"alpha"},{"name":"beta"}]`,
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+}
+
+func TestTOMLProcessor_ParseFile_Errors(t *testing.T) {
+	tests := []struct {
+		content string
+		message string
+	}{
+		{"a = 1\na = 2\n", "key 'a' is defined more than once at line 2"},
+		{"[a]\nx = 1\n[a]\ny = 2\n", "table 'a' is defined more than once at line 3"},
+		{"a = { x = 1 }\n[a]\n", "cannot extend 'a', which is defined inline at line 2"},
+		{"a = \"unterminated\n", "unterminated string at line 1"},
+		{"a = 1 b = 2\n", "unexpected 'b' after value at line 1"},
+		{"a = 017\n", "invalid value '017' at line 1"},
+		{"a = \"\\q\"\n", "invalid escape '\\q' at line 1"},
+	}
+	for _, test := range tests {
+		_, err := CreateTOMLProcessor().ParseFile(Options{FilePath: StdinPath, Reader: strings.NewReader(test.content)})
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("Expected error containing %q for %q, got %v", test.message, test.content, err)
+		}
+	}
+}
+
+func TestTOMLProcessor_ProcessFileWithMerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[app]\nname = \"new\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write TOML file: %v", err)
+	}
+
+	result, err := CreateTOMLProcessor().ProcessFileWithMerge(map[string]string{"app_name": "old", "OTHER": "kept"}, Options{FilePath: path})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := map[string]string{"app_name": "new", "OTHER": "kept"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
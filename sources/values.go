@@ -0,0 +1,133 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// stringifyValue converts a decoded JSON/YAML scalar to its string form.
+// Common types are handled with strconv to avoid the reflection cost of fmt.Sprintf("%v").
+//
+// Conversion rules:
+//   - numbers decoded as json.Number keep the text they were written with
+//   - booleans render as true or false
+//   - other floats render in plain decimal notation, never with an exponent
+func stringifyValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int8:
+		return strconv.FormatInt(int64(v), 10)
+	case int16:
+		return strconv.FormatInt(int64(v), 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		// Fall back to fmt for anything else (such as nil)
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// jsonNumberPattern matches numbers written in JSON syntax
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// mergeYAMLMappings copies the entries of a merge key's mapping (or list of mappings) into
+// result, keeping entries that are already present
+func mergeYAMLMappings(result map[string]interface{}, merged interface{}) {
+	switch v := merged.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if _, exists := result[key]; !exists {
+				result[key] = value
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			mergeYAMLMappings(result, item)
+		}
+	}
+}
+
+// yamlNodeValue converts a decoded YAML node to the values the JSON decoder would produce,
+// keeping numbers as written (as json.Number) and timestamps as their original text.
+// It returns an error only for scalars that fail to decode.
+func yamlNodeValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlNodeValue(node.Alias)
+	case yaml.MappingNode:
+		result := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			converted, err := yamlNodeValue(value)
+			if err != nil {
+				return nil, err
+			}
+
+			// Merge keys copy entries from the referenced mappings without overriding
+			if key.Tag == "!!merge" {
+				mergeYAMLMappings(result, converted)
+				continue
+			}
+			result[key.Value] = converted
+		}
+		return result, nil
+	case yaml.SequenceNode:
+		result := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			converted, err := yamlNodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, converted)
+		}
+		return result, nil
+	}
+
+	switch node.ShortTag() {
+	case "!!int", "!!float":
+		if jsonNumberPattern.MatchString(node.Value) {
+			return json.Number(node.Value), nil
+		}
+	case "!!str", "!!timestamp", "!!binary":
+		return node.Value, nil
+	}
+
+	// Booleans, nulls, and numbers in YAML-only notations (such as 0x1F or .inf)
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
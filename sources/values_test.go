@@ -0,0 +1,107 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestStringifyValue(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{"text", "text"},
+		{true, "true"},
+		{false, "false"},
+		{42, "42"},
+		{int64(-7), "-7"},
+		{uint32(9), "9"},
+		{3.5, "3.5"},
+		{float32(0.25), "0.25"},
+		{nil, "<nil>"},
+	}
+
+	for _, test := range tests {
+		result := stringifyValue(test.value)
+		if result != test.expected {
+			t.Errorf("stringifyValue(%#v) = %q, expected %q", test.value, result, test.expected)
+		}
+		// The fast path must agree with the fmt-based formatting it replaces
+		if legacy := fmt.Sprintf("%v", test.value); result != legacy {
+			t.Errorf("stringifyValue(%#v) = %q, differs from %%v formatting %q", test.value, result, legacy)
+		}
+	}
+}
+
+func TestStringifyValue_ConversionRules(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{json.Number("12345678901234567890"), "12345678901234567890"},
+		{json.Number("1.50"), "1.50"},
+		{json.Number("1e3"), "1e3"},
+		{float64(1e21), "1000000000000000000000"},
+		{float64(0.0000001), "0.0000001"},
+		{float64(100), "100"},
+	}
+
+	for _, test := range tests {
+		result := stringifyValue(test.value)
+		if result != test.expected {
+			t.Errorf("stringifyValue(%#v) = %q, expected %q", test.value, result, test.expected)
+		}
+	}
+}
+
+func TestYAMLNodeValue_KeepsLexicalForm(t *testing.T) {
+	content := `int: 5432
+big: 123456789012345678901234567890
+float: 1.50
+exp: 1e10
+hex: 0x1F
+yes_word: yes
+bool: true
+null_value: ~
+date: 2024-01-02
+base: &base
+  a: 1
+merged:
+  <<: *base
+  b: 2
+`
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	converted, err := yamlNodeValue(&document)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	values := converted.(map[string]interface{})
+
+	expected := map[string]string{
+		"int":        "5432",
+		"big":        "123456789012345678901234567890",
+		"float":      "1.50",
+		"exp":        "1e10",
+		"hex":        "31",
+		"yes_word":   "yes",
+		"bool":       "true",
+		"null_value": "<nil>",
+		"date":       "2024-01-02",
+	}
+	for key, want := range expected {
+		if got := stringifyValue(values[key]); got != want {
+			t.Errorf("Key %s: expected %q, got %q", key, want, got)
+		}
+	}
+
+	merged := values["merged"].(map[string]interface{})
+	if stringifyValue(merged["a"]) != "1" || stringifyValue(merged["b"]) != "2" {
+		t.Errorf("Expected merge key to copy entries, got %v", merged)
+	}
+}
@@ -0,0 +1,77 @@
+package sources
+
+import "sort"
+
+// Variables is an insertion-ordered set of environment variables. Keys keep the position of
+// their first definition; a key that is removed and later set again moves to the end.
+type Variables struct {
+	values map[string]string
+	order  []string       // Keys in definition order, possibly including stale entries
+	index  map[string]int // Position in order of each key's current definition
+}
+
+// NewVariables creates an empty ordered variable set
+func NewVariables() *Variables {
+	return &Variables{
+		values: make(map[string]string),
+		index:  make(map[string]int),
+	}
+}
+
+// VariablesFromMap creates an ordered variable set from a map, ordering its keys alphabetically
+func VariablesFromMap(kvs map[string]string) *Variables {
+	keys := make([]string, 0, len(kvs))
+	for key := range kvs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	vars := NewVariables()
+	for _, key := range keys {
+		vars.Set(key, kvs[key])
+	}
+	return vars
+}
+
+// Set assigns value to key, appending the key if it is not currently defined
+func (v *Variables) Set(key string, value string) {
+	if _, exists := v.values[key]; !exists {
+		v.index[key] = len(v.order)
+		v.order = append(v.order, key)
+	}
+	v.values[key] = value
+}
+
+// Get returns the value of key and whether it is defined
+func (v *Variables) Get(key string) (string, bool) {
+	value, exists := v.values[key]
+	return value, exists
+}
+
+// Delete removes key
+func (v *Variables) Delete(key string) {
+	delete(v.values, key)
+}
+
+// Len returns the number of defined variables
+func (v *Variables) Len() int {
+	return len(v.values)
+}
+
+// Keys returns the defined keys in definition order
+func (v *Variables) Keys() []string {
+	keys := make([]string, 0, len(v.values))
+	for i, key := range v.order {
+		// Skip keys that were deleted, and stale positions of keys that were re-added
+		if _, exists := v.values[key]; exists && v.index[key] == i {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Map returns the underlying key-value map. Deleting keys from it is equivalent to Delete;
+// new keys must be added with Set so their position is recorded.
+func (v *Variables) Map() map[string]string {
+	return v.values
+}
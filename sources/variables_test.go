@@ -0,0 +1,52 @@
+package sources
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVariables_KeysKeepDefinitionOrder(t *testing.T) {
+	vars := NewVariables()
+	vars.Set("ZETA", "1")
+	vars.Set("ALPHA", "2")
+	vars.Set("MIDDLE", "3")
+
+	// Redefining a key keeps its position
+	vars.Set("ZETA", "4")
+
+	expected := []string{"ZETA", "ALPHA", "MIDDLE"}
+	if keys := vars.Keys(); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+	if value, _ := vars.Get("ZETA"); value != "4" {
+		t.Errorf("Expected ZETA=4, got %s", value)
+	}
+}
+
+func TestVariables_RemovedKeysMoveToEndWhenReadded(t *testing.T) {
+	vars := NewVariables()
+	vars.Set("A", "1")
+	vars.Set("B", "2")
+	vars.Set("C", "3")
+
+	vars.Delete("A")
+	delete(vars.Map(), "B") // Deleting through the map is equivalent
+	vars.Set("A", "again")
+
+	expected := []string{"C", "A"}
+	if keys := vars.Keys(); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+	if vars.Len() != 2 {
+		t.Errorf("Expected 2 variables, got %d", vars.Len())
+	}
+}
+
+func TestVariablesFromMap_SortsKeys(t *testing.T) {
+	vars := VariablesFromMap(map[string]string{"B": "2", "A": "1", "C": "3"})
+
+	expected := []string{"A", "B", "C"}
+	if keys := vars.Keys(); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+}
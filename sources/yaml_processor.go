@@ -1,13 +1,11 @@
 package sources
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
-	"regexp"
-	"strings"
+	"io"
 
-	"github.com/santhosh-tekuri/jsonschema/v6"
 	"gopkg.in/yaml.v3"
 )
 
@@ -21,89 +19,104 @@ func CreateYAMLProcessor() *YAMLProcessor {
 
 // isValidKey checks if a key matches the required regex pattern
 func (yp *YAMLProcessor) isValidKey(key string) bool {
-	matched, _ := regexp.MatchString(`^[A-Za-z_][A-Za-z0-9_]*$`, key)
-	return matched
+	return isValidKey(key)
 }
 
 // ProcessFile reads a YAML file and extracts key-value pairs
 func (yp *YAMLProcessor) ProcessFile(filePath string) (map[string]string, error) {
-	file, err := os.Open(filePath)
+	envFile, err := yp.ParseFile(Options{FilePath: filePath})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open YAML file '%s': %w", filePath, err)
+		return nil, err
+	}
+
+	result := make(map[string]string, len(envFile.Variables))
+	for _, variable := range envFile.Variables {
+		result[variable.Key] = variable.Value
+	}
+
+	return result, nil
+}
+
+// ParseFile reads the YAML file from options and extracts its variables in document order
+func (yp *YAMLProcessor) ParseFile(options Options) (EnvFile, error) {
+	filePath := options.FilePath
+	keys, err := newKeyValidator(options)
+	if err != nil {
+		return EnvFile{}, err
 	}
-	defer file.Close()
 
-	// First, read the entire file to check for $schema
+	data, err := readInput(options)
+	if err != nil {
+		return EnvFile{}, fmt.Errorf("failed to open YAML file '%s': %w", filePath, err)
+	}
+	// Files saved on Windows may carry a BOM and CRLF line endings
+	data = normalizeContent(data)
+
+	// First, read the entire file to check for $schema; the node tree also records key order
+	var document yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&document); errors.Is(err, io.EOF) {
+		// The file is empty or only has comments
+		return emptySource(options)
+	} else if err != nil {
+		return EnvFile{}, newParseError(filePath, yamlErrorLine(err), fmt.Errorf("failed to parse YAML file '%s': %w", filePath, err))
+	}
 	var rawData map[string]interface{}
-	if err := yaml.NewDecoder(file).Decode(&rawData); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML file '%s': %w", filePath, err)
+	if err := document.Decode(&rawData); err != nil {
+		return EnvFile{}, newParseError(filePath, yamlErrorLine(err), fmt.Errorf("failed to parse YAML file '%s': %w", filePath, err))
+	}
+
+	// Variable values keep numbers and timestamps as written in the file
+	converted, err := yamlNodeValue(&document)
+	if err != nil {
+		return EnvFile{}, newParseError(filePath, yamlErrorLine(err), fmt.Errorf("failed to parse YAML file '%s': %w", filePath, err))
 	}
+	values, _ := converted.(map[string]interface{})
 
 	// Check if there's a $schema field
-	if schemaURL, hasSchema := rawData["$schema"]; hasSchema {
+	if schemaValue, hasSchema := rawData["$schema"]; hasSchema {
+		schemaURL, ok := schemaValue.(string)
+		if !ok {
+			return EnvFile{}, newParseError(filePath, 0, fmt.Errorf("$schema in '%s' must be a string", filePath))
+		}
 		// Validate against the schema before processing
-		if err := yp.validateAgainstSchema(rawData, schemaURL.(string), filePath); err != nil {
-			return nil, fmt.Errorf("JSON schema validation failed for '%s': %w", filePath, err)
+		if err := yp.validateAgainstSchema(rawData, schemaURL, filePath); err != nil {
+			return EnvFile{}, newParseError(filePath, 0, fmt.Errorf("JSON schema validation failed for '%s': %w", filePath, err))
 		}
 	}
 
-	// Convert to string key-value pairs, filtering invalid keys and $schema
-	result := make(map[string]string)
-	for key, value := range rawData {
+	// Convert to string key-value pairs in document order, filtering invalid keys and $schema
+	// and flattening nested structures
+	envFile := EnvFile{
+		Filename:  filePath,
+		Variables: make([]EnvVar, 0, len(rawData)),
+	}
+	order := yamlKeyOrder(&document)
+	flattener := newFlattener(options, keys, order)
+	for _, key := range orderedKeys(rawData, order.keys(nil)) {
 		// Skip the $schema field itself
 		if key == "$schema" {
 			continue
 		}
 
-		if yp.isValidKey(key) {
-			result[key] = fmt.Sprintf("%v", value)
+		name, ok, err := keys.check(key, 0)
+		if err != nil {
+			return EnvFile{}, err
+		}
+		if ok {
+			if err := flattener.add(name, []string{key}, values[key], &envFile.Variables); err != nil {
+				return EnvFile{}, err
+			}
 		}
 	}
+	keys.warn()
 
-	return result, nil
+	return envFile, nil
 }
 
 // validateAgainstSchema validates the YAML data against the specified schema
 func (yp *YAMLProcessor) validateAgainstSchema(data map[string]interface{}, schemaURL string, yamlFilePath string) error {
-	// Handle local schema files
-	if strings.HasPrefix(schemaURL, "./") || strings.HasPrefix(schemaURL, "../") || !strings.HasPrefix(schemaURL, "http") {
-		// For local schemas, resolve the path relative to the YAML file being processed
-		yamlDir := filepath.Dir(yamlFilePath)
-		schemaPath := filepath.Join(yamlDir, schemaURL)
-
-		// Create a new compiler and compile the schema directly from the file
-		compiler := jsonschema.NewCompiler()
-		schema, err := compiler.Compile(schemaPath)
-		if err != nil {
-			return fmt.Errorf("failed to compile local schema from '%s': %w", schemaPath, err)
-		}
-
-		// Validate the data against the schema
-		if err := schema.Validate(data); err != nil {
-			return fmt.Errorf("data does not match local schema: %w", err)
-		}
-
-		return nil
-	}
-
-	// For remote schemas, try to fetch and validate
-	compiler := jsonschema.NewCompiler()
-	if err := compiler.AddResource(schemaURL, nil); err != nil {
-		return fmt.Errorf("failed to add remote schema resource: %w", err)
-	}
-
-	// Compile the schema
-	schema, err := compiler.Compile(schemaURL)
-	if err != nil {
-		return fmt.Errorf("failed to compile remote schema: %w", err)
-	}
-
-	// Validate the data against the schema
-	if err := schema.Validate(data); err != nil {
-		return fmt.Errorf("data does not match remote schema: %w", err)
-	}
-
-	return nil
+	// Schemas are compiled once per run and shared across files
+	return validateDocument(data, schemaURL, yamlFilePath)
 }
 
 // ProcessFileWithMerge merges existing key-value pairs with those from a YAML file
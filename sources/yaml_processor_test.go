@@ -2,7 +2,9 @@ package sources
 
 import (
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -98,9 +100,41 @@ func TestYAMLProcessor_ProcessFile_EmptyYAML(t *testing.T) {
 	}
 
 	processor := CreateYAMLProcessor()
-	_, err = processor.ProcessFile(tempFile.Name())
+	result, err := processor.ProcessFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Expected no error for empty YAML file, got: %v", err)
+	}
+	if len(result) != 0 {
+		t.Errorf("Expected empty map, got %v", result)
+	}
+
+	// Strict mode rejects the empty file
+	_, err = processor.ParseFile(Options{FilePath: tempFile.Name(), StrictEmpty: true})
 	if err == nil {
-		t.Error("Expected error for empty YAML file")
+		t.Error("Expected error for empty YAML file in strict mode")
+	}
+}
+
+func TestYAMLProcessor_ProcessFile_CommentsOnlyYAML(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.yaml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("# nothing configured yet\n\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	processor := CreateYAMLProcessor()
+	result, err := processor.ProcessFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(result) != 0 {
+		t.Errorf("Expected empty map, got %v", result)
 	}
 }
 
@@ -142,11 +176,16 @@ features:
 		t.Fatalf("Expected no error, got: %v", err)
 	}
 
-	// The current implementation handles nested structures by converting them to string representations
+	// Nested structures are flattened, joining keys with underscores
 	expected := map[string]string{
-		"database": "map[credentials:map[password:secret username:admin] host:localhost port:5432]",
-		"api":      "map[key:abc123 secret:xyz789 timeout:30]",
-		"features": "[enabled disabled pending]",
+		"database_host":                 "localhost",
+		"database_port":                 "5432",
+		"database_credentials_username": "admin",
+		"database_credentials_password": "secret",
+		"api_key":                       "abc123",
+		"api_secret":                    "xyz789",
+		"api_timeout":                   "30",
+		"features":                      "enabled,disabled,pending",
 	}
 
 	if !reflect.DeepEqual(result, expected) {
@@ -233,3 +272,161 @@ func TestYAMLProcessor_ProcessFileWithMerge_EmptyExisting(t *testing.T) {
 		t.Errorf("Expected %v, got %v", expected, result)
 	}
 }
+
+func TestYAMLProcessor_ProcessFile_CRLFWithBOM(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.yaml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("\xef\xbb\xbfKEY1: value1\r\nKEY2: |\r\n  line1\r\n  line2\r\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	processor := CreateYAMLProcessor()
+	result, err := processor.ProcessFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := map[string]string{
+		"KEY1": "value1",
+		"KEY2": "line1\nline2\n",
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestYAMLProcessor_ParseFile_KeepsDocumentOrder(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.yaml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = tempFile.WriteString("ZETA: 1\nALPHA: 2\nnested:\n  B: 1\n  A:\n    Z: 1\n    Y: 2\nMIDDLE: 3\n")
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	processor := CreateYAMLProcessor()
+	envFile, err := processor.ParseFile(Options{FilePath: tempFile.Name()})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var keys []string
+	for _, variable := range envFile.Variables {
+		keys = append(keys, variable.Key)
+	}
+
+	expected := []string{"ZETA", "ALPHA", "nested_B", "nested_A_Z", "nested_A_Y", "MIDDLE"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+}
+
+func TestYAMLProcessor_ParseFile_NestedOptions(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-*.yaml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	yamlContent := `database:
+  host: localhost
+  port: 5432
+servers:
+  - name: a
+  - name: b
+`
+	_, err = tempFile.WriteString(yamlContent)
+	if err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		options  Options
+		expected map[string]string
+	}{
+		{
+			name:    "custom delimiter",
+			options: Options{Delimiter: "__"},
+			expected: map[string]string{
+				"database__host": "localhost",
+				"database__port": "5432",
+				"servers":        `[{"name":"a"},{"name":"b"}]`,
+			},
+		},
+		{
+			name:    "nested as JSON",
+			options: Options{NestedAsJSON: true},
+			expected: map[string]string{
+				"database": `{"host":"localhost","port":5432}`,
+				"servers":  `[{"name":"a"},{"name":"b"}]`,
+			},
+		},
+	}
+
+	processor := CreateYAMLProcessor()
+	for _, tt := range tests {
+		options := tt.options
+		options.FilePath = tempFile.Name()
+		envFile, err := processor.ParseFile(options)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", tt.name, err)
+		}
+
+		result := make(map[string]string)
+		for _, variable := range envFile.Variables {
+			result[variable.Key] = variable.Value
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, result)
+		}
+	}
+}
+
+func TestYAMLProcessor_ParseFile_DelimiterInvalidKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("database:\n  host: localhost\nPORT: 80\n"), 0644); err != nil {
+		t.Fatalf("Failed to write YAML file: %v", err)
+	}
+
+	tests := []struct {
+		invalidKeys string
+		expected    map[string]string
+	}{
+		{InvalidKeysWarn, map[string]string{"PORT": "80"}},
+		{InvalidKeysRelaxed, map[string]string{"database.host": "localhost", "PORT": "80"}},
+		{InvalidKeysSanitize, map[string]string{"database_host": "localhost", "PORT": "80"}},
+	}
+
+	processor := CreateYAMLProcessor()
+	for _, tt := range tests {
+		envFile, err := processor.ParseFile(Options{FilePath: path, Delimiter: ".", InvalidKeys: tt.invalidKeys})
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", tt.invalidKeys, err)
+		}
+		result := make(map[string]string)
+		for _, variable := range envFile.Variables {
+			result[variable.Key] = variable.Value
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.invalidKeys, tt.expected, result)
+		}
+	}
+
+	_, err := processor.ParseFile(Options{FilePath: path, Delimiter: ".", InvalidKeys: InvalidKeysError})
+	if err == nil || !strings.Contains(err.Error(), "invalid key 'database.host'") {
+		t.Errorf("Expected an invalid key error for 'database.host', got: %v", err)
+	}
+}